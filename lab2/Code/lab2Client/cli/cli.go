@@ -6,9 +6,10 @@ import (
 
 // Config - config with server host/port
 type Config struct {
-	Host    string
-	Port    int
-	Clients int
+	Host     string
+	Port     int
+	Clients  int
+	Encoding string
 }
 
 var (
@@ -29,6 +30,12 @@ var (
 		13,
 		"Number of clients.",
 	)
+
+	fEncoding = flag.String(
+		"encoding",
+		"xml",
+		"Encoding offered to the server: xml, json or binary.",
+	)
 )
 
 // ParseArgs - parses cmd arguments and returns config with data
@@ -38,8 +45,9 @@ func ParseArgs() *Config {
 	}
 
 	return &Config{
-		Host:    *fHost,
-		Port:    *fPort,
-		Clients: *fClients,
+		Host:     *fHost,
+		Port:     *fPort,
+		Clients:  *fClients,
+		Encoding: *fEncoding,
 	}
 }
@@ -1,15 +1,16 @@
 package client
 
 import (
-	"bufio"
 	"context"
-	"encoding/binary"
-	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"lab2Protocol/protocol"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 )
 
@@ -18,20 +19,22 @@ type Client struct {
 	protocol string
 	host     string
 	port     int
+	encoding string // encoding offered to the server, server may answer with default one
 }
 
 // NewClient - returns a new websocket client.
-func NewClient(host string, port int) *Client {
+func NewClient(host string, port int, encoding string) *Client {
 	return &Client{
 		protocol: "tcp",
 		host:     host,
 		port:     port,
+		encoding: encoding,
 	}
 }
 
 // Connect - connects to the socket server.
 func (c *Client) Connect(ID string) error {
-	path := fmt.Sprintf("%s:%d", c.host, c.port)
+	path := net.JoinHostPort(c.host, strconv.Itoa(c.port))
 	conn, err := net.Dial(c.protocol, path)
 	if err != nil {
 		return err
@@ -46,8 +49,14 @@ func (c *Client) Connect(ID string) error {
 
 	log.Println("Connected to", path)
 
-	// send base data to the server
-	if err := c.write(conn, &request{ClientName: ID}); err != nil {
+	// introduce client to the server
+	encoder := protocol.NewEncoder(conn)
+	if err := encoder.Encode(&protocol.Hello{ClientName: ID, Encodings: []string{c.encoding}}); err != nil {
+		return err
+	}
+
+	decoder := protocol.NewDecoder(conn, 0)
+	if err := c.waitForWelcome(encoder, decoder); err != nil {
 		return err
 	}
 
@@ -55,80 +64,105 @@ func (c *Client) Connect(ID string) error {
 	// message waiting was moved to another goroutine.
 	// Otherwise, ctx.Done() will be processed only after some message arrives
 	// (conn.Read() is a blocking operation)
-	dataCh := make(chan *response)
+	dataCh := make(chan *protocol.ClientList)
 	ctx, cancel := c.handleCancel()
-	go c.messageChecker(conn, cancel, dataCh)
+	defer cancel()
+	go c.messageChecker(ctx, decoder, dataCh)
 
-	return c.waitForMessage(ctx, dataCh, ID)
+	return c.waitForMessage(ctx, encoder, dataCh, ID)
+}
+
+// waitForWelcome - reads server answer to hello and switches to encoding chosen by server.
+// Server answers right after hello, so it's fine to block here.
+func (c *Client) waitForWelcome(encoder *protocol.Encoder, decoder *protocol.Decoder) error {
+	msg, err := decoder.Decode()
+	if err != nil {
+		return err
+	}
+
+	switch msg := msg.(type) {
+	case *protocol.Welcome:
+		enc, err := protocol.LookupEncoding(msg.Encoding)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Welcomed by server as %s at %d, encoding: %s", msg.ClientKey, msg.Time, enc.Name())
+		encoder.SetEncoding(enc)
+		decoder.SetEncoding(enc)
+
+		return nil
+	case *protocol.Error:
+		return msg
+	default:
+		return fmt.Errorf("welcome expected, got %s", msg.Type())
+	}
 }
 
 // waitForMessage - waits for incoming data to process.
-func (c *Client) waitForMessage(ctx context.Context, data <-chan *response, ID string) error {
+// On shutdown client says bye, so server doesn't have to wait for closed connection.
+func (c *Client) waitForMessage(
+	ctx context.Context,
+	encoder *protocol.Encoder,
+	data <-chan *protocol.ClientList,
+	ID string,
+) error {
 	// No ping is required, if connection was lost,
-	// messageChecker will receive an error, and ctx.Done will be called.
+	// messageChecker will receive an error, and close data channel.
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Shutting down...")
 
-			return nil
-		case d := <-data:
+			return encoder.Encode(&protocol.Bye{Reason: "client is shutting down"})
+		case d, ok := <-data:
+			if !ok {
+				return nil
+			}
+
 			c.prettyPrint(ID, d)
 		}
 	}
 }
 
-// write - sends message to the server.
-func (c *Client) write(conn net.Conn, data interface{}) error {
-	bts, err := xml.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	if err := c.writeFull(conn, bts); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// writeFull - writes full message to socket (required due to reader size limitations).
-func (c *Client) writeFull(conn net.Conn, bts []byte) error {
-	size := len(bts)
-	base := make([]byte, 4)
-	binary.BigEndian.PutUint32(base, uint32(size))
-	base = append(base, bts...)
+// messageChecker - waits for messages, closes data channel when server leaves or connection is lost.
+func (c *Client) messageChecker(ctx context.Context, decoder *protocol.Decoder, data chan<- *protocol.ClientList) {
+	defer close(data)
 
-	// Same as server.
-	writer := bufio.NewWriterSize(conn, size)
-	_, err := writer.Write(base)
+	for {
+		msg, err := decoder.Decode()
+		if err != nil {
+			// connection is closed on shutdown, it's not an error
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				log.Println(err)
+			}
 
-	if err != nil {
-		return err
-	}
+			return
+		}
 
-	// Flush data to be sure that all bts was sent.
-	return writer.Flush()
-}
+		switch msg := msg.(type) {
+		case *protocol.ClientList:
+			select {
+			case data <- msg:
+			case <-ctx.Done():
+				return
+			}
+		case *protocol.Bye:
+			log.Println("Server said bye:", msg.Reason)
 
-// messageChecker - waits for message
-func (c *Client) messageChecker(conn net.Conn, cancel context.CancelFunc, data chan<- *response) {
-	connReader := bufio.NewReader(conn) // don't want to recreate reader over and over again
+			return
+		case *protocol.Error:
+			log.Println(msg)
 
-	for {
-		resp := new(response)
-		if err := c.read(connReader, resp); err != nil {
-			log.Println(err)
-			cancel() // ctx.Done() if there is an error
 			return
+		default:
+			log.Println("Unexpected message:", msg.Type())
 		}
-
-		data <- resp
 	}
 }
 
 // prettyPrint - pretty prints the server response.
-func (c *Client) prettyPrint(clientId string, resp *response) {
+func (c *Client) prettyPrint(clientId string, resp *protocol.ClientList) {
 	if len(resp.Clients) == 0 {
 		return
 	}
@@ -142,81 +176,6 @@ func (c *Client) prettyPrint(clientId string, resp *response) {
 	log.Println(message)
 }
 
-// read - reads server response.
-func (c *Client) read(reader *bufio.Reader, data interface{}) error {
-	// get int size from message
-	size, err := c.getSize(reader)
-	if err != nil {
-		return err
-	}
-
-	bts, err := c.readFull(reader, int(size))
-	if err != nil {
-		return err
-	}
-
-	return xml.Unmarshal(bts, data)
-}
-
-// readFull - reads full message (required due to reader size limitations)
-func (c *Client) readFull(reader *bufio.Reader, size int) ([]byte, error) {
-	fullMsg := make([]byte, 0)
-
-	// Without size in message, there is possible situation,
-	// when message will be exactly 4096 bytes,
-	// and Peek() will hang after read
-	// + size allows to separate data is socket when there is multiple different messages
-	for {
-		buffSize := reader.Buffered() // max reader size == 4096
-
-		// Get required chunk size
-		chunkSize := 0
-		if size < buffSize {
-			chunkSize = size
-		} else {
-			chunkSize = buffSize
-		}
-		// Create tmp storage, read bytes into it, and append them to the full message.
-		buff := make([]byte, chunkSize)
-
-		_, err := reader.Read(buff)
-		if err != nil {
-			return nil, err
-		}
-		fullMsg = append(fullMsg, buff...)
-
-		// Break if message is fully read.
-		size -= chunkSize
-		if size == 0 {
-			break
-		}
-
-		// Reader would be empty until peek.
-		if _, err := reader.Peek(1); err != nil {
-			return nil, err
-		}
-	}
-
-	return fullMsg, nil
-}
-
-// getSize - reads first 4 bytes of size
-func (c *Client) getSize(reader *bufio.Reader) (uint32, error) {
-	// Reader would be empty until peek.
-	if _, err := reader.Peek(1); err != nil {
-		return 0, err
-	}
-
-	btsSize := make([]byte, 4)
-
-	_, err := reader.Read(btsSize)
-	if err != nil {
-		return 0, err
-	}
-
-	return binary.BigEndian.Uint32(btsSize), nil
-}
-
 // handleCancel - handles cancellation.
 func (c *Client) handleCancel() (context.Context, context.CancelFunc) {
 	signals := make(chan os.Signal, 1)
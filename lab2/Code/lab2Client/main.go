@@ -10,23 +10,23 @@ import (
 
 // runClients - runs specified amount of clients.
 // It's superstructure upon client package.
-func runClients(host string, port, clientsNum int) {
+func runClients(host, encoding string, port, clientsNum int) {
 	var wg sync.WaitGroup // want to wait until all clients would be done
 	wg.Add(clientsNum)
 
 	for i := 0; i < clientsNum; i++ {
 		name := "Pogrebenko_" + strconv.Itoa(i)
-		go clientRunner(&wg, host, name, port)
+		go clientRunner(&wg, host, name, encoding, port)
 	}
 
 	wg.Wait() // waiting for all clients to finish
 }
 
 // clientRunner - runs single client.
-func clientRunner(wg *sync.WaitGroup, host, name string, port int) {
+func clientRunner(wg *sync.WaitGroup, host, name, encoding string, port int) {
 	defer wg.Done()
 
-	if err := client.NewClient(host, port).Connect(name); err != nil {
+	if err := client.NewClient(host, port, encoding).Connect(name); err != nil {
 		log.Println(err)
 	}
 }
@@ -39,5 +39,5 @@ func main() {
 	config := cli.ParseArgs()
 	log.SetFlags(log.LstdFlags | log.Lshortfile | log.Lmicroseconds)
 
-	runClients(config.Host, config.Port, config.Clients)
+	runClients(config.Host, config.Encoding, config.Port, config.Clients)
 }
@@ -0,0 +1,3 @@
+module lab2Protocol
+
+go 1.15
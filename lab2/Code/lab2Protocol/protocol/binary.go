@@ -0,0 +1,202 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Binary payload is fields of message in order of their declaration:
+//
+//	int64          - varint
+//	string         - uvarint length, then bytes
+//	list           - uvarint number of items, then items
+//
+// There are no field names or tags, so it's the most compact encoding,
+// but fields of message could only be appended to the end.
+
+// errBinaryPayload - payload doesn't match the message.
+var errBinaryPayload = errors.New("malformed binary payload")
+
+// binaryEncoding - compact length-prefixed payload.
+type binaryEncoding struct{}
+
+func (binaryEncoding) Name() string { return "binary" }
+
+func (binaryEncoding) Marshal(msg Message) ([]byte, error) {
+	w := binaryWriter{}
+
+	switch msg := msg.(type) {
+	case *Hello:
+		w.string(msg.ClientName)
+		w.strings(msg.Encodings)
+	case *Welcome:
+		w.string(msg.ClientKey)
+		w.int(msg.Time)
+		w.string(msg.Encoding)
+	case *ClientList:
+		w.uint(uint64(len(msg.Clients)))
+
+		for _, c := range msg.Clients {
+			w.int(c.Connected)
+			w.string(c.Name)
+			w.string(c.IP)
+		}
+
+		w.int(msg.Timer)
+	case *Error:
+		w.string(msg.Message)
+	case *Bye:
+		w.string(msg.Reason)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, msg.Type())
+	}
+
+	return w.b, nil
+}
+
+func (binaryEncoding) Unmarshal(payload []byte, msg Message) error {
+	r := binaryReader{b: payload}
+
+	switch msg := msg.(type) {
+	case *Hello:
+		msg.ClientName = r.string()
+		msg.Encodings = r.strings()
+	case *Welcome:
+		msg.ClientKey = r.string()
+		msg.Time = r.int()
+		msg.Encoding = r.string()
+	case *ClientList:
+		// every client takes 3 bytes at least, so broken count can't make huge allocation
+		if n := r.count(3); n > 0 {
+			msg.Clients = make([]*Client, n)
+
+			for i := range msg.Clients {
+				msg.Clients[i] = &Client{Connected: r.int(), Name: r.string(), IP: r.string()}
+			}
+		}
+
+		msg.Timer = r.int()
+	case *Error:
+		msg.Message = r.string()
+	case *Bye:
+		msg.Reason = r.string()
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownType, msg.Type())
+	}
+
+	if r.err == nil && len(r.b) != 0 {
+		r.err = fmt.Errorf("%w: %d trailing bytes", errBinaryPayload, len(r.b))
+	}
+
+	return r.err
+}
+
+// binaryWriter - appends fields to payload.
+type binaryWriter struct {
+	b []byte
+}
+
+func (w *binaryWriter) uint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.b = append(w.b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func (w *binaryWriter) int(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.b = append(w.b, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+func (w *binaryWriter) string(s string) {
+	w.uint(uint64(len(s)))
+	w.b = append(w.b, s...)
+}
+
+func (w *binaryWriter) strings(list []string) {
+	w.uint(uint64(len(list)))
+
+	for _, s := range list {
+		w.string(s)
+	}
+}
+
+// binaryReader - reads fields from payload. After first error it returns zero values,
+// so fields are read without checks, and error is checked once at the end.
+type binaryReader struct {
+	b   []byte
+	err error
+}
+
+func (r *binaryReader) fail(field string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: bad %s", errBinaryPayload, field)
+	}
+
+	r.b = nil
+}
+
+func (r *binaryReader) uint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.fail("uvarint")
+
+		return 0
+	}
+
+	r.b = r.b[n:]
+
+	return v
+}
+
+func (r *binaryReader) int() int64 {
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.fail("varint")
+
+		return 0
+	}
+
+	r.b = r.b[n:]
+
+	return v
+}
+
+// count - reads number of list items, each of them takes minSize bytes at least.
+func (r *binaryReader) count(minSize int) int {
+	n := r.uint()
+	if n > uint64(len(r.b)/minSize) {
+		r.fail("list length")
+
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *binaryReader) string() string {
+	n := r.uint()
+	if n > uint64(len(r.b)) {
+		r.fail("string length")
+
+		return ""
+	}
+
+	s := string(r.b[:n])
+	r.b = r.b[n:]
+
+	return s
+}
+
+func (r *binaryReader) strings() []string {
+	n := r.count(1)
+	if n == 0 {
+		return nil
+	}
+
+	list := make([]string, n)
+	for i := range list {
+		list[i] = r.string()
+	}
+
+	return list
+}
@@ -0,0 +1,130 @@
+package protocol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Marshal - returns frame of message in DefaultEncoding.
+func Marshal(msg Message) ([]byte, error) {
+	return MarshalWith(DefaultEncoding, msg)
+}
+
+// MarshalWith - returns frame of message in enc. Frame could be written to any number of connections
+// of the same encoding, so message sent to many clients is encoded only once.
+func MarshalWith(enc Encoding, msg Message) ([]byte, error) {
+	if _, ok := messages[msg.Type()]; !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownType, msg.Type())
+	}
+
+	payload, err := enc.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s as %s: %w", msg.Type(), enc.Name(), err)
+	}
+
+	frame := make([]byte, HeaderSize, HeaderSize+len(payload))
+	putHeader(frame, Header{Version: Version, Type: msg.Type(), Length: uint32(len(payload))})
+
+	return append(frame, payload...), nil
+}
+
+// Encoder - writes messages to stream.
+type Encoder struct {
+	w   io.Writer
+	enc Encoding
+}
+
+// NewEncoder - returns a new encoder writing to w in DefaultEncoding.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, enc: DefaultEncoding}
+}
+
+// SetEncoding - changes encoding of next messages, it's done after handshake.
+func (e *Encoder) SetEncoding(enc Encoding) {
+	e.enc = enc
+}
+
+// Encoding - returns encoding of messages, frames passed to WriteFrame have to be of it.
+func (e *Encoder) Encoding() Encoding {
+	return e.enc
+}
+
+// Encode - writes frame of message.
+func (e *Encoder) Encode(msg Message) error {
+	frame, err := MarshalWith(e.enc, msg)
+	if err != nil {
+		return err
+	}
+
+	return e.WriteFrame(frame)
+}
+
+// WriteFrame - writes frame returned by MarshalWith. Frame is written by single write,
+// so frames of different goroutines aren't mixed, but order of them isn't defined.
+func (e *Encoder) WriteFrame(frame []byte) error {
+	if _, err := e.w.Write(frame); err != nil {
+		return fmt.Errorf("write frame: %w", err)
+	}
+
+	return nil
+}
+
+// Decoder - reads messages from stream.
+type Decoder struct {
+	r       *bufio.Reader
+	enc     Encoding
+	maxSize uint32
+	header  [HeaderSize]byte
+}
+
+// NewDecoder - returns a new decoder reading from r in DefaultEncoding, frames with payload larger than maxSize are rejected,
+// 0 means DefaultMaxFrameSize. Decoder buffers r, so nothing else should read from it.
+func NewDecoder(r io.Reader, maxSize uint32) *Decoder {
+	if maxSize == 0 {
+		maxSize = DefaultMaxFrameSize
+	}
+
+	return &Decoder{r: bufio.NewReader(r), enc: DefaultEncoding, maxSize: maxSize}
+}
+
+// SetEncoding - changes encoding of next messages, it's done after handshake.
+func (d *Decoder) SetEncoding(enc Encoding) {
+	d.enc = enc
+}
+
+// Decode - reads next message. It returns io.EOF if stream is closed between frames.
+// After error in header the stream is out of sync and has to be closed,
+// after error in payload the frame is skipped, so next message could be read.
+func (d *Decoder) Decode() (Message, error) {
+	if _, err := io.ReadFull(d.r, d.header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	h, err := parseHeader(d.header[:], d.maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payload is read only when header is valid, so its size is already limited.
+	payload := make([]byte, h.Length)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, fmt.Errorf("read %s payload: %w", h.Type, err)
+	}
+
+	msg := messages[h.Type]()
+	if err := d.enc.Unmarshal(payload, msg); err != nil {
+		return nil, fmt.Errorf("unmarshal %s as %s: %w", h.Type, d.enc.Name(), err)
+	}
+
+	return msg, nil
+}
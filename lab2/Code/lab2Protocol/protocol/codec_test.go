@@ -0,0 +1,250 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testMessages - one message of every type.
+func testMessages() []Message {
+	return []Message{
+		&Hello{ClientName: "Pogrebenko_0"},
+		&Hello{ClientName: "Pogrebenko_1", Encodings: []string{"binary", "json"}},
+		&Welcome{ClientKey: "127.0.0.1:50000", Time: 1600000000},
+		&Welcome{ClientKey: "[::1]:50001", Time: -1, Encoding: "binary"},
+		&ClientList{Timer: 1600000000, Clients: []*Client{
+			{Connected: 1600000001, Name: "Pogrebenko_0", IP: "127.0.0.1:50000"},
+			{Connected: 1600000002, Name: "<&\">", IP: "[::1]:50001"},
+		}},
+		&ClientList{Timer: 1},
+		&Error{Message: "hello expected"},
+		&Bye{Reason: "shutting down"},
+	}
+}
+
+// normalize - clears fields which are filled only by decoding, so messages could be compared.
+func normalize(msg Message) Message {
+	value := reflect.ValueOf(msg).Elem()
+	value.FieldByName("XMLName").Set(reflect.Zero(value.FieldByName("XMLName").Type()))
+
+	if list, ok := msg.(*ClientList); ok {
+		for _, client := range list.Clients {
+			client.XMLName.Local = ""
+		}
+	}
+
+	return msg
+}
+
+// testEncodings - all supported encodings.
+func testEncodings() []Encoding {
+	return []Encoding{XML, JSON, Binary}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, enc := range testEncodings() {
+		stream := new(bytes.Buffer)
+		encoder := NewEncoder(stream)
+		encoder.SetEncoding(enc)
+
+		for _, msg := range testMessages() {
+			if err := encoder.Encode(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		decoder := NewDecoder(stream, 0)
+		decoder.SetEncoding(enc)
+
+		for _, expected := range testMessages() {
+			msg, err := decoder.Decode()
+			if err != nil {
+				t.Fatalf("%s: %v", enc.Name(), err)
+			}
+
+			if !reflect.DeepEqual(normalize(msg), expected) {
+				t.Errorf("%s: decoded %#v, expected %#v", enc.Name(), msg, expected)
+			}
+		}
+
+		if msg, err := decoder.Decode(); err != io.EOF {
+			t.Errorf("%s: expected EOF after last frame, got %#v, error: %v", enc.Name(), msg, err)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		offered  []string
+		expected Encoding
+	}{
+		{offered: nil, expected: XML},
+		{offered: []string{"json"}, expected: JSON},
+		{offered: []string{"BINARY", "json"}, expected: Binary},
+		{offered: []string{"protobuf", "", "json", "binary"}, expected: JSON},
+		{offered: []string{"protobuf"}, expected: XML},
+	}
+
+	for _, c := range cases {
+		if enc := Negotiate(c.offered); enc != c.expected {
+			t.Errorf("Negotiate(%q) = %s, expected %s", c.offered, enc.Name(), c.expected.Name())
+		}
+	}
+
+	if _, err := LookupEncoding("protobuf"); err == nil {
+		t.Error("unknown encoding is found")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	frame, err := Marshal(&Hello{ClientName: "client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := func(i int, b byte) []byte {
+		corrupted := append([]byte{}, frame...)
+		corrupted[i] = b
+
+		return corrupted
+	}
+
+	cases := map[string]struct {
+		stream  []byte
+		maxSize uint32
+		err     error
+	}{
+		"magic":     {stream: corrupt(0, 0), err: ErrBadMagic},
+		"version":   {stream: corrupt(2, Version+1), err: ErrUnsupportedVersion},
+		"type":      {stream: corrupt(3, 0), err: ErrUnknownType},
+		"size":      {stream: frame, maxSize: uint32(len(frame) - HeaderSize - 1), err: ErrFrameTooLarge},
+		"length":    {stream: corrupt(4, 0xff), err: ErrFrameTooLarge},
+		"header":    {stream: frame[:HeaderSize-1], err: io.ErrUnexpectedEOF},
+		"payload":   {stream: frame[:len(frame)-1], err: io.ErrUnexpectedEOF},
+		"wrongType": {stream: corrupt(3, uint8(TypeBye))},
+	}
+
+	for name, c := range cases {
+		msg, err := NewDecoder(bytes.NewReader(c.stream), c.maxSize).Decode()
+
+		if err == nil || c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s: decoded %#v, error: %v, expected %v", name, msg, err, c.err)
+		}
+	}
+}
+
+// TestDecodeFuzz - decodes random and mutated streams of every encoding: decoder never panics, never reads payload
+// larger than max size, and every decoded message is encoded and decoded back the same.
+func TestDecodeFuzz(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for _, enc := range testEncodings() {
+		seeds := make([][]byte, 0)
+
+		for _, msg := range testMessages() {
+			frame, err := MarshalWith(enc, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seeds = append(seeds, frame)
+		}
+
+		decode := func(stream []byte, maxSize uint32) (Message, error) {
+			decoder := NewDecoder(bytes.NewReader(stream), maxSize)
+			decoder.SetEncoding(enc)
+
+			return decoder.Decode()
+		}
+
+		for i := 0; i < 50000; i++ {
+			var stream []byte
+
+			if i%4 == 0 {
+				stream = make([]byte, random.Intn(64))
+				random.Read(stream)
+			} else {
+				stream = append([]byte{}, seeds[random.Intn(len(seeds))]...)
+
+				for n := random.Intn(3); n >= 0; n-- {
+					stream[random.Intn(len(stream))] = byte(random.Intn(256))
+				}
+
+				if random.Intn(4) == 0 {
+					stream = stream[:random.Intn(len(stream))]
+				}
+			}
+
+			msg, err := decode(stream, 256)
+			if err != nil {
+				continue
+			}
+
+			frame, err := MarshalWith(enc, msg)
+			if err != nil {
+				t.Fatalf("%s: stream %x decoded as %#v, but it isn't encoded: %v", enc.Name(), stream, msg, err)
+			}
+
+			again, err := decode(frame, 0)
+			if err != nil || !reflect.DeepEqual(again, msg) {
+				t.Fatalf("%s: stream %x decoded as %#v, but its frame is decoded as %#v, error: %v",
+					enc.Name(), stream, msg, again, err)
+			}
+		}
+	}
+}
+
+// BenchmarkEncodings - compares encodings on client list, it's the only message sent on every tick.
+// Size of frame is reported as bytes/frame.
+func BenchmarkEncodings(b *testing.B) {
+	list := &ClientList{Timer: 1600000000}
+	for i := 0; i < 13; i++ {
+		list.Clients = append(list.Clients, &Client{
+			Connected: 1600000000 + int64(i),
+			Name:      "Pogrebenko_" + strconv.Itoa(i),
+			IP:        "127.0.0.1:" + strconv.Itoa(50000+i),
+		})
+	}
+
+	for _, enc := range testEncodings() {
+		frame, err := MarshalWith(enc, list)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(enc.Name()+"/marshal", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := MarshalWith(enc, list); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(len(frame)), "bytes/frame")
+		})
+
+		b.Run(enc.Name()+"/decode", func(b *testing.B) {
+			b.ReportAllocs()
+
+			reader := bytes.NewReader(frame)
+			decoder := NewDecoder(reader, 0)
+			decoder.SetEncoding(enc)
+
+			for i := 0; i < b.N; i++ {
+				reader.Reset(frame)
+
+				if _, err := decoder.Decode(); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(len(frame)), "bytes/frame")
+		})
+	}
+}
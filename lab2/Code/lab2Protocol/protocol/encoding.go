@@ -0,0 +1,87 @@
+package protocol
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Encoding - encoding of frame payload. Frame header is the same for all encodings,
+// encoding of connection is negotiated during handshake and isn't sent in frames.
+type Encoding interface {
+	// Name - name of encoding, it's sent in handshake.
+	Name() string
+	// Marshal - returns payload of message.
+	Marshal(msg Message) ([]byte, error)
+	// Unmarshal - fills message from payload.
+	Unmarshal(payload []byte, msg Message) error
+}
+
+// Supported encodings.
+var (
+	XML    Encoding = xmlEncoding{}
+	JSON   Encoding = jsonEncoding{}
+	Binary Encoding = binaryEncoding{}
+
+	// DefaultEncoding - encoding of handshake, and of connection if peers didn't agree on other one.
+	DefaultEncoding = XML
+)
+
+// encodings - supported encodings by their names.
+var encodings = map[string]Encoding{
+	XML.Name():    XML,
+	JSON.Name():   JSON,
+	Binary.Name(): Binary,
+}
+
+// LookupEncoding - returns encoding by its name, empty name means DefaultEncoding.
+func LookupEncoding(name string) (Encoding, error) {
+	if name == "" {
+		return DefaultEncoding, nil
+	}
+
+	if enc, ok := encodings[strings.ToLower(name)]; ok {
+		return enc, nil
+	}
+
+	return nil, fmt.Errorf("unknown encoding %q", name)
+}
+
+// Negotiate - returns first supported encoding of offered by peer in order of its preference,
+// DefaultEncoding if none of them is supported. Peers that don't offer anything get DefaultEncoding too.
+func Negotiate(offered []string) Encoding {
+	for _, name := range offered {
+		if enc, err := LookupEncoding(name); err == nil && name != "" {
+			return enc
+		}
+	}
+
+	return DefaultEncoding
+}
+
+// xmlEncoding - encoding/xml payload, it's the original encoding of the protocol.
+type xmlEncoding struct{}
+
+func (xmlEncoding) Name() string { return "xml" }
+
+func (xmlEncoding) Marshal(msg Message) ([]byte, error) {
+	return xml.Marshal(msg)
+}
+
+func (xmlEncoding) Unmarshal(payload []byte, msg Message) error {
+	return xml.Unmarshal(payload, msg)
+}
+
+// jsonEncoding - encoding/json payload.
+type jsonEncoding struct{}
+
+func (jsonEncoding) Name() string { return "json" }
+
+func (jsonEncoding) Marshal(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (jsonEncoding) Unmarshal(payload []byte, msg Message) error {
+	return json.Unmarshal(payload, msg)
+}
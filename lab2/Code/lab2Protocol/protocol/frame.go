@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Every message is sent in frame: fixed size header and payload of header length.
+// Header is big endian:
+//
+//	magic   2 bytes - Magic, separates protocol data from garbage
+//	version 1 byte  - Version of the protocol
+//	type    1 byte  - MessageType of payload
+//	length  4 bytes - size of payload in bytes
+const (
+	Magic      uint16 = 0x4c32 // "L2"
+	Version    uint8  = 1
+	HeaderSize        = 8
+
+	// DefaultMaxFrameSize - max size of payload accepted by decoder, if other isn't set.
+	DefaultMaxFrameSize = 1 << 20
+)
+
+var (
+	// ErrBadMagic - frame doesn't start with Magic, stream is broken or it isn't protocol stream at all.
+	ErrBadMagic = errors.New("bad frame magic")
+	// ErrUnsupportedVersion - frame is of other protocol version.
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	// ErrUnknownType - frame has type of unknown message.
+	ErrUnknownType = errors.New("unknown message type")
+	// ErrFrameTooLarge - payload of frame is larger than max frame size.
+	ErrFrameTooLarge = errors.New("frame is too large")
+)
+
+// Header - header of frame.
+type Header struct {
+	Version uint8
+	Type    MessageType
+	Length  uint32
+}
+
+// putHeader - writes header of frame to first HeaderSize bytes of b.
+func putHeader(b []byte, h Header) {
+	binary.BigEndian.PutUint16(b[0:2], Magic)
+	b[2] = h.Version
+	b[3] = uint8(h.Type)
+	binary.BigEndian.PutUint32(b[4:8], h.Length)
+}
+
+// parseHeader - parses header of frame from first HeaderSize bytes of b and checks it,
+// payload longer than maxSize is rejected before it's read.
+func parseHeader(b []byte, maxSize uint32) (Header, error) {
+	if magic := binary.BigEndian.Uint16(b[0:2]); magic != Magic {
+		return Header{}, fmt.Errorf("%w: %#04x", ErrBadMagic, magic)
+	}
+
+	h := Header{
+		Version: b[2],
+		Type:    MessageType(b[3]),
+		Length:  binary.BigEndian.Uint32(b[4:8]),
+	}
+
+	if h.Version != Version {
+		return h, fmt.Errorf("%w: %d", ErrUnsupportedVersion, h.Version)
+	}
+
+	if _, ok := messages[h.Type]; !ok {
+		return h, fmt.Errorf("%w: %d", ErrUnknownType, h.Type)
+	}
+
+	if h.Length > maxSize {
+		return h, fmt.Errorf("%w: %d bytes, max %d", ErrFrameTooLarge, h.Length, maxSize)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// MessageType - type of message in frame header.
+type MessageType uint8
+
+// Message types. Values are sent over the wire, so they must never be changed or reused.
+const (
+	TypeHello      MessageType = 1 // client -> server, first message of connection
+	TypeWelcome    MessageType = 2 // server -> client, answer to hello
+	TypeClientList MessageType = 3 // server -> client, sent on every timer tick
+	TypeError      MessageType = 4 // both ways, sender closes connection after it
+	TypeBye        MessageType = 5 // both ways, sender closes connection after it
+)
+
+// String - returns name of message type for logs.
+func (t MessageType) String() string {
+	switch t {
+	case TypeHello:
+		return "hello"
+	case TypeWelcome:
+		return "welcome"
+	case TypeClientList:
+		return "client-list"
+	case TypeError:
+		return "error"
+	case TypeBye:
+		return "bye"
+	default:
+		return fmt.Sprintf("type(%d)", uint8(t))
+	}
+}
+
+// Message - typed message sent in frame.
+type Message interface {
+	Type() MessageType
+}
+
+// messages - constructors of messages by their type, decoder unmarshals payload into new message.
+// New message kind needs only its struct, type and entry here, framing is the same for all of them.
+var messages = map[MessageType]func() Message{
+	TypeHello:      func() Message { return new(Hello) },
+	TypeWelcome:    func() Message { return new(Welcome) },
+	TypeClientList: func() Message { return new(ClientList) },
+	TypeError:      func() Message { return new(Error) },
+	TypeBye:        func() Message { return new(Bye) },
+}
+
+// Hello - introduces client to the server.
+type Hello struct {
+	XMLName    xml.Name `xml:"Hello" json:"-"`
+	ClientName string   `xml:"ClientName" json:"client_name"`
+	// encodings supported by client in order of its preference, server picks one of them
+	Encodings []string `xml:"Encoding,omitempty" json:"encodings,omitempty"`
+}
+
+// Welcome - server accepted the client.
+type Welcome struct {
+	XMLName   xml.Name `xml:"Welcome" json:"-"`
+	ClientKey string   `xml:"ClientKey" json:"client_key"`   // key of client on the server, it's host:port of client
+	Time      int64    `xml:"ServerTime" json:"server_time"` // time when client was accepted, unix timestamp
+	// encoding of all next messages of connection in both ways, empty means DefaultEncoding
+	Encoding string `xml:"Encoding,omitempty" json:"encoding,omitempty"`
+}
+
+// ClientList - clients connected to the server.
+type ClientList struct {
+	XMLName xml.Name  `xml:"ClientList" json:"-"`
+	Clients []*Client `xml:"Client" json:"clients"`
+	Timer   int64     `xml:"Timer" json:"timer"` // time when timer was started, unix timestamp
+}
+
+// Client - client connected to the server.
+type Client struct {
+	XMLName   xml.Name `xml:"Client" json:"-"`
+	Connected int64    `xml:"ClientTime" json:"client_time"` // time when client connected, unix timestamp
+	Name      string   `xml:"ClientName" json:"client_name"` // client name
+	IP        string   `xml:"ClientIP" json:"client_ip"`     // client IP (to distinguish clients with the same names)
+}
+
+// Error - peer failed to process message, e.g. connection didn't start with hello.
+type Error struct {
+	XMLName xml.Name `xml:"Error" json:"-"`
+	Message string   `xml:"Message" json:"message"`
+}
+
+// Bye - peer is leaving, e.g. it's shutting down.
+type Bye struct {
+	XMLName xml.Name `xml:"Bye" json:"-"`
+	Reason  string   `xml:"Reason" json:"reason"`
+}
+
+func (*Hello) Type() MessageType      { return TypeHello }
+func (*Welcome) Type() MessageType    { return TypeWelcome }
+func (*ClientList) Type() MessageType { return TypeClientList }
+func (*Error) Type() MessageType      { return TypeError }
+func (*Bye) Type() MessageType        { return TypeBye }
+
+// Error - makes error message usable as error.
+func (e *Error) Error() string {
+	return "peer error: " + e.Message
+}
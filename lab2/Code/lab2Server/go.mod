@@ -1,3 +1,8 @@
 module lab2Server
 
 go 1.15
+
+require lab2Protocol v0.0.0
+
+// Protocol is shared by server and client, it's taken from the source tree.
+replace lab2Protocol => ../lab2Protocol
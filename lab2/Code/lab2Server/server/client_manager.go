@@ -1,52 +1,54 @@
 package server
 
 import (
-	"encoding/xml"
 	"errors"
 	"fmt"
+	"lab2Protocol/protocol"
 	"log"
 	"sync"
 )
 
 // ClientManager - manages the incoming clients.
-// I calculate clientsXML once per tick,
+// I calculate clientsFrames once per tick, one frame per encoding used by clients,
 // to avoid recalculations and remarshalling the same data for each client
 // It's bad too, anyway. Marshalling data by each routine is fine, as far as i now.
 type ClientManager struct {
-	clients    map[string]*clientData // hash is used to avoid collisions.
-	clientsXML []byte
-	mutex      *sync.Mutex
+	clients       map[string]*clientData // hash is used to avoid collisions.
+	clientsFrames map[string][]byte      // client list frames by encoding name, ready to be written to every client
+	mutex         *sync.Mutex
 }
 
 // ClientData - manages the incoming clients.
 type clientData struct {
-	ready chan<- bool
-	name  string
-	ip    string
-	time  int64
+	ready    chan<- bool
+	name     string
+	ip       string
+	time     int64
+	encoding protocol.Encoding // negotiated encoding of the client connection
 }
 
 // newClientManager - returns a new client manager.
 func newClientManager() *ClientManager {
 	return &ClientManager{
-		clientsXML: nil,
-		clients:    make(map[string]*clientData),
-		mutex:      &sync.Mutex{},
+		clientsFrames: make(map[string][]byte),
+		clients:       make(map[string]*clientData),
+		mutex:         &sync.Mutex{},
 	}
 }
 
 // addClient - adds new client to the map by the host - port value.
-func (c *ClientManager) addClient(name, addr string, time int64, ch chan<- bool) string {
+func (c *ClientManager) addClient(name, addr string, time int64, enc protocol.Encoding, ch chan<- bool) string {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	log.Println("Adding new client:", name)
+	log.Printf("Adding new client: %s, encoding: %s", name, enc.Name())
 	// Since host:port value is unique, no collisions is possible.
 	c.clients[addr] = &clientData{
-		ready: ch,
-		name:  name,
-		ip:    addr,
-		time:  time,
+		ready:    ch,
+		name:     name,
+		ip:       addr,
+		time:     time,
+		encoding: enc,
 	}
 
 	return addr
@@ -69,12 +71,13 @@ func (c *ClientManager) delClient(clientKey string) error {
 	return nil
 }
 
-// getClients - returns XML list of clients in bytes.
-func (c *ClientManager) getClients() []byte {
+// getClients - returns frame of client list message in enc,
+// nil if client of this encoding connected after the last update.
+func (c *ClientManager) getClients(enc protocol.Encoding) []byte {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	return c.clientsXML
+	return c.clientsFrames[enc.Name()]
 }
 
 // notifyClients - notify each routine about new data to send.
@@ -89,18 +92,21 @@ func (c *ClientManager) notifyClients() {
 	}
 }
 
-// notifyClients - updates XMl data on tick.
-func (c *ClientManager) updateClients(time int64) (err error) {
+// updateClients - updates client list frames on tick.
+// List is marshalled once for every encoding used by connected clients, not for every client.
+func (c *ClientManager) updateClients(time int64) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	log.Println("Updating clients...")
 
-	clientsList := make([]*client, len(c.clients))
+	clientsList := make([]*protocol.Client, len(c.clients))
+	encodings := make(map[string]protocol.Encoding)
 	idx := 0
 
 	for _, d := range c.clients {
-		clientsList[idx] = &client{
+		encodings[d.encoding.Name()] = d.encoding
+		clientsList[idx] = &protocol.Client{
 			Connected: d.time,
 			Name:      d.name,
 			IP:        d.ip,
@@ -109,10 +115,22 @@ func (c *ClientManager) updateClients(time int64) (err error) {
 		idx++
 	}
 
-	c.clientsXML, err = xml.Marshal(&response{
+	list := &protocol.ClientList{
 		Clients: clientsList,
 		Timer:   time,
-	})
+	}
+	frames := make(map[string][]byte, len(encodings))
+
+	for name, enc := range encodings {
+		frame, err := protocol.MarshalWith(enc, list)
+		if err != nil {
+			return err
+		}
 
-	return
+		frames[name] = frame
+	}
+
+	c.clientsFrames = frames
+
+	return nil
 }
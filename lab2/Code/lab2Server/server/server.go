@@ -1,21 +1,22 @@
 package server
 
 import (
-	"bufio"
 	"context"
-	"encoding/binary"
-	"encoding/xml"
-	"fmt"
+	"errors"
 	"io"
+	"lab2Protocol/protocol"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
-	"time"
 )
 
+// maxClientFrame - max payload of client message, client sends only hello and bye.
+const maxClientFrame = 4096
+
 var (
 	// it's fine for sync.Once to be global.
 	// It's kind of a singleton pattern, and sometimes it really useful.
@@ -49,7 +50,7 @@ func NewServer(host string, port, interval int) *Server {
 
 // Run - runs the websocket server.
 func (s *Server) Run() error {
-	path := fmt.Sprintf("%s:%d", s.host, s.port)
+	path := net.JoinHostPort(s.host, strconv.Itoa(s.port))
 	log.Printf("Starting %s server on: %s", s.protocol, path)
 
 	listener, err := net.Listen(s.protocol, path)
@@ -136,171 +137,130 @@ func (s *Server) waitForClient(listener net.Listener, conns chan<- net.Conn) {
 }
 
 // handleClient - handles incoming client connection.
+// Client has to introduce itself with hello, it's welcomed and gets client list on every tick after it.
+// Client may offer encodings in hello, the first one supported by server is used after welcome.
 func (s *Server) handleClient(ctx context.Context, conn net.Conn) {
-	reader := bufio.NewReader(conn)
-	req := new(request)
+	decoder := protocol.NewDecoder(conn, maxClientFrame)
+	encoder := protocol.NewEncoder(conn)
+
+	msg, err := decoder.Decode()
+	if err != nil {
+		log.Println("ERROR: error reading hello, ", err)
+		s.closeConn(conn)
+
+		return
+	}
+
+	hello, ok := msg.(*protocol.Hello)
+	if !ok {
+		log.Printf("ERROR: hello expected from %s, got %s", conn.RemoteAddr().String(), msg.Type())
+
+		if err := encoder.Encode(&protocol.Error{Message: "hello expected, got " + msg.Type().String()}); err != nil {
+			log.Println(err)
+		}
+
+		s.closeConn(conn)
 
-	if err := s.read(reader, req); err != nil {
-		log.Println("ERROR: error reading request, ", err)
 		return
 	}
 
+	// Handshake is in default encoding, welcome tells the client which one is used after it.
+	enc := protocol.Negotiate(hello.Encodings)
 	ready := make(chan bool, 1)
-	clientKey := s.addClient(req.ClientName, conn.RemoteAddr().String(), s.getTime(), ready)
+	clientKey := s.addClient(hello.ClientName, conn.RemoteAddr().String(), s.getTime(), enc, ready)
 	defer s.cleanClient(conn, clientKey)
 
-	s.processClient(ctx, conn, ready)
+	if err := encoder.Encode(&protocol.Welcome{ClientKey: clientKey, Time: s.getTime(), Encoding: enc.Name()}); err != nil {
+		log.Println(err)
+
+		return
+	}
+
+	encoder.SetEncoding(enc)
+	decoder.SetEncoding(enc)
+
+	// Client messages are read in another routine, so leaving client is noticed while waiting for tick.
+	left := make(chan struct{})
+	go s.watchClient(decoder, clientKey, left)
+
+	s.processClient(ctx, encoder, ready, left)
 }
 
 // processClient - processes client connection
-func (s *Server) processClient(ctx context.Context, conn net.Conn, ready <-chan bool) {
+func (s *Server) processClient(ctx context.Context, encoder *protocol.Encoder, ready <-chan bool, left <-chan struct{}) {
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Shutting down server...")
 
+			if err := encoder.Encode(&protocol.Bye{Reason: "server is shutting down"}); err != nil {
+				log.Println(err)
+			}
+
 			return
 		case <-ready:
 			// Just to avoid remarshaling the same data for every routine,
-			// getClients already returns []byte
-			if err := s.writeFull(conn, s.getClients()); err != nil {
+			// getClients already returns frame in client encoding
+			frame := s.getClients(encoder.Encoding())
+			if frame == nil {
+				continue
+			}
+
+			if err := encoder.WriteFrame(frame); err != nil {
 				log.Println(err)
 
 				return
 			}
-		default:
-			// Ping 0.5 time in a second, so CPU won't be trashed by infinite loop.
-			if !s.isAlive(conn) {
-				return
-			}
+		case <-left:
+			return
 		}
 	}
 }
 
-// Pings the connection. Waits for 0.5 second. If no EOF - connection is opened.
-func (s *Server) isAlive(c net.Conn) bool {
-	one := make([]byte, 1)
-	if err := c.SetReadDeadline(time.Now().Add(time.Millisecond * 500)); err != nil {
-		log.Println(err)
+// watchClient - reads client messages until client says bye or connection is closed, then closes left.
+func (s *Server) watchClient(decoder *protocol.Decoder, clientKey string, left chan<- struct{}) {
+	defer close(left)
 
-		return false
-	}
+	for {
+		msg, err := decoder.Decode()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Client %s, error reading message: %v", clientKey, err)
+			}
 
-	// client doesn't send anything, so it's fine to Read() instead of Peek()
-	if _, err := c.Read(one); err == io.EOF {
-		return false
-	}
+			return
+		}
+
+		switch msg := msg.(type) {
+		case *protocol.Bye:
+			log.Printf("Client %s said bye: %s", clientKey, msg.Reason)
+
+			return
+		case *protocol.Error:
+			log.Printf("Client %s failed: %s", clientKey, msg.Message)
 
-	return true
+			return
+		default:
+			log.Printf("Client %s, unexpected message: %s", clientKey, msg.Type())
+		}
+	}
 }
 
 // cleanClient - cleans client data after it left.
 func (s *Server) cleanClient(conn net.Conn, clientKey string) {
-	log.Printf("Client %s, closing connection...", conn.RemoteAddr().String())
-	if err := conn.Close(); err != nil {
-		log.Println(err)
-	}
+	s.closeConn(conn)
 
 	if err := s.delClient(clientKey); err != nil {
 		log.Println("ERROR: error deleting client,", err)
 	}
 }
 
-// writeFull - writes full message to socket (required due to reader size limitations).
-func (s *Server) writeFull(conn net.Conn, bts []byte) error {
-	size := len(bts)
-	base := make([]byte, 4)
-	binary.BigEndian.PutUint32(base, uint32(size))
-	base = append(base, bts...)
-
-	// maybe, it will be better to create 1 writer outside the function,
-	// and write message the same way i read them,
-	// but it's 3 AM here, i cant think, i'm already writing very bad code,
-	// and i REALLY want to sleep.
-	writer := bufio.NewWriterSize(conn, size)
-	_, err := writer.Write(base)
-
-	if err != nil {
-		return err
-	}
-
-	// Flush data to be sure that all bts was sent.
-	return writer.Flush()
-}
-
-// read - reads server response.
-func (s *Server) read(reader *bufio.Reader, data interface{}) error {
-	// get int size from message
-	size, err := s.getSize(reader)
-	if err != nil {
-		return err
-	}
-
-	bts, err := s.readFull(reader, int(size))
-	if err != nil {
-		return err
-	}
-
-	return xml.Unmarshal(bts, data)
-}
-
-// readFull - reads full message (required due to reader size limitations)
-func (s *Server) readFull(reader *bufio.Reader, size int) ([]byte, error) {
-	fullMsg := make([]byte, 0)
-
-	// Without size in message, there is possible situation,
-	// when message will be exactly 4096 bytes,
-	// and Peek() will hang after read
-	// + size allows to separate data is socket when there is multiple different messages
-	for {
-		buffSize := reader.Buffered() // max reader size == 4096
-
-		// Get required chunk size
-		chunkSize := 0
-		if size < buffSize {
-			chunkSize = size
-		} else {
-			chunkSize = buffSize
-		}
-		// Create tmp storage, read bytes into it, and append them to the full message.
-		buff := make([]byte, chunkSize)
-
-		_, err := reader.Read(buff)
-		if err != nil {
-			return nil, err
-		}
-		fullMsg = append(fullMsg, buff...)
-
-		// Break if message is fully read.
-		size -= chunkSize
-		if size == 0 {
-			break
-		}
-
-		// Reader would be empty until peek.
-		if _, err := reader.Peek(1); err != nil {
-			return nil, err
-		}
-	}
-
-	return fullMsg, nil
-}
-
-// getSize - reads first 4 bytes of size
-func (s *Server) getSize(reader *bufio.Reader) (uint32, error) {
-	// Reader would be empty until peek.
-	if _, err := reader.Peek(1); err != nil {
-		return 0, err
-	}
-
-	btsSize := make([]byte, 4)
-
-	_, err := reader.Read(btsSize)
-	if err != nil {
-		return 0, err
+// closeConn - closes client connection.
+func (s *Server) closeConn(conn net.Conn) {
+	log.Printf("Client %s, closing connection...", conn.RemoteAddr().String())
+	if err := conn.Close(); err != nil {
+		log.Println(err)
 	}
-
-	return binary.BigEndian.Uint32(btsSize), nil
 }
 
 // handleCancel - handles cancellation
@@ -3,50 +3,60 @@ package api
 import (
 	"activity_api/api/api_common"
 	"activity_api/common/models"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strings"
 )
 
 // GetActivities - get all activity records
 func (a *AApi) GetActivities(w http.ResponseWriter, r *http.Request) {
-	activities, err := a.sqlManager.GetActivities()
-
-	entry := a.logger.WithField("func", "GetActivities")
+	entry := a.log(r).WithField("func", "GetActivities")
 	entry.Debug("Request from: ", r.RemoteAddr)
 
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	activities, err := a.sqlManager.GetActivities(r.Context())
+
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetActivities(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with activities list (len %d)", r.RemoteAddr, len(activities))
-	api_common.RespondWithJson(w, http.StatusOK, &activities, a.logger)
+	start, end := api_common.Paginate(page, len(activities))
+	api_common.RespondWithPage(w, r, http.StatusOK, activities[start:end], page, a.log(r))
 }
 
 // GetActivity - returns activity record with given ID
 func (a *AApi) GetActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "GetActivity")
+	entry := a.log(r).WithField("func", "GetActivity")
 	entry.Debugf("Request from %s, activityID: %s", r.RemoteAddr, vars["id"])
 
-	activity, err := a.sqlManager.GetActivity(vars["id"])
+	activity, err := a.sqlManager.GetActivity(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetActivity(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -56,76 +66,134 @@ func (a *AApi) GetActivity(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, activity doesn't exist", r.RemoteAddr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusNotFound,
 			"depart doesn't exists",
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *activity)
-	api_common.RespondWithJson(w, http.StatusOK, &activity, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &activity, a.log(r))
 }
 
 // CreateActivity - creates activity record from given JSON.
 func (a *AApi) CreateActivity(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "CreateActivity")
+	entry := a.log(r).WithField("func", "CreateActivity")
 	entry.Debug("Request from:", r.RemoteAddr)
 
 	activity := new(models.Activity)
 
-	if err := json.NewDecoder(r.Body).Decode(activity); err != nil {
+	if err := api_common.DecodeJSON(r, activity); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	if err := validateActivity(activity); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
 	entry.Debugf("Creating activity %+v, request from: %s", activity, r.RemoteAddr)
-	id, err := a.sqlManager.CreateActivity(activity)
+	id, err := a.sqlManager.CreateActivity(r.Context(), activity)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("CreateActivity(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	activity.RecordID = id
+	a.publishActivity(r, activity)
+	a.publishWebhook(r, models.WebhookActivityCreated, activity)
+
 	entry.Debugf("Activity created, responding to %s with: %d", r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusCreated, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
 }
 
 // DeleteActivity - deletes activity record with given ID
 func (a *AApi) DeleteActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "DeleteActivity")
+	entry := a.log(r).WithField("func", "DeleteActivity")
 	entry.Debugf("Request from %s, activityID: %s", r.RemoteAddr, vars["id"])
 
-	id, err := a.sqlManager.DeleteActivity(vars["id"])
+	id, err := a.sqlManager.DeleteActivity(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("DeleteActivity(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Activity %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusOK, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// validateActivity - checks app breakdown of activity record, time of repeated app is summed.
+func validateActivity(activity *models.Activity) error {
+	apps := make([]*models.AppTime, 0, len(activity.Apps))
+	byName := make(map[string]*models.AppTime, len(activity.Apps))
+
+	var sum int64
+
+	for _, app := range activity.Apps {
+		if app == nil {
+			return errors.New("apps: app must not be null")
+		}
+
+		name := strings.TrimSpace(app.App)
+
+		if name == "" {
+			return errors.New("apps: app name must not be empty")
+		}
+
+		if app.Time < 0 {
+			return fmt.Errorf("apps: time of %q must not be negative, got %d", name, app.Time)
+		}
+
+		sum += app.Time
+
+		if known, ok := byName[name]; ok {
+			known.Time += app.Time
+
+			continue
+		}
+
+		byName[name] = &models.AppTime{App: name, Time: app.Time}
+		apps = append(apps, byName[name])
+	}
+
+	if len(apps) > 0 && sum > activity.TotalTime {
+		return fmt.Errorf("apps: sum of app time %d exceeds total_time %d", sum, activity.TotalTime)
+	}
+
+	activity.Apps = apps
+
+	return nil
 }
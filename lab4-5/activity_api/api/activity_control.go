@@ -2,61 +2,119 @@ package api
 
 import (
 	"activity_api/api/api_common"
+	"activity_api/common/models"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strconv"
 )
 
+// queryRollup - query param to sum activity of descendant departments too.
+const queryRollup = "rollup"
+
 // GetUsersActivity - returns data about user activity for given period of time
-// If no time is set is URL query - all time stat is collected.
+// If no time is set is URL query - all time stat is collected. Time is RFC 3339 or unix time.
 func (a *AApi) GetUsersActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	timeStart := r.URL.Query().Get("TimeStart")
-	timeEnd := r.URL.Query().Get("TimeEnd")
+	entry := a.log(r).WithField("func", "GetUsersActivity")
+	entry.Debugf("Request from %s, url query: %s", r.RemoteAddr, r.URL.RawQuery)
 
-	entry := a.logger.WithField("func", "GetUsersActivity")
-	entry.Debugf("Request from %s, url timeStart: %s, timeEnd: %s", r.RemoteAddr, timeStart, timeEnd)
+	timeStart, timeEnd, ok := a.timeRange(w, r)
 
-	activity, err := a.sqlManager.GetUserActivity(vars["id"], timeStart, timeEnd)
+	if !ok {
+		return
+	}
+
+	activity, err := a.sqlManager.GetUserActivity(r.Context(), vars["id"], timeStart, timeEnd)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetUserActivity(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, &activity)
-	api_common.RespondWithJson(w, http.StatusOK, &activity, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &activity, a.log(r))
 }
 
+// GetDepartmentsActivity - returns data about department users activity for given period of time,
+// with rollup query param activity of all descendant departments is included.
+// Activity is anonymized for analysts.
 func (a *AApi) GetDepartmentsActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	timeStart := r.URL.Query().Get("TimeStart")
-	timeEnd := r.URL.Query().Get("TimeEnd")
+	rollupParam := r.URL.Query().Get(queryRollup)
+
+	entry := a.log(r).WithField("func", "GetDepartmentsActivity")
+	entry.Debugf("Request from %s, url query: %s", r.RemoteAddr, r.URL.RawQuery)
+
+	timeStart, timeEnd, ok := a.timeRange(w, r)
+
+	if !ok {
+		return
+	}
 
-	entry := a.logger.WithField("func", "GetDepartmentsActivity")
-	entry.Debugf("Request from %s, url timeStart: %s, timeEnd: %s", r.RemoteAddr, timeStart, timeEnd)
+	rollup := false
 
-	activity, err := a.sqlManager.GetDepartmentActivity(vars["id"], timeStart, timeEnd)
+	if rollupParam != "" {
+		var err error
+
+		if rollup, err = strconv.ParseBool(rollupParam); err != nil {
+			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("%s: boolean expected, got %q", queryRollup, rollupParam),
+				a.log(r),
+			)
+
+			return
+		}
+	}
+
+	activity, err := a.sqlManager.GetDepartmentActivity(r.Context(), vars["id"], timeStart, timeEnd, rollup)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetDepartmentActivity(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	if activity != nil && a.anonymized(r) {
+		var members []*models.DepartmentMember
+		// Tree minus reports of its parts must not be time of too few users.
+		if rollup {
+			if members, err = a.sqlManager.GetDepartmentTreeMembers(r.Context(), vars["id"], timeStart, timeEnd); err != nil {
+				entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+				api_common.RespondWithError(
+					w,
+					r,
+					http.StatusUnprocessableEntity,
+					fmt.Sprintf("GetDepartmentTreeMembers(): %v", err),
+					a.log(r),
+				)
+
+				return
+			}
+		}
+
+		a.anonymizer.DepartmentActivity(activity, rollup, members)
+	}
+
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, &activity)
-	api_common.RespondWithJson(w, http.StatusOK, &activity, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &activity, a.log(r))
 }
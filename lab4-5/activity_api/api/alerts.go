@@ -0,0 +1,226 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/alerting"
+	"activity_api/common/models"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// queryState - query param of alert rules state filter.
+	queryState = "state"
+	// defaultAlertPeriod - period of rule if it isn't set, a week.
+	defaultAlertPeriod = int64(7 * 24 * time.Hour / time.Second)
+)
+
+// alertNotifiers - notifiers rule could use.
+var alertNotifiers = []string{models.AlertNotifierLog, models.AlertNotifierWebhook}
+
+// GetAlertRules - returns all alert rules with their state, optionally filtered by state.
+func (a *AApi) GetAlertRules(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get(queryState)
+
+	entry := a.log(r).WithField("func", "GetAlertRules")
+	entry.Debugf("Request from %s, state: %q", r.RemoteAddr, state)
+
+	if state != "" && state != models.AlertOK && state != models.AlertFiring {
+		entry.Errorf("Respond to %s, unknown state: %q", r.RemoteAddr, state)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusBadRequest,
+			fmt.Sprintf("%s: unknown state %q", queryState, state),
+			a.log(r),
+		)
+
+		return
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	rules, err := a.sqlManager.GetAlertRules(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetAlertRules(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if state != "" {
+		filtered := make([]*models.AlertRule, 0, len(rules))
+
+		for _, rule := range rules {
+			if rule.State == state {
+				filtered = append(filtered, rule)
+			}
+		}
+
+		rules = filtered
+	}
+
+	entry.Debugf("Responding to %s with alert rules list (len %d)", r.RemoteAddr, len(rules))
+	start, end := api_common.Paginate(page, len(rules))
+	api_common.RespondWithPage(w, r, http.StatusOK, rules[start:end], page, a.log(r))
+}
+
+// GetAlertRule - returns alert rule with given ID.
+func (a *AApi) GetAlertRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetAlertRule")
+	entry.Debugf("Request from %s, ruleID: %s", r.RemoteAddr, vars["id"])
+
+	rule, err := a.sqlManager.GetAlertRule(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetAlertRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if rule == nil {
+		entry.Warnf("Respond to %s, alert rule doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "alert rule doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *rule)
+	api_common.RespondWithJson(w, r, http.StatusOK, rule, a.log(r))
+}
+
+// CreateAlertRule - creates alert rule from given JSON, rule is evaluated by alerts job.
+func (a *AApi) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateAlertRule")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	rule := new(models.AlertRule)
+
+	if err := api_common.DecodeJSON(r, rule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+	// State is written by alerts job only, new rule isn't firing until it's evaluated.
+	rule.State = models.AlertOK
+	rule.Value = 0
+	rule.EvaluatedAt = 0
+	rule.CreatedAt = time.Now().Unix()
+	rule.ChangedAt = rule.CreatedAt
+
+	entry.Debugf("Creating alert rule %+v, request from: %s", rule, r.RemoteAddr)
+	id, err := a.sqlManager.CreateAlertRule(r.Context(), rule)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateAlertRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Alert rule created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// DeleteAlertRule - deletes alert rule with given ID.
+func (a *AApi) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "DeleteAlertRule")
+	entry.Debugf("Request from %s, ruleID: %s", r.RemoteAddr, vars["id"])
+
+	id, err := a.sqlManager.DeleteAlertRule(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteAlertRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Alert rule %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// validateAlertRule - checks alert rule and sets default period and normalized notifiers list.
+func validateAlertRule(rule *models.AlertRule) error {
+	if rule.Kind != models.AlertUserRatio && rule.Kind != models.AlertDepartmentDrop {
+		return fmt.Errorf("kind: unknown kind %q, expected: %s, %s",
+			rule.Kind, models.AlertUserRatio, models.AlertDepartmentDrop)
+	}
+
+	if rule.TargetID <= 0 {
+		return fmt.Errorf("target_id: positive id of user or department expected, got %d", rule.TargetID)
+	}
+
+	if rule.Threshold <= 0 || rule.Threshold > 1 {
+		return fmt.Errorf("threshold: value in range (0, 1] expected, got %v", rule.Threshold)
+	}
+
+	if rule.Period < 0 {
+		return fmt.Errorf("period: must not be negative, got %d", rule.Period)
+	}
+
+	if rule.Period == 0 {
+		rule.Period = defaultAlertPeriod
+	}
+
+	names := alerting.Notifiers(rule)
+
+	for _, name := range names {
+		if name != models.AlertNotifierLog && name != models.AlertNotifierWebhook {
+			return fmt.Errorf("notifiers: unknown notifier %q, expected: %s", name, strings.Join(alertNotifiers, ", "))
+		}
+	}
+
+	rule.Notifiers = strings.Join(names, ",")
+
+	return nil
+}
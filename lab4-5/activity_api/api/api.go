@@ -1,24 +1,72 @@
 package api
 
 import (
+	"activity_api/api/api_common"
 	"activity_api/api/auth"
+	"activity_api/api/event_hub"
 	"activity_api/api/middleware"
+	"activity_api/api/openapi"
+	"activity_api/common/anonymity"
+	"activity_api/common/breaker"
 	"activity_api/common/cancellation"
+	"activity_api/common/models"
+	"activity_api/common/scheduler"
+	"activity_api/common/webhook"
+	"activity_api/common/worktime"
 	"activity_api/data_manager/cache"
 	"activity_api/data_manager/db/core"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
+// serverWriteTimeout - max time to write response, streamed responses are closed before it.
+const serverWriteTimeout = 15 * time.Second
+
+// Config - AApi config.
+type Config struct {
+	Addr          string        // addr to listen
+	ShutdownDelay time.Duration // time to keep serving after readiness flipped to unhealthy
+	TLS           *tls.Config   // if set - api is served over https
+	RateLimit     float64       // requests per second for one client, 0 - unlimited
+	RateBurst     int           // requests allowed for one client at once
+	LegacySunset  time.Time     // sunset date of routes without version prefix, zero - not planned
+	// Webhooks - dispatcher of outbound webhooks, if nil - domain events aren't queued.
+	Webhooks *webhook.Dispatcher
+	// Jobs - scheduler of background jobs, if nil - job routes respond with 404.
+	Jobs *scheduler.Scheduler
+	// Superadmin - name of default tenant admin allowed to manage tenants, if empty - tenant routes respond with 403.
+	// Superadmin is created on startup with superadmin role, its name can't be registered or unregistered.
+	Superadmin string
+	// Anonymizer - anonymizes department reports of analysts, if nil - default one without noise is used.
+	Anonymizer *anonymity.Anonymizer
+}
+
 // AApi - activity api for AAService
 type AApi struct {
 	router *mux.Router
 	server *http.Server
 	cancel *cancellation.Token
+	ready  int32 // 1 if api is ready to serve requests, accessed atomically
+
+	spec          *openapi.Document               // OpenAPI document served on /openapi.json
+	shutdownDelay time.Duration                   // time to keep serving after readiness flipped to unhealthy
+	legacySunset  time.Time                       // sunset date of routes without version prefix
+	rateLimit     *middleware.RateLimitMiddleware // kept to change limits on config reload
+	events        *event_hub.Hub                  // live feed of created activity records
+	webhooks      *webhook.Dispatcher             // queues domain events for webhooks, could be nil
+	jobs          *scheduler.Scheduler            // background jobs, could be nil
+	workTime      *worktime.Reporter              // expected versus actual work time reports
+	anonymizer    *anonymity.Anonymizer           // anonymizes department reports of analysts
+	superadmin    string                          // name of admin allowed to manage tenants
 
 	auth     auth.IAuth
 	token    auth.IToken
@@ -26,32 +74,54 @@ type AApi struct {
 
 	cacheManager cache.ICacheManager
 	sqlManager   core.ISQLDatabase
+	cacheBreaker *breaker.Breaker // circuit breaker of cache, requests fail fast while it's open
+	sqlBreaker   *breaker.Breaker // circuit breaker of db, requests fail fast while it's open
 	logger       logrus.FieldLogger
 }
 
 // NewAApi - returns new AApi
 func NewAApi(
-	addr string,
+	config *Config,
 	sqlManager core.ISQLDatabase,
 	cacheManager cache.ICacheManager,
+	sqlBreaker *breaker.Breaker,
+	cacheBreaker *breaker.Breaker,
 	ctx context.Context,
 	logger logrus.FieldLogger,
 ) *AApi {
 	api := &AApi{
-		router:       mux.NewRouter(),
-		cancel:       cancellation.NewCustomToken(ctx, 1).Close(),
-		auth:         auth.NewAuth(cacheManager, logger),
-		password:     new(auth.PasswordManager),
-		token:        auth.NewToken(logger),
-		cacheManager: cacheManager,
-		sqlManager:   sqlManager,
-		logger:       logger.WithField("module", "AApi"),
+		router:        mux.NewRouter(),
+		spec:          newSpec(),
+		events:        event_hub.NewHub(),
+		webhooks:      config.Webhooks,
+		jobs:          config.Jobs,
+		workTime:      worktime.NewReporter(sqlManager),
+		anonymizer:    config.Anonymizer,
+		superadmin:    config.Superadmin,
+		shutdownDelay: config.ShutdownDelay,
+		legacySunset:  config.LegacySunset,
+		cancel:        cancellation.NewCustomToken(ctx, 1).Close(),
+		auth:          auth.NewAuth(cacheManager, logger),
+		password:      new(auth.PasswordManager),
+		token:         auth.NewToken(logger),
+		cacheManager:  cacheManager,
+		sqlManager:    sqlManager,
+		cacheBreaker:  cacheBreaker,
+		sqlBreaker:    sqlBreaker,
+		logger:        logger.WithField("module", "AApi"),
+	}
+
+	if api.anonymizer == nil {
+		api.anonymizer = anonymity.NewAnonymizer(0, 0)
 	}
+
+	api.rateLimit = middleware.NewRateLimitMiddleware(api.logger, config.RateLimit, config.RateBurst)
 	// New http server for api
 	api.server = &http.Server{
-		Addr:         addr,
+		Addr:         config.Addr,
 		Handler:      api.router,
-		WriteTimeout: 15 * time.Second,
+		TLSConfig:    config.TLS,
+		WriteTimeout: serverWriteTimeout,
 		ReadTimeout:  15 * time.Second,
 	}
 	// init api routs
@@ -60,63 +130,346 @@ func NewAApi(
 
 func (a *AApi) initRoutes() *AApi {
 	a.logger.WithField("func", "initRoutes").Info("Initializing routes for api...")
-	// Init auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(
-		a.logger,
-		routeLogin, // Exclude some routes from authz check
-		routeRegister,
-		routeRefresh,
-	)
-	//Init logging middleware
+	// Init auth middleware, public routes of every version are excluded from authz check
+	authMiddleware := middleware.NewAuthMiddleware(a.logger, publicRoutes()...)
+	// Init request ID and logging middlewares
+	requestIDMiddleware := middleware.NewRequestIDMiddleware(a.logger)
 	loggingMiddleware := middleware.NewLoggingMiddleware(a.logger)
-	// Add logging middleware and auth middleware to router
-	a.router.Use(loggingMiddleware.LogAuthMiddleware, authMiddleware.TokenAuthMiddleware)
+	// Init deprecation middleware, deprecated routes are added on version registration.
+	deprecationMiddleware := middleware.NewDeprecationMiddleware(a.logger, a.legacySunset, apiV1.prefix)
+	// Init compatibility middleware, legacy routes are added on version registration.
+	compatMiddleware := middleware.NewCompatMiddleware(a.logger)
+	// Init dependency middleware, routes requirements are added after routes registration.
+	dependencyMiddleware := middleware.NewDependencyMiddleware(a.logger)
+	// Init tenant middleware, it rejects tokens of suspended tenants.
+	tenantMiddleware := middleware.NewTenantMiddleware(a.logger, a.tenantActive)
+	// Init role middleware, analysts are restricted to department aggregates.
+	roleMiddleware := middleware.NewRoleMiddleware(a.logger, a.adminRole)
+	allowRoles(roleMiddleware)
+	// Agents with client certificate are able to push activity without token.
+	for _, name := range routeNames(routeActivities) {
+		authMiddleware.AllowClientCert(name, http.MethodPost)
+	}
+	// Browsers can't set headers of EventSource and WebSocket requests.
+	authMiddleware.AllowQueryToken(routeNames(routeEvents)...)
+	// Add request ID, logging, deprecation, compatibility, rate limit, auth, dependency, tenant and role middlewares
+	// to router.
+	// Request ID and compatibility mode go first, so they are applied to access log and auth errors.
+	a.router.Use(
+		requestIDMiddleware.RequestIDMiddleware,
+		loggingMiddleware.AccessLogMiddleware,
+		deprecationMiddleware.DeprecationMiddleware,
+		compatMiddleware.CompatMiddleware,
+		a.rateLimit.RateLimitMiddleware,
+		authMiddleware.TokenAuthMiddleware,
+		dependencyMiddleware.DependencyMiddleware,
+		tenantMiddleware.TenantMiddleware,
+		roleMiddleware.RoleMiddleware,
+	)
 	// Default route
 	a.router.NotFoundHandler = http.HandlerFunc(a.defHandler)
+	// Init health routes, they aren't versioned
+	a.registerRoute(a.router, "", a.Live, routeHealthLive, http.MethodGet)
+	a.registerRoute(a.router, "", a.Ready, routeHealthReady, http.MethodGet)
+	// Init versioned routes. Subrouter of version without prefix matches any path,
+	// so versions with prefix have to be registered before it.
+	for _, version := range apiVersions {
+		a.initVersionRoutes(version, deprecationMiddleware, compatMiddleware)
+	}
+	// Init routes dependencies
+	a.requireDependencies(dependencyMiddleware)
+
+	return a
+}
+
+// initVersionRoutes - registers routes of given api version on its own subrouter.
+func (a *AApi) initVersionRoutes(
+	version *apiVersion,
+	deprecation *middleware.DeprecationMiddleware,
+	compat *middleware.CompatMiddleware,
+) {
+	var router *mux.Router
+
+	if version.prefix != "" {
+		router = a.router.PathPrefix(version.prefix).Subrouter()
+	} else {
+		router = a.router.NewRoute().Subrouter()
+	}
+
+	prefix := version.prefix
+	// Init OpenAPI document route
+	a.registerRoute(router, prefix, a.OpenAPI, routeOpenAPI, http.MethodGet)
 	// Init authz\auth routes
-	a.registerRoute(a.Login, routeLogin, http.MethodPost)
-	a.registerRoute(a.Logout, routeLogout, http.MethodPost)
-	a.registerRoute(a.Refresh, routeRefresh, http.MethodPost)
+	a.registerRoute(router, prefix, a.Login, routeLogin, http.MethodPost)
+	a.registerRoute(router, prefix, a.Logout, routeLogout, http.MethodPost)
+	a.registerRoute(router, prefix, a.Refresh, routeRefresh, http.MethodPost)
 
-	a.registerRoute(a.Register, routeRegister, http.MethodPost)
-	a.registerRoute(a.Unregister, routeUnregister, http.MethodDelete)
+	a.registerRoute(router, prefix, a.Register, routeRegister, http.MethodPost)
+	a.registerRoute(router, prefix, a.Unregister, routeUnregister, http.MethodDelete)
 	// Init department routes
-	a.registerRoute(a.CreateDepartment, routeDepartments, http.MethodPost)
-	a.registerRoute(a.GetDepartments, routeDepartments, http.MethodGet)
-	a.registerRoute(a.GetDepartment, routeDepartment, http.MethodGet)
-	a.registerRoute(a.DeleteDepartment, routeDepartment, http.MethodDelete)
+	a.registerRoute(router, prefix, a.CreateDepartment, routeDepartments, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetDepartments, routeDepartments, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetDepartment, routeDepartment, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteDepartment, routeDepartment, http.MethodDelete)
+	a.registerRoute(router, prefix, a.GetDepartmentsTree, routeDepartmentsTree, http.MethodGet)
+	a.registerRoute(router, prefix, a.MoveDepartment, routeDepartmentMove, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetDepartmentSchedule, routeDepartmentSchedule, http.MethodGet)
+	a.registerRoute(router, prefix, a.SetDepartmentSchedule, routeDepartmentSchedule, http.MethodPut)
+	a.registerRoute(router, prefix, a.DeleteDepartmentSchedule, routeDepartmentSchedule, http.MethodDelete)
 	// Init users routes
-	a.registerRoute(a.CreateUser, routeUsers, http.MethodPost)
-	a.registerRoute(a.GetUsers, routeUsers, http.MethodGet)
-	a.registerRoute(a.GetUser, routeUser, http.MethodGet)
-	a.registerRoute(a.DeleteUser, routeUser, http.MethodDelete)
+	a.registerRoute(router, prefix, a.CreateUser, routeUsers, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetUsers, routeUsers, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetUser, routeUser, http.MethodGet)
+	a.registerRoute(router, prefix, a.UpdateUser, routeUser, http.MethodPut)
+	a.registerRoute(router, prefix, a.DeleteUser, routeUser, http.MethodDelete)
+	a.registerRoute(router, prefix, a.TransferUser, routeUserTransfer, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetUserDepartments, routeUserDepartments, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetUserSchedule, routeUserSchedule, http.MethodGet)
+	a.registerRoute(router, prefix, a.SetUserSchedule, routeUserSchedule, http.MethodPut)
+	a.registerRoute(router, prefix, a.DeleteUserSchedule, routeUserSchedule, http.MethodDelete)
+	// Init data subject requests routes
+	a.registerRoute(router, prefix, a.ExportUserData, routeUserExport, http.MethodGet)
+	a.registerRoute(router, prefix, a.EraseUser, routeUserErase, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetSubjectRequests, routeSubjectRequests, http.MethodGet)
 	// Init activity routes
-	a.registerRoute(a.CreateActivity, routeActivities, http.MethodPost)
-	a.registerRoute(a.GetActivities, routeActivities, http.MethodGet)
-	a.registerRoute(a.GetActivity, routeActivity, http.MethodGet)
-	a.registerRoute(a.DeleteActivity, routeActivity, http.MethodDelete)
+	a.registerRoute(router, prefix, a.CreateActivity, routeActivities, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetActivities, routeActivities, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetActivity, routeActivity, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteActivity, routeActivity, http.MethodDelete)
 	// Init activity check routes
-	a.registerRoute(a.GetDepartmentsActivity, routeDepartmentsActivity, http.MethodGet)
-	a.registerRoute(a.GetUsersActivity, routeUsersActivity, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetDepartmentsActivity, routeDepartmentsActivity, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetUsersActivity, routeUsersActivity, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetDepartmentsWorkReport, routeDepartmentsWorkReport, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetUsersWorkReport, routeUsersWorkReport, http.MethodGet)
+	// Init live feed route
+	a.registerRoute(router, prefix, a.Events, routeEvents, http.MethodGet)
+	// Init webhooks routes
+	a.registerRoute(router, prefix, a.CreateWebhook, routeWebhooks, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetWebhooks, routeWebhooks, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetWebhook, routeWebhook, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteWebhook, routeWebhook, http.MethodDelete)
+	a.registerRoute(router, prefix, a.GetWebhookDeliveries, routeWebhookDeliveries, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetDeadDeliveries, routeDeadDeliveries, http.MethodGet)
+	a.registerRoute(router, prefix, a.RedeliverDelivery, routeRedeliver, http.MethodPost)
+	// Init alert rules routes
+	a.registerRoute(router, prefix, a.CreateAlertRule, routeAlertRules, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetAlertRules, routeAlertRules, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetAlertRule, routeAlertRule, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteAlertRule, routeAlertRule, http.MethodDelete)
+	// Init activity category rules routes
+	a.registerRoute(router, prefix, a.CreateCategoryRule, routeCategoryRules, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetCategoryRules, routeCategoryRules, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetCategoryRule, routeCategoryRule, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteCategoryRule, routeCategoryRule, http.MethodDelete)
+	// Init retention policies routes
+	a.registerRoute(router, prefix, a.CreateRetentionPolicy, routeRetentionPolicies, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetRetentionPolicies, routeRetentionPolicies, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetRetentionPolicy, routeRetentionPolicy, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteRetentionPolicy, routeRetentionPolicy, http.MethodDelete)
+	// Init time off routes
+	a.registerRoute(router, prefix, a.CreateTimeOff, routeTimeOffs, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetTimeOffs, routeTimeOffs, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetTimeOff, routeTimeOff, http.MethodGet)
+	a.registerRoute(router, prefix, a.DeleteTimeOff, routeTimeOff, http.MethodDelete)
+	// Init background jobs routes
+	a.registerRoute(router, prefix, a.GetJobs, routeJobs, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetJob, routeJob, http.MethodGet)
+	a.registerRoute(router, prefix, a.RunJob, routeJobRun, http.MethodPost)
+	// Init tenants routes, they are allowed to superadmin only
+	a.registerRoute(router, prefix, a.CreateTenant, routeTenants, http.MethodPost)
+	a.registerRoute(router, prefix, a.GetTenants, routeTenants, http.MethodGet)
+	a.registerRoute(router, prefix, a.GetTenant, routeTenant, http.MethodGet)
+	a.registerRoute(router, prefix, a.SuspendTenant, routeTenantSuspend, http.MethodPost)
+	a.registerRoute(router, prefix, a.ResumeTenant, routeTenantResume, http.MethodPost)
+	a.registerRoute(router, prefix, a.CreateTenantAdmin, routeTenantAdmins, http.MethodPost)
+	// Init import and export routes
+	a.registerRoute(router, prefix, a.ImportUsers, routeImportUsers, http.MethodPost)
+	a.registerRoute(router, prefix, a.ExportUsers, routeExportUsers, http.MethodGet)
+	a.registerRoute(router, prefix, a.ExportDepartments, routeExportDepartments, http.MethodGet)
+	a.registerRoute(router, prefix, a.ExportActivities, routeExportActivities, http.MethodGet)
+	a.registerRoute(router, prefix, a.ExportUsersReport, routeExportUsersReport, http.MethodGet)
+	a.registerRoute(router, prefix, a.ExportDepartmentsReport, routeExportDepartmentsReport, http.MethodGet)
 
-	return a
+	// Walk never fails here, because walk function always returns nil.
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if version.deprecated {
+			deprecation.Deprecate(route.GetName())
+		}
+
+		if version.compat {
+			compat.Compat(route.GetName())
+		}
+
+		return nil
+	})
 }
 
-// registerRoute - route init helper
-func (a *AApi) registerRoute(f func(http.ResponseWriter, *http.Request), path string, methods ...string) {
+// requireDependencies - every route except health probes and OpenAPI document requires db,
+// routes that work with tokens require cache as well.
+func (a *AApi) requireDependencies(m *middleware.DependencyMiddleware) {
+	noDependencies := make(map[string]bool)
+	cacheRoutes := make(map[string]bool)
+
+	for _, name := range append(routeNames(routeOpenAPI), unversionedRoutes...) {
+		noDependencies[name] = true
+	}
+
+	for _, name := range routeNames(routeLogin, routeLogout, routeRefresh, routeUnregister) {
+		cacheRoutes[name] = true
+	}
+	// Walk never fails here, because walk function always returns nil.
+	_ = a.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		name := route.GetName()
+
+		if name == "" || noDependencies[name] { // subrouters have no name
+			return nil
+		}
+
+		m.Require(name, a.sqlBreaker)
+
+		if cacheRoutes[name] {
+			m.Require(name, a.cacheBreaker)
+		}
+
+		return nil
+	})
+}
+
+// registerRoute - route init helper. Route is named by its full path,
+// so it's unique across versions and could be excluded from authz.
+func (a *AApi) registerRoute(
+	router *mux.Router,
+	prefix string,
+	f func(http.ResponseWriter, *http.Request),
+	path string,
+	methods ...string,
+) {
 	a.logger.WithField("func", "registerRoute").
-		Debugf("Initializing route %s with methods: %v", path, methods)
-	a.router.HandleFunc(path, f).Name(path).Methods(methods...) // Name if set for ability to exclude route from authz
+		Debugf("Initializing route %s%s with methods: %v", prefix, path, methods)
+	router.HandleFunc(path, f).Name(prefix + path).Methods(methods...)
+}
+
+// log - returns api logger with ID of given request.
+func (a *AApi) log(r *http.Request) logrus.FieldLogger {
+	return api_common.Logger(r, a.logger)
+}
+
+// pagination - parses pagination of list request, responds with 400 if it's invalid.
+func (a *AApi) pagination(w http.ResponseWriter, r *http.Request) (*models.Pagination, bool) {
+	page, err := api_common.ParsePagination(r)
+
+	if err != nil {
+		a.log(r).WithField("func", "pagination").Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, err.Error(), a.log(r))
+
+		return nil, false
+	}
+
+	return page, true
+}
+
+// timeRange - parses TimeStart and TimeEnd query params, responds with 400 if range is malformed.
+func (a *AApi) timeRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	start, end, err := api_common.ParseTimeRange(r)
+
+	if err != nil {
+		a.log(r).WithField("func", "timeRange").Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, err.Error(), a.log(r))
+
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, end, true
 }
 
 // Start - starts api server
 func (a *AApi) Start() {
-	a.logger.WithField("func", "Start").Info("Staring AApi on:", a.server.Addr)
-	a.logger.Info("Listen and serve:", a.server.ListenAndServe())
+	entry := a.logger.WithField("func", "Start")
+	entry.Info("Staring AApi on:", a.server.Addr)
+
+	listener, err := net.Listen("tcp", a.server.Addr)
+
+	if err != nil {
+		entry.Errorf("Listen error: %v", err)
+
+		return
+	}
+	// Api is ready only when it's actually listening.
+	a.setReady(true)
+
+	if a.server.TLSConfig != nil {
+		entry.Info("Serving over TLS")
+		// Certificate is provided by TLSConfig, so files are not passed here.
+		err = a.server.ServeTLS(listener, "", "")
+	} else {
+		err = a.server.Serve(listener)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		entry.Errorf("Serve error: %v", err)
+
+		return
+	}
+
+	entry.Info("Api server stopped")
+}
+
+// Shutdown - gracefully stops api server: flips readiness to unhealthy, waits for shutdown delay,
+// so load balancer could stop sending requests, and then waits for in-flight requests to finish.
+// If ctx expires before all requests are finished, remaining connections are closed forcibly.
+func (a *AApi) Shutdown(ctx context.Context) error {
+	entry := a.logger.WithField("func", "Shutdown")
+	entry.Info("Shutting down AApi...")
+
+	a.setReady(false)
+
+	if a.shutdownDelay > 0 {
+		entry.Infof("Waiting %v before closing listener...", a.shutdownDelay)
+
+		select {
+		case <-time.After(a.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		entry.Warnf("Graceful shutdown failed: %v, closing connections...", err)
+
+		if closeErr := a.server.Close(); closeErr != nil {
+			return fmt.Errorf("server Close(): %w", closeErr)
+		}
+
+		return fmt.Errorf("server Shutdown(): %w", err)
+	}
+
+	return nil
 }
 
-// Close - closes api server
+// Close - closes api server immediately, in-flight requests are aborted.
 func (a *AApi) Close() error {
 	a.logger.WithField("func", "Close").Info("Closing AApi....")
+	a.setReady(false)
+
 	return a.server.Close()
 }
+
+// SetRateLimit - changes rate limit of api, could be called while api is serving requests.
+func (a *AApi) SetRateLimit(rate float64, burst int) {
+	a.rateLimit.SetLimit(rate, burst)
+}
+
+// setReady - sets api readiness.
+func (a *AApi) setReady(ready bool) {
+	var value int32
+
+	if ready {
+		value = 1
+	}
+
+	atomic.StoreInt32(&a.ready, value)
+}
+
+// isReady - returns true if api is ready to serve requests.
+func (a *AApi) isReady() bool {
+	return atomic.LoadInt32(&a.ready) == 1
+}
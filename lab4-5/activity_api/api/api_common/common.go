@@ -1,19 +1,79 @@
 package api_common
 
 import (
+	"activity_api/common/models"
 	"encoding/json"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
 // RespondWithError - responds with error message to client.
-func RespondWithError(w http.ResponseWriter, code int, message string, logger logrus.FieldLogger) {
-	RespondWithJson(w, code, map[string]string{"Error": message}, logger)
+func RespondWithError(w http.ResponseWriter, r *http.Request, code int, message string, logger logrus.FieldLogger) {
+	RespondWithErrorData(w, r, code, message, nil, logger)
+}
+
+// RespondWithErrorData - responds with error message and data describing the error, e.g. results of failed import.
+// Legacy clients get error message only.
+func RespondWithErrorData(
+	w http.ResponseWriter,
+	r *http.Request,
+	code int,
+	message string,
+	payload interface{},
+	logger logrus.FieldLogger,
+) {
+	if IsCompat(r.Context()) {
+		respond(w, code, ToLegacy(&models.Error{Error: message}), logger)
+
+		return
+	}
+
+	respond(w, code, &models.Envelope{
+		Data:  payload,
+		Error: &models.ErrorBody{Code: code, Message: message},
+		Meta:  &models.Meta{RequestID: RequestID(r.Context())},
+	}, logger)
 }
 
 // RespondWithJson - responds to client with given data and code.
-func RespondWithJson(w http.ResponseWriter, code int, payload interface{}, logger logrus.FieldLogger) {
-	entry := logger.WithField("func", "RespondWithJson")
+func RespondWithJson(w http.ResponseWriter, r *http.Request, code int, payload interface{}, logger logrus.FieldLogger) {
+	RespondWithPage(w, r, code, payload, nil, logger)
+}
+
+// RespondWithPage - responds to client with given page of data and code.
+// Legacy clients get bare payload with Go field names, others get it in envelope.
+func RespondWithPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	code int,
+	payload interface{},
+	pagination *models.Pagination,
+	logger logrus.FieldLogger,
+) {
+	if IsCompat(r.Context()) {
+		respond(w, code, ToLegacy(payload), logger)
+
+		return
+	}
+
+	respond(w, code, &models.Envelope{
+		Data: payload,
+		Meta: &models.Meta{RequestID: RequestID(r.Context()), Pagination: pagination},
+	}, logger)
+}
+
+// DecodeJSON - decodes request body to v, legacy clients send Go field names.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	if IsCompat(r.Context()) {
+		return FromLegacy(r.Body, v)
+	}
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// respond - writes given payload as json.
+func respond(w http.ResponseWriter, code int, payload interface{}, logger logrus.FieldLogger) {
+	entry := logger.WithField("func", "respond")
 	response, err := json.Marshal(payload)
 
 	if err != nil {
@@ -26,6 +86,4 @@ func RespondWithJson(w http.ResponseWriter, code int, payload interface{}, logge
 	if _, err := w.Write(response); err != nil {
 		entry.Warn("Response write error:", err)
 	}
-
-	return
 }
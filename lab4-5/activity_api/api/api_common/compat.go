@@ -0,0 +1,148 @@
+package api_common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Legacy JSON shape (api without version prefix) uses Go field names, because models had no json tags.
+// Fields which had explicit names before are marked with `legacy:"name"` tag.
+// Instead of duplicating every model, untagged copies of model types are built by reflection.
+
+// legacyTypes - cache of legacy types, key and value are reflect.Type.
+var legacyTypes sync.Map
+
+// ToLegacy - returns copy of v which is marshalled to legacy JSON shape.
+func ToLegacy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(v)
+
+	return convert(value, legacyType(value.Type())).Interface()
+}
+
+// FromLegacy - decodes JSON in legacy shape from reader to v, v must be a pointer.
+func FromLegacy(reader io.Reader, v interface{}) error {
+	value := reflect.ValueOf(v)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("FromLegacy(): non-nil pointer expected, got %T", v)
+	}
+
+	legacy := reflect.New(legacyType(value.Type().Elem()))
+
+	if err := json.NewDecoder(reader).Decode(legacy.Interface()); err != nil {
+		return err
+	}
+
+	value.Elem().Set(convert(legacy.Elem(), value.Type().Elem()))
+
+	return nil
+}
+
+// legacyType - returns type with the same layout as t, but with legacy json names.
+func legacyType(t reflect.Type) reflect.Type {
+	if cached, ok := legacyTypes.Load(t); ok {
+		return cached.(reflect.Type)
+	}
+
+	var legacy reflect.Type
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		legacy = reflect.PtrTo(legacyType(t.Elem()))
+	case reflect.Slice:
+		legacy = reflect.SliceOf(legacyType(t.Elem()))
+	case reflect.Map:
+		legacy = reflect.MapOf(t.Key(), legacyType(t.Elem()))
+	case reflect.Struct:
+		legacy = legacyStruct(t)
+	default:
+		legacy = t
+	}
+
+	legacyTypes.Store(t, legacy)
+
+	return legacy
+}
+
+// legacyStruct - legacyType helper for structs. Structs with custom marshalling
+// or unexported fields are kept as is.
+func legacyStruct(t reflect.Type) reflect.Type {
+	marshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	if t.Implements(marshaler) || reflect.PtrTo(t).Implements(marshaler) {
+		return t
+	}
+
+	fields := make([]reflect.StructField, t.NumField())
+
+	for i := range fields {
+		field := t.Field(i)
+
+		if field.PkgPath != "" {
+			return t
+		}
+
+		name := field.Name
+
+		if tag, ok := field.Tag.Lookup("legacy"); ok {
+			name = tag
+		}
+
+		fields[i] = reflect.StructField{
+			Name: field.Name,
+			Type: legacyType(field.Type),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s"`, name)),
+		}
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// convert - copies value to value of given type, types must have the same layout.
+func convert(value reflect.Value, to reflect.Type) reflect.Value {
+	if value.Type() == to {
+		return value
+	}
+
+	result := reflect.New(to).Elem()
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			ptr := reflect.New(to.Elem())
+			ptr.Elem().Set(convert(value.Elem(), to.Elem()))
+			result.Set(ptr)
+		}
+	case reflect.Slice:
+		if !value.IsNil() {
+			result.Set(reflect.MakeSlice(to, value.Len(), value.Len()))
+
+			for i := 0; i < value.Len(); i++ {
+				result.Index(i).Set(convert(value.Index(i), to.Elem()))
+			}
+		}
+	case reflect.Map:
+		if !value.IsNil() {
+			result.Set(reflect.MakeMapWithSize(to, value.Len()))
+
+			for _, key := range value.MapKeys() {
+				result.SetMapIndex(key, convert(value.MapIndex(key), to.Elem()))
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			result.Field(i).Set(convert(value.Field(i), to.Field(i).Type))
+		}
+	default:
+		result.Set(value.Convert(to))
+	}
+
+	return result
+}
@@ -0,0 +1,79 @@
+package api_common
+
+import (
+	"activity_api/common/models"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Compat(t *testing.T) {
+	t.Run("ToLegacy_field_names", func(t *testing.T) {
+		users := []*models.User{{UserID: 1, UserName: "name", DepartmentID: 2}}
+
+		bts, err := json.Marshal(ToLegacy(users))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := `[{"UserID":1,"UserName":"name","DepartmentID":2,"Email":"","EmployeeNumber":"",` +
+			`"JobTitle":"","Status":"","EmploymentStart":0,"EmploymentEnd":0}]`
+
+		if string(bts) != expected {
+			t.Errorf("expected %s, got %s", expected, bts)
+		}
+	})
+
+	t.Run("ToLegacy_legacy_tag", func(t *testing.T) {
+		bts, err := json.Marshal(ToLegacy(&models.Tokens{AccessToken: "a", RefreshToken: "r"}))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := `{"access_token":"a","refresh_token":"r"}`; string(bts) != expected {
+			t.Errorf("expected %s, got %s", expected, bts)
+		}
+	})
+
+	t.Run("ToLegacy_nil", func(t *testing.T) {
+		var users []*models.User
+
+		bts, err := json.Marshal(ToLegacy(users))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(bts) != "null" {
+			t.Errorf("expected null, got %s", bts)
+		}
+	})
+
+	t.Run("FromLegacy_field_names", func(t *testing.T) {
+		activity := new(models.Activity)
+		body := `{"UserID":1,"TotalTime":20,"ActiveTime":10,"Date":1600000000}`
+
+		if err := FromLegacy(strings.NewReader(body), activity); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := &models.Activity{UserID: 1, TotalTime: 20, ActiveTime: 10, Date: 1600000000}
+
+		if !reflect.DeepEqual(activity, expected) {
+			t.Errorf("expected %+v, got %+v", expected, activity)
+		}
+	})
+
+	t.Run("FromLegacy_invalid", func(t *testing.T) {
+		if err := FromLegacy(strings.NewReader(`{"UserID":"one"}`), new(models.User)); err == nil {
+			t.Error("error expected for invalid field type")
+		}
+
+		if err := FromLegacy(strings.NewReader(`{}`), models.User{}); err == nil {
+			t.Error("error expected for non pointer")
+		}
+	})
+}
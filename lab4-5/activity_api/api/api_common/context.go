@@ -0,0 +1,63 @@
+package api_common
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// contextKey - private type for context keys, so they can't collide with keys from other packages.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	compatKey
+	roleKey
+)
+
+// WithRequestID - returns copy of given context with request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID - returns request ID from given context, empty string if there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+
+	return requestID
+}
+
+// Logger - returns logger with request ID field of given request,
+// so all entries of one request could be found in log.
+func Logger(r *http.Request, logger logrus.FieldLogger) logrus.FieldLogger {
+	if requestID := RequestID(r.Context()); requestID != "" {
+		return logger.WithField("request_id", requestID)
+	}
+
+	return logger
+}
+
+// WithCompat - returns copy of given context with compatibility mode on,
+// responses are written in legacy shape.
+func WithCompat(ctx context.Context) context.Context {
+	return context.WithValue(ctx, compatKey, true)
+}
+
+// IsCompat - returns true if compatibility mode is on in given context.
+func IsCompat(ctx context.Context) bool {
+	compat, _ := ctx.Value(compatKey).(bool)
+
+	return compat
+}
+
+// WithRole - returns copy of given context with role of admin which made request.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// Role - returns role of admin from given context, empty string if request isn't made by admin.
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+
+	return role
+}
@@ -0,0 +1,57 @@
+package api_common
+
+import (
+	"activity_api/common/models"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Pagination query params.
+const (
+	QueryLimit  = "limit"
+	QueryOffset = "offset"
+)
+
+// ParsePagination - parses limit and offset query params of list request, both are optional.
+func ParsePagination(r *http.Request) (*models.Pagination, error) {
+	page := new(models.Pagination)
+	params := map[string]*int{QueryLimit: &page.Limit, QueryOffset: &page.Offset}
+
+	for name, value := range params {
+		raw := r.URL.Query().Get(name)
+
+		if raw == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(raw)
+
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s: non-negative integer expected, got %q", name, raw)
+		}
+
+		*value = parsed
+	}
+
+	return page, nil
+}
+
+// Paginate - sets total of page and returns bounds of page in list of total length.
+func Paginate(page *models.Pagination, total int) (int, int) {
+	page.Total = total
+
+	start := page.Offset
+
+	if start > total {
+		start = total
+	}
+
+	end := total
+
+	if page.Limit > 0 && start+page.Limit < total {
+		end = start + page.Limit
+	}
+
+	return start, end
+}
@@ -0,0 +1,67 @@
+package api_common
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Time range query params.
+const (
+	QueryTimeStart = "TimeStart"
+	QueryTimeEnd   = "TimeEnd"
+)
+
+var (
+	// minTime, maxTime - range of accepted times, any of them could be written both ways. It's range of RFC 3339
+	// times except zero time and earlier ones, because zero time means range isn't limited.
+	minTime = time.Time{}.Unix() + 1
+	maxTime = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC).Unix()
+)
+
+// ParseTime - parses time of query param: RFC 3339 time or unix time in seconds, fraction of second is dropped.
+// Empty value is zero time, i.e. range isn't limited by it.
+func ParseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if unix < minTime || unix > maxTime {
+			return time.Time{}, fmt.Errorf("unix time out of range, got %q", raw)
+		}
+
+		return time.Unix(unix, 0).UTC(), nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RFC 3339 or unix time expected, got %q", raw)
+	}
+
+	if unix := parsed.Unix(); unix < minTime || unix > maxTime {
+		return time.Time{}, fmt.Errorf("time out of range, got %q", raw)
+	}
+
+	return time.Unix(parsed.Unix(), 0).UTC(), nil
+}
+
+// ParseTimeRange - parses optional TimeStart and TimeEnd query params of request, zero time isn't limited.
+// Range with start after end is valid, it's just empty.
+func ParseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	start, err := ParseTime(r.URL.Query().Get(QueryTimeStart))
+
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s: %w", QueryTimeStart, err)
+	}
+
+	end, err := ParseTime(r.URL.Query().Get(QueryTimeEnd))
+
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s: %w", QueryTimeEnd, err)
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,108 @@
+package api_common
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_ParseTime(t *testing.T) {
+	valid := map[string]int64{
+		"0":                         0,
+		"-1":                        -1,
+		"1577836800":                1577836800,
+		"2020-01-01T00:00:00Z":      1577836800,
+		"2020-01-01T03:00:00+03:00": 1577836800,
+		"2020-01-01T00:00:00.9Z":    1577836800,
+		"9999-12-31T23:59:59Z":      maxTime,
+	}
+
+	for raw, expected := range valid {
+		parsed, err := ParseTime(raw)
+
+		if err != nil || parsed.Unix() != expected {
+			t.Errorf("ParseTime(%q) = %v, error: %v, expected %d", raw, parsed, err, expected)
+		}
+	}
+
+	if parsed, err := ParseTime(""); err != nil || !parsed.IsZero() {
+		t.Errorf("Empty time parsed as %v, error: %v", parsed, err)
+	}
+
+	for _, raw := range []string{
+		" 1", "1.5", "1e9", "0x10", "2020-01-01", "2020-01-01 00:00:00Z", "2020-13-01T00:00:00Z",
+		"'; DROP TABLE user_activity; --", "9223372036854775807", "-62135596800", "0001-01-01T00:00:00Z",
+	} {
+		if parsed, err := ParseTime(raw); err == nil {
+			t.Errorf("Invalid time %q parsed as %v", raw, parsed)
+		}
+	}
+}
+
+// Test_ParseTimeFuzz - parses random and mutated times: parsing never panics, parsed time is never zero
+// and within range, and it's parsed back the same from both unix and RFC 3339 form.
+func Test_ParseTimeFuzz(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	alphabet := []byte("0123456789-+:.TZtz ';e")
+	seeds := []string{"1577836800", "-1", "2020-01-01T00:00:00Z", "2020-02-29T23:59:59.999+14:00"}
+
+	for i := 0; i < 100000; i++ {
+		var raw string
+
+		switch i % 3 {
+		case 0: // random unix time, mostly out of range
+			raw = strconv.FormatInt(random.Int63()>>uint(random.Intn(64))-random.Int63()>>uint(random.Intn(64)), 10)
+		case 1: // random RFC 3339 time
+			raw = time.Unix(minTime+random.Int63n(maxTime-minTime), 0).
+				In(time.FixedZone("", (random.Intn(48)-24)*1800)).Format(time.RFC3339)
+		default: // mutated seed
+			mutated := []byte(seeds[random.Intn(len(seeds))])
+
+			for n := random.Intn(4); n >= 0; n-- {
+				mutated[random.Intn(len(mutated))] = alphabet[random.Intn(len(alphabet))]
+			}
+
+			raw = string(mutated)
+		}
+
+		parsed, err := ParseTime(raw)
+
+		if err != nil {
+			continue
+		}
+
+		if parsed.IsZero() || parsed.Unix() < minTime || parsed.Unix() > maxTime {
+			t.Fatalf("ParseTime(%q) = %v, out of range", raw, parsed)
+		}
+
+		for _, again := range []string{strconv.FormatInt(parsed.Unix(), 10), parsed.Format(time.RFC3339)} {
+			if reparsed, err := ParseTime(again); err != nil || !reparsed.Equal(parsed) {
+				t.Fatalf("ParseTime(%q) = %v, but %q parsed as %v, error: %v", raw, parsed, again, reparsed, err)
+			}
+		}
+	}
+}
+
+func Test_ParseTimeRange(t *testing.T) {
+	parse := func(start, end string) error {
+		query := url.Values{QueryTimeStart: {start}, QueryTimeEnd: {end}}
+		_, _, err := ParseTimeRange(httptest.NewRequest("GET", "/control?"+query.Encode(), nil))
+
+		return err
+	}
+
+	for _, valid := range [][2]string{{"", ""}, {"1", ""}, {"", "1"}, {"2", "1"}, {"1", "2020-01-01T00:00:00Z"}} {
+		if err := parse(valid[0], valid[1]); err != nil {
+			t.Errorf("Range %v isn't parsed: %v", valid, err)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"x", ""}, {"", "x"}, {"1", "2020-01-01"}, {"0001-01-01T00:00:00Z", ""}} {
+		if err := parse(invalid[0], invalid[1]); err == nil {
+			t.Errorf("Invalid range %v is parsed", invalid)
+		}
+	}
+}
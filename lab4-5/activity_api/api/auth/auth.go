@@ -34,6 +34,8 @@ type service struct {
 type AccessDetails struct {
 	TokenUuid string
 	Username  string
+	TenantID  int64 // tenant of admin, all requests with the token are scoped by it
+	Expires   int64 // unix time token expires at
 }
 
 // TokenDetails - JWT token details.
@@ -26,6 +26,35 @@ func TokenValid(r *http.Request) error {
 	return nil
 }
 
+// TokenTenant - checks if given token in the request is valid and returns its tenant.
+func TokenTenant(r *http.Request) (int64, error) {
+	token, err := verifyToken(r)
+
+	if err != nil {
+		return 0, err
+	}
+
+	access, err := extract(token)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return access.TenantID, nil
+}
+
+// ClaimsTenant - returns tenant of token claims, tokens issued before tenants were added have no tenant.
+func ClaimsTenant(claims jwt.MapClaims) (int64, error) {
+	// Numbers of MapClaims are decoded as float64.
+	tenantID, ok := claims["tenant_id"].(float64)
+
+	if !ok || tenantID <= 0 {
+		return 0, errors.New("token has no tenant")
+	}
+
+	return int64(tenantID), nil
+}
+
 // verifyToken - checks if given token in the request is valid.
 func verifyToken(r *http.Request) (*jwt.Token, error) {
 	return ParseToken(extractToken(r))
@@ -48,6 +77,10 @@ func ParseToken(tokenString string) (*jwt.Token, error) {
 	})
 }
 
+// QueryToken - query param with access token, accepted on routes where clients can't set headers,
+// e.g. browser EventSource and WebSocket.
+const QueryToken = "access_token"
+
 // extractToken - get the token from the request body
 func extractToken(r *http.Request) string {
 	bearerToken := r.Header.Get("Authorization")
@@ -67,13 +100,18 @@ func extract(token *jwt.Token) (*AccessDetails, error) {
 	if ok && token.Valid {
 		accessUuid, ok := claims["access_uuid"].(string)
 		username, userOk := claims["user_id"].(string)
+		// Numbers of MapClaims are decoded as float64.
+		expires, _ := claims["exp"].(float64)
+		tenantID, err := ClaimsTenant(claims)
 
-		if ok == false || userOk == false {
+		if ok == false || userOk == false || err != nil {
 			return nil, errors.New("unauthorized")
 		} else {
 			return &AccessDetails{
 				TokenUuid: accessUuid,
 				Username:  username,
+				TenantID:  tenantID,
+				Expires:   int64(expires),
 			}, nil
 		}
 	}
@@ -14,7 +14,7 @@ import (
 
 // IToken - token AAService interface.
 type IToken interface {
-	CreateToken(userId string) (*TokenDetails, error)
+	CreateToken(userId string, tenantID int64) (*TokenDetails, error)
 	ExtractTokenMetadata(*http.Request) (*AccessDetails, error)
 }
 
@@ -30,8 +30,8 @@ func NewToken(logger logrus.FieldLogger) IToken {
 	}
 }
 
-// CreateToken - creates access and refresh jwt token.
-func (t *tokenService) CreateToken(username string) (*TokenDetails, error) {
+// CreateToken - creates access and refresh jwt token, tenant of admin is carried in claims of both.
+func (t *tokenService) CreateToken(username string, tenantID int64) (*TokenDetails, error) {
 	entry := t.logger.WithField("func", "CreateToken")
 	entry.Debug("Creating token for:", username)
 
@@ -48,6 +48,7 @@ func (t *tokenService) CreateToken(username string) (*TokenDetails, error) {
 	atClaims := jwt.MapClaims{}
 	atClaims["access_uuid"] = td.TokenUuid
 	atClaims["user_id"] = username
+	atClaims["tenant_id"] = tenantID
 	atClaims["exp"] = td.AtExpires
 	at := jwt.NewWithClaims(jwt.SigningMethodRS256, atClaims)
 	block, rest := pem.Decode([]byte(os.Getenv("TOKEN_PRIVATE")))
@@ -76,6 +77,7 @@ func (t *tokenService) CreateToken(username string) (*TokenDetails, error) {
 	rtClaims := jwt.MapClaims{}
 	rtClaims["refresh_uuid"] = td.RefreshUuid
 	rtClaims["user_id"] = username
+	rtClaims["tenant_id"] = tenantID
 	rtClaims["exp"] = td.RtExpires
 	rt := jwt.NewWithClaims(jwt.SigningMethodRS256, rtClaims)
 	// Sign refresh token with parsed private key.
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"activity_api/common/key_generator"
+	"github.com/dgrijalva/jwt-go"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// setKeys - sets new token keys to env.
+func setKeys(t *testing.T) {
+	private, public, err := key_generator.GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Setenv("TOKEN_PRIVATE", private); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Setenv("TOKEN_PUBLIC", public); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// bearer - returns request with given access token.
+func bearer(token string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	return r
+}
+
+func TestToken_Tenant(t *testing.T) {
+	setKeys(t)
+	tokens := NewToken(logger)
+
+	t.Run("TokenTenant_claim", func(t *testing.T) {
+		td, err := tokens.CreateToken("admin", 7)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tenantID, err := TokenTenant(bearer(td.AccessToken))
+
+		if err != nil || tenantID != 7 {
+			t.Errorf("expected tenant 7, got %d, error: %v", tenantID, err)
+		}
+
+		access, err := tokens.ExtractTokenMetadata(bearer(td.AccessToken))
+
+		if err != nil || access.TenantID != 7 || access.Username != "admin" {
+			t.Errorf("unexpected access details: %+v, error: %v", access, err)
+		}
+
+		refresh, err := ParseToken(td.RefreshToken)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tenantID, err := ClaimsTenant(refresh.Claims.(jwt.MapClaims)); err != nil || tenantID != 7 {
+			t.Errorf("expected tenant 7 in refresh token, got %d, error: %v", tenantID, err)
+		}
+	})
+
+	t.Run("TokenTenant_noClaim", func(t *testing.T) {
+		for _, claims := range []jwt.MapClaims{{}, {"tenant_id": 0.0}, {"tenant_id": "1"}} {
+			if _, err := ClaimsTenant(claims); err == nil {
+				t.Errorf("claims without valid tenant are accepted: %v", claims)
+			}
+		}
+	})
+}
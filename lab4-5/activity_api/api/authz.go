@@ -5,7 +5,7 @@ import (
 	"activity_api/api/api_common"
 	"activity_api/api/auth"
 	"activity_api/common/models"
-	"encoding/json"
+	"activity_api/data_manager/db/core"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
@@ -15,17 +15,18 @@ import (
 // Login - login handler, checks request name and password,
 // and if its valid, return access and refresh token to the user.
 func (a *AApi) Login(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "Login")
+	entry := a.log(r).WithField("func", "Login")
 	entry.Debug("Request from:", r.RemoteAddr)
 	var req models.Admin
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := api_common.DecodeJSON(r, &req); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -33,27 +34,42 @@ func (a *AApi) Login(w http.ResponseWriter, r *http.Request) {
 
 	entry.Debugf("Request from %s, admin name: %s", r.RemoteAddr, req.Username)
 	// Check if admin with given name and password hash exists.
-	if code, err := a.checkAdmin(&req); err != nil {
+	if code, err := a.checkAdmin(r, &req); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			code,
 			fmt.Sprintf("checkAdmin(): %v", err),
-			a.logger,
+			a.log(r),
+		)
+
+		return
+	}
+	// Admins of suspended tenant can't login.
+	if code, err := a.checkTenant(r, req.TenantID); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			code,
+			fmt.Sprintf("checkTenant(): %v", err),
+			a.log(r),
 		)
 
 		return
 	}
 	// If all is fine - create auth token for admin.
-	ts, err := a.token.CreateToken(req.Username)
+	ts, err := a.token.CreateToken(req.Username, req.TenantID)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("CreateToken(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -63,26 +79,27 @@ func (a *AApi) Login(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("CreateAuth(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
-	tokens := map[string]string{
-		"access_token":  ts.AccessToken,
-		"refresh_token": ts.RefreshToken,
+	tokens := models.Tokens{
+		AccessToken:  ts.AccessToken,
+		RefreshToken: ts.RefreshToken,
 	}
 
 	entry.Debugf("Responding to %s with tokens...", r.RemoteAddr)
-	api_common.RespondWithJson(w, http.StatusOK, &tokens, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &tokens, a.log(r))
 }
 
 // Logout - logouts user, deletes his tokens.
 func (a *AApi) Logout(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "Logout")
+	entry := a.log(r).WithField("func", "Logout")
 	entry.Debugf("Request from %s", r.RemoteAddr)
 	//If metadata is passed and the tokens valid, delete them from the redis store
 	metadata, err := a.token.ExtractTokenMetadata(r)
@@ -91,9 +108,10 @@ func (a *AApi) Logout(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("ExtractTokenMetadata(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -104,9 +122,10 @@ func (a *AApi) Logout(w http.ResponseWriter, r *http.Request) {
 			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 			api_common.RespondWithError(
 				w,
+				r,
 				http.StatusBadRequest,
 				fmt.Sprintf("DeleteTokens(): %v", err),
-				a.logger,
+				a.log(r),
 			)
 
 			return
@@ -114,89 +133,36 @@ func (a *AApi) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	entry.Debugf("Responding to %s with OK...", r.RemoteAddr)
-	api_common.RespondWithJson(w, http.StatusOK, nil, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, nil, a.log(r))
 }
 
 // Register - registers admin with data from JSON.
 func (a *AApi) Register(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "Register")
+	entry := a.log(r).WithField("func", "Register")
 	entry.Debug("Request from:", r.RemoteAddr)
 
 	req := new(models.Admin)
 
-	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+	if err := api_common.DecodeJSON(r, req); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
-		)
-
-		return
-	}
-	// Check if admin with given name exists in db.
-	admin, err := a.sqlManager.GetAdmin(req.Username)
-
-	if err != nil {
-		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
-		api_common.RespondWithError(
-			w,
-			http.StatusUnprocessableEntity,
-			fmt.Sprintf("GetAdmin(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
-	// If admin with given name already exists - return an error.
-	if admin != nil {
-		entry.Errorf("Respond to %s, admin with name %s already exists", r.RemoteAddr, req.Username)
-		api_common.RespondWithError(
-			w,
-			http.StatusConflict,
-			"admin with given name already exists",
-			a.logger,
-		)
-
-		return
-	}
-	// Get hash of a password hash (salt is used).
-	req.Hash, err = a.password.HashPassword(req.Hash)
-
-	if err != nil {
-		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
-		api_common.RespondWithError(
-			w,
-			http.StatusUnprocessableEntity,
-			fmt.Sprintf("HashPassword(): %v", err),
-			a.logger,
-		)
-
-		return
-	}
-	// Create admin if all is good.
-	id, err := a.sqlManager.CreateAdmin(req)
-
-	if err != nil {
-		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
-		api_common.RespondWithError(
-			w,
-			http.StatusUnprocessableEntity,
-			fmt.Sprintf("CreateAdmin(): %v", err),
-			a.logger,
-		)
-
-		return
-	}
-
-	entry.Debugf("Responding to %s with: %d", r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusCreated, &models.ObjectID{ID: id}, a.logger)
+	// Public registration creates admins of default tenant only.
+	req.TenantID = core.DefaultTenant
+	a.createAdmin(w, r, req)
 }
 
 // Unregister - deletes user from database.
 func (a *AApi) Unregister(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "Unregister")
+	entry := a.log(r).WithField("func", "Unregister")
 	entry.Debug("Request from:", r.RemoteAddr)
 
 	metadata, err := a.token.ExtractTokenMetadata(r)
@@ -205,9 +171,10 @@ func (a *AApi) Unregister(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("ExtractTokenMetadata(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -217,24 +184,34 @@ func (a *AApi) Unregister(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			"invalid user metadata",
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	// Superadmin is created from config, deleted one would be created again on restart.
+	if a.superadmin != "" && metadata.Username == a.superadmin {
+		entry.Warnf("Respond to %s, superadmin %s can't unregister", r.RemoteAddr, metadata.Username)
+		api_common.RespondWithError(w, r, http.StatusForbidden, "superadmin can't unregister", a.log(r))
+
+		return
+	}
+
 	entry.Info("Deleting admin with name:", metadata.Username)
-	_, err = a.sqlManager.DeleteAdmin(metadata.Username)
+	_, err = a.sqlManager.DeleteAdmin(r.Context(), metadata.Username)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("DeleteAdmin(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -247,25 +224,25 @@ func (a *AApi) Unregister(w http.ResponseWriter, r *http.Request) {
 
 // Refresh - refreshes user access token with refresh token.
 func (a *AApi) Refresh(w http.ResponseWriter, r *http.Request) {
-	mapToken := map[string]string{}
-	decoder := json.NewDecoder(r.Body)
+	body := new(models.Tokens)
 
-	entry := a.logger.WithField("func", "Refresh")
+	entry := a.log(r).WithField("func", "Refresh")
 	entry.Debug("Request from:", r.RemoteAddr)
 
-	if err := decoder.Decode(&mapToken); err != nil {
+	if err := api_common.DecodeJSON(r, body); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
-	refreshToken := mapToken["refresh_token"]
+	refreshToken := body.RefreshToken
 	//verify the token
 	token, err := auth.ParseToken(refreshToken)
 	//if there is an error, the token must have expired
@@ -273,9 +250,10 @@ func (a *AApi) Refresh(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnauthorized,
 			fmt.Sprintf("ParseToken(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -285,9 +263,10 @@ func (a *AApi) Refresh(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond error to %s, invalid token", r.RemoteAddr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnauthorized,
 			"invalid token",
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -300,16 +279,17 @@ func (a *AApi) Refresh(w http.ResponseWriter, r *http.Request) {
 		entry.Errorf("Respond error to %s, refresh expired", r.RemoteAddr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnauthorized,
 			"refresh expired",
-			a.logger,
+			a.log(r),
 		)
 	}
 }
 
 // refresh - Refresh helper.
 func (a *AApi) refresh(w http.ResponseWriter, r *http.Request, claims jwt.MapClaims) {
-	entry := a.logger.WithField("func", "refresh")
+	entry := a.log(r).WithField("func", "refresh")
 
 	refreshUuid, ok := claims["refresh_uuid"].(string) //convert the interface to string
 
@@ -317,9 +297,10 @@ func (a *AApi) refresh(w http.ResponseWriter, r *http.Request, claims jwt.MapCla
 		entry.Errorf("Respond error to %s, invalid refresh uuid", r.RemoteAddr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			"invalid refresh uuid",
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -331,9 +312,38 @@ func (a *AApi) refresh(w http.ResponseWriter, r *http.Request, claims jwt.MapCla
 		entry.Errorf("Respond error to %s, invalid username claims", r.RemoteAddr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			"error getting username",
-			a.logger,
+			a.log(r),
+		)
+
+		return
+	}
+
+	tenantID, err := auth.ClaimsTenant(claims)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnauthorized,
+			fmt.Sprintf("ClaimsTenant(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if code, err := a.checkTenant(r, tenantID); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			code,
+			fmt.Sprintf("checkTenant(): %v", err),
+			a.log(r),
 		)
 
 		return
@@ -341,29 +351,31 @@ func (a *AApi) refresh(w http.ResponseWriter, r *http.Request, claims jwt.MapCla
 
 	entry.Debugf("Refreshing token for admin %s  (addr: %s)", userId, r.RemoteAddr)
 	//Delete the previous Refresh Token
-	err := a.auth.DeleteRefresh(refreshUuid)
+	err = a.auth.DeleteRefresh(refreshUuid)
 	if err != nil { //if any goes wrong
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnauthorized,
 			fmt.Sprintf("DeleteRefresh(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 	//Create new pairs of refresh and access tokens
 	entry.Debug("Creating token for admin:", userId)
-	ts, err := a.token.CreateToken(userId)
+	ts, err := a.token.CreateToken(userId, tenantID)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusForbidden,
 			fmt.Sprintf("CreateToken(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -377,27 +389,108 @@ func (a *AApi) refresh(w http.ResponseWriter, r *http.Request, claims jwt.MapCla
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, saveErr)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusForbidden,
 			fmt.Sprintf("CreateAuth(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
-	tokens := map[string]string{
-		"access_token":  ts.AccessToken,
-		"refresh_token": ts.RefreshToken,
+	tokens := models.Tokens{
+		AccessToken:  ts.AccessToken,
+		RefreshToken: ts.RefreshToken,
 	}
 
 	entry.Debugf("Responding to %s (admin: %s) with refreshed tokens...", r.RemoteAddr, userId)
-	api_common.RespondWithJson(w, http.StatusCreated, &tokens, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &tokens, a.log(r))
 }
 
-func (a *AApi) checkAdmin(req *models.Admin) (int, error) {
-	a.logger.WithField("func", "checkAdmin").Debug("Checking admin with name: ", req.Username)
+// createAdmin - Register and CreateTenantAdmin helper, creates admin with unique name.
+func (a *AApi) createAdmin(w http.ResponseWriter, r *http.Request, req *models.Admin) {
+	entry := a.log(r).WithField("func", "createAdmin")
+
+	if err := validRole(req); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+	// Superadmin is created from config only, so its name can't be taken before it.
+	if a.superadmin != "" && req.Username == a.superadmin {
+		entry.Warnf("Respond to %s, name of superadmin %s is reserved", r.RemoteAddr, req.Username)
+		api_common.RespondWithError(w, r, http.StatusForbidden, "name is reserved for superadmin", a.log(r))
 
-	admin, err := a.sqlManager.GetAdmin(req.Username)
+		return
+	}
+	// Check if admin with given name exists in db.
+	admin, err := a.sqlManager.GetAdmin(r.Context(), req.Username)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetAdmin(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+	// If admin with given name already exists - return an error.
+	if admin != nil {
+		entry.Errorf("Respond to %s, admin with name %s already exists", r.RemoteAddr, req.Username)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusConflict,
+			"admin with given name already exists",
+			a.log(r),
+		)
+
+		return
+	}
+	// Get hash of a password hash (salt is used).
+	req.Hash, err = a.password.HashPassword(req.Hash)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("HashPassword(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+	// Create admin if all is good.
+	id, err := a.sqlManager.CreateAdmin(r.Context(), req)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateAdmin(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+func (a *AApi) checkAdmin(r *http.Request, req *models.Admin) (int, error) {
+	a.log(r).WithField("func", "checkAdmin").Debug("Checking admin with name: ", req.Username)
+
+	admin, err := a.sqlManager.GetAdmin(r.Context(), req.Username)
 
 	if err != nil {
 		return http.StatusUnprocessableEntity, err
@@ -411,22 +504,41 @@ func (a *AApi) checkAdmin(req *models.Admin) (int, error) {
 		return http.StatusUnauthorized, errors.New("please provide valid login details")
 	}
 
+	req.TenantID = admin.TenantID
+
+	return -1, nil
+}
+
+// checkTenant - returns an error if tenant doesn't exist or it's suspended.
+func (a *AApi) checkTenant(r *http.Request, tenantID int64) (int, error) {
+	a.log(r).WithField("func", "checkTenant").Debug("Checking tenant: ", tenantID)
+
+	active, err := a.tenantActive(r.Context(), tenantID)
+
+	if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	if !active {
+		return http.StatusForbidden, fmt.Errorf("tenant %d is suspended or doesn't exist", tenantID)
+	}
+
 	return -1, nil
 }
 
 // Decided not to use this check in Unregister
 // SQL will return an error anyway if it doesn't exist
 
-//admin, err := a.sqlManager.GetAdmin(metadata.Username)
+//admin, err := a.sqlManager.GetAdmin(r.Context(), metadata.Username)
 //
 //if err != nil {
-//	api_common.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+//	api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error())
 //
 //	return
 //}
 //
 //if admin == nil {
-//	api_common.RespondWithError(w, http.StatusNotFound, "admin doesn't exists")
+//	api_common.RespondWithError(w, r, http.StatusNotFound, "admin doesn't exists")
 //
 //	return
 //}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// activityCategories - categories rule could map apps to.
+var activityCategories = []string{models.CategoryProductive, models.CategoryNeutral, models.CategoryUnproductive}
+
+// GetCategoryRules - returns all category rules.
+func (a *AApi) GetCategoryRules(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "GetCategoryRules")
+	entry.Debug("Request from: ", r.RemoteAddr)
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	rules, err := a.sqlManager.GetCategoryRules(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetCategoryRules(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with category rules list (len %d)", r.RemoteAddr, len(rules))
+	start, end := api_common.Paginate(page, len(rules))
+	api_common.RespondWithPage(w, r, http.StatusOK, rules[start:end], page, a.log(r))
+}
+
+// GetCategoryRule - returns category rule with given ID.
+func (a *AApi) GetCategoryRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetCategoryRule")
+	entry.Debugf("Request from %s, ruleID: %s", r.RemoteAddr, vars["id"])
+
+	rule, err := a.sqlManager.GetCategoryRule(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetCategoryRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if rule == nil {
+		entry.Warnf("Respond to %s, category rule doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "category rule doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *rule)
+	api_common.RespondWithJson(w, r, http.StatusOK, rule, a.log(r))
+}
+
+// CreateCategoryRule - creates category rule from given JSON, it applies to already recorded activity too.
+func (a *AApi) CreateCategoryRule(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateCategoryRule")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	rule := new(models.CategoryRule)
+
+	if err := api_common.DecodeJSON(r, rule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateCategoryRule(rule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
+	rule.CreatedAt = time.Now().Unix()
+
+	entry.Debugf("Creating category rule %+v, request from: %s", rule, r.RemoteAddr)
+	id, err := a.sqlManager.CreateCategoryRule(r.Context(), rule)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateCategoryRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Category rule created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// DeleteCategoryRule - deletes category rule with given ID.
+func (a *AApi) DeleteCategoryRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "DeleteCategoryRule")
+	entry.Debugf("Request from %s, ruleID: %s", r.RemoteAddr, vars["id"])
+
+	id, err := a.sqlManager.DeleteCategoryRule(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteCategoryRule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Category rule %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// validateCategoryRule - checks category rule, pattern is matched by SQLite GLOB,
+// its syntax is the same as of filepath.Match for names without separators.
+func validateCategoryRule(rule *models.CategoryRule) error {
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+
+	if rule.Pattern == "" {
+		return errors.New("pattern: must not be empty")
+	}
+
+	if _, err := filepath.Match(rule.Pattern, ""); err != nil {
+		return fmt.Errorf("pattern: invalid glob %q: %v", rule.Pattern, err)
+	}
+
+	for _, category := range activityCategories {
+		if rule.Category == category {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("category: unknown category %q, expected: %s",
+		rule.Category, strings.Join(activityCategories, ", "))
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func (a *AApi) defHandler(w http.ResponseWriter, r *http.Request) {
-	a.logger.WithField("func", "defHandler").
+	a.log(r).WithField("func", "defHandler").
 		Debugf("Request from %s on path: %s", r.RemoteAddr, r.RequestURI)
-	api_common.RespondWithError(w, http.StatusNotFound, "handler doesn't exist", a.logger)
+	api_common.RespondWithError(w, r, http.StatusNotFound, "handler doesn't exist", a.log(r))
 }
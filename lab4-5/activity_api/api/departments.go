@@ -3,50 +3,63 @@ package api
 import (
 	"activity_api/api/api_common"
 	"activity_api/common/models"
-	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"sort"
+	"strconv"
 )
 
+// queryRoot - query param of departments tree root.
+const queryRoot = "root"
+
 // GetDepartments - returns all departments records.
 func (a *AApi) GetDepartments(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "GetDepartments")
+	entry := a.log(r).WithField("func", "GetDepartments")
 	entry.Debug("Request from:", r.RemoteAddr)
 
-	departs, err := a.sqlManager.GetDepartments()
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	departs, err := a.sqlManager.GetDepartments(r.Context())
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetDepartments(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, &departs)
-	api_common.RespondWithJson(w, http.StatusOK, &departs, a.logger)
+	start, end := api_common.Paginate(page, len(departs))
+	api_common.RespondWithPage(w, r, http.StatusOK, departs[start:end], page, a.log(r))
 }
 
 // GetDepartment - returns department record with given ID.
 func (a *AApi) GetDepartment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "GetDepartment")
+	entry := a.log(r).WithField("func", "GetDepartment")
 	entry.Debugf("Request from %s, DepartID: %s", r.RemoteAddr, vars["id"])
 
-	depart, err := a.sqlManager.GetDepartment(vars["id"])
+	depart, err := a.sqlManager.GetDepartment(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetDepartment(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -54,72 +67,285 @@ func (a *AApi) GetDepartment(w http.ResponseWriter, r *http.Request) {
 
 	if depart == nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
-		api_common.RespondWithError(w, http.StatusNotFound, "depart doesn't exists", a.logger)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "depart doesn't exists", a.log(r))
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, depart)
-	api_common.RespondWithJson(w, http.StatusOK, &depart, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &depart, a.log(r))
 }
 
 // CreateDepartment - writes to db department record from json.
 func (a *AApi) CreateDepartment(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "CreateDepartment")
+	entry := a.log(r).WithField("func", "CreateDepartment")
 	entry.Debug("Request from:", r.RemoteAddr)
 
 	depart := new(models.Department)
 
-	if err := json.NewDecoder(r.Body).Decode(depart); err != nil {
+	if err := api_common.DecodeJSON(r, depart); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
+			a.log(r),
+		)
+
+		return
+	}
+
+	if depart.ParentID < 0 {
+		entry.Errorf("Respond to %s, negative parent id: %d", r.RemoteAddr, depart.ParentID)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("parent_id: must not be negative, got %d", depart.ParentID),
+			a.log(r),
 		)
 
 		return
 	}
 
-	id, err := a.sqlManager.CreateDepartment(depart)
+	id, err := a.sqlManager.CreateDepartment(r.Context(), depart)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("CreateDepartment(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Department created, responding to %s with: %d", r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusCreated, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
 }
 
 // DeleteDepartment - deletes department with given ID from DB.
 func (a *AApi) DeleteDepartment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "DeleteDepartment")
+	entry := a.log(r).WithField("func", "DeleteDepartment")
 	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
 
-	id, err := a.sqlManager.DeleteDepartment(vars["id"])
+	id, err := a.sqlManager.DeleteDepartment(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("DeleteDepartment(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Department %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusOK, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// GetDepartmentsTree - returns departments tree as list in depth-first order,
+// with root query param only subtree of given department is returned.
+func (a *AApi) GetDepartmentsTree(w http.ResponseWriter, r *http.Request) {
+	rootParam := r.URL.Query().Get(queryRoot)
+
+	entry := a.log(r).WithField("func", "GetDepartmentsTree")
+	entry.Debugf("Request from %s, root: %q", r.RemoteAddr, rootParam)
+
+	var root int64
+
+	if rootParam != "" {
+		var err error
+
+		if root, err = strconv.ParseInt(rootParam, 10, 64); err != nil || root <= 0 {
+			entry.Errorf("Respond to %s, invalid root: %q", r.RemoteAddr, rootParam)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("%s: positive department id expected, got %q", queryRoot, rootParam),
+				a.log(r),
+			)
+
+			return
+		}
+	}
+
+	departs, err := a.sqlManager.GetDepartments(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetDepartments(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	tree := departmentsTree(departs, root)
+
+	if tree == nil {
+		entry.Warnf("Respond to %s, root department doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "depart doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with departments tree (len %d)", r.RemoteAddr, len(tree))
+	api_common.RespondWithJson(w, r, http.StatusOK, tree, a.log(r))
+}
+
+// MoveDepartment - sets parent of department with given ID, responds with moved department.
+// Department can't be moved under itself or its descendant.
+func (a *AApi) MoveDepartment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "MoveDepartment")
+	entry.Debugf("Request from %s, DepartID: %s", r.RemoteAddr, vars["id"])
+
+	move := new(models.DepartmentMove)
+
+	if err := api_common.DecodeJSON(r, move); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if move.ParentID < 0 {
+		entry.Errorf("Respond to %s, negative parent id: %d", r.RemoteAddr, move.ParentID)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("parent_id: must not be negative, got %d", move.ParentID),
+			a.log(r),
+		)
+
+		return
+	}
+
+	moved, err := a.sqlManager.MoveDepartment(r.Context(), vars["id"], move.ParentID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("MoveDepartment(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if moved == 0 {
+		entry.Warnf("Respond to %s, department doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "depart doesn't exists", a.log(r))
+
+		return
+	}
+
+	depart, err := a.sqlManager.GetDepartment(r.Context(), vars["id"])
+
+	if err != nil || depart == nil {
+		entry.Errorf("Respond to %s, moved department isn't read: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetDepartment(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Department %s moved, responding to %s with: %+v", vars["id"], r.RemoteAddr, depart)
+	api_common.RespondWithJson(w, r, http.StatusOK, depart, a.log(r))
+}
+
+// departmentsTree - returns subtree of root department in depth-first order, nil if root doesn't exist.
+// With 0 root the whole forest is returned, departments with unknown parent are its roots too.
+func departmentsTree(departs []*models.Department, root int64) []*models.DepartmentNode {
+	byID := make(map[int64]*models.Department, len(departs))
+	children := make(map[int64][]int64, len(departs))
+
+	for _, depart := range departs {
+		byID[depart.DepartmentID] = depart
+	}
+
+	for _, depart := range departs {
+		parent := depart.ParentID
+
+		if _, ok := byID[parent]; !ok {
+			parent = 0
+		}
+
+		children[parent] = append(children[parent], depart.DepartmentID)
+	}
+
+	for _, ids := range children {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	roots := children[0]
+
+	if root != 0 {
+		if _, ok := byID[root]; !ok {
+			return nil
+		}
+
+		roots = []int64{root}
+	}
+
+	tree := make([]*models.DepartmentNode, 0, len(departs))
+	visited := make(map[int64]bool, len(departs))
+
+	var walk func(id int64, depth int)
+	walk = func(id int64, depth int) {
+		// Db prevents cycles, visited guards against ones written before that.
+		if visited[id] {
+			return
+		}
+
+		visited[id] = true
+		depart := byID[id]
+		tree = append(tree, &models.DepartmentNode{
+			DepartmentID:   depart.DepartmentID,
+			DepartmentName: depart.DepartmentName,
+			ParentID:       depart.ParentID,
+			Depth:          depth,
+			Children:       append([]int64{}, children[id]...),
+		})
+
+		for _, child := range children[id] {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, id := range roots {
+		walk(id, 0)
+	}
+
+	return tree
 }
@@ -0,0 +1,110 @@
+// Package event_hub - fan-out of live activity events to subscribers.
+package event_hub
+
+import (
+	"activity_api/common/models"
+	"sync"
+	"sync/atomic"
+)
+
+// Filter - subscription filter, zero user and department match any event.
+// Tenant isn't optional: subscriber receives events published to its tenant only.
+type Filter struct {
+	TenantID     int64
+	UserID       int64
+	DepartmentID int64
+}
+
+// Match - returns true if event passes the filter.
+func (f Filter) Match(event *models.Event) bool {
+	if event.Activity == nil {
+		return false
+	}
+
+	if f.UserID != 0 && f.UserID != event.Activity.UserID {
+		return false
+	}
+
+	return f.DepartmentID == 0 || f.DepartmentID == event.DepartmentID
+}
+
+// Subscription - subscriber of the hub. Events are queued in bounded buffer,
+// if subscriber doesn't read them in time, new events are dropped and counted.
+type Subscription struct {
+	filter  Filter
+	events  chan *models.Event
+	dropped int64 // accessed atomically
+}
+
+// Events - returns channel of subscription events, it's never closed.
+// Events are shared between subscribers and must not be modified.
+func (s *Subscription) Events() <-chan *models.Event {
+	return s.events
+}
+
+// Filter - returns filter of subscription.
+func (s *Subscription) Filter() Filter {
+	return s.filter
+}
+
+// TakeDropped - returns number of events dropped since previous call.
+func (s *Subscription) TakeDropped() int64 {
+	return atomic.SwapInt64(&s.dropped, 0)
+}
+
+// Hub - delivers published events to matching subscribers. Publish never blocks,
+// so slow subscriber doesn't slow down neither publisher nor other subscribers.
+type Hub struct {
+	mtx         sync.RWMutex
+	subscribers map[*Subscription]bool
+}
+
+// NewHub - returns new event hub.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*Subscription]bool)}
+}
+
+// Subscribe - adds subscriber with given filter and size of events buffer.
+func (h *Hub) Subscribe(filter Filter, buffer int) *Subscription {
+	s := &Subscription{filter: filter, events: make(chan *models.Event, buffer)}
+
+	h.mtx.Lock()
+	h.subscribers[s] = true
+	h.mtx.Unlock()
+
+	return s
+}
+
+// Unsubscribe - removes subscriber, events aren't queued for it anymore.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mtx.Lock()
+	delete(h.subscribers, s)
+	h.mtx.Unlock()
+}
+
+// Len - returns number of subscribers.
+func (h *Hub) Len() int {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	return len(h.subscribers)
+}
+
+// Publish - queues event of given tenant for every matching subscriber of the tenant,
+// event is dropped for subscribers with full buffer.
+func (h *Hub) Publish(tenantID int64, event *models.Event) {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	for s := range h.subscribers {
+		if s.filter.TenantID != tenantID || !s.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+}
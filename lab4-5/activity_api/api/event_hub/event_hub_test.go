@@ -0,0 +1,97 @@
+package event_hub
+
+import (
+	"activity_api/common/models"
+	"testing"
+)
+
+// tenant - tenant of events published in tests.
+const tenant = 1
+
+// activityEvent - returns activity event of given user and department.
+func activityEvent(userID, departmentID int64) *models.Event {
+	return &models.Event{
+		Type:         models.EventActivity,
+		Activity:     &models.Activity{UserID: userID},
+		DepartmentID: departmentID,
+	}
+}
+
+func Test_Hub(t *testing.T) {
+	t.Run("Publish_filters", func(t *testing.T) {
+		hub := NewHub()
+		all := hub.Subscribe(Filter{TenantID: tenant}, 10)
+		user := hub.Subscribe(Filter{TenantID: tenant, UserID: 1}, 10)
+		department := hub.Subscribe(Filter{TenantID: tenant, DepartmentID: 2}, 10)
+
+		hub.Publish(tenant, activityEvent(1, 3))
+		hub.Publish(tenant, activityEvent(4, 2))
+
+		for name, c := range map[string]struct {
+			s        *Subscription
+			expected int
+		}{
+			"all":        {all, 2},
+			"user":       {user, 1},
+			"department": {department, 1},
+		} {
+			if n := len(c.s.Events()); n != c.expected {
+				t.Errorf("%s: expected %d events, got %d", name, c.expected, n)
+			}
+		}
+	})
+
+	t.Run("Publish_tenant", func(t *testing.T) {
+		hub := NewHub()
+		own := hub.Subscribe(Filter{TenantID: tenant}, 10)
+		other := hub.Subscribe(Filter{TenantID: tenant + 1}, 10)
+
+		hub.Publish(tenant, activityEvent(1, 1))
+
+		if n := len(own.Events()); n != 1 {
+			t.Errorf("expected 1 event of own tenant, got %d", n)
+		}
+
+		if n := len(other.Events()); n != 0 {
+			t.Errorf("event is delivered to subscriber of other tenant, got %d", n)
+		}
+	})
+
+	t.Run("Publish_drops_when_full", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe(Filter{TenantID: tenant}, 2)
+
+		for i := 0; i < 5; i++ {
+			hub.Publish(tenant, activityEvent(1, 1))
+		}
+
+		if n := len(s.Events()); n != 2 {
+			t.Errorf("expected 2 queued events, got %d", n)
+		}
+
+		if dropped := s.TakeDropped(); dropped != 3 {
+			t.Errorf("expected 3 dropped events, got %d", dropped)
+		}
+
+		if dropped := s.TakeDropped(); dropped != 0 {
+			t.Errorf("dropped counter isn't reset, got %d", dropped)
+		}
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		hub := NewHub()
+		s := hub.Subscribe(Filter{TenantID: tenant}, 1)
+		hub.Unsubscribe(s)
+		hub.Publish(tenant, activityEvent(1, 1))
+
+		if hub.Len() != 0 || len(s.Events()) != 0 {
+			t.Error("event is delivered to removed subscriber")
+		}
+	})
+
+	t.Run("Filter_ignores_totals", func(t *testing.T) {
+		if (Filter{TenantID: tenant}).Match(&models.Event{Type: models.EventTotals}) {
+			t.Error("totals event must not be published")
+		}
+	})
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/api/event_hub"
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventsBuffer - number of events queued for one connection, newer events are dropped while queue is full.
+	eventsBuffer = 64
+	// eventsTotalsInterval - interval of running totals push.
+	eventsTotalsInterval = 5 * time.Second
+	// eventsWriteTimeout - max time to write single WebSocket message, slower client is disconnected.
+	eventsWriteTimeout = 10 * time.Second
+	// sseLifetime - SSE stream is closed before server write timeout, EventSource reconnects in sseRetry.
+	sseLifetime = serverWriteTimeout - time.Second
+	sseRetry    = time.Second
+)
+
+// Events - streams live feed of created activity records over SSE, or over WebSocket if connection is upgraded.
+// Feed is filtered by userID and departmentID query params, running totals of the feed are pushed periodically,
+// totals are counted from TimeStart if it's set. Stream is closed when access token expires.
+func (a *AApi) Events(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "Events")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	filter, err := eventsFilter(r)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, err.Error(), a.log(r))
+
+		return
+	}
+
+	if _, _, ok := a.timeRange(w, r); !ok {
+		return
+	}
+
+	access, err := a.token.ExtractTokenMetadata(r)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnauthorized,
+			fmt.Sprintf("ExtractTokenMetadata(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+	// Feed of admin is limited to its tenant.
+	filter.TenantID = access.TenantID
+
+	expires := time.Unix(access.Expires, 0)
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		a.serveWebSocket(w, r, filter, expires)
+
+		return
+	}
+
+	a.serveSSE(w, r, filter, expires)
+}
+
+// serveSSE - streams events as Server-Sent Events.
+func (a *AApi) serveSSE(w http.ResponseWriter, r *http.Request, filter event_hub.Filter, expires time.Time) {
+	entry := a.log(r).WithField("func", "serveSSE")
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		entry.Errorf("Respond to %s, error: streaming isn't supported", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusInternalServerError, "streaming isn't supported", a.log(r))
+
+		return
+	}
+
+	if lifetime := time.Now().Add(sseLifetime); lifetime.Before(expires) {
+		expires = lifetime
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disables buffering of nginx proxy
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		entry.Warn("Response write error:", err)
+
+		return
+	}
+
+	flusher.Flush()
+
+	err := a.streamEvents(r.Context(), r, filter, expires, func(event *models.Event) error {
+		data, err := json.Marshal(eventPayload(r, event))
+
+		if err != nil {
+			return fmt.Errorf("json.Marshal(): %w", err)
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return fmt.Errorf("write event: %w", err)
+		}
+
+		flusher.Flush()
+
+		return nil
+	})
+
+	if err != nil {
+		entry.Warnf("Events stream to %s stopped, error: %v", r.RemoteAddr, err)
+	}
+}
+
+// serveWebSocket - streams events as WebSocket text messages, messages from client are ignored.
+func (a *AApi) serveWebSocket(w http.ResponseWriter, r *http.Request, filter event_hub.Filter, expires time.Time) {
+	entry := a.log(r).WithField("func", "serveWebSocket")
+	// Connection of HTTP/2 request can't be taken over.
+	if r.ProtoMajor != 1 {
+		entry.Errorf("Respond to %s, error: WebSocket over %s", r.RemoteAddr, r.Proto)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, "WebSocket requires HTTP/1.1", a.log(r))
+
+		return
+	}
+	// Origin isn't checked: cookies aren't used, so page of other origin can't open feed without token.
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		// Deadlines of http server are kept after connection is taken over.
+		if err := ws.SetReadDeadline(time.Time{}); err != nil {
+			entry.Warn("SetReadDeadline() error:", err)
+
+			return
+		}
+		// Client messages are read only to handle close and ping frames, feed stops when connection is closed.
+		go func() {
+			defer cancel()
+
+			var message string
+
+			for {
+				if err := websocket.Message.Receive(ws, &message); err != nil {
+					return
+				}
+			}
+		}()
+
+		err := a.streamEvents(ctx, r, filter, expires, func(event *models.Event) error {
+			if err := ws.SetWriteDeadline(time.Now().Add(eventsWriteTimeout)); err != nil {
+				return fmt.Errorf("SetWriteDeadline(): %w", err)
+			}
+
+			return websocket.JSON.Send(ws, eventPayload(r, event))
+		})
+
+		if err != nil {
+			entry.Warnf("Events stream to %s stopped, error: %v", r.RemoteAddr, err)
+		}
+	}}
+
+	server.ServeHTTP(w, r)
+}
+
+// streamEvents - sends events of subscription and periodic totals until ctx is done, api is stopping,
+// expires is reached or send fails. Send is called from one goroutine, so slow client blocks only own stream,
+// events published meanwhile are queued, and dropped when queue is full.
+func (a *AApi) streamEvents(
+	ctx context.Context,
+	r *http.Request,
+	filter event_hub.Filter,
+	expires time.Time,
+	send func(*models.Event) error,
+) error {
+	entry := a.log(r).WithField("func", "streamEvents")
+	subscription := a.events.Subscribe(filter, eventsBuffer)
+	defer a.events.Unsubscribe(subscription)
+
+	ticker := time.NewTicker(eventsTotalsInterval)
+	defer ticker.Stop()
+
+	expired := time.NewTimer(time.Until(expires))
+	defer expired.Stop()
+
+	timeStart, _, _ := api_common.ParseTimeRange(r) // range is checked by Events
+	var id int64
+	// write - sends copy of event, because events from hub are shared between connections.
+	write := func(event *models.Event) error {
+		id++
+		out := *event
+		out.ID = id
+		out.Time = time.Now().Unix()
+		out.Dropped = subscription.TakeDropped()
+
+		return send(&out)
+	}
+	// totals - sends running totals, db errors are logged only, so stream survives short db outage.
+	totals := func() error {
+		event, err := a.eventTotals(ctx, filter, timeStart)
+
+		if err != nil {
+			entry.Warn("eventTotals() error:", err)
+
+			return nil
+		}
+
+		return write(event)
+	}
+
+	entry.Debugf("Streaming events to %s, filter: %+v", r.RemoteAddr, filter)
+	// Totals are sent right away, so client doesn't wait for first tick.
+	if err := totals(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.cancel.Cancelled():
+			return nil
+		case <-expired.C:
+			entry.Debugf("Events stream to %s expired", r.RemoteAddr)
+
+			return nil
+		case <-ticker.C:
+			if err := totals(); err != nil {
+				return err
+			}
+		case event := <-subscription.Events():
+			if err := write(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventTotals - returns running totals event of given filter, totals of every department if filter is empty.
+func (a *AApi) eventTotals(ctx context.Context, filter event_hub.Filter, timeStart time.Time) (*models.Event, error) {
+	event := &models.Event{Type: models.EventTotals}
+
+	if filter.UserID != 0 {
+		totals, err := a.sqlManager.GetUserActivity(ctx, strconv.FormatInt(filter.UserID, 10), timeStart, time.Time{})
+
+		if err != nil {
+			return nil, fmt.Errorf("GetUserActivity(): %w", err)
+		}
+
+		event.UserTotals = totals
+	}
+
+	if filter.DepartmentID != 0 {
+		departmentID := strconv.FormatInt(filter.DepartmentID, 10)
+		totals, err := a.sqlManager.GetDepartmentActivity(ctx, departmentID, timeStart, time.Time{}, false)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetDepartmentActivity(): %w", err)
+		}
+
+		event.DepartmentTotals = []*models.DepartmentActivity{totals}
+	}
+
+	if filter.UserID != 0 || filter.DepartmentID != 0 {
+		return event, nil
+	}
+
+	err := a.sqlManager.StreamDepartmentsReport(ctx, timeStart, time.Time{}, func(report *models.DepartmentReport) error {
+		event.DepartmentTotals = append(event.DepartmentTotals, &models.DepartmentActivity{
+			DepartmentID: report.DepartmentID,
+			ActiveTime:   report.ActiveTime,
+			TotalTime:    report.TotalTime,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("StreamDepartmentsReport(): %w", err)
+	}
+
+	return event, nil
+}
+
+// publishActivity - publishes created activity record to live feed.
+// Department of the user is looked up only if someone listens to the feed.
+func (a *AApi) publishActivity(r *http.Request, activity *models.Activity) {
+	if a.events.Len() == 0 {
+		return
+	}
+
+	entry := a.log(r).WithField("func", "publishActivity")
+	tenantID, err := core.TenantID(r.Context())
+
+	if err != nil {
+		entry.Warn("TenantID() error:", err)
+
+		return
+	}
+
+	event := &models.Event{Type: models.EventActivity, Activity: activity}
+	user, err := a.sqlManager.GetUser(r.Context(), strconv.FormatInt(activity.UserID, 10))
+	// Event is published anyway, it just doesn't match department filters.
+	if err != nil {
+		entry.Warn("GetUser() error:", err)
+	}
+
+	if user != nil {
+		event.DepartmentID = user.DepartmentID
+	}
+
+	a.events.Publish(tenantID, event)
+}
+
+// eventsFilter - parses userID and departmentID filter of live feed.
+func eventsFilter(r *http.Request) (event_hub.Filter, error) {
+	filter := event_hub.Filter{}
+	params := map[string]*int64{"userID": &filter.UserID, "departmentID": &filter.DepartmentID}
+
+	for name, value := range params {
+		raw := r.URL.Query().Get(name)
+
+		if raw == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+
+		if err != nil || parsed <= 0 {
+			return filter, fmt.Errorf("%s: positive integer expected, got %q", name, raw)
+		}
+
+		*value = parsed
+	}
+
+	return filter, nil
+}
+
+// eventPayload - returns event in shape of request api version.
+func eventPayload(r *http.Request, event *models.Event) interface{} {
+	if api_common.IsCompat(r.Context()) {
+		return api_common.ToLegacy(event)
+	}
+
+	return event
+}
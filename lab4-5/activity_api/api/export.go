@@ -0,0 +1,250 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/export"
+	"activity_api/common/models"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// queryFormat - export format param, overrides Accept header.
+	queryFormat = "format"
+	// exportFlushRows - number of rows after which written rows are flushed to client.
+	exportFlushRows = 500
+)
+
+// exportFunc - streams rows of exported table to write.
+type exportFunc func(ctx context.Context, write rowWriter) error
+
+// rowWriter - writes row of exported table.
+type rowWriter func(values ...interface{}) error
+
+// ExportUsers - exports users, if departmentID was specified in URL query - only users of the department.
+func (a *AApi) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	depID := r.URL.Query().Get("departmentID")
+	header := []string{
+		"user_id",
+		"user_name",
+		"department_id",
+		"email",
+		"employee_number",
+		"job_title",
+		"status",
+		"employment_start",
+		"employment_end",
+	}
+
+	stream := func(ctx context.Context, write rowWriter) error {
+		return a.sqlManager.StreamUsers(ctx, depID, func(user *models.User) error {
+			return write(
+				user.UserID,
+				user.UserName,
+				user.DepartmentID,
+				user.Email,
+				user.EmployeeNumber,
+				user.JobTitle,
+				user.Status,
+				user.EmploymentStart,
+				user.EmploymentEnd,
+			)
+		})
+	}
+
+	a.export(w, r, "ExportUsers", "users", header, stream)
+}
+
+// ExportDepartments - exports departments.
+func (a *AApi) ExportDepartments(w http.ResponseWriter, r *http.Request) {
+	header := []string{"department_id", "department_name", "parent_id"}
+
+	stream := func(ctx context.Context, write rowWriter) error {
+		return a.sqlManager.StreamDepartments(ctx, func(department *models.Department) error {
+			return write(department.DepartmentID, department.DepartmentName, department.ParentID)
+		})
+	}
+
+	a.export(w, r, "ExportDepartments", "departments", header, stream)
+}
+
+// ExportActivities - exports raw activity records.
+func (a *AApi) ExportActivities(w http.ResponseWriter, r *http.Request) {
+	header := []string{"record_id", "user_id", "active_time", "total_time", "date"}
+
+	stream := func(ctx context.Context, write rowWriter) error {
+		return a.sqlManager.StreamActivities(ctx, func(activity *models.Activity) error {
+			return write(activity.RecordID, activity.UserID, activity.ActiveTime, activity.TotalTime, activity.Date)
+		})
+	}
+
+	a.export(w, r, "ExportActivities", "activities", header, stream)
+}
+
+// ExportUsersReport - exports activity time of every user for given period of time.
+// If no time is set is URL query - all time stat is collected.
+func (a *AApi) ExportUsersReport(w http.ResponseWriter, r *http.Request) {
+	timeStart, timeEnd, ok := a.timeRange(w, r)
+
+	if !ok {
+		return
+	}
+
+	header := []string{"user_id", "user_name", "department_id", "active_time", "total_time"}
+
+	stream := func(ctx context.Context, write rowWriter) error {
+		return a.sqlManager.StreamUsersReport(ctx, timeStart, timeEnd, func(report *models.UserReport) error {
+			return write(report.UserID, report.UserName, report.DepartmentID, report.ActiveTime, report.TotalTime)
+		})
+	}
+
+	a.export(w, r, "ExportUsersReport", "users_report", header, stream)
+}
+
+// ExportDepartmentsReport - exports activity time of every department for given period of time.
+// If no time is set is URL query - all time stat is collected. Analysts get anonymized report,
+// departments with too few users are left out.
+func (a *AApi) ExportDepartmentsReport(w http.ResponseWriter, r *http.Request) {
+	timeStart, timeEnd, ok := a.timeRange(w, r)
+
+	if !ok {
+		return
+	}
+
+	header := []string{"department_id", "department_name", "active_time", "total_time"}
+
+	stream := func(ctx context.Context, write rowWriter) error {
+		anonymized := a.anonymized(r)
+		each := func(report *models.DepartmentReport) error {
+			if anonymized && !a.anonymizer.DepartmentReport(report) {
+				return nil
+			}
+
+			return write(report.DepartmentID, report.DepartmentName, report.ActiveTime, report.TotalTime)
+		}
+
+		return a.sqlManager.StreamDepartmentsReport(ctx, timeStart, timeEnd, each)
+	}
+
+	a.export(w, r, "ExportDepartmentsReport", "departments_report", header, stream)
+}
+
+// export - streams table in format requested by format param or Accept header.
+// Rows are flushed to client as they come from db, so an error in the middle of export can't be reported
+// with status code anymore, such response is aborted and client gets truncated body.
+func (a *AApi) export(
+	w http.ResponseWriter,
+	r *http.Request,
+	handler, name string,
+	header []string,
+	stream exportFunc,
+) {
+	entry := a.log(r).WithField("func", handler)
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	format, err := export.Negotiate(r.URL.Query().Get(queryFormat), r.Header.Get("Accept"))
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, err.Error(), a.log(r))
+
+		return
+	}
+
+	response := &exportResponse{
+		ResponseWriter: w,
+		contentType:    export.ContentType(format),
+		filename:       name + "." + format,
+	}
+	// Writers buffer output, so nothing reaches client until first flush, and errors before it are reported as usual.
+	writer, err := export.NewWriter(format, response, name)
+
+	if err == nil {
+		err = a.exportRows(r.Context(), writer, header, stream, response)
+	}
+
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err == nil {
+		entry.Debugf("Exported %s as %s to %s", name, format, r.RemoteAddr)
+
+		return
+	}
+
+	if response.started {
+		entry.Errorf("Export to %s aborted, error: %v", r.RemoteAddr, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+	api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%s(): %v", handler, err), a.log(r))
+}
+
+// exportRows - writes header and streamed rows, flushes them to client every exportFlushRows rows.
+func (a *AApi) exportRows(
+	ctx context.Context,
+	writer export.Writer,
+	header []string,
+	stream exportFunc,
+	response *exportResponse,
+) error {
+	values := make([]interface{}, len(header))
+
+	for i, column := range header {
+		values[i] = column
+	}
+
+	if err := writer.WriteRow(values...); err != nil {
+		return fmt.Errorf("WriteRow(): %w", err)
+	}
+
+	rows := 0
+
+	return stream(ctx, func(values ...interface{}) error {
+		if err := writer.WriteRow(values...); err != nil {
+			return fmt.Errorf("WriteRow(): %w", err)
+		}
+
+		if rows++; rows%exportFlushRows != 0 {
+			return nil
+		}
+
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("Flush(): %w", err)
+		}
+
+		response.Flush()
+
+		return nil
+	})
+}
+
+// exportResponse - sets export headers on first write, so error response could be written until then.
+type exportResponse struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool // true after first write, status code is already sent
+}
+
+// Write - writes export data, headers are sent with first write.
+func (e *exportResponse) Write(b []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.Header().Set("Content-Type", e.contentType)
+		e.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename))
+		e.WriteHeader(http.StatusOK)
+	}
+
+	return e.ResponseWriter.Write(b)
+}
+
+// Flush - sends written data to client if response writer supports it.
+func (e *exportResponse) Flush() {
+	if flusher, ok := e.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"net/http"
+)
+
+// Live - liveness probe, responds OK while process is able to serve http.
+func (a *AApi) Live(w http.ResponseWriter, r *http.Request) {
+	a.log(r).WithField("func", "Live").Debug("Request from:", r.RemoteAddr)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.Status{Status: "alive"}, a.log(r))
+}
+
+// Ready - readiness probe, responds with 503 when api is starting or shutting down.
+func (a *AApi) Ready(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "Ready")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	if !a.isReady() {
+		entry.Debugf("Respond to %s, api is not ready", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusServiceUnavailable, "api is not ready", a.log(r))
+
+		return
+	}
+
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.Status{Status: "ready"}, a.log(r))
+}
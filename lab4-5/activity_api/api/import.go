@@ -0,0 +1,109 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/csv_import"
+	"activity_api/common/models"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// queryDryRun - import param, dry run validates file against db without writing.
+	queryDryRun = "dry_run"
+	// maxImportSize - max size of imported file.
+	maxImportSize = 10 << 20
+)
+
+// ImportUsers - imports users and departments from CSV body in single transaction,
+// departments are matched by name or created. Responds with result of every row.
+func (a *AApi) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "ImportUsers")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	dryRun := false
+
+	if raw := r.URL.Query().Get(queryDryRun); raw != "" {
+		var err error
+
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("%s: boolean expected, got %q", queryDryRun, raw),
+				a.log(r),
+			)
+
+			return
+		}
+	}
+
+	rows, err := csv_import.Parse(http.MaxBytesReader(w, r.Body, maxImportSize))
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Parse(): %v", err), a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Importing %d rows, dry run: %v, request from: %s", len(rows), dryRun, r.RemoteAddr)
+	result, err := a.sqlManager.ImportUsers(r.Context(), rows, dryRun)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("ImportUsers(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	switch {
+	case result.Failed > 0 && !dryRun:
+		entry.Debugf("Import failed, %d rows are invalid, responding to %s", result.Failed, r.RemoteAddr)
+		api_common.RespondWithErrorData(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("%d rows failed, nothing is imported", result.Failed),
+			result,
+			a.log(r),
+		)
+	case dryRun:
+		entry.Debugf("Dry run finished, responding to %s with: %+v", r.RemoteAddr, *result)
+		api_common.RespondWithJson(w, r, http.StatusOK, result, a.log(r))
+	default:
+		a.publishImported(r, rows, result)
+		entry.Debugf("Import committed, responding to %s with: %+v", r.RemoteAddr, *result)
+		api_common.RespondWithJson(w, r, http.StatusCreated, result, a.log(r))
+	}
+}
+
+// publishImported - queues webhook event for every user created by committed import.
+func (a *AApi) publishImported(r *http.Request, rows []*models.ImportRow, result *models.ImportResult) {
+	names := make(map[int]string, len(rows))
+
+	for _, row := range rows {
+		names[row.Line] = row.UserName
+	}
+
+	for _, row := range result.Rows {
+		if row.UserID == 0 {
+			continue
+		}
+
+		a.publishWebhook(r, models.WebhookUserCreated, &models.User{
+			UserID:       row.UserID,
+			UserName:     names[row.Line],
+			DepartmentID: row.DepartmentID,
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/scheduler"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// GetJobs - returns background jobs with their schedule and last run.
+func (a *AApi) GetJobs(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "GetJobs")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	if !a.jobsEnabled(w, r) {
+		return
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	jobs := a.jobs.Jobs()
+
+	entry.Debugf("Responding to %s with jobs list (len %d)", r.RemoteAddr, len(jobs))
+	start, end := api_common.Paginate(page, len(jobs))
+	api_common.RespondWithPage(w, r, http.StatusOK, jobs[start:end], page, a.log(r))
+}
+
+// GetJob - returns background job with given name and history of its runs.
+func (a *AApi) GetJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetJob")
+	entry.Debugf("Request from %s, job: %s", r.RemoteAddr, vars["name"])
+
+	if !a.jobsEnabled(w, r) {
+		return
+	}
+
+	job := a.jobs.Job(vars["name"])
+
+	if job == nil {
+		entry.Warnf("Respond to %s, job doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "job doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with job %s (history len %d)", r.RemoteAddr, job.Name, len(job.History))
+	api_common.RespondWithJson(w, r, http.StatusOK, job, a.log(r))
+}
+
+// RunJob - starts background job with given name out of its schedule, responds with started run.
+// Job runs don't overlap, so running job isn't started again.
+func (a *AApi) RunJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "RunJob")
+	entry.Debugf("Request from %s, job: %s", r.RemoteAddr, vars["name"])
+
+	if !a.jobsEnabled(w, r) {
+		return
+	}
+
+	run, err := a.jobs.Trigger(vars["name"])
+
+	if err != nil {
+		code := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			code = http.StatusNotFound
+		case errors.Is(err, scheduler.ErrJobRunning):
+			code = http.StatusConflict
+		case errors.Is(err, scheduler.ErrStopped):
+			code = http.StatusServiceUnavailable
+		}
+
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, code, fmt.Sprintf("Trigger(): %v", err), a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Job %s started, responding to %s", vars["name"], r.RemoteAddr)
+	api_common.RespondWithJson(w, r, http.StatusAccepted, run, a.log(r))
+}
+
+// jobsEnabled - responds with 404 if api is started without job scheduler.
+func (a *AApi) jobsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if a.jobs != nil {
+		return true
+	}
+
+	a.log(r).WithField("func", "jobsEnabled").Warnf("Respond to %s, job scheduler isn't set", r.RemoteAddr)
+	api_common.RespondWithError(w, r, http.StatusNotFound, "job scheduler isn't enabled", a.log(r))
+
+	return false
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"activity_api/api/api_common"
 	"activity_api/api/auth"
+	"activity_api/data_manager/db/core"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -11,6 +12,8 @@ import (
 
 type AuthMiddleware struct {
 	exclusions map[string]bool
+	certRoutes map[string]bool // "route method" pairs where verified client certificate replaces token
+	queryToken map[string]bool // routes where token could be passed in query instead of header
 	logger     logrus.FieldLogger
 }
 
@@ -18,6 +21,8 @@ func NewAuthMiddleware(logger logrus.FieldLogger, exclusions ...string) *AuthMid
 	m := new(AuthMiddleware)
 	m.logger = logger.WithField("module", "AuthMiddleware")
 	m.exclusions = make(map[string]bool)
+	m.certRoutes = make(map[string]bool)
+	m.queryToken = make(map[string]bool)
 
 	m.logger.Debugf("Adding exclusions to auth middleware: %v", exclusions)
 	for _, path := range exclusions {
@@ -27,25 +32,75 @@ func NewAuthMiddleware(logger logrus.FieldLogger, exclusions ...string) *AuthMid
 	return m
 }
 
+// AllowClientCert - allows requests with verified TLS client certificate to given route and methods without token,
+// so agents are able to push data over mTLS.
+func (m *AuthMiddleware) AllowClientCert(route string, methods ...string) {
+	m.logger.Debugf("Allowing client certificate auth for route %s, methods: %v", route, methods)
+
+	for _, method := range methods {
+		m.certRoutes[route+" "+method] = true
+	}
+}
+
+// AllowQueryToken - allows to pass access token of given routes in query param, if header isn't set.
+func (m *AuthMiddleware) AllowQueryToken(routes ...string) {
+	m.logger.Debugf("Allowing query token for routes: %v", routes)
+
+	for _, route := range routes {
+		m.queryToken[route] = true
+	}
+}
+
+// TokenAuthMiddleware - checks token of protected routes and puts its tenant to request context,
+// requests authorized with client certificate belong to default tenant.
 func (m *AuthMiddleware) TokenAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if _, ok := m.exclusions[mux.CurrentRoute(r).GetName()]; !ok {
-			entry := m.logger.WithField("func", "TokenAuthMiddleware")
-			entry.Debugf("Request on protected handler %s from %s", r.RequestURI, r.RemoteAddr)
-			err := auth.TokenValid(r)
+		name := mux.CurrentRoute(r).GetName()
+
+		if _, ok := m.exclusions[name]; !ok {
+			entry := api_common.Logger(r, m.logger).WithField("func", "TokenAuthMiddleware")
+
+			if m.certRoutes[name+" "+r.Method] && hasVerifiedCert(r) {
+				entry.Debugf("Request on %s from %s authorized with client certificate %s",
+					requestURI(r),
+					r.RemoteAddr,
+					r.TLS.VerifiedChains[0][0].Subject.CommonName,
+				)
+				next.ServeHTTP(w, r.WithContext(core.WithTenant(r.Context(), core.DefaultTenant)))
+
+				return
+			}
+
+			entry.Debugf("Request on protected handler %s from %s", requestURI(r), r.RemoteAddr)
+			// Token from query is moved to header, so handlers read it the same way.
+			if token := r.URL.Query().Get(auth.QueryToken); m.queryToken[name] && token != "" &&
+				r.Header.Get("Authorization") == "" {
+				r = r.Clone(r.Context())
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			tenantID, err := auth.TokenTenant(r)
 
 			if err != nil {
 				entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 				api_common.RespondWithError(
 					w,
+					r,
 					http.StatusUnauthorized,
-					fmt.Sprintf("TokenValid(): %v", err),
-					m.logger)
+					fmt.Sprintf("TokenTenant(): %v", err),
+					entry)
 
 				return
 			}
+
+			r = r.WithContext(core.WithTenant(r.Context(), tenantID))
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// hasVerifiedCert - returns true if client sent certificate verified by server TLS config.
+func hasVerifiedCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// CompatMiddleware - turns on compatibility mode for routes of legacy api,
+// so old agents get responses in the shape they were written for.
+type CompatMiddleware struct {
+	routes map[string]bool // names of routes served in compatibility mode
+	logger logrus.FieldLogger
+}
+
+// NewCompatMiddleware - returns new compatibility middleware.
+func NewCompatMiddleware(logger logrus.FieldLogger) *CompatMiddleware {
+	return &CompatMiddleware{
+		routes: make(map[string]bool),
+		logger: logger.WithField("module", "CompatMiddleware"),
+	}
+}
+
+// Compat - serves given routes in compatibility mode.
+func (m *CompatMiddleware) Compat(routes ...string) {
+	for _, route := range routes {
+		m.routes[route] = true
+	}
+}
+
+// CompatMiddleware - marks request context of compatible route, responses are written in legacy shape.
+func (m *CompatMiddleware) CompatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && m.routes[route.GetName()] {
+			api_common.Logger(r, m.logger).WithField("func", "CompatMiddleware").
+				Debugf("Serving %s in compatibility mode", r.URL.Path)
+			r = r.WithContext(api_common.WithCompat(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
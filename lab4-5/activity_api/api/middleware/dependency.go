@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/breaker"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// DependencyMiddleware - rejects requests with 503 immediately while dependency required by route is unavailable,
+// so clients don't wait for timeouts of dead db or cache.
+type DependencyMiddleware struct {
+	routes map[string][]*breaker.Breaker // route name -> breakers of required dependencies
+	logger logrus.FieldLogger
+}
+
+func NewDependencyMiddleware(logger logrus.FieldLogger) *DependencyMiddleware {
+	m := new(DependencyMiddleware)
+	m.logger = logger.WithField("module", "DependencyMiddleware")
+	m.routes = make(map[string][]*breaker.Breaker)
+
+	return m
+}
+
+// Require - adds given dependencies to the list of dependencies required by route, duplicates are skipped.
+// Should be called before api starts serving requests.
+func (m *DependencyMiddleware) Require(route string, breakers ...*breaker.Breaker) {
+	for _, b := range breakers {
+		if m.requires(route, b) {
+			continue
+		}
+
+		m.logger.Debugf("Route %s requires %s", route, b.Name())
+		m.routes[route] = append(m.routes[route], b)
+	}
+}
+
+// requires - returns true if route already requires given dependency.
+func (m *DependencyMiddleware) requires(route string, b *breaker.Breaker) bool {
+	for _, required := range m.routes[route] {
+		if required == b {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *DependencyMiddleware) DependencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, b := range m.routes[mux.CurrentRoute(r).GetName()] {
+			if err := b.Allow(); err != nil {
+				entry := api_common.Logger(r, m.logger).WithField("func", "DependencyMiddleware")
+				entry.Warnf("Respond to %s, error: %v", r.RemoteAddr, err)
+				api_common.RespondWithError(w, r, http.StatusServiceUnavailable, err.Error(), entry)
+
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"activity_api/common/breaker"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDependencyMiddleware - tests that requests fail fast only on routes which dependency is unavailable.
+func TestDependencyMiddleware(t *testing.T) {
+	db := breaker.NewBreaker("db", 1)
+	cache := breaker.NewBreaker("cache", 1)
+
+	m := NewDependencyMiddleware(logger)
+	m.Require("/users", db)
+	m.Require("/login", db, cache)
+
+	router := mux.NewRouter()
+	router.Use(m.DependencyMiddleware)
+
+	for _, path := range []string{"/users", "/login"} {
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {}).Name(path)
+	}
+
+	serve := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return w.Code
+	}
+
+	if code := serve("/login"); code != http.StatusOK {
+		t.Fatalf("unexpected code with closed breakers: %d", code)
+	}
+
+	cache.Failure(errors.New("cache is down"))
+
+	if code := serve("/users"); code != http.StatusOK {
+		t.Fatalf("route without cache dependency failed: %d", code)
+	}
+
+	if code := serve("/login"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for route with open dependency, got: %d", code)
+	}
+}
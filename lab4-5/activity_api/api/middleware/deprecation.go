@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// Deprecation headers, see RFC 8594 for Sunset.
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+// DeprecationMiddleware - marks responses of deprecated routes, so clients could migrate to successor version.
+// Headers are added to every response of deprecated route, including auth and rate limit errors.
+type DeprecationMiddleware struct {
+	routes    map[string]bool // names of deprecated routes
+	sunset    time.Time       // date after which deprecated routes could be removed, zero - not planned yet
+	successor string          // path prefix of successor version, e.g. /v1
+	logger    logrus.FieldLogger
+}
+
+// NewDeprecationMiddleware - returns new deprecation middleware.
+func NewDeprecationMiddleware(logger logrus.FieldLogger, sunset time.Time, successor string) *DeprecationMiddleware {
+	return &DeprecationMiddleware{
+		routes:    make(map[string]bool),
+		sunset:    sunset,
+		successor: successor,
+		logger:    logger.WithField("module", "DeprecationMiddleware"),
+	}
+}
+
+// Deprecate - marks given routes as deprecated.
+func (m *DeprecationMiddleware) Deprecate(routes ...string) {
+	for _, route := range routes {
+		m.routes[route] = true
+	}
+}
+
+// DeprecationMiddleware - adds Deprecation, Sunset and successor Link headers to response of deprecated route.
+func (m *DeprecationMiddleware) DeprecationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route == nil || !m.routes[route.GetName()] {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		api_common.Logger(r, m.logger).WithField("func", "DeprecationMiddleware").
+			Debugf("Deprecated route %s requested by %s", r.URL.Path, r.UserAgent())
+
+		w.Header().Set(HeaderDeprecation, "true")
+
+		if !m.sunset.IsZero() {
+			w.Header().Set(HeaderSunset, m.sunset.UTC().Format(http.TimeFormat))
+		}
+
+		w.Header().Set(HeaderLink, fmt.Sprintf("<%s%s>; rel=\"successor-version\"", m.successor, r.URL.Path))
+
+		next.ServeHTTP(w, r)
+	})
+}
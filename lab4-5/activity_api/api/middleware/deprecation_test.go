@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestDeprecationMiddleware - tests that only deprecated routes get deprecation headers.
+func TestDeprecationMiddleware(t *testing.T) {
+	sunset := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := NewDeprecationMiddleware(logger, sunset, "/v1")
+	m.Deprecate("/users")
+
+	router := mux.NewRouter()
+	router.Use(m.DeprecationMiddleware)
+	router.HandleFunc("/v1/users", handler).Name("/v1/users")
+	router.HandleFunc("/users", handler).Name("/users")
+
+	serve := func(path string) http.Header {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected code for %s: %d", path, w.Code)
+		}
+
+		return w.Header()
+	}
+
+	if header := serve("/v1/users"); header.Get(HeaderDeprecation) != "" {
+		t.Errorf("versioned route must not be deprecated, headers: %v", header)
+	}
+
+	header := serve("/users")
+
+	if header.Get(HeaderDeprecation) != "true" {
+		t.Errorf("deprecation header expected, headers: %v", header)
+	}
+
+	if header.Get(HeaderSunset) != "Wed, 30 Jun 2027 00:00:00 GMT" {
+		t.Errorf("unexpected sunset header: %s", header.Get(HeaderSunset))
+	}
+
+	if header.Get(HeaderLink) != `</v1/users>; rel="successor-version"` {
+		t.Errorf("unexpected link header: %s", header.Get(HeaderLink))
+	}
+}
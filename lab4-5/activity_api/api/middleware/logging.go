@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"activity_api/api/api_common"
+	"activity_api/api/auth"
+	"bufio"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"time"
 )
 
 type LoggingMiddleware struct {
@@ -16,10 +21,87 @@ func NewLoggingMiddleware(logger logrus.FieldLogger) *LoggingMiddleware {
 	return m
 }
 
-func (l *LoggingMiddleware) LogAuthMiddleware(next http.Handler) http.Handler {
+// AccessLogMiddleware - writes access log line for every request after it was served.
+func (l *LoggingMiddleware) AccessLogMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		l.logger.WithField("func", "LogAuthMiddleware").
-			Debugf("API Logger: %s %s -> %s", r.RemoteAddr, r.Method, r.RequestURI)
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		api_common.Logger(r, l.logger).WithFields(logrus.Fields{
+			"func":       "AccessLogMiddleware",
+			"remote":     r.RemoteAddr,
+			"method":     r.Method,
+			"uri":        requestURI(r),
+			"status":     recorder.status,
+			"bytes":      recorder.bytes,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"user_agent": r.UserAgent(),
+		}).Info("API access")
 	})
 }
+
+// requestURI - returns request URI for log, access token passed in query is masked.
+func requestURI(r *http.Request) string {
+	query := r.URL.Query()
+
+	if _, ok := query[auth.QueryToken]; !ok {
+		return r.RequestURI
+	}
+
+	query.Set(auth.QueryToken, "***")
+	masked := *r.URL
+	masked.RawQuery = query.Encode()
+
+	return masked.RequestURI()
+}
+
+// responseRecorder - http.ResponseWriter wrapper that remembers status code and number of bytes written.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader - remembers status code and passes it to underlying writer.
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write - counts written bytes and passes them to underlying writer.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+
+	return n, err
+}
+
+// Flush - passes flush to underlying writer, so streamed responses aren't held in buffer.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Hijack - passes hijack to underlying writer, so WebSocket connections could take over the connection.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	r.status = http.StatusSwitchingProtocols
+	r.wroteHeader = true
+
+	return hijacker.Hijack()
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRequestURI - tests that access token in query isn't written to access log.
+func TestRequestURI(t *testing.T) {
+	t.Run("RequestURI_masked", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/v1/events?userID=1&access_token=secret", nil)
+		uri := requestURI(r)
+
+		if strings.Contains(uri, "secret") || !strings.Contains(uri, "userID=1") {
+			t.Errorf("Token isn't masked: %s", uri)
+		}
+	})
+
+	t.Run("RequestURI_noToken", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/v1/events?userID=1", nil)
+
+		if uri := requestURI(r); uri != "/v1/events?userID=1" {
+			t.Errorf("Unexpected uri: %s", uri)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// bucketsCleanupSize - number of client buckets after which idle buckets are removed.
+	bucketsCleanupSize = 10000
+	// bucketIdleTime - bucket is idle if client didn't make requests for this time.
+	bucketIdleTime = time.Minute
+)
+
+// RateLimitMiddleware - limits number of requests per client (by IP) with token bucket.
+// Limit could be changed in runtime, e.g. on config reload.
+type RateLimitMiddleware struct {
+	rate  float64 // tokens per second, 0 - unlimited
+	burst float64 // bucket size
+
+	buckets map[string]*bucket
+	mtx     sync.Mutex
+	logger  logrus.FieldLogger
+}
+
+// bucket - token bucket of single client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimitMiddleware(logger logrus.FieldLogger, rate float64, burst int) *RateLimitMiddleware {
+	m := new(RateLimitMiddleware)
+	m.logger = logger.WithField("module", "RateLimitMiddleware")
+	m.buckets = make(map[string]*bucket)
+	m.SetLimit(rate, burst)
+
+	return m
+}
+
+// SetLimit - sets new limit, rate <= 0 disables limiting, burst <= 0 means burst equal to rate.
+func (m *RateLimitMiddleware) SetLimit(rate float64, burst int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.logger.WithField("func", "SetLimit").Infof("Setting rate limit: %v rps, burst: %d", rate, burst)
+	m.rate = math.Max(rate, 0)
+	m.burst = float64(burst)
+
+	if m.burst <= 0 {
+		m.burst = math.Max(math.Ceil(m.rate), 1)
+	}
+	// Old buckets could have more tokens than new burst allows.
+	m.buckets = make(map[string]*bucket)
+}
+
+func (m *RateLimitMiddleware) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := m.allow(clientIP(r), time.Now()); !ok {
+			entry := api_common.Logger(r, m.logger).WithField("func", "RateLimitMiddleware")
+			entry.Warnf("Respond to %s, rate limit exceeded", r.RemoteAddr)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusTooManyRequests,
+				fmt.Sprintf("rate limit exceeded, retry after %v", wait.Round(time.Millisecond)),
+				entry,
+			)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow - takes token from client bucket, returns false and time to wait if there are no tokens.
+func (m *RateLimitMiddleware) allow(client string, now time.Time) (time.Duration, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.rate == 0 {
+		return 0, true
+	}
+
+	if len(m.buckets) >= bucketsCleanupSize {
+		m.cleanup(now)
+	}
+
+	b, ok := m.buckets[client]
+
+	if !ok {
+		b = &bucket{tokens: m.burst, last: now}
+		m.buckets[client] = b
+	}
+
+	b.tokens = math.Min(m.burst, b.tokens+now.Sub(b.last).Seconds()*m.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / m.rate * float64(time.Second)), false
+	}
+
+	b.tokens--
+
+	return 0, true
+}
+
+// cleanup - removes buckets of idle clients. Requires lock.
+func (m *RateLimitMiddleware) cleanup(now time.Time) {
+	for client, b := range m.buckets {
+		if now.Sub(b.last) > bucketIdleTime {
+			delete(m.buckets, client)
+		}
+	}
+}
+
+// clientIP - returns IP of the client without port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
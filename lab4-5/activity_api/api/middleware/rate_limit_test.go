@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRateLimitMiddleware_allow - tests token bucket of rate limiter.
+func TestRateLimitMiddleware_allow(t *testing.T) {
+	m := NewRateLimitMiddleware(logger, 2, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, ok := m.allow("client", now); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+
+	wait, ok := m.allow("client", now)
+
+	if ok {
+		t.Fatal("request over burst was allowed")
+	}
+
+	if wait != time.Millisecond*500 {
+		t.Fatalf("unexpected wait time: %v", wait)
+	}
+
+	if _, ok := m.allow("other", now); !ok {
+		t.Fatal("request of other client was rejected")
+	}
+	// After half of second client gets one token back.
+	if _, ok := m.allow("client", now.Add(time.Millisecond*500)); !ok {
+		t.Fatal("request after refill was rejected")
+	}
+	// Disabled limit allows everything.
+	m.SetLimit(0, 0)
+
+	for i := 0; i < 10; i++ {
+		if _, ok := m.allow("client", now); !ok {
+			t.Fatal("request was rejected with disabled limit")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	// HeaderRequestID - header with request ID, taken from client if present, and always returned to client.
+	HeaderRequestID = "X-Request-ID"
+	// maxRequestIDLen - client request IDs longer than this are replaced with generated one.
+	maxRequestIDLen = 128
+)
+
+type RequestIDMiddleware struct {
+	logger logrus.FieldLogger
+}
+
+func NewRequestIDMiddleware(logger logrus.FieldLogger) *RequestIDMiddleware {
+	m := new(RequestIDMiddleware)
+	m.logger = logger.WithField("module", "RequestIDMiddleware")
+
+	return m
+}
+
+// RequestIDMiddleware - puts request ID to the request context and response headers.
+func (m *RequestIDMiddleware) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+
+		if !validRequestID(requestID) {
+			if requestID != "" {
+				m.logger.WithField("func", "RequestIDMiddleware").
+					Debugf("Invalid request ID from %s, generating new one", r.RemoteAddr)
+			}
+
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(HeaderRequestID, requestID)
+		next.ServeHTTP(w, r.WithContext(api_common.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID - checks that request ID is not empty, not too long and contains only printable ASCII,
+// so client can't inject anything into log.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
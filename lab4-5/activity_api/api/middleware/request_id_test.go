@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var logger = &logrus.Logger{
+	Level: logrus.FatalLevel,
+}
+
+// serveWithRequestID - serves request through request ID middleware, returns ID seen by handler and response.
+func serveWithRequestID(r *http.Request) (string, *httptest.ResponseRecorder) {
+	var seen string
+
+	handler := NewRequestIDMiddleware(logger).RequestIDMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = api_common.RequestID(r.Context())
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return seen, w
+}
+
+// TestRequestIDMiddleware - tests RequestIDMiddleware
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("RequestID_fromHeader", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.Header.Set(HeaderRequestID, "client-request-1")
+
+		seen, w := serveWithRequestID(r)
+
+		if seen != "client-request-1" {
+			t.Fatalf("unexpected request ID in context: %q", seen)
+		}
+
+		if got := w.Header().Get(HeaderRequestID); got != seen {
+			t.Fatalf("unexpected request ID in response: %q", got)
+		}
+	})
+
+	t.Run("RequestID_generated", func(t *testing.T) {
+		seen, w := serveWithRequestID(httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		if seen == "" {
+			t.Fatal("request ID wasn't generated")
+		}
+
+		if got := w.Header().Get(HeaderRequestID); got != seen {
+			t.Fatalf("unexpected request ID in response: %q", got)
+		}
+	})
+
+	t.Run("RequestID_invalid", func(t *testing.T) {
+		for _, id := range []string{"bad id", "line\nbreak", strings.Repeat("a", maxRequestIDLen+1)} {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set(HeaderRequestID, id)
+
+			if seen, _ := serveWithRequestID(r); seen == id || seen == "" {
+				t.Fatalf("invalid request ID %q wasn't replaced: %q", id, seen)
+			}
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/data_manager/db/core"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// RoleLookup - returns role of admin which made request, empty role if request isn't made by admin,
+// e.g. it's authorized with client certificate.
+type RoleLookup func(r *http.Request) (string, error)
+
+// RoleMiddleware - rejects requests of roles to routes which aren't allowed for them and puts role
+// to request context. Roles without allowed routes have access to every route.
+// It goes after auth middleware, requests without tenant are passed.
+type RoleMiddleware struct {
+	lookup  RoleLookup
+	allowed map[string]map[string]bool // role -> "route method" allowed for role
+	logger  logrus.FieldLogger
+}
+
+func NewRoleMiddleware(logger logrus.FieldLogger, lookup RoleLookup) *RoleMiddleware {
+	m := new(RoleMiddleware)
+	m.logger = logger.WithField("module", "RoleMiddleware")
+	m.lookup = lookup
+	m.allowed = make(map[string]map[string]bool)
+
+	return m
+}
+
+// Allow - allows given route and methods to role, role is restricted to allowed routes only.
+func (m *RoleMiddleware) Allow(role string, route string, methods ...string) {
+	m.logger.Debugf("Allowing route %s to role %s, methods: %v", route, role, methods)
+
+	if m.allowed[role] == nil {
+		m.allowed[role] = make(map[string]bool)
+	}
+
+	for _, method := range methods {
+		m.allowed[role][route+" "+method] = true
+	}
+}
+
+func (m *RoleMiddleware) RoleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := core.TenantID(r.Context()); err != nil {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		entry := api_common.Logger(r, m.logger).WithField("func", "RoleMiddleware")
+		role, err := m.lookup(r)
+
+		if err != nil {
+			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+			api_common.RespondWithError(w, r, http.StatusUnauthorized, fmt.Sprintf("role: %v", err), entry)
+
+			return
+		}
+
+		if allowed, ok := m.allowed[role]; ok {
+			route := ""
+
+			if current := mux.CurrentRoute(r); current != nil {
+				route = current.GetName()
+			}
+
+			if !allowed[route+" "+r.Method] {
+				entry.Warnf("Respond to %s, role %s isn't allowed to %s %s", r.RemoteAddr, role, r.Method, route)
+				api_common.RespondWithError(
+					w,
+					r,
+					http.StatusForbidden,
+					fmt.Sprintf("role %s isn't allowed to %s %s", role, r.Method, r.URL.Path),
+					entry,
+				)
+
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(api_common.WithRole(r.Context(), role)))
+	})
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/data_manager/db/core"
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRoleMiddleware - tests that restricted roles reach allowed routes only and role is put to request context.
+func TestRoleMiddleware(t *testing.T) {
+	m := NewRoleMiddleware(logger, func(r *http.Request) (string, error) {
+		if r.Header.Get("Role") == "broken" {
+			return "", errors.New("admin doesn't exist")
+		}
+
+		return r.Header.Get("Role"), nil
+	})
+	m.Allow("analyst", "/control/department", http.MethodGet)
+
+	router := mux.NewRouter()
+	router.Use(m.RoleMiddleware)
+	role := ""
+
+	for _, path := range []string{"/users", "/control/department"} {
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			role = api_common.Role(r.Context())
+		}).Name(path)
+	}
+
+	serve := func(ctx context.Context, method, path, role string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil).WithContext(ctx)
+		r.Header.Set("Role", role)
+		router.ServeHTTP(w, r)
+
+		return w.Code
+	}
+	tenant := core.WithTenant(context.Background(), core.DefaultTenant)
+
+	t.Run("RoleMiddleware_noTenant", func(t *testing.T) {
+		if code := serve(context.Background(), http.MethodGet, "/users", "analyst"); code != http.StatusOK {
+			t.Errorf("request without tenant failed: %d", code)
+		}
+	})
+
+	t.Run("RoleMiddleware_admin", func(t *testing.T) {
+		if code := serve(tenant, http.MethodGet, "/users", "admin"); code != http.StatusOK || role != "admin" {
+			t.Errorf("request of admin failed: %d, role in context: %q", code, role)
+		}
+	})
+
+	t.Run("RoleMiddleware_allowed", func(t *testing.T) {
+		if code := serve(tenant, http.MethodGet, "/control/department", "analyst"); code != http.StatusOK ||
+			role != "analyst" {
+			t.Errorf("allowed request of analyst failed: %d, role in context: %q", code, role)
+		}
+	})
+
+	t.Run("RoleMiddleware_forbidden", func(t *testing.T) {
+		if code := serve(tenant, http.MethodGet, "/users", "analyst"); code != http.StatusForbidden {
+			t.Errorf("expected 403 for route not allowed to role, got: %d", code)
+		}
+
+		if code := serve(tenant, http.MethodPost, "/control/department", "analyst"); code != http.StatusForbidden {
+			t.Errorf("expected 403 for method not allowed to role, got: %d", code)
+		}
+	})
+
+	t.Run("RoleMiddleware_lookupFailed", func(t *testing.T) {
+		if code := serve(tenant, http.MethodGet, "/users", "broken"); code != http.StatusUnauthorized {
+			t.Errorf("expected 401 when lookup failed, got: %d", code)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// TenantCheck - returns true if tenant is allowed to use api.
+type TenantCheck func(ctx context.Context, tenantID int64) (bool, error)
+
+// TenantMiddleware - rejects requests of tenants which aren't allowed to use api, e.g. suspended ones.
+// It goes after auth middleware, which puts tenant to request context. Requests without tenant are passed.
+type TenantMiddleware struct {
+	check  TenantCheck
+	logger logrus.FieldLogger
+}
+
+func NewTenantMiddleware(logger logrus.FieldLogger, check TenantCheck) *TenantMiddleware {
+	m := new(TenantMiddleware)
+	m.logger = logger.WithField("module", "TenantMiddleware")
+	m.check = check
+
+	return m
+}
+
+func (m *TenantMiddleware) TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := core.TenantID(r.Context())
+
+		if err != nil {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		entry := api_common.Logger(r, m.logger).WithField("func", "TenantMiddleware")
+		allowed, err := m.check(r.Context(), tenantID)
+
+		if err != nil {
+			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+			api_common.RespondWithError(w, r, http.StatusServiceUnavailable, fmt.Sprintf("tenant: %v", err), entry)
+
+			return
+		}
+
+		if !allowed {
+			entry.Warnf("Respond to %s, tenant %d is suspended or doesn't exist", r.RemoteAddr, tenantID)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusForbidden,
+				fmt.Sprintf("tenant %d is suspended or doesn't exist", tenantID),
+				entry,
+			)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"activity_api/data_manager/db/core"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTenantMiddleware - tests that requests of rejected tenants fail and requests without tenant are passed.
+func TestTenantMiddleware(t *testing.T) {
+	const (
+		suspended = 2
+		broken    = 3
+	)
+
+	m := NewTenantMiddleware(logger, func(_ context.Context, tenantID int64) (bool, error) {
+		if tenantID == broken {
+			return false, errors.New("db is down")
+		}
+
+		return tenantID != suspended, nil
+	})
+
+	handler := m.TenantMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(ctx context.Context) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(ctx))
+
+		return w.Code
+	}
+
+	t.Run("TenantMiddleware_noTenant", func(t *testing.T) {
+		if code := serve(context.Background()); code != http.StatusOK {
+			t.Errorf("request without tenant failed: %d", code)
+		}
+	})
+
+	t.Run("TenantMiddleware_active", func(t *testing.T) {
+		if code := serve(core.WithTenant(context.Background(), core.DefaultTenant)); code != http.StatusOK {
+			t.Errorf("request of active tenant failed: %d", code)
+		}
+	})
+
+	t.Run("TenantMiddleware_suspended", func(t *testing.T) {
+		if code := serve(core.WithTenant(context.Background(), suspended)); code != http.StatusForbidden {
+			t.Errorf("expected 403 for suspended tenant, got: %d", code)
+		}
+	})
+	t.Run("TenantMiddleware_checkFailed", func(t *testing.T) {
+		if code := serve(core.WithTenant(context.Background(), broken)); code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503 when check failed, got: %d", code)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+// Package openapi - minimal OpenAPI 3 document model, only parts used by AApi are described.
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version - OpenAPI specification version of the document.
+const Version = "3.0.3"
+
+const (
+	contentJSON      = "application/json"
+	componentSchemas = "#/components/schemas/"
+)
+
+// Document - OpenAPI root document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Info - API metadata.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem - operations of one path, key is lower case http method.
+type PathItem map[string]*Operation
+
+// Operation - single API operation.
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]SecurityRequirement `json:"security,omitempty"` // empty slice - operation is public
+}
+
+// Parameter - path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody - operation request body.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response - operation response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType - content of request or response.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema - data type description.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// Components - reusable parts of the document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme - auth scheme description.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement - required security schemes, key is scheme name.
+type SecurityRequirement map[string][]string
+
+// NewDocument - returns new empty document.
+func NewDocument(title, version, description string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+}
+
+// AddOperation - adds operation on given mux path template, e.g. /users/{id:[0-9]+}.
+// Path variables are converted to path parameters, their regexps are kept as patterns.
+func (d *Document) AddOperation(method, muxPath string, op *Operation) {
+	path, params := ConvertPath(muxPath)
+	op.Parameters = append(params, op.Parameters...)
+
+	item, ok := d.Paths[path]
+
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+
+	(*item)[strings.ToLower(method)] = op
+}
+
+// Operation - returns operation by mux path template and method, nil if it isn't described.
+func (d *Document) Operation(method, muxPath string) *Operation {
+	path, _ := ConvertPath(muxPath)
+	item, ok := d.Paths[path]
+
+	if !ok {
+		return nil
+	}
+
+	return (*item)[strings.ToLower(method)]
+}
+
+// Operations - calls f for every operation of the document.
+func (d *Document) Operations(f func(method, path string, op *Operation)) {
+	for path, item := range d.Paths {
+		for method, op := range *item {
+			f(strings.ToUpper(method), path, op)
+		}
+	}
+}
+
+// AddSchema - adds schema of given value to components and returns reference to it.
+func (d *Document) AddSchema(name string, value interface{}) *Schema {
+	d.Components.Schemas[name] = SchemaOf(value)
+
+	return Ref(name)
+}
+
+// Ref - returns reference to component schema.
+func Ref(name string) *Schema {
+	return &Schema{Ref: componentSchemas + name}
+}
+
+// ArrayOf - returns array schema of given items.
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// JSONBody - returns required json request body.
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{contentJSON: {Schema: schema}},
+	}
+}
+
+// FileBody - returns required request body, which is sent as file of given content type.
+func FileBody(contentType string) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{contentType: {Schema: &Schema{Type: "string", Format: "binary"}}},
+	}
+}
+
+// JSONResponse - returns json response, schema could be nil if response has no body.
+func JSONResponse(description string, schema *Schema) *Response {
+	response := &Response{Description: description}
+
+	if schema != nil {
+		response.Content = map[string]*MediaType{contentJSON: {Schema: schema}}
+	}
+
+	return response
+}
+
+// FileResponse - returns binary file response, which could be sent in any of given content types.
+func FileResponse(description string, contentTypes ...string) *Response {
+	response := &Response{Description: description, Content: make(map[string]*MediaType, len(contentTypes))}
+
+	for _, contentType := range contentTypes {
+		response.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+
+	return response
+}
+
+// QueryParam - returns optional query parameter.
+func QueryParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// Responses - returns responses map, key is status code.
+func Responses(responses map[int]*Response) map[string]*Response {
+	result := make(map[string]*Response, len(responses))
+
+	for code, response := range responses {
+		result[strconv.Itoa(code)] = response
+	}
+
+	return result
+}
+
+// muxVariable - mux path variable: {name} or {name:pattern}.
+var muxVariable = regexp.MustCompile(`{([^{}:]+)(?::([^{}]+))?}`)
+
+// ConvertPath - converts mux path template to OpenAPI path and its path parameters.
+func ConvertPath(muxPath string) (string, []*Parameter) {
+	params := make([]*Parameter, 0)
+
+	path := muxVariable.ReplaceAllStringFunc(muxPath, func(variable string) string {
+		match := muxVariable.FindStringSubmatch(variable)
+		schema := &Schema{Type: "string", Pattern: match[2]}
+
+		if match[2] == "[0-9]+" {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+
+		params = append(params, &Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+
+		return "{" + match[1] + "}"
+	})
+
+	return path, params
+}
+
+// SchemaOf - returns schema of given value built by reflection.
+// Field names follow encoding/json rules: json tag name if set, field name otherwise.
+func SchemaOf(value interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(value))
+}
+
+// schemaOf - SchemaOf helper.
+func schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaOf(t.Elem())
+		schema.Nullable = true
+
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := field.Name
+
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+
+			schema.Properties[name] = schemaOf(field.Type)
+		}
+
+		return schema
+	default:
+		return &Schema{}
+	}
+}
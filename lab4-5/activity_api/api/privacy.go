@@ -0,0 +1,214 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"activity_api/common/privacy"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportUserData - responds with zip archive of everything held about user with given ID:
+// profile, memberships, schedule, leaves, alert rules, activity records and earlier subject requests.
+// Export is recorded as subject request.
+func (a *AApi) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "ExportUserData")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	admin, ok := a.requestedBy(w, r)
+
+	if !ok {
+		return
+	}
+
+	data, err := privacy.Collect(r.Context(), a.sqlManager, vars["id"], time.Now().Unix())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Collect(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if data == nil {
+		entry.Warnf("Respond to %s, user doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
+
+		return
+	}
+
+	request := &models.SubjectRequest{
+		UserID:      data.User.UserID,
+		Kind:        models.SubjectExport,
+		RequestedBy: admin,
+		Records:     int64(len(data.Activities)),
+		CreatedAt:   data.ExportedAt,
+	}
+
+	if _, err := a.sqlManager.CreateSubjectRequest(r.Context(), request); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateSubjectRequest(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	response := &exportResponse{
+		ResponseWriter: w,
+		contentType:    privacy.ContentType,
+		filename:       privacy.FileName(data.User.UserID),
+	}
+
+	if err := privacy.WriteArchive(response, data); err != nil {
+		if response.started {
+			entry.Errorf("Export to %s aborted, error: %v", r.RemoteAddr, err)
+			panic(http.ErrAbortHandler)
+		}
+
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("WriteArchive(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Exported data of user %s to %s, records: %d", vars["id"], r.RemoteAddr, request.Records)
+}
+
+// EraseUser - irreversibly erases user with given ID: profile and personal data are deleted, activity is moved
+// to new pseudonymous user of the same department, so department totals don't change. Responds with
+// erasure request, it's kept to prove erasure.
+func (a *AApi) EraseUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "EraseUser")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	admin, ok := a.requestedBy(w, r)
+
+	if !ok {
+		return
+	}
+
+	userID, _ := strconv.ParseInt(vars["id"], 10, 64) // route matches digits only
+	request := &models.SubjectRequest{
+		UserID:      userID,
+		Kind:        models.SubjectErasure,
+		RequestedBy: admin,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	erased, err := a.sqlManager.EraseUser(r.Context(), vars["id"], request)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("EraseUser(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if erased == nil {
+		entry.Warnf("Respond to %s, user doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
+
+		return
+	}
+
+	a.publishWebhook(r, models.WebhookUserDeleted, &models.ObjectID{ID: userID})
+
+	entry.Debugf("User %s erased, responding to %s with: %+v", vars["id"], r.RemoteAddr, *erased)
+	api_common.RespondWithJson(w, r, http.StatusOK, erased, a.log(r))
+}
+
+// GetSubjectRequests - returns export and erasure requests, of user with userID from query if it's set.
+func (a *AApi) GetSubjectRequests(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("userID")
+	entry := a.log(r).WithField("func", "GetSubjectRequests")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, userID)
+
+	if userID != "" {
+		if id, err := strconv.ParseInt(userID, 10, 64); err != nil || id <= 0 {
+			entry.Errorf("Respond to %s, invalid userID: %q", r.RemoteAddr, userID)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("userID: positive integer expected, got %q", userID),
+				a.log(r),
+			)
+
+			return
+		}
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	requests, err := a.sqlManager.GetSubjectRequests(r.Context(), userID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetSubjectRequests(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with subject requests list (len %d)", r.RemoteAddr, len(requests))
+	start, end := api_common.Paginate(page, len(requests))
+	api_common.RespondWithPage(w, r, http.StatusOK, requests[start:end], page, a.log(r))
+}
+
+// requestedBy - returns name of admin which made request, responds with 401 if it's unknown.
+func (a *AApi) requestedBy(w http.ResponseWriter, r *http.Request) (string, bool) {
+	entry := a.log(r).WithField("func", "requestedBy")
+	access, err := a.token.ExtractTokenMetadata(r)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnauthorized,
+			fmt.Sprintf("ExtractTokenMetadata(): %v", err),
+			a.log(r),
+		)
+
+		return "", false
+	}
+
+	return access.Username, true
+}
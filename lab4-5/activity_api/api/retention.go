@@ -0,0 +1,170 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+// GetRetentionPolicies - returns all retention policies.
+func (a *AApi) GetRetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "GetRetentionPolicies")
+	entry.Debug("Request from: ", r.RemoteAddr)
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	policies, err := a.sqlManager.GetRetentionPolicies(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetRetentionPolicies(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with retention policies list (len %d)", r.RemoteAddr, len(policies))
+	start, end := api_common.Paginate(page, len(policies))
+	api_common.RespondWithPage(w, r, http.StatusOK, policies[start:end], page, a.log(r))
+}
+
+// GetRetentionPolicy - returns retention policy with given ID.
+func (a *AApi) GetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetRetentionPolicy")
+	entry.Debugf("Request from %s, policyID: %s", r.RemoteAddr, vars["id"])
+
+	policy, err := a.sqlManager.GetRetentionPolicy(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetRetentionPolicy(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if policy == nil {
+		entry.Warnf("Respond to %s, retention policy doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "retention policy doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *policy)
+	api_common.RespondWithJson(w, r, http.StatusOK, policy, a.log(r))
+}
+
+// CreateRetentionPolicy - creates retention policy of tenant or department from given JSON,
+// it's applied by retention job.
+func (a *AApi) CreateRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateRetentionPolicy")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	policy := new(models.RetentionPolicy)
+
+	if err := api_common.DecodeJSON(r, policy); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateRetentionPolicy(policy); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
+	policy.CreatedAt = time.Now().Unix()
+
+	entry.Debugf("Creating retention policy %+v, request from: %s", policy, r.RemoteAddr)
+	id, err := a.sqlManager.CreateRetentionPolicy(r.Context(), policy)
+
+	if err != nil {
+		code := http.StatusUnprocessableEntity
+
+		if errors.Is(err, core.ErrPolicyExists) {
+			code = http.StatusConflict
+		}
+
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			code,
+			fmt.Sprintf("CreateRetentionPolicy(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Retention policy created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// DeleteRetentionPolicy - deletes retention policy with given ID, records of its users aren't archived anymore,
+// unless policy of parent department or of tenant applies to them.
+func (a *AApi) DeleteRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "DeleteRetentionPolicy")
+	entry.Debugf("Request from %s, policyID: %s", r.RemoteAddr, vars["id"])
+
+	id, err := a.sqlManager.DeleteRetentionPolicy(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteRetentionPolicy(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Retention policy %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// validateRetentionPolicy - checks retention policy, today's records are always kept.
+func validateRetentionPolicy(policy *models.RetentionPolicy) error {
+	if policy.DepartmentID < 0 {
+		return fmt.Errorf("department_id: must not be negative, got %d", policy.DepartmentID)
+	}
+
+	if policy.Days < 1 {
+		return fmt.Errorf("days: must be at least 1, got %d", policy.Days)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/api/middleware"
+	"activity_api/common/models"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// analystRoutes - routes allowed to analysts, they see department aggregates only.
+var analystRoutes = map[string]string{
+	routeLogout:                  http.MethodPost,
+	routeUnregister:              http.MethodDelete,
+	routeDepartments:             http.MethodGet,
+	routeDepartment:              http.MethodGet,
+	routeDepartmentsTree:         http.MethodGet,
+	routeDepartmentsActivity:     http.MethodGet,
+	routeDepartmentsWorkReport:   http.MethodGet,
+	routeExportDepartments:       http.MethodGet,
+	routeExportDepartmentsReport: http.MethodGet,
+}
+
+// allowRoles - restricts analysts to analyst routes of every version.
+func allowRoles(m *middleware.RoleMiddleware) {
+	for route, method := range analystRoutes {
+		for _, name := range routeNames(route) {
+			m.Allow(models.RoleAnalyst, name, method)
+		}
+	}
+}
+
+// adminRole - returns role of admin which made request, empty role if request has no token.
+func (a *AApi) adminRole(r *http.Request) (string, error) {
+	if r.Header.Get("Authorization") == "" {
+		return "", nil
+	}
+
+	access, err := a.token.ExtractTokenMetadata(r)
+
+	if err != nil {
+		return "", fmt.Errorf("ExtractTokenMetadata(): %w", err)
+	}
+
+	admin, err := a.sqlManager.GetAdmin(r.Context(), access.Username)
+
+	if err != nil {
+		return "", fmt.Errorf("GetAdmin(): %w", err)
+	}
+
+	if admin == nil {
+		return "", errors.New("admin doesn't exist")
+	}
+
+	return admin.Role, nil
+}
+
+// anonymized - returns true if reports of request have to be anonymized, i.e. it's made by analyst.
+func (a *AApi) anonymized(r *http.Request) bool {
+	return api_common.Role(r.Context()) == models.RoleAnalyst
+}
+
+// validRole - returns error if role of created admin is unknown, empty role is replaced with admin one.
+func validRole(admin *models.Admin) error {
+	switch admin.Role {
+	case "":
+		admin.Role = models.RoleAdmin
+	case models.RoleAdmin, models.RoleAnalyst:
+	default:
+		return fmt.Errorf("role: unknown role %q, expected one of: %s, %s", admin.Role, models.RoleAdmin, models.RoleAnalyst)
+	}
+
+	return nil
+}
@@ -7,16 +7,145 @@ const (
 	routeRegister   = "/register"
 	routeUnregister = "/unregister"
 
-	routeDepartments = "/departments"
-	routeDepartment  = routeDepartments + "/{id:[0-9]+}"
+	routeDepartments        = "/departments"
+	routeDepartment         = routeDepartments + "/{id:[0-9]+}"
+	routeDepartmentsTree    = routeDepartments + "/tree"
+	routeDepartmentMove     = routeDepartment + "/move"
+	routeDepartmentSchedule = routeDepartment + "/schedule"
 
-	routeUsers = "/users"
-	routeUser  = routeUsers + "/{id:[0-9]+}"
+	routeUsers           = "/users"
+	routeUser            = routeUsers + "/{id:[0-9]+}"
+	routeUserTransfer    = routeUser + "/transfer"
+	routeUserDepartments = routeUser + "/departments"
+	routeUserSchedule    = routeUser + "/schedule"
+	routeUserExport      = routeUser + "/export"
+	routeUserErase       = routeUser + "/erase"
+
+	routeSubjectRequests = "/subject-requests"
 
 	routeActivities = "/activities"
 	routeActivity   = routeActivities + "/{id:[0-9]+}"
 
-	routeControl             = "/control"
-	routeUsersActivity       = routeControl + "/user/{id:[0-9]+}"
-	routeDepartmentsActivity = routeControl + "/department/{id:[0-9]+}"
+	routeHealth      = "/health"
+	routeHealthLive  = routeHealth + "/live"
+	routeHealthReady = routeHealth + "/ready"
+
+	routeOpenAPI = "/openapi.json"
+
+	routeControl               = "/control"
+	routeUsersActivity         = routeControl + "/user/{id:[0-9]+}"
+	routeDepartmentsActivity   = routeControl + "/department/{id:[0-9]+}"
+	routeUsersWorkReport       = routeUsersActivity + "/utilization"
+	routeDepartmentsWorkReport = routeDepartmentsActivity + "/utilization"
+
+	routeEvents = "/events"
+
+	routeWebhooks          = "/webhooks"
+	routeWebhook           = routeWebhooks + "/{id:[0-9]+}"
+	routeWebhookDeliveries = routeWebhook + "/deliveries"
+	routeDeadDeliveries    = routeWebhooks + "/deliveries/dead"
+	routeRedeliver         = routeWebhooks + "/deliveries/{id:[0-9]+}/redeliver"
+
+	routeAlerts     = "/alerts"
+	routeAlertRules = routeAlerts + "/rules"
+	routeAlertRule  = routeAlertRules + "/{id:[0-9]+}"
+
+	routeCategories    = "/categories"
+	routeCategoryRules = routeCategories + "/rules"
+	routeCategoryRule  = routeCategoryRules + "/{id:[0-9]+}"
+
+	routeRetention         = "/retention"
+	routeRetentionPolicies = routeRetention + "/policies"
+	routeRetentionPolicy   = routeRetentionPolicies + "/{id:[0-9]+}"
+
+	routeTimeOffs = "/time-off"
+	routeTimeOff  = routeTimeOffs + "/{id:[0-9]+}"
+
+	routeJobs   = "/jobs"
+	routeJob    = routeJobs + "/{name:[a-z0-9_]+}"
+	routeJobRun = routeJob + "/run"
+
+	routeImport      = "/import"
+	routeImportUsers = routeImport + "/users"
+
+	routeExport                  = "/export"
+	routeExportUsers             = routeExport + "/users"
+	routeExportDepartments       = routeExport + "/departments"
+	routeExportActivities        = routeExport + "/activities"
+	routeExportUsersReport       = routeExport + "/reports/users"
+	routeExportDepartmentsReport = routeExport + "/reports/departments"
+
+	routeTenants       = "/tenants"
+	routeTenant        = routeTenants + "/{id:[0-9]+}"
+	routeTenantSuspend = routeTenant + "/suspend"
+	routeTenantResume  = routeTenant + "/resume"
+	routeTenantAdmins  = routeTenant + "/admins"
 )
+
+// apiVersion - group of routes served under common path prefix.
+type apiVersion struct {
+	prefix     string   // path prefix, e.g. /v1
+	public     []string // routes of the version excluded from token auth
+	deprecated bool     // version is kept as alias only, responses carry deprecation headers
+	compat     bool     // responses are written in legacy shape: bare payload with Go field names
+}
+
+var (
+	// apiV1 - current api version.
+	apiV1 = &apiVersion{
+		prefix: "/v1",
+		public: []string{routeLogin, routeRegister, routeRefresh, routeOpenAPI},
+	}
+	// apiLegacy - routes without version prefix, deprecated alias of v1 kept for old agents.
+	apiLegacy = &apiVersion{
+		prefix:     "",
+		public:     []string{routeLogin, routeRegister, routeRefresh, routeOpenAPI},
+		deprecated: true,
+		compat:     true,
+	}
+	apiVersions = []*apiVersion{apiV1, apiLegacy}
+	// unversionedRoutes - infrastructure routes served without version prefix, they don't require token.
+	unversionedRoutes = []string{routeHealthLive, routeHealthReady}
+)
+
+// name - returns name of version route, names are unique across versions.
+func (v *apiVersion) name(route string) string {
+	return v.prefix + route
+}
+
+// isPublic - returns true if route of the version doesn't require token.
+func (v *apiVersion) isPublic(route string) bool {
+	for _, public := range v.public {
+		if public == route {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publicRoutes - returns names of all routes excluded from token auth.
+func publicRoutes() []string {
+	names := append([]string{}, unversionedRoutes...)
+
+	for _, version := range apiVersions {
+		for _, route := range version.public {
+			names = append(names, version.name(route))
+		}
+	}
+
+	return names
+}
+
+// routeNames - returns names of given routes in all versions.
+func routeNames(routes ...string) []string {
+	names := make([]string, 0, len(routes)*len(apiVersions))
+
+	for _, version := range apiVersions {
+		for _, route := range routes {
+			names = append(names, version.name(route))
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,597 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/api/auth"
+	"activity_api/api/openapi"
+	"activity_api/common/export"
+	"activity_api/common/models"
+	"activity_api/common/privacy"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	specTitle   = "Activity API"
+	specVersion = "1.0.0"
+	// bearerAuth - name of JWT security scheme.
+	bearerAuth = "bearerAuth"
+)
+
+// Operation tags.
+const (
+	tagAuth        = "auth"
+	tagHealth      = "health"
+	tagDepartments = "departments"
+	tagUsers       = "users"
+	tagActivities  = "activities"
+	tagControl     = "control"
+	tagWebhooks    = "webhooks"
+	tagAlerts      = "alerts"
+	tagCategories  = "categories"
+	tagWorkTime    = "worktime"
+	tagRetention   = "retention"
+	tagPrivacy     = "privacy"
+	tagJobs        = "jobs"
+	tagTenants     = "tenants"
+	tagImport      = "import"
+	tagExport      = "export"
+	tagMeta        = "meta"
+)
+
+// newSpec - returns OpenAPI document of current api version and unversioned routes.
+// Every route registered in initRoutes must be described here, it's checked by tests.
+// Deprecated routes without version prefix aren't described, they are aliases of v1 routes.
+func newSpec() *openapi.Document {
+	doc := openapi.NewDocument(
+		specTitle,
+		specVersion,
+		"API to collect users activity and calculate it for users and departments.",
+	)
+	doc.Components.SecuritySchemes[bearerAuth] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token from /v1/login or /v1/refresh.",
+	}
+	doc.Security = []openapi.SecurityRequirement{{bearerAuth: {}}}
+
+	admin := doc.AddSchema("Admin", models.Admin{})
+	doc.Components.Schemas["Admin"].Properties["role"].Enum = []string{models.RoleAdmin, models.RoleAnalyst}
+	tokens := doc.AddSchema("Tokens", models.Tokens{})
+	department := doc.AddSchema("Department", models.Department{})
+	departmentNode := doc.AddSchema("DepartmentNode", models.DepartmentNode{})
+	departmentMove := doc.AddSchema("DepartmentMove", models.DepartmentMove{})
+	user := doc.AddSchema("User", models.User{})
+	userTransfer := doc.AddSchema("UserTransfer", models.UserTransfer{})
+	membership := doc.AddSchema("Membership", models.Membership{})
+	activity := doc.AddSchema("Activity", models.Activity{})
+	categoryRule := doc.AddSchema("CategoryRule", models.CategoryRule{})
+	retentionPolicy := doc.AddSchema("RetentionPolicy", models.RetentionPolicy{})
+	doc.AddSchema("ArchiveManifest", models.ArchiveManifest{})
+	subjectRequest := doc.AddSchema("SubjectRequest", models.SubjectRequest{})
+	doc.AddSchema("SubjectData", models.SubjectData{})
+	workSchedule := doc.AddSchema("WorkSchedule", models.WorkSchedule{})
+	timeOff := doc.AddSchema("TimeOff", models.TimeOff{})
+	workReport := doc.AddSchema("WorkReport", models.WorkReport{})
+	departmentWorkReport := doc.AddSchema("DepartmentWorkReport", models.DepartmentWorkReport{})
+	userActivity := doc.AddSchema("UserActivity", models.UserActivity{})
+	departmentActivity := doc.AddSchema("DepartmentActivity", models.DepartmentActivity{})
+	objectID := doc.AddSchema("ObjectID", models.ObjectID{})
+	importResult := doc.AddSchema("ImportResult", models.ImportResult{})
+	event := doc.AddSchema("Event", models.Event{})
+	webhookSchema := doc.AddSchema("Webhook", models.Webhook{})
+	delivery := doc.AddSchema("WebhookDelivery", models.WebhookDelivery{})
+	doc.AddSchema("WebhookPayload", models.WebhookPayload{})
+	alertRule := doc.AddSchema("AlertRule", models.AlertRule{})
+	doc.AddSchema("Alert", models.Alert{})
+	job := doc.AddSchema("Job", models.Job{})
+	jobRun := doc.AddSchema("JobRun", models.JobRun{})
+	tenant := doc.AddSchema("Tenant", models.Tenant{})
+	status := doc.AddSchema("Status", models.Status{})
+	meta := doc.AddSchema("Meta", models.Meta{})
+	errorBody := doc.AddSchema("ErrorBody", models.ErrorBody{})
+	doc.Components.Schemas["ErrorResponse"] = &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"error": errorBody, "meta": meta},
+	}
+	errorSchema := openapi.Ref("ErrorResponse")
+
+	refresh := openapi.SchemaOf(models.Tokens{})
+	delete(refresh.Properties, "access_token")
+
+	// Time is RFC 3339 or unix time, so it's described as string.
+	timeRange := []*openapi.Parameter{
+		openapi.QueryParam(api_common.QueryTimeStart, "RFC 3339 or unix time, only records after it are counted",
+			&openapi.Schema{Type: "string"}),
+		openapi.QueryParam(api_common.QueryTimeEnd, "RFC 3339 or unix time, only records before it are counted",
+			&openapi.Schema{Type: "string"}),
+	}
+
+	integer := &openapi.Schema{Type: "integer", Format: "int32"}
+	pagination := []*openapi.Parameter{
+		openapi.QueryParam(api_common.QueryLimit, "Max number of records in response, 0 - all", integer),
+		openapi.QueryParam(api_common.QueryOffset, "Number of records to skip", integer),
+	}
+
+	spec := &specBuilder{doc: doc, meta: meta, errorSchema: errorSchema}
+	// Meta and health routes
+	spec.add(http.MethodGet, routeOpenAPI, tagMeta, "OpenAPI", "This document", nil,
+		http.StatusOK, "OpenAPI document", &openapi.Schema{Type: "object"})
+	spec.add(http.MethodGet, routeHealthLive, tagHealth, "Live", "Liveness probe", nil,
+		http.StatusOK, "Process is alive", status)
+	spec.add(http.MethodGet, routeHealthReady, tagHealth, "Ready", "Readiness probe", nil,
+		http.StatusOK, "Api is ready to serve requests", status).
+		Responses["503"] = openapi.JSONResponse("Api is starting or shutting down", errorSchema)
+	// Auth routes
+	spec.add(http.MethodPost, routeLogin, tagAuth, "Login", "Login with admin name and password hash", admin,
+		http.StatusOK, "Access and refresh tokens", tokens)
+	spec.add(http.MethodPost, routeLogout, tagAuth, "Logout", "Delete tokens of current admin", nil,
+		http.StatusOK, "Logged out", nil)
+	spec.add(http.MethodPost, routeRefresh, tagAuth, "Refresh", "Replace tokens using refresh token", refresh,
+		http.StatusCreated, "New access and refresh tokens", tokens)
+	spec.add(http.MethodPost, routeRegister, tagAuth, "Register", "Register new admin", admin,
+		http.StatusCreated, "ID of created admin", objectID).
+		Responses["403"] = openapi.JSONResponse("Name is reserved for superadmin", errorSchema)
+	spec.add(http.MethodDelete, routeUnregister, tagAuth, "Unregister", "Delete current admin and logout", nil,
+		http.StatusOK, "Admin deleted", nil).
+		Responses["403"] = openapi.JSONResponse("Superadmin can't unregister", errorSchema)
+	// Department routes
+	spec.add(http.MethodPost, routeDepartments, tagDepartments, "CreateDepartment", "Create department", department,
+		http.StatusCreated, "ID of created department", objectID)
+	spec.list(routeDepartments, tagDepartments, "GetDepartments", "List departments",
+		"Page of departments", department, pagination)
+	spec.add(http.MethodGet, routeDepartment, tagDepartments, "GetDepartment", "Get department", nil,
+		http.StatusOK, "Department", department).
+		Responses["404"] = openapi.JSONResponse("Department doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeDepartment, tagDepartments, "DeleteDepartment",
+		"Delete department with its schedule, its subdepartments are moved to its parent", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	op := spec.add(http.MethodGet, routeDepartmentsTree, tagDepartments, "GetDepartmentsTree",
+		"Departments tree in depth-first order, every department is followed by its descendants", nil,
+		http.StatusOK, "Departments tree", openapi.ArrayOf(departmentNode))
+	op.Parameters = append(op.Parameters, openapi.QueryParam(queryRoot, "Only subtree of given department",
+		&openapi.Schema{Type: "integer", Format: "int64"}))
+	op.Responses["400"] = openapi.JSONResponse("Invalid root", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Root department doesn't exist", errorSchema)
+	op = spec.add(http.MethodPost, routeDepartmentMove, tagDepartments, "MoveDepartment",
+		"Move department under parent, 0 parent makes it top level. "+
+			"Department can't be moved under itself or its descendant", departmentMove,
+		http.StatusOK, "Moved department", department)
+	op.Responses["404"] = openapi.JSONResponse("Department doesn't exist", errorSchema)
+	// User routes
+	doc.Components.Schemas["User"].Properties["status"].Enum = []string{
+		models.UserActive,
+		models.UserOnLeave,
+		models.UserTerminated,
+	}
+	op = spec.add(http.MethodPost, routeUsers, tagUsers, "CreateUser", "Create user, empty status means active", user,
+		http.StatusCreated, "ID of created user", objectID)
+	op.Responses["409"] = openapi.JSONResponse("Employee number is taken by other user", errorSchema)
+	departmentID := openapi.QueryParam(
+		"departmentID",
+		"Only users of given department are listed",
+		&openapi.Schema{Type: "integer", Format: "int64"},
+	)
+	spec.list(routeUsers, tagUsers, "GetUsers", "List users", "Page of users", user, append(pagination, departmentID))
+	spec.add(http.MethodGet, routeUser, tagUsers, "GetUser", "Get user", nil,
+		http.StatusOK, "User", user).
+		Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	op = spec.add(http.MethodPut, routeUser, tagUsers, "UpdateUser",
+		"Update user profile, department is changed by transfer only", user,
+		http.StatusOK, "Updated user", user)
+	op.Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	op.Responses["409"] = openapi.JSONResponse("Employee number is taken by other user", errorSchema)
+	spec.add(http.MethodDelete, routeUser, tagUsers, "DeleteUser", "Delete user with its membership history, schedule and leaves", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	spec.add(http.MethodPost, routeUserTransfer, tagUsers, "TransferUser",
+		"Move user to department, activity recorded before the transfer still counts toward previous department",
+		userTransfer, http.StatusOK, "Membership history of user", openapi.ArrayOf(membership)).
+		Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	spec.list(routeUserDepartments, tagUsers, "GetUserDepartments", "Department membership history of user",
+		"Page of memberships, oldest first", membership, pagination).
+		Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	// Data subject requests routes
+	op = spec.add(http.MethodGet, routeUserExport, tagPrivacy, "ExportUserData",
+		"Export everything held about user, export is recorded as subject request", nil,
+		http.StatusOK, "", nil)
+	op.Responses["200"] = openapi.FileResponse(
+		"Zip archive with "+privacy.SubjectFile+" (SubjectData) and "+privacy.ActivityFile+
+			" (one Activity per line). Response is aborted if export fails after streaming has started",
+		privacy.ContentType,
+	)
+	op.Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	spec.add(http.MethodPost, routeUserErase, tagPrivacy, "EraseUser",
+		"Irreversibly erase user: profile, schedule, leaves and alert rules of user are deleted, "+
+			"its activity is moved to new pseudonymous user of the same department, so department totals don't change",
+		nil,
+		http.StatusOK, "Erasure request", subjectRequest).
+		Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	op = spec.list(routeSubjectRequests, tagPrivacy, "GetSubjectRequests", "List export and erasure requests",
+		"Page of subject requests", subjectRequest, append(pagination, openapi.QueryParam(
+			"userID",
+			"Only requests of given user, erased users included",
+			&openapi.Schema{Type: "integer", Format: "int64"},
+		)))
+	op.Responses["400"] = openapi.JSONResponse("Invalid userID or pagination", errorSchema)
+	// Activity routes
+	// Agents could push activity with verified TLS client certificate instead of token,
+	// OpenAPI 3.0 has no mutual TLS security scheme, so it's mentioned in summary only.
+	spec.add(http.MethodPost, routeActivities, tagActivities, "CreateActivity",
+		"Record activity with optional time breakdown by app, token or verified client certificate is required",
+		activity,
+		http.StatusCreated, "ID of created record", objectID)
+	spec.list(routeActivities, tagActivities, "GetActivities", "List activity records",
+		"Page of activity records", activity, pagination)
+	spec.add(http.MethodGet, routeActivity, tagActivities, "GetActivity", "Get activity record", nil,
+		http.StatusOK, "Activity record", activity).
+		Responses["404"] = openapi.JSONResponse("Record doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeActivity, tagActivities, "DeleteActivity", "Delete activity record", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	// Activity control routes
+	op = spec.add(http.MethodGet, routeUsersActivity, tagControl, "GetUsersActivity", "Activity time of user", nil,
+		http.StatusOK, "Sum of user activity and of its app time by category, zero if there are no records", userActivity)
+	op.Parameters = append(op.Parameters, timeRange...)
+	op.Responses["400"] = openapi.JSONResponse("Invalid time range", errorSchema)
+	op = spec.add(http.MethodGet, routeDepartmentsActivity, tagControl, "GetDepartmentsActivity",
+		"Activity time of department", nil,
+		http.StatusOK, "Sum of department users activity and of their app time by category, zero if there are no records. "+
+			"For analysts it's anonymized: time has noise, department with too few users is suppressed, "+
+			"as well as tree with too few users in its suppressed departments",
+		departmentActivity)
+	op.Parameters = append(op.Parameters, timeRange...)
+	op.Parameters = append(op.Parameters, openapi.QueryParam(queryRollup,
+		"Sum activity of users of all descendant departments too", &openapi.Schema{Type: "boolean"}))
+	op.Responses["400"] = openapi.JSONResponse("Invalid rollup or time range", errorSchema)
+	// Live feed routes
+	op = spec.add(http.MethodGet, routeEvents, tagControl, "Events",
+		"Live feed of created activity records and running totals over SSE, or WebSocket if connection is upgraded",
+		nil, http.StatusOK, "", nil)
+	op.Responses["200"] = &openapi.Response{
+		Description: "Server-Sent Events stream, data of every event is Event json. " +
+			"Stream is closed before server write timeout and on token expiry, EventSource reconnects by itself",
+		Content: map[string]*openapi.MediaType{"text/event-stream": {Schema: event}},
+	}
+	op.Responses["101"] = &openapi.Response{Description: "Switched to WebSocket, every text message is Event json"}
+	op.Responses["400"] = openapi.JSONResponse("Invalid filter or TimeStart", errorSchema)
+	op.Parameters = append(op.Parameters,
+		openapi.QueryParam("userID", "Only activity of given user", &openapi.Schema{Type: "integer", Format: "int64"}),
+		openapi.QueryParam("departmentID", "Only activity of users of given department",
+			&openapi.Schema{Type: "integer", Format: "int64"}),
+		timeRange[0],
+		openapi.QueryParam(auth.QueryToken, "Access token for clients that can't set Authorization header",
+			&openapi.Schema{Type: "string"}),
+	)
+	// Webhooks routes
+	spec.add(http.MethodPost, routeWebhooks, tagWebhooks, "CreateWebhook",
+		"Subscribe url to domain events: "+strings.Join(webhookEvents, ", ")+
+			". Payload is signed with secret, it's generated if not set", webhookSchema,
+		http.StatusCreated, "Created webhook with secret", webhookSchema)
+	spec.list(routeWebhooks, tagWebhooks, "GetWebhooks", "List webhooks without secrets",
+		"Page of webhooks", webhookSchema, pagination)
+	spec.add(http.MethodGet, routeWebhook, tagWebhooks, "GetWebhook", "Get webhook without secret", nil,
+		http.StatusOK, "Webhook", webhookSchema).
+		Responses["404"] = openapi.JSONResponse("Webhook doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeWebhook, tagWebhooks, "DeleteWebhook", "Delete webhook and its deliveries", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	op = spec.list(routeWebhookDeliveries, tagWebhooks, "GetWebhookDeliveries", "List deliveries of webhook",
+		"Page of deliveries", delivery, append(pagination, openapi.QueryParam(
+			queryStatus,
+			"Only deliveries with given status",
+			&openapi.Schema{
+				Type: "string",
+				Enum: []string{models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead},
+			},
+		)))
+	op.Responses["400"] = openapi.JSONResponse("Unknown status", errorSchema)
+	spec.list(routeDeadDeliveries, tagWebhooks, "GetDeadDeliveries",
+		"Dead-letter list: deliveries of all webhooks which failed every attempt", "Page of deliveries",
+		delivery, pagination)
+	spec.add(http.MethodPost, routeRedeliver, tagWebhooks, "RedeliverDelivery",
+		"Queue delivered or dead delivery again with reset attempts", nil,
+		http.StatusAccepted, "Number of queued deliveries", objectID).
+		Responses["404"] = openapi.JSONResponse("Delivery doesn't exist or is already pending", errorSchema)
+	// Alert rules routes
+	spec.add(http.MethodPost, routeAlertRules, tagAlerts, "CreateAlertRule",
+		"Create alert rule: "+models.AlertUserRatio+" fires when active/total ratio of user is below threshold, "+
+			models.AlertDepartmentDrop+" - when department total time dropped by threshold part against previous period. "+
+			"Notifiers: "+strings.Join(alertNotifiers, ", ")+", state is set by alerts job", alertRule,
+		http.StatusCreated, "ID of created rule", objectID)
+	op = spec.list(routeAlertRules, tagAlerts, "GetAlertRules", "List alert rules with their state",
+		"Page of alert rules", alertRule, append(pagination, openapi.QueryParam(
+			queryState,
+			"Only rules in given state",
+			&openapi.Schema{Type: "string", Enum: []string{models.AlertOK, models.AlertFiring}},
+		)))
+	op.Responses["400"] = openapi.JSONResponse("Unknown state or invalid pagination", errorSchema)
+	spec.add(http.MethodGet, routeAlertRule, tagAlerts, "GetAlertRule", "Get alert rule", nil,
+		http.StatusOK, "Alert rule", alertRule).
+		Responses["404"] = openapi.JSONResponse("Alert rule doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeAlertRule, tagAlerts, "DeleteAlertRule", "Delete alert rule", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	// Activity category rules routes
+	doc.Components.Schemas["CategoryRule"].Properties["category"].Enum = activityCategories
+	spec.add(http.MethodPost, routeCategoryRules, tagCategories, "CreateCategoryRule",
+		"Create rule mapping apps matching case-insensitive glob pattern to category. "+
+			"Rule with the longest matching pattern wins, apps without rule are neutral", categoryRule,
+		http.StatusCreated, "ID of created rule", objectID)
+	spec.list(routeCategoryRules, tagCategories, "GetCategoryRules", "List category rules",
+		"Page of category rules", categoryRule, pagination)
+	spec.add(http.MethodGet, routeCategoryRule, tagCategories, "GetCategoryRule", "Get category rule", nil,
+		http.StatusOK, "Category rule", categoryRule).
+		Responses["404"] = openapi.JSONResponse("Category rule doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeCategoryRule, tagCategories, "DeleteCategoryRule", "Delete category rule", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	// Retention policies routes
+	spec.add(http.MethodPost, routeRetentionPolicies, tagRetention, "CreateRetentionPolicy",
+		"Create retention policy of tenant (department 0) or of department with its subdepartments. "+
+			"Older records are archived to compressed files by retention job and deleted, their time stays in reports",
+		retentionPolicy,
+		http.StatusCreated, "ID of created policy", objectID).
+		Responses["409"] = openapi.JSONResponse("Tenant or department already has policy", errorSchema)
+	spec.list(routeRetentionPolicies, tagRetention, "GetRetentionPolicies", "List retention policies",
+		"Page of retention policies", retentionPolicy, pagination)
+	spec.add(http.MethodGet, routeRetentionPolicy, tagRetention, "GetRetentionPolicy", "Get retention policy", nil,
+		http.StatusOK, "Retention policy", retentionPolicy).
+		Responses["404"] = openapi.JSONResponse("Retention policy doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeRetentionPolicy, tagRetention, "DeleteRetentionPolicy",
+		"Delete retention policy", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	// Work time routes
+	scheduleSummary := "schedule: expected work seconds of every weekday and UTC offset of its time zone"
+	spec.add(http.MethodGet, routeUserSchedule, tagWorkTime, "GetUserSchedule", "Get own schedule of user", nil,
+		http.StatusOK, "Schedule", workSchedule).
+		Responses["404"] = openapi.JSONResponse("User has no own schedule", errorSchema)
+	spec.add(http.MethodPut, routeUserSchedule, tagWorkTime, "SetUserSchedule",
+		"Set user "+scheduleSummary+", it overrides schedule of department", workSchedule,
+		http.StatusOK, "Schedule", workSchedule).
+		Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeUserSchedule, tagWorkTime, "DeleteUserSchedule",
+		"Delete own schedule of user, schedule of department applies to it again", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	spec.add(http.MethodGet, routeDepartmentSchedule, tagWorkTime, "GetDepartmentSchedule",
+		"Get own schedule of department", nil,
+		http.StatusOK, "Schedule", workSchedule).
+		Responses["404"] = openapi.JSONResponse("Department has no own schedule", errorSchema)
+	spec.add(http.MethodPut, routeDepartmentSchedule, tagWorkTime, "SetDepartmentSchedule",
+		"Set department "+scheduleSummary+". It applies to users of department and of its subdepartments "+
+			"without own schedule, users without any schedule work 8 hours from Monday to Friday, UTC", workSchedule,
+		http.StatusOK, "Schedule", workSchedule).
+		Responses["404"] = openapi.JSONResponse("Department doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeDepartmentSchedule, tagWorkTime, "DeleteDepartmentSchedule",
+		"Delete own schedule of department", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	spec.add(http.MethodPost, routeTimeOffs, tagWorkTime, "CreateTimeOff",
+		"Create leave of user, or holiday of all users if user isn't set. No work is expected on days of time off",
+		timeOff, http.StatusCreated, "ID of created time off", objectID)
+	op = spec.list(routeTimeOffs, tagWorkTime, "GetTimeOffs", "List leaves and holidays",
+		"Page of time off", timeOff, append(pagination, openapi.QueryParam(
+			"userID",
+			"Only leaves of given user and holidays",
+			&openapi.Schema{Type: "integer", Format: "int64"},
+		)))
+	op.Responses["400"] = openapi.JSONResponse("Invalid userID or pagination", errorSchema)
+	spec.add(http.MethodGet, routeTimeOff, tagWorkTime, "GetTimeOff", "Get time off", nil,
+		http.StatusOK, "Time off", timeOff).
+		Responses["404"] = openapi.JSONResponse("Time off doesn't exist", errorSchema)
+	spec.add(http.MethodDelete, routeTimeOff, tagWorkTime, "DeleteTimeOff", "Delete time off", nil,
+		http.StatusOK, "Number of deleted rows", objectID)
+	reportRange := []*openapi.Parameter{
+		openapi.QueryParam(api_common.QueryTimeStart, "RFC 3339 or unix time, report starts from the day of it, required",
+			&openapi.Schema{Type: "string"}),
+		openapi.QueryParam(api_common.QueryTimeEnd, "RFC 3339 or unix time, report ends on the day before it, required, "+
+			"period must not be longer than a year", &openapi.Schema{Type: "string"}),
+	}
+	op = spec.add(http.MethodGet, routeUsersWorkReport, tagWorkTime, "GetUsersWorkReport",
+		"Expected versus actual work time of user by days of its schedule time zone", nil,
+		http.StatusOK, "Work report with days, overtime and utilization", workReport)
+	op.Parameters = append(op.Parameters, reportRange...)
+	op.Responses["400"] = openapi.JSONResponse("Invalid period", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("User doesn't exist", errorSchema)
+	op = spec.add(http.MethodGet, routeDepartmentsWorkReport, tagWorkTime, "GetDepartmentsWorkReport",
+		"Expected versus actual work time of department users, "+
+			"every user is reported over days of its membership in department only", nil,
+		http.StatusOK, "Sum of users work reports with report of every user, "+
+			"analysts get anonymized sum without reports of users", departmentWorkReport)
+	op.Parameters = append(op.Parameters, reportRange...)
+	op.Responses["400"] = openapi.JSONResponse("Invalid period", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Department doesn't exist", errorSchema)
+	// Background jobs routes
+	spec.list(routeJobs, tagJobs, "GetJobs", "List background jobs with schedule and last run",
+		"Page of jobs", job, pagination)
+	spec.add(http.MethodGet, routeJob, tagJobs, "GetJob", "Get background job with history of recent runs", nil,
+		http.StatusOK, "Job", job).
+		Responses["404"] = openapi.JSONResponse("Job doesn't exist", errorSchema)
+	op = spec.add(http.MethodPost, routeJobRun, tagJobs, "RunJob",
+		"Start background job out of schedule, runs of a job don't overlap", nil,
+		http.StatusAccepted, "Started run", jobRun)
+	op.Responses["404"] = openapi.JSONResponse("Job doesn't exist", errorSchema)
+	op.Responses["409"] = openapi.JSONResponse("Job is already running", errorSchema)
+	// Tenants routes, superadmin only
+	op = spec.add(http.MethodPost, routeTenants, tagTenants, "CreateTenant", "Create tenant, superadmin only", tenant,
+		http.StatusCreated, "ID of created tenant", objectID)
+	op.Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op.Responses["409"] = openapi.JSONResponse("Tenant with given name already exists", errorSchema)
+	spec.list(routeTenants, tagTenants, "GetTenants", "List tenants, superadmin only",
+		"Page of tenants", tenant, pagination).
+		Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op = spec.add(http.MethodGet, routeTenant, tagTenants, "GetTenant", "Get tenant, superadmin only", nil,
+		http.StatusOK, "Tenant", tenant)
+	op.Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Tenant doesn't exist", errorSchema)
+	op = spec.add(http.MethodPost, routeTenantSuspend, tagTenants, "SuspendTenant",
+		"Suspend tenant, its admins can't login and their tokens are rejected. Default tenant can't be suspended", nil,
+		http.StatusOK, "Suspended tenant", tenant)
+	op.Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Tenant doesn't exist", errorSchema)
+	op = spec.add(http.MethodPost, routeTenantResume, tagTenants, "ResumeTenant", "Resume suspended tenant", nil,
+		http.StatusOK, "Resumed tenant", tenant)
+	op.Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Tenant doesn't exist", errorSchema)
+	op = spec.add(http.MethodPost, routeTenantAdmins, tagTenants, "CreateTenantAdmin",
+		"Create admin of tenant, superadmin only", admin,
+		http.StatusCreated, "ID of created admin", objectID)
+	op.Responses["403"] = openapi.JSONResponse("Admin isn't superadmin", errorSchema)
+	op.Responses["404"] = openapi.JSONResponse("Tenant doesn't exist", errorSchema)
+	op.Responses["409"] = openapi.JSONResponse("Admin with given name already exists", errorSchema)
+	// Import routes
+	op = spec.add(http.MethodPost, routeImportUsers, tagImport, "ImportUsers",
+		"Import departments and users from CSV with department_name and user_name columns, "+
+			"departments are matched by name or created", nil,
+		http.StatusCreated, "Import committed, result of every row", importResult)
+	op.RequestBody = openapi.FileBody(export.ContentTypeCSV)
+	op.Parameters = append(op.Parameters, openapi.QueryParam(
+		queryDryRun,
+		"Validate file against db without writing",
+		&openapi.Schema{Type: "boolean"},
+	))
+	op.Responses["200"] = openapi.JSONResponse("Dry run result of every row", spec.envelope(importResult))
+	op.Responses["400"] = openapi.JSONResponse("File can't be parsed or invalid params", errorSchema)
+	op.Responses["422"] = openapi.JSONResponse("Some rows failed and nothing is imported, or db error", &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"data": importResult, "error": errorBody, "meta": meta},
+	})
+	// Export routes
+	spec.export(routeExportUsers, "ExportUsers", "Export users", departmentID)
+	spec.export(routeExportDepartments, "ExportDepartments", "Export departments")
+	spec.export(routeExportActivities, "ExportActivities", "Export activity records")
+	for _, op := range []*openapi.Operation{
+		spec.export(routeExportUsersReport, "ExportUsersReport", "Export activity time of every user", timeRange...),
+		spec.export(routeExportDepartmentsReport, "ExportDepartmentsReport", "Export activity time of every department, "+
+			"analysts get anonymized report without departments with too few users", timeRange...),
+	} {
+		op.Responses["400"] = openapi.JSONResponse("Unsupported export format or invalid time range", errorSchema)
+	}
+
+	return doc
+}
+
+// specBuilder - newSpec helper, adds common error responses to operations.
+type specBuilder struct {
+	doc         *openapi.Document
+	meta        *openapi.Schema
+	errorSchema *openapi.Schema
+}
+
+// list - adds GET operation of paginated list.
+func (s *specBuilder) list(
+	path, tag, id, summary, description string,
+	item *openapi.Schema,
+	params []*openapi.Parameter,
+) *openapi.Operation {
+	op := s.add(http.MethodGet, path, tag, id, summary, nil, http.StatusOK, description, openapi.ArrayOf(item))
+	op.Parameters = append(op.Parameters, params...)
+	op.Responses["400"] = openapi.JSONResponse("Invalid pagination", s.errorSchema)
+
+	return op
+}
+
+// export - adds GET operation of table export, format is chosen by format param or Accept header.
+func (s *specBuilder) export(path, id, summary string, params ...*openapi.Parameter) *openapi.Operation {
+	op := s.add(http.MethodGet, path, tagExport, id, summary, nil, http.StatusOK, "", nil)
+	op.Responses["200"] = openapi.FileResponse(
+		"Table streamed as attachment, first row is header. "+
+			"Response is aborted if export fails after streaming has started",
+		export.ContentTypeCSV,
+		export.ContentTypeXLSX,
+	)
+	op.Responses["400"] = openapi.JSONResponse("Unsupported export format", s.errorSchema)
+	op.Parameters = append(op.Parameters, openapi.QueryParam(
+		queryFormat,
+		"Export format, overrides Accept header, csv is used by default",
+		&openapi.Schema{Type: "string", Enum: []string{export.FormatCSV, export.FormatXLSX}},
+	))
+	op.Parameters = append(op.Parameters, params...)
+
+	return op
+}
+
+// envelope - returns schema of response envelope with given data.
+func (s *specBuilder) envelope(data *openapi.Schema) *openapi.Schema {
+	envelope := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"meta": s.meta},
+	}
+
+	if data != nil {
+		envelope.Properties["data"] = data
+	}
+
+	return envelope
+}
+
+// add - adds operation with given success response, public routes don't require token.
+// Response is wrapped in envelope, except OpenAPI document itself.
+func (s *specBuilder) add(
+	method, path, tag, id, summary string,
+	body *openapi.Schema,
+	code int,
+	description string,
+	response *openapi.Schema,
+) *openapi.Operation {
+	if path != routeOpenAPI {
+		response = s.envelope(response)
+	}
+
+	responses := map[int]*openapi.Response{
+		code:                           openapi.JSONResponse(description, response),
+		http.StatusUnprocessableEntity: openapi.JSONResponse("Invalid request or db error", s.errorSchema),
+		http.StatusTooManyRequests:     openapi.JSONResponse("Rate limit exceeded", s.errorSchema),
+	}
+
+	op := &openapi.Operation{
+		OperationID: id,
+		Summary:     summary,
+		Tags:        []string{tag},
+	}
+
+	if body != nil {
+		op.RequestBody = openapi.JSONBody(body)
+	}
+
+	unversioned := isUnversioned(path)
+
+	if unversioned || apiV1.isPublic(path) {
+		op.Security = &[]openapi.SecurityRequirement{}
+	} else {
+		responses[http.StatusUnauthorized] = openapi.JSONResponse("Missing or invalid access token", s.errorSchema)
+	}
+
+	if !unversioned && path != routeOpenAPI {
+		responses[http.StatusServiceUnavailable] = openapi.JSONResponse("Db or cache is unavailable", s.errorSchema)
+	}
+
+	op.Responses = openapi.Responses(responses)
+
+	if !unversioned {
+		path = apiV1.name(path)
+	}
+
+	s.doc.AddOperation(method, path, op)
+
+	return op
+}
+
+// isUnversioned - returns true for routes served without version prefix.
+func isUnversioned(route string) bool {
+	for _, unversioned := range unversionedRoutes {
+		if unversioned == route {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenAPI - responds with OpenAPI document of the api.
+func (a *AApi) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "OpenAPI")
+	entry.Debug("Request from:", r.RemoteAddr)
+	// Document isn't wrapped in envelope, so it could be used by OpenAPI tools as is.
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(a.spec); err != nil {
+		entry.Warn("Response write error:", err)
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"activity_api/api/middleware"
+	"activity_api/api/openapi"
+	"activity_api/common/api_client"
+	"activity_api/common/breaker"
+	"activity_api/common/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestApi() *AApi {
+	return NewAApi(
+		&Config{},
+		nil,
+		nil,
+		breaker.NewBreaker("sql", 1),
+		breaker.NewBreaker("cache", 1),
+		context.Background(),
+		&logrus.Logger{Level: logrus.FatalLevel},
+	)
+}
+
+func Test_Spec(t *testing.T) {
+	a := newTestApi()
+
+	t.Run("Spec_matches_routes", func(t *testing.T) {
+		registered := 0
+
+		err := a.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			if route.GetName() == "" { // subrouter
+				return nil
+			}
+
+			path, err := route.GetPathTemplate()
+
+			if err != nil {
+				return err
+			}
+
+			methods, err := route.GetMethods()
+
+			if err != nil {
+				return err
+			}
+
+			for _, method := range methods {
+				if a.spec.Operation(method, path) != nil {
+					registered++
+
+					continue
+				}
+				// Deprecated alias isn't described, but its successor must be.
+				if a.spec.Operation(method, apiV1.name(path)) == nil {
+					t.Errorf("route %s %s isn't described in OpenAPI document", method, path)
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		described := 0
+		a.spec.Operations(func(method, path string, op *openapi.Operation) { described++ })
+
+		if described != registered {
+			t.Errorf("OpenAPI document describes %d operations, but %d routes are registered", described, registered)
+		}
+	})
+
+	t.Run("Spec_client_methods", func(t *testing.T) {
+		client := reflect.TypeOf(new(api_client.Client))
+
+		a.spec.Operations(func(method, path string, op *openapi.Operation) {
+			if _, ok := client.MethodByName(op.OperationID); !ok {
+				t.Errorf("client has no method for operation %s (%s %s)", op.OperationID, method, path)
+			}
+		})
+	})
+
+	t.Run("Spec_served", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiV1.name(routeOpenAPI), nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		doc := new(openapi.Document)
+
+		if err := json.Unmarshal(rec.Body.Bytes(), doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.OpenAPI != openapi.Version || len(doc.Paths) != len(a.spec.Paths) {
+			t.Errorf("unexpected document: version %s, %d paths", doc.OpenAPI, len(doc.Paths))
+		}
+
+		op := doc.Operation(http.MethodGet, apiV1.name(routeUsersActivity))
+
+		if op == nil || len(op.Parameters) != 3 {
+			t.Fatalf("id, TimeStart and TimeEnd parameters expected for %s", routeUsersActivity)
+		}
+
+		if op.Parameters[0].In != "path" || op.Parameters[1].Name != "TimeStart" {
+			t.Errorf("unexpected parameters: %+v, %+v", op.Parameters[0], op.Parameters[1])
+		}
+	})
+
+	t.Run("Spec_public_routes", func(t *testing.T) {
+		a.spec.Operations(func(method, path string, op *openapi.Operation) {
+			public := op.Security != nil && len(*op.Security) == 0
+
+			for _, name := range publicRoutes() {
+				if converted, _ := openapi.ConvertPath(name); converted == path && !public {
+					t.Errorf("public operation %s must not require token", op.OperationID)
+				}
+			}
+		})
+	})
+}
+
+func Test_Versions(t *testing.T) {
+	a := newTestApi()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return rec
+	}
+
+	t.Run("Versions_auth_exclusions", func(t *testing.T) {
+		cases := map[string]int{
+			routeHealthLive:              http.StatusOK,
+			apiV1.name(routeOpenAPI):     http.StatusOK,
+			apiLegacy.name(routeOpenAPI): http.StatusOK,
+			apiV1.name(routeUsers):       http.StatusUnauthorized,
+			apiLegacy.name(routeUsers):   http.StatusUnauthorized,
+			apiV1.name(routeHealthLive):  http.StatusNotFound,
+			"/v2" + routeUsers:           http.StatusNotFound,
+		}
+
+		for path, code := range cases {
+			if rec := serve(path); rec.Code != code {
+				t.Errorf("%s: expected %d, got %d", path, code, rec.Code)
+			}
+		}
+	})
+
+	t.Run("Versions_deprecation_headers", func(t *testing.T) {
+		if rec := serve(apiV1.name(routeOpenAPI)); rec.Header().Get(middleware.HeaderDeprecation) != "" {
+			t.Errorf("v1 route must not be deprecated, headers: %v", rec.Header())
+		}
+
+		rec := serve(apiLegacy.name(routeUsers))
+
+		if rec.Header().Get(middleware.HeaderDeprecation) != "true" {
+			t.Errorf("legacy route must be deprecated, headers: %v", rec.Header())
+		}
+
+		if link := rec.Header().Get(middleware.HeaderLink); link != `</v1/users>; rel="successor-version"` {
+			t.Errorf("unexpected successor link: %s", link)
+		}
+
+		if rec := serve(routeHealthLive); rec.Header().Get(middleware.HeaderDeprecation) != "" {
+			t.Errorf("unversioned route must not be deprecated, headers: %v", rec.Header())
+		}
+	})
+
+	t.Run("Versions_response_shape", func(t *testing.T) {
+		rec := serve(apiV1.name(routeUsers))
+		envelope := new(models.Envelope)
+
+		if err := json.Unmarshal(rec.Body.Bytes(), envelope); err != nil {
+			t.Fatal(err)
+		}
+
+		if envelope.Error == nil || envelope.Error.Code != http.StatusUnauthorized || envelope.Meta.RequestID == "" {
+			t.Errorf("error envelope with request id expected, got: %s", rec.Body.String())
+		}
+
+		rec = serve(apiLegacy.name(routeUsers))
+		legacy := make(map[string]interface{})
+
+		if err := json.Unmarshal(rec.Body.Bytes(), &legacy); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := legacy["Error"]; !ok || len(legacy) != 1 {
+			t.Errorf("legacy error expected, got: %s", rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetTenants - returns all tenants, superadmin only.
+func (a *AApi) GetTenants(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "GetTenants")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	if !a.superadminOnly(w, r) {
+		return
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	tenants, err := a.sqlManager.GetTenants(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetTenants(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with tenants list (len %d)", r.RemoteAddr, len(tenants))
+	start, end := api_common.Paginate(page, len(tenants))
+	api_common.RespondWithPage(w, r, http.StatusOK, tenants[start:end], page, a.log(r))
+}
+
+// GetTenant - returns tenant with given ID, superadmin only.
+func (a *AApi) GetTenant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetTenant")
+	entry.Debugf("Request from %s, tenantID: %s", r.RemoteAddr, vars["id"])
+
+	if !a.superadminOnly(w, r) {
+		return
+	}
+
+	tenant, ok := a.tenant(w, r, vars["id"])
+
+	if !ok {
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *tenant)
+	api_common.RespondWithJson(w, r, http.StatusOK, tenant, a.log(r))
+}
+
+// CreateTenant - creates tenant from given JSON, superadmin only. Tenant names are unique.
+func (a *AApi) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateTenant")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	if !a.superadminOnly(w, r) {
+		return
+	}
+
+	tenant := new(models.Tenant)
+
+	if err := api_common.DecodeJSON(r, tenant); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	tenant.TenantName = strings.TrimSpace(tenant.TenantName)
+
+	if tenant.TenantName == "" {
+		entry.Errorf("Respond to %s, tenant name is empty", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, "tenant_name: must not be empty", a.log(r))
+
+		return
+	}
+
+	tenants, err := a.sqlManager.GetTenants(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetTenants(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	for _, existing := range tenants {
+		if existing.TenantName == tenant.TenantName {
+			entry.Errorf("Respond to %s, tenant %s already exists", r.RemoteAddr, tenant.TenantName)
+			api_common.RespondWithError(w, r, http.StatusConflict, "tenant with given name already exists", a.log(r))
+
+			return
+		}
+	}
+	// New tenant is active, it's suspended by its own route only.
+	tenant.Suspended = false
+	tenant.CreatedAt = time.Now().Unix()
+
+	id, err := a.sqlManager.CreateTenant(r.Context(), tenant)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateTenant(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Tenant created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// SuspendTenant - suspends tenant with given ID, superadmin only.
+// Admins of suspended tenant can't login and their tokens are rejected, data of tenant is kept.
+func (a *AApi) SuspendTenant(w http.ResponseWriter, r *http.Request) {
+	a.setSuspended(w, r, true)
+}
+
+// ResumeTenant - resumes suspended tenant with given ID, superadmin only.
+func (a *AApi) ResumeTenant(w http.ResponseWriter, r *http.Request) {
+	a.setSuspended(w, r, false)
+}
+
+// CreateTenantAdmin - creates admin of tenant with given ID from JSON, superadmin only.
+// Admins registered on public route belong to default tenant.
+func (a *AApi) CreateTenantAdmin(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "CreateTenantAdmin")
+	entry.Debugf("Request from %s, tenantID: %s", r.RemoteAddr, vars["id"])
+
+	if !a.superadminOnly(w, r) {
+		return
+	}
+
+	tenant, ok := a.tenant(w, r, vars["id"])
+
+	if !ok {
+		return
+	}
+
+	admin := new(models.Admin)
+
+	if err := api_common.DecodeJSON(r, admin); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	admin.TenantID = tenant.TenantID
+	a.createAdmin(w, r, admin)
+}
+
+// setSuspended - SuspendTenant and ResumeTenant helper, responds with updated tenant.
+func (a *AApi) setSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "setSuspended")
+	entry.Debugf("Request from %s, tenantID: %s, suspended: %t", r.RemoteAddr, vars["id"], suspended)
+
+	if !a.superadminOnly(w, r) {
+		return
+	}
+	// Superadmin belongs to default tenant, so it can't be suspended.
+	if suspended && vars["id"] == strconv.FormatInt(core.DefaultTenant, 10) {
+		entry.Errorf("Respond to %s, default tenant can't be suspended", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, "default tenant can't be suspended", a.log(r))
+
+		return
+	}
+
+	updated, err := a.sqlManager.SuspendTenant(r.Context(), vars["id"], suspended)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("SuspendTenant(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if updated == 0 {
+		entry.Warnf("Respond to %s, tenant doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "tenant doesn't exists", a.log(r))
+
+		return
+	}
+
+	tenant, ok := a.tenant(w, r, vars["id"])
+
+	if !ok {
+		return
+	}
+
+	entry.Infof("Tenant %s suspended: %t", vars["id"], suspended)
+	api_common.RespondWithJson(w, r, http.StatusOK, tenant, a.log(r))
+}
+
+// tenant - returns tenant with given ID, responds with error if it doesn't exist.
+func (a *AApi) tenant(w http.ResponseWriter, r *http.Request, tenantID string) (*models.Tenant, bool) {
+	entry := a.log(r).WithField("func", "tenant")
+	tenant, err := a.sqlManager.GetTenant(r.Context(), tenantID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetTenant(): %v", err),
+			a.log(r),
+		)
+
+		return nil, false
+	}
+
+	if tenant == nil {
+		entry.Warnf("Respond to %s, tenant doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "tenant doesn't exists", a.log(r))
+
+		return nil, false
+	}
+
+	return tenant, true
+}
+
+// superadminOnly - responds with 403 if request isn't made by superadmin:
+// admin of default tenant with name set in config and superadmin role, which is given on startup only.
+func (a *AApi) superadminOnly(w http.ResponseWriter, r *http.Request) bool {
+	entry := a.log(r).WithField("func", "superadminOnly")
+	access, err := a.token.ExtractTokenMetadata(r)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnauthorized,
+			fmt.Sprintf("ExtractTokenMetadata(): %v", err),
+			a.log(r),
+		)
+
+		return false
+	}
+
+	superadmin := api_common.Role(r.Context()) == models.RoleSuperadmin
+
+	if !superadmin || a.superadmin == "" || access.Username != a.superadmin || access.TenantID != core.DefaultTenant {
+		entry.Warnf("Respond to %s, admin %s isn't superadmin", r.RemoteAddr, access.Username)
+		api_common.RespondWithError(w, r, http.StatusForbidden, "superadmin only", a.log(r))
+
+		return false
+	}
+
+	return true
+}
+
+// tenantActive - returns true if tenant with given ID exists and isn't suspended.
+func (a *AApi) tenantActive(ctx context.Context, tenantID int64) (bool, error) {
+	tenant, err := a.sqlManager.GetTenant(ctx, strconv.FormatInt(tenantID, 10))
+
+	if err != nil {
+		return false, fmt.Errorf("GetTenant(): %w", err)
+	}
+
+	return tenant != nil && !tenant.Suspended, nil
+}
@@ -3,52 +3,66 @@ package api
 import (
 	"activity_api/api/api_common"
 	"activity_api/common/models"
-	"encoding/json"
+	"activity_api/data_manager/db/core"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // GetUsers - returns all users. If departmentID was specified in URL query - return all users by department
 func (a *AApi) GetUsers(w http.ResponseWriter, r *http.Request) {
 	depID := r.URL.Query().Get("departmentID")
 
-	entry := a.logger.WithField("func", "GetUsers")
+	entry := a.log(r).WithField("func", "GetUsers")
 	entry.Debugf("Request from %s, url departmentID: %s", r.RemoteAddr, depID)
 
-	users, err := a.sqlManager.GetUsers(depID)
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	users, err := a.sqlManager.GetUsers(r.Context(), depID)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetUsers(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, &users)
-	api_common.RespondWithJson(w, http.StatusOK, &users, a.logger)
+	start, end := api_common.Paginate(page, len(users))
+	api_common.RespondWithPage(w, r, http.StatusOK, users[start:end], page, a.log(r))
 }
 
 // GetUser - returns user with given ID
 func (a *AApi) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "GetUser")
+	entry := a.log(r).WithField("func", "GetUser")
 	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
 
-	user, err := a.sqlManager.GetUser(vars["id"])
+	user, err := a.sqlManager.GetUser(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("GetUser(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
@@ -56,73 +70,344 @@ func (a *AApi) GetUser(w http.ResponseWriter, r *http.Request) {
 	// Id user is nil == doesn't exists
 	if user == nil {
 		entry.Warnf("Respond to %s, error: %v", r.RemoteAddr, err)
-		api_common.RespondWithError(w, http.StatusNotFound, "user doesn't exists", a.logger)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
 
 		return
 	}
 
 	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, user)
-	api_common.RespondWithJson(w, http.StatusOK, &user, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &user, a.log(r))
 }
 
 // CreateUser - writes user to DB from given JSON.
 func (a *AApi) CreateUser(w http.ResponseWriter, r *http.Request) {
-	entry := a.logger.WithField("func", "CreateUser")
+	entry := a.log(r).WithField("func", "CreateUser")
 	entry.Debug("Request from:", r.RemoteAddr)
 
 	user := new(models.User)
 
-	if err := json.NewDecoder(r.Body).Decode(user); err != nil {
+	if err := api_common.DecodeJSON(r, user); err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("Decode(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	if err := validateUser(user); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
 	entry.Debugf("Creating user %+v, request from: %s", user, r.RemoteAddr)
-	id, err := a.sqlManager.CreateUser(user)
+	id, err := a.sqlManager.CreateUser(r.Context(), user)
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
-			http.StatusUnprocessableEntity,
+			r,
+			userErrorCode(err),
 			fmt.Sprintf("CreateUser(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	user.UserID = id
+	a.publishWebhook(r, models.WebhookUserCreated, user)
+
 	entry.Debugf("User created, responding to %s with: %d", r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusCreated, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
 }
 
 // DeleteUser - deletes user with given ID from DB.
 func (a *AApi) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	entry := a.logger.WithField("func", "DeleteUser")
+	entry := a.log(r).WithField("func", "DeleteUser")
 	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
 
-	id, err := a.sqlManager.DeleteUser(vars["id"])
+	id, err := a.sqlManager.DeleteUser(r.Context(), vars["id"])
 
 	if err != nil {
 		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
 		api_common.RespondWithError(
 			w,
+			r,
 			http.StatusUnprocessableEntity,
 			fmt.Sprintf("DeleteUser(): %v", err),
-			a.logger,
+			a.log(r),
 		)
 
 		return
 	}
 
+	if id > 0 {
+		userID, _ := strconv.ParseInt(vars["id"], 10, 64) // route matches digits only
+		a.publishWebhook(r, models.WebhookUserDeleted, &models.ObjectID{ID: userID})
+	}
+
 	entry.Debugf("User %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
-	api_common.RespondWithJson(w, http.StatusOK, &models.ObjectID{ID: id}, a.logger)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// UpdateUser - updates profile of user with given ID from JSON, responds with updated user.
+// Department isn't changed here, user is moved by TransferUser, so membership history is kept.
+func (a *AApi) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "UpdateUser")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	user := new(models.User)
+
+	if err := api_common.DecodeJSON(r, user); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateUser(user); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
+	current, ok := a.user(w, r, vars["id"])
+
+	if !ok {
+		return
+	}
+
+	if user.DepartmentID != 0 && user.DepartmentID != current.DepartmentID {
+		entry.Errorf("Respond to %s, department is changed by update", r.RemoteAddr)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			"department_id: user is moved to other department by transfer",
+			a.log(r),
+		)
+
+		return
+	}
+
+	user.UserID = current.UserID
+
+	if _, err := a.sqlManager.UpdateUser(r.Context(), user); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			userErrorCode(err),
+			fmt.Sprintf("UpdateUser(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	updated, ok := a.user(w, r, vars["id"])
+
+	if !ok {
+		return
+	}
+
+	entry.Debugf("User %s updated, responding to %s with: %+v", vars["id"], r.RemoteAddr, updated)
+	api_common.RespondWithJson(w, r, http.StatusOK, updated, a.log(r))
+}
+
+// TransferUser - moves user with given ID to department from JSON, responds with membership history.
+// Activity recorded before the transfer still counts toward previous department.
+func (a *AApi) TransferUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "TransferUser")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	transfer := new(models.UserTransfer)
+
+	if err := api_common.DecodeJSON(r, transfer); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if transfer.DepartmentID <= 0 || transfer.EffectiveFrom < 0 {
+		entry.Errorf("Respond to %s, invalid transfer: %+v", r.RemoteAddr, *transfer)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			"department_id: must be positive, effective_from: must not be negative",
+			a.log(r),
+		)
+
+		return
+	}
+
+	if transfer.EffectiveFrom == 0 {
+		transfer.EffectiveFrom = time.Now().Unix()
+	}
+
+	transferred, err := a.sqlManager.TransferUser(
+		r.Context(),
+		vars["id"],
+		transfer.DepartmentID,
+		transfer.EffectiveFrom,
+	)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			userErrorCode(err),
+			fmt.Sprintf("TransferUser(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if transferred == 0 {
+		entry.Warnf("Respond to %s, user doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Infof("User %s transferred to department %d", vars["id"], transfer.DepartmentID)
+	a.GetUserDepartments(w, r)
+}
+
+// GetUserDepartments - returns department membership history of user with given ID, oldest first.
+func (a *AApi) GetUserDepartments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetUserDepartments")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	if _, ok := a.user(w, r, vars["id"]); !ok {
+		return
+	}
+
+	memberships, err := a.sqlManager.GetMemberships(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetMemberships(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with memberships list (len %d)", r.RemoteAddr, len(memberships))
+	start, end := api_common.Paginate(page, len(memberships))
+	api_common.RespondWithPage(w, r, http.StatusOK, memberships[start:end], page, a.log(r))
+}
+
+// user - returns user with given ID, responds with error if it doesn't exist.
+func (a *AApi) user(w http.ResponseWriter, r *http.Request, userID string) (*models.User, bool) {
+	entry := a.log(r).WithField("func", "user")
+	user, err := a.sqlManager.GetUser(r.Context(), userID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetUser(): %v", err),
+			a.log(r),
+		)
+
+		return nil, false
+	}
+
+	if user == nil {
+		entry.Warnf("Respond to %s, user doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+// validateUser - checks profile of created or updated user, empty status means active user.
+func validateUser(user *models.User) error {
+	user.UserName = strings.TrimSpace(user.UserName)
+	user.Email = strings.TrimSpace(user.Email)
+	user.EmployeeNumber = strings.TrimSpace(user.EmployeeNumber)
+	user.JobTitle = strings.TrimSpace(user.JobTitle)
+
+	if user.UserName == "" {
+		return errors.New("user_name: must not be empty")
+	}
+
+	if user.Email != "" {
+		if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+			return fmt.Errorf("email: address expected, got %q", user.Email)
+		}
+	}
+
+	switch user.Status {
+	case "":
+		user.Status = models.UserActive
+	case models.UserActive, models.UserOnLeave, models.UserTerminated:
+	default:
+		return fmt.Errorf("status: unknown status %q, expected: %s, %s, %s",
+			user.Status, models.UserActive, models.UserOnLeave, models.UserTerminated)
+	}
+
+	if user.EmploymentStart < 0 || user.EmploymentEnd < 0 {
+		return errors.New("employment_start, employment_end: must not be negative")
+	}
+
+	if user.EmploymentEnd != 0 && user.EmploymentEnd < user.EmploymentStart {
+		return errors.New("employment_end: must not be before employment_start")
+	}
+
+	return nil
+}
+
+// userErrorCode - returns status code of user db error.
+func userErrorCode(err error) int {
+	if errors.Is(err, core.ErrEmployeeNumberTaken) {
+		return http.StatusConflict
+	}
+
+	return http.StatusUnprocessableEntity
 }
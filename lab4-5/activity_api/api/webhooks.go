@@ -0,0 +1,359 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"activity_api/common/webhook"
+	"activity_api/data_manager/db/core"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhookEvents - types of domain events webhook could subscribe to.
+var webhookEvents = []string{
+	models.WebhookUserCreated,
+	models.WebhookUserDeleted,
+	models.WebhookActivityCreated,
+	models.WebhookAlertFiring,
+	models.WebhookAlertResolved,
+}
+
+// queryStatus - query param of deliveries status filter.
+const queryStatus = "status"
+
+// GetWebhooks - returns all webhooks, secrets aren't returned.
+func (a *AApi) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "GetWebhooks")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	webhooks, err := a.sqlManager.GetWebhooks(r.Context())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetWebhooks(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	for _, hook := range webhooks {
+		hook.Secret = ""
+	}
+
+	entry.Debugf("Responding to %s with webhooks list (len %d)", r.RemoteAddr, len(webhooks))
+	start, end := api_common.Paginate(page, len(webhooks))
+	api_common.RespondWithPage(w, r, http.StatusOK, webhooks[start:end], page, a.log(r))
+}
+
+// GetWebhook - returns webhook with given ID, secret isn't returned.
+func (a *AApi) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetWebhook")
+	entry.Debugf("Request from %s, webhookID: %s", r.RemoteAddr, vars["id"])
+
+	hook, err := a.sqlManager.GetWebhook(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetWebhook(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if hook == nil {
+		entry.Warnf("Respond to %s, webhook doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "webhook doesn't exists", a.log(r))
+
+		return
+	}
+
+	hook.Secret = ""
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *hook)
+	api_common.RespondWithJson(w, r, http.StatusOK, hook, a.log(r))
+}
+
+// CreateWebhook - creates webhook from given JSON. Secret is generated if it isn't set,
+// it's returned only in this response.
+func (a *AApi) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateWebhook")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	hook := new(models.Webhook)
+
+	if err := api_common.DecodeJSON(r, hook); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateWebhook(hook); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
+	if hook.Secret == "" {
+		secret, err := webhook.NewSecret()
+
+		if err != nil {
+			entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusInternalServerError,
+				fmt.Sprintf("NewSecret(): %v", err),
+				a.log(r),
+			)
+
+			return
+		}
+
+		hook.Secret = secret
+	}
+
+	hook.CreatedAt = time.Now().Unix()
+
+	entry.Debugf("Creating webhook for %s, request from: %s", hook.URL, r.RemoteAddr)
+	id, err := a.sqlManager.CreateWebhook(r.Context(), hook)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateWebhook(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	hook.WebhookID = id
+
+	entry.Debugf("Webhook created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, hook, a.log(r))
+}
+
+// DeleteWebhook - deletes webhook with given ID and its deliveries.
+func (a *AApi) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "DeleteWebhook")
+	entry.Debugf("Request from %s, webhookID: %s", r.RemoteAddr, vars["id"])
+
+	id, err := a.sqlManager.DeleteWebhook(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteWebhook(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Webhook %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// GetWebhookDeliveries - returns deliveries of webhook with given ID, optionally filtered by status.
+func (a *AApi) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	a.getDeliveries(w, r, mux.Vars(r)["id"], r.URL.Query().Get(queryStatus))
+}
+
+// GetDeadDeliveries - returns dead-letter list: deliveries of all webhooks which failed every attempt.
+func (a *AApi) GetDeadDeliveries(w http.ResponseWriter, r *http.Request) {
+	a.getDeliveries(w, r, "", models.DeliveryDead)
+}
+
+// RedeliverDelivery - queues delivered or dead delivery with given ID again, attempts are reset.
+func (a *AApi) RedeliverDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "RedeliverDelivery")
+	entry.Debugf("Request from %s, deliveryID: %s", r.RemoteAddr, vars["id"])
+
+	queued, err := a.sqlManager.RedeliverDelivery(r.Context(), vars["id"], time.Now().Unix())
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("RedeliverDelivery(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if queued == 0 {
+		entry.Warnf("Respond to %s, delivery doesn't exist or is already pending", r.RemoteAddr)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusNotFound,
+			"delivery doesn't exists or is already pending",
+			a.log(r),
+		)
+
+		return
+	}
+
+	if a.webhooks != nil {
+		a.webhooks.Notify()
+	}
+
+	entry.Debugf("Delivery %s queued, responding to %s", vars["id"], r.RemoteAddr)
+	api_common.RespondWithJson(w, r, http.StatusAccepted, &models.ObjectID{ID: queued}, a.log(r))
+}
+
+// getDeliveries - responds with deliveries of given webhook and status, empty values match any.
+func (a *AApi) getDeliveries(w http.ResponseWriter, r *http.Request, webhookID, status string) {
+	entry := a.log(r).WithField("func", "getDeliveries")
+	entry.Debugf("Request from %s, webhookID: %q, status: %q", r.RemoteAddr, webhookID, status)
+
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		entry.Errorf("Respond to %s, unknown status: %q", r.RemoteAddr, status)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusBadRequest,
+			fmt.Sprintf("%s: unknown status %q", queryStatus, status),
+			a.log(r),
+		)
+
+		return
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	deliveries, err := a.sqlManager.GetDeliveries(r.Context(), webhookID, status)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetDeliveries(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with deliveries list (len %d)", r.RemoteAddr, len(deliveries))
+	start, end := api_common.Paginate(page, len(deliveries))
+	api_common.RespondWithPage(w, r, http.StatusOK, deliveries[start:end], page, a.log(r))
+}
+
+// publishWebhook - queues domain event for webhooks of request tenant. Event must not be lost if request is cancelled
+// or service is stopping after the change is written, so queueing isn't cancelled: api is stopped before db.
+// Queue errors don't fail the request, they are only logged.
+func (a *AApi) publishWebhook(r *http.Request, event string, data interface{}) {
+	if a.webhooks == nil {
+		return
+	}
+
+	entry := a.log(r).WithField("func", "publishWebhook")
+	tenantID, err := core.TenantID(r.Context())
+
+	if err != nil {
+		entry.Errorf("TenantID() %s error: %v", event, err)
+
+		return
+	}
+
+	if err := a.webhooks.Enqueue(core.WithTenant(context.Background(), tenantID), event, data); err != nil {
+		entry.Errorf("Enqueue() %s error: %v", event, err)
+	}
+}
+
+// validateWebhook - checks webhook url and normalizes its events list.
+func validateWebhook(hook *models.Webhook) error {
+	parsed, err := url.Parse(hook.URL)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url: absolute http or https url expected, got %q", hook.URL)
+	}
+
+	events := make([]string, 0, len(webhookEvents))
+	seen := make(map[string]bool)
+
+	for _, event := range strings.Split(hook.Events, ",") {
+		event = strings.TrimSpace(event)
+
+		if event == "" || seen[event] {
+			continue
+		}
+
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("events: unknown event %q, expected: %s", event, strings.Join(webhookEvents, ", "))
+		}
+
+		seen[event] = true
+		events = append(events, event)
+	}
+
+	hook.Events = strings.Join(events, ",")
+
+	if len(hook.Secret) > 0 && len(hook.Secret) < 16 {
+		return errors.New("secret: at least 16 characters expected")
+	}
+
+	return nil
+}
+
+// isWebhookEvent - returns true if webhook could subscribe to given event.
+func isWebhookEvent(event string) bool {
+	for _, known := range webhookEvents {
+		if known == event {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,497 @@
+package api
+
+import (
+	"activity_api/api/api_common"
+	"activity_api/common/models"
+	"activity_api/common/worktime"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxUTCOffset - max offset of schedule time zone, seconds.
+	maxUTCOffset = int64(14 * time.Hour / time.Second)
+	// dayLength - max expected work time of one day, seconds.
+	dayLength = int64(24 * time.Hour / time.Second)
+)
+
+// GetUserSchedule - returns own schedule of user with given ID.
+func (a *AApi) GetUserSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.getSchedule(w, r, userID, 0)
+}
+
+// SetUserSchedule - sets schedule of user with given ID from JSON, it overrides schedule of department.
+func (a *AApi) SetUserSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.setSchedule(w, r, userID, 0)
+}
+
+// DeleteUserSchedule - deletes own schedule of user with given ID, schedule of department applies to it again.
+func (a *AApi) DeleteUserSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.deleteSchedule(w, r, userID, 0)
+}
+
+// GetDepartmentSchedule - returns own schedule of department with given ID.
+func (a *AApi) GetDepartmentSchedule(w http.ResponseWriter, r *http.Request) {
+	departID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.getSchedule(w, r, 0, departID)
+}
+
+// SetDepartmentSchedule - sets schedule of department with given ID from JSON,
+// it applies to users of department and its subdepartments without own schedule.
+func (a *AApi) SetDepartmentSchedule(w http.ResponseWriter, r *http.Request) {
+	departID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.setSchedule(w, r, 0, departID)
+}
+
+// DeleteDepartmentSchedule - deletes own schedule of department with given ID.
+func (a *AApi) DeleteDepartmentSchedule(w http.ResponseWriter, r *http.Request) {
+	departID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64) // route matches digits only
+	a.deleteSchedule(w, r, 0, departID)
+}
+
+// getSchedule - responds with own schedule of user or department.
+func (a *AApi) getSchedule(w http.ResponseWriter, r *http.Request, userID, departID int64) {
+	entry := a.log(r).WithField("func", "getSchedule")
+	entry.Debugf("Request from %s, userID: %d, departmentID: %d", r.RemoteAddr, userID, departID)
+
+	schedule, err := a.sqlManager.GetWorkSchedule(r.Context(), userID, departID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetWorkSchedule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if schedule == nil {
+		entry.Warnf("Respond to %s, schedule doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "schedule doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *schedule)
+	api_common.RespondWithJson(w, r, http.StatusOK, schedule, a.log(r))
+}
+
+// setSchedule - sets schedule of user or department from JSON, responds with it.
+func (a *AApi) setSchedule(w http.ResponseWriter, r *http.Request, userID, departID int64) {
+	entry := a.log(r).WithField("func", "setSchedule")
+	entry.Debugf("Request from %s, userID: %d, departmentID: %d", r.RemoteAddr, userID, departID)
+
+	schedule := new(models.WorkSchedule)
+
+	if err := api_common.DecodeJSON(r, schedule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateSchedule(schedule); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+	// Owner is taken from route only.
+	schedule.UserID = userID
+	schedule.DepartmentID = departID
+
+	set, err := a.sqlManager.SetWorkSchedule(r.Context(), schedule)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("SetWorkSchedule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if set == 0 {
+		entry.Warnf("Respond to %s, owner of schedule doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, scheduleOwner(schedule)+" doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Schedule set, responding to %s with: %+v", r.RemoteAddr, *schedule)
+	api_common.RespondWithJson(w, r, http.StatusOK, schedule, a.log(r))
+}
+
+// deleteSchedule - deletes own schedule of user or department.
+func (a *AApi) deleteSchedule(w http.ResponseWriter, r *http.Request, userID, departID int64) {
+	entry := a.log(r).WithField("func", "deleteSchedule")
+	entry.Debugf("Request from %s, userID: %d, departmentID: %d", r.RemoteAddr, userID, departID)
+
+	id, err := a.sqlManager.DeleteWorkSchedule(r.Context(), userID, departID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteWorkSchedule(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Schedule deleted, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// GetTimeOffs - returns leaves and holidays, with userID query param - leaves of the user and holidays.
+func (a *AApi) GetTimeOffs(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("userID")
+	entry := a.log(r).WithField("func", "GetTimeOffs")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, userID)
+
+	if userID != "" {
+		if id, err := strconv.ParseInt(userID, 10, 64); err != nil || id <= 0 {
+			entry.Errorf("Respond to %s, invalid userID: %q", r.RemoteAddr, userID)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("userID: positive integer expected, got %q", userID),
+				a.log(r),
+			)
+
+			return
+		}
+	}
+
+	page, ok := a.pagination(w, r)
+
+	if !ok {
+		return
+	}
+
+	timeOffs, err := a.sqlManager.GetTimeOffs(r.Context(), userID)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetTimeOffs(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with time off list (len %d)", r.RemoteAddr, len(timeOffs))
+	start, end := api_common.Paginate(page, len(timeOffs))
+	api_common.RespondWithPage(w, r, http.StatusOK, timeOffs[start:end], page, a.log(r))
+}
+
+// GetTimeOff - returns time off with given ID.
+func (a *AApi) GetTimeOff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetTimeOff")
+	entry.Debugf("Request from %s, timeOffID: %s", r.RemoteAddr, vars["id"])
+
+	timeOff, err := a.sqlManager.GetTimeOff(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("GetTimeOff(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if timeOff == nil {
+		entry.Warnf("Respond to %s, time off doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "time off doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with: %+v", r.RemoteAddr, *timeOff)
+	api_common.RespondWithJson(w, r, http.StatusOK, timeOff, a.log(r))
+}
+
+// CreateTimeOff - creates leave of user or holiday (without user) from given JSON.
+func (a *AApi) CreateTimeOff(w http.ResponseWriter, r *http.Request) {
+	entry := a.log(r).WithField("func", "CreateTimeOff")
+	entry.Debug("Request from:", r.RemoteAddr)
+
+	timeOff := new(models.TimeOff)
+
+	if err := api_common.DecodeJSON(r, timeOff); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("Decode(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	if err := validateTimeOff(timeOff); err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(w, r, http.StatusUnprocessableEntity, err.Error(), a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Creating time off %+v, request from: %s", timeOff, r.RemoteAddr)
+	id, err := a.sqlManager.CreateTimeOff(r.Context(), timeOff)
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("CreateTimeOff(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Time off created, responding to %s with: %d", r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusCreated, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// DeleteTimeOff - deletes time off with given ID.
+func (a *AApi) DeleteTimeOff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "DeleteTimeOff")
+	entry.Debugf("Request from %s, timeOffID: %s", r.RemoteAddr, vars["id"])
+
+	id, err := a.sqlManager.DeleteTimeOff(r.Context(), vars["id"])
+
+	if err != nil {
+		entry.Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+		api_common.RespondWithError(
+			w,
+			r,
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("DeleteTimeOff(): %v", err),
+			a.log(r),
+		)
+
+		return
+	}
+
+	entry.Debugf("Time off %s deleted, responding to %s with: %d", vars["id"], r.RemoteAddr, id)
+	api_common.RespondWithJson(w, r, http.StatusOK, &models.ObjectID{ID: id}, a.log(r))
+}
+
+// GetUsersWorkReport - returns expected versus actual work time of user by days of given period.
+func (a *AApi) GetUsersWorkReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetUsersWorkReport")
+	entry.Debugf("Request from %s, userID: %s", r.RemoteAddr, vars["id"])
+
+	start, end, ok := a.reportPeriod(w, r)
+
+	if !ok {
+		return
+	}
+
+	report, err := a.workTime.UserReport(r.Context(), vars["id"], start, end)
+
+	if a.reportFailed(w, r, err) {
+		return
+	}
+
+	if report == nil {
+		entry.Warnf("Respond to %s, user doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "user doesn't exists", a.log(r))
+
+		return
+	}
+
+	entry.Debugf("Responding to %s with report of %s - %s", r.RemoteAddr, report.From, report.To)
+	api_common.RespondWithJson(w, r, http.StatusOK, report, a.log(r))
+}
+
+// GetDepartmentsWorkReport - returns expected versus actual work time of department members for given period,
+// every member is reported over days of its membership only. Analysts get department totals only.
+func (a *AApi) GetDepartmentsWorkReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry := a.log(r).WithField("func", "GetDepartmentsWorkReport")
+	entry.Debugf("Request from %s, departmentID: %s", r.RemoteAddr, vars["id"])
+
+	start, end, ok := a.reportPeriod(w, r)
+
+	if !ok {
+		return
+	}
+
+	report, err := a.workTime.DepartmentReport(r.Context(), vars["id"], start, end)
+
+	if a.reportFailed(w, r, err) {
+		return
+	}
+
+	if report == nil {
+		entry.Warnf("Respond to %s, department doesn't exist", r.RemoteAddr)
+		api_common.RespondWithError(w, r, http.StatusNotFound, "department doesn't exists", a.log(r))
+
+		return
+	}
+
+	if a.anonymized(r) {
+		a.anonymizer.DepartmentWorkReport(report)
+	}
+
+	entry.Debugf("Responding to %s with report of %s - %s", r.RemoteAddr, report.From, report.To)
+	api_common.RespondWithJson(w, r, http.StatusOK, report, a.log(r))
+}
+
+// reportPeriod - parses required TimeStart and TimeEnd of work report, responds with error if they are invalid.
+func (a *AApi) reportPeriod(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	entry := a.log(r).WithField("func", "reportPeriod")
+	period := make([]int64, 0, 2)
+
+	for _, name := range []string{api_common.QueryTimeStart, api_common.QueryTimeEnd} {
+		raw := r.URL.Query().Get(name)
+		parsed, err := api_common.ParseTime(raw)
+
+		if err != nil || parsed.IsZero() || parsed.Unix() < 0 {
+			entry.Errorf("Respond to %s, invalid %s: %q", r.RemoteAddr, name, raw)
+			api_common.RespondWithError(
+				w,
+				r,
+				http.StatusBadRequest,
+				fmt.Sprintf("%s: RFC 3339 or unix time expected, got %q", name, raw),
+				a.log(r),
+			)
+
+			return 0, 0, false
+		}
+
+		period = append(period, parsed.Unix())
+	}
+
+	return period[0], period[1], true
+}
+
+// reportFailed - responds with error of work report building, returns false if there is no error.
+func (a *AApi) reportFailed(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	a.log(r).WithField("func", "reportFailed").Errorf("Respond to %s, error: %v", r.RemoteAddr, err)
+
+	if errors.Is(err, worktime.ErrInvalidPeriod) {
+		api_common.RespondWithError(w, r, http.StatusBadRequest, err.Error(), a.log(r))
+
+		return true
+	}
+
+	api_common.RespondWithError(
+		w,
+		r,
+		http.StatusUnprocessableEntity,
+		fmt.Sprintf("work report: %v", err),
+		a.log(r),
+	)
+
+	return true
+}
+
+// validateSchedule - checks expected time of every weekday and time zone offset of schedule.
+func validateSchedule(schedule *models.WorkSchedule) error {
+	weekdays := []int64{
+		schedule.Monday,
+		schedule.Tuesday,
+		schedule.Wednesday,
+		schedule.Thursday,
+		schedule.Friday,
+		schedule.Saturday,
+		schedule.Sunday,
+	}
+
+	for i, expected := range weekdays {
+		if expected < 0 || expected > dayLength {
+			// Schedule starts from Monday, weekdays are numbered from Sunday.
+			name := strings.ToLower(time.Weekday((i + 1) % 7).String())
+
+			return fmt.Errorf("%s: seconds from 0 to %d expected, got %d", name, dayLength, expected)
+		}
+	}
+
+	if schedule.UTCOffset < -maxUTCOffset || schedule.UTCOffset > maxUTCOffset {
+		return fmt.Errorf("utc_offset: seconds from %d to %d expected, got %d",
+			-maxUTCOffset, maxUTCOffset, schedule.UTCOffset)
+	}
+
+	return nil
+}
+
+// validateTimeOff - checks dates and user of time off.
+func validateTimeOff(timeOff *models.TimeOff) error {
+	timeOff.Description = strings.TrimSpace(timeOff.Description)
+
+	if timeOff.UserID < 0 {
+		return errors.New("user_id: must not be negative")
+	}
+
+	if _, err := time.Parse(worktime.DateLayout, timeOff.From); err != nil {
+		return fmt.Errorf("from: date %s expected, got %q", worktime.DateLayout, timeOff.From)
+	}
+
+	if _, err := time.Parse(worktime.DateLayout, timeOff.To); err != nil {
+		return fmt.Errorf("to: date %s expected, got %q", worktime.DateLayout, timeOff.To)
+	}
+	// Dates are in YYYY-MM-DD format, so they are compared as strings.
+	if timeOff.To < timeOff.From {
+		return errors.New("to: must not be before from")
+	}
+
+	return nil
+}
+
+// scheduleOwner - returns name of owner of schedule for error messages.
+func scheduleOwner(schedule *models.WorkSchedule) string {
+	if schedule.UserID != 0 {
+		return "user"
+	}
+
+	return "department"
+}
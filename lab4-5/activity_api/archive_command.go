@@ -0,0 +1,58 @@
+package main
+
+import (
+	"activity_api/common/config_parser"
+	"activity_api/control"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// restoreCommand - name of command that restores activity records from archive instead of starting service.
+const restoreCommand = "restore-archive"
+
+// runRestoreArchive - runs archive restore command and returns exit code. Usage:
+// restore-archive archive.ndjson.gz [service config flags]
+func runRestoreArchive(args []string) int {
+	flags := flag.NewFlagSet(restoreCommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s archive.ndjson.gz [config flags]\n", os.Args[0], restoreCommand)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return 1
+	}
+
+	config, err := config_parser.LoadConfig(flags.Args()[1:])
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Load config error:", err)
+
+		return 1
+	}
+
+	manifest, restored, err := control.RestoreArchive(config, flags.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Restore error:", err)
+
+		return 1
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Tenant %d: restored %d of %d archived records, %d already existed or their users were deleted\n",
+		manifest.TenantID,
+		restored,
+		manifest.Records,
+		manifest.Records-restored,
+	)
+
+	return 0
+}
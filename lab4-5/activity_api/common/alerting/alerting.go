@@ -0,0 +1,202 @@
+package alerting
+
+import (
+	"activity_api/common/models"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store - alert rules and activity they are evaluated against.
+type Store interface {
+	GetAlertRules(ctx context.Context) ([]*models.AlertRule, error)
+	UpdateAlertRuleState(ctx context.Context, rule *models.AlertRule) error
+	GetUserActivity(ctx context.Context, userID string, startTime, endTime time.Time) (*models.UserActivity, error)
+	GetDepartmentActivity(
+		ctx context.Context,
+		departID string,
+		startTime, endTime time.Time,
+		rollup bool,
+	) (*models.DepartmentActivity, error)
+}
+
+// Evaluator - evaluates alert rules and notifies about changes of their state, it's run periodically
+// by job scheduler. Rule without data for its period (no user activity, or no department activity
+// in previous period) keeps its state.
+type Evaluator struct {
+	store     Store
+	notifiers map[string]Notifier
+	mtx       sync.RWMutex // notifiers could be registered while rules are evaluated
+	logger    logrus.FieldLogger
+}
+
+// NewEvaluator - returns new evaluator of rules from given store.
+func NewEvaluator(store Store, logger logrus.FieldLogger) *Evaluator {
+	return &Evaluator{
+		store:     store,
+		notifiers: make(map[string]Notifier),
+		logger:    logger.WithField("module", "AlertEvaluator"),
+	}
+}
+
+// Register - adds notifier, which is used by rules with given name in notifiers list.
+func (e *Evaluator) Register(name string, notifier Notifier) *Evaluator {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.notifiers[name] = notifier
+
+	return e
+}
+
+// Evaluate - evaluates every rule at given time. Error of single rule doesn't stop evaluation of others.
+func (e *Evaluator) Evaluate(ctx context.Context, now time.Time) error {
+	entry := e.logger.WithField("func", "Evaluate")
+	rules, err := e.store.GetAlertRules(ctx)
+
+	if err != nil {
+		return fmt.Errorf("GetAlertRules(): %w", err)
+	}
+
+	for _, rule := range rules {
+		if err := e.evaluate(ctx, rule, now); err != nil {
+			entry.WithField("rule", rule.RuleID).Errorf("evaluate() error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// evaluate - evaluates single rule, writes result and notifies about state change.
+func (e *Evaluator) evaluate(ctx context.Context, rule *models.AlertRule, now time.Time) error {
+	value, firing, ok, err := e.check(ctx, rule, now)
+
+	if err != nil {
+		return fmt.Errorf("check(): %w", err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	state := models.AlertOK
+
+	if firing {
+		state = models.AlertFiring
+	}
+
+	changed := state != rule.State
+	rule.Value = value
+	rule.EvaluatedAt = now.Unix()
+
+	if changed {
+		rule.State = state
+		rule.ChangedAt = now.Unix()
+	}
+
+	if err := e.store.UpdateAlertRuleState(ctx, rule); err != nil {
+		return fmt.Errorf("UpdateAlertRuleState(): %w", err)
+	}
+
+	if changed {
+		e.notify(ctx, &models.Alert{Rule: rule, State: state, Value: value, Time: now.Unix()})
+	}
+
+	return nil
+}
+
+// check - returns value of rule at given time and whether it's firing, ok is false if there is no data.
+func (e *Evaluator) check(ctx context.Context, rule *models.AlertRule, now time.Time) (float64, bool, bool, error) {
+	period := time.Duration(rule.Period) * time.Second
+	target := strconv.FormatInt(rule.TargetID, 10)
+	start, end := window(now.Add(-period), now)
+
+	switch rule.Kind {
+	case models.AlertUserRatio:
+		activity, err := e.store.GetUserActivity(ctx, target, start, end)
+
+		if err != nil {
+			return 0, false, false, fmt.Errorf("GetUserActivity(): %w", err)
+		}
+
+		if activity.TotalTime == 0 {
+			return 0, false, false, nil
+		}
+
+		ratio := float64(activity.ActiveTime) / float64(activity.TotalTime)
+
+		return ratio, ratio < rule.Threshold, true, nil
+	case models.AlertDepartmentDrop:
+		current, err := e.store.GetDepartmentActivity(ctx, target, start, end, false)
+
+		if err != nil {
+			return 0, false, false, fmt.Errorf("GetDepartmentActivity(): %w", err)
+		}
+
+		previousStart, previousEnd := window(now.Add(-2*period), now.Add(-period))
+		previous, err := e.store.GetDepartmentActivity(ctx, target, previousStart, previousEnd, false)
+
+		if err != nil {
+			return 0, false, false, fmt.Errorf("GetDepartmentActivity(): %w", err)
+		}
+
+		if previous.TotalTime == 0 {
+			return 0, false, false, nil
+		}
+
+		drop := float64(previous.TotalTime-current.TotalTime) / float64(previous.TotalTime)
+
+		return drop, drop >= rule.Threshold, true, nil
+	default:
+		return 0, false, false, fmt.Errorf("unknown rule kind %q", rule.Kind)
+	}
+}
+
+// notify - sends alert to every notifier of the rule, errors are only logged.
+func (e *Evaluator) notify(ctx context.Context, alert *models.Alert) {
+	entry := e.logger.WithFields(logrus.Fields{"func": "notify", "rule": alert.Rule.RuleID})
+
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	for _, name := range Notifiers(alert.Rule) {
+		notifier, ok := e.notifiers[name]
+
+		if !ok {
+			entry.Warnf("Notifier %q isn't registered", name)
+
+			continue
+		}
+
+		if err := notifier.Notify(ctx, alert); err != nil {
+			entry.Errorf("Notifier %q error: %v", name, err)
+		}
+	}
+}
+
+// Notifiers - returns names of rule notifiers, log notifier is used if list is empty.
+func Notifiers(rule *models.AlertRule) []string {
+	names := make([]string, 0)
+
+	for _, name := range strings.Split(rule.Notifiers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		names = append(names, models.AlertNotifierLog)
+	}
+
+	return names
+}
+
+// window - returns bounds of activity query for period (from, to]. Query bounds are exclusive,
+// so record exactly on the border of adjacent periods is counted once, in the earlier one.
+func window(from, to time.Time) (time.Time, time.Time) {
+	return from, to.Add(time.Second)
+}
@@ -0,0 +1,225 @@
+package alerting
+
+import (
+	"activity_api/common/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryStore - rules and activity records of single user in memory, the user is in department 1.
+type memoryStore struct {
+	rules    []*models.AlertRule
+	activity []*models.Activity
+}
+
+func (s *memoryStore) GetAlertRules(_ context.Context) ([]*models.AlertRule, error) {
+	rules := make([]*models.AlertRule, 0, len(s.rules))
+
+	for _, rule := range s.rules {
+		copied := *rule
+		rules = append(rules, &copied)
+	}
+
+	return rules, nil
+}
+
+func (s *memoryStore) UpdateAlertRuleState(_ context.Context, rule *models.AlertRule) error {
+	for i, stored := range s.rules {
+		if stored.RuleID == rule.RuleID {
+			copied := *rule
+			s.rules[i] = &copied
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) GetUserActivity(_ context.Context, _ string, start, end time.Time) (*models.UserActivity, error) {
+	active, total := s.sum(start, end)
+
+	return &models.UserActivity{UserID: 1, ActiveTime: active, TotalTime: total}, nil
+}
+
+func (s *memoryStore) GetDepartmentActivity(
+	_ context.Context,
+	_ string,
+	start, end time.Time,
+	_ bool,
+) (*models.DepartmentActivity, error) {
+	active, total := s.sum(start, end)
+
+	return &models.DepartmentActivity{DepartmentID: 1, ActiveTime: active, TotalTime: total}, nil
+}
+
+// sum - sums records between exclusive bounds, like activity queries do.
+func (s *memoryStore) sum(start, end time.Time) (int64, int64) {
+	from, to := start.Unix(), end.Unix()
+
+	var active, total int64
+
+	for _, record := range s.activity {
+		if record.Date > from && record.Date < to {
+			active += record.ActiveTime
+			total += record.TotalTime
+		}
+	}
+
+	return active, total
+}
+
+// recordNotifier - remembers received alerts.
+type recordNotifier struct {
+	alerts []*models.Alert
+}
+
+func (n *recordNotifier) Notify(_ context.Context, alert *models.Alert) error {
+	n.alerts = append(n.alerts, alert)
+
+	return nil
+}
+
+const (
+	testPeriod = 7 * 24 * 60 * 60
+	testNow    = 1600000000
+)
+
+// newTestEvaluator - returns evaluator of given rule and records, and notifier of the rule.
+func newTestEvaluator(rule *models.AlertRule, activity ...*models.Activity) (*Evaluator, *memoryStore, *recordNotifier) {
+	rule.RuleID = 1
+	rule.TargetID = 1
+	rule.Period = testPeriod
+	rule.State = models.AlertOK
+	rule.Notifiers = "test"
+
+	store := &memoryStore{rules: []*models.AlertRule{rule}, activity: activity}
+	notifier := new(recordNotifier)
+	evaluator := NewEvaluator(store, &logrus.Logger{Level: logrus.FatalLevel}).Register("test", notifier)
+
+	return evaluator, store, notifier
+}
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(testNow, 0)
+
+	t.Run("Evaluate_userRatioFiring", func(t *testing.T) {
+		evaluator, store, notifier := newTestEvaluator(
+			&models.AlertRule{Kind: models.AlertUserRatio, Threshold: 0.5},
+			&models.Activity{ActiveTime: 10, TotalTime: 100, Date: testNow - 60},
+		)
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if rule := store.rules[0]; rule.State != models.AlertFiring || rule.Value != 0.1 || rule.ChangedAt != testNow {
+			t.Errorf("Unexpected rule after evaluation: %+v", rule)
+		}
+
+		if len(notifier.alerts) != 1 || notifier.alerts[0].State != models.AlertFiring {
+			t.Fatalf("Unexpected alerts: %+v", notifier.alerts)
+		}
+		// State isn't changed, so there is no new notification.
+		if err := evaluator.Evaluate(ctx, now.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(notifier.alerts) != 1 || store.rules[0].ChangedAt != testNow {
+			t.Errorf("Notified without state change: %+v", notifier.alerts)
+		}
+	})
+
+	t.Run("Evaluate_userRatioResolved", func(t *testing.T) {
+		evaluator, store, notifier := newTestEvaluator(
+			&models.AlertRule{Kind: models.AlertUserRatio, Threshold: 0.5},
+			&models.Activity{ActiveTime: 10, TotalTime: 100, Date: testNow - 60},
+		)
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		store.activity = append(store.activity, &models.Activity{ActiveTime: 100, TotalTime: 100, Date: testNow})
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(notifier.alerts) != 2 || notifier.alerts[1].State != models.AlertOK || store.rules[0].Value != 0.55 {
+			t.Errorf("Alert isn't resolved: %+v, rule: %+v", notifier.alerts, store.rules[0])
+		}
+	})
+
+	t.Run("Evaluate_noData", func(t *testing.T) {
+		evaluator, store, notifier := newTestEvaluator(
+			&models.AlertRule{Kind: models.AlertUserRatio, Threshold: 0.5},
+			&models.Activity{ActiveTime: 10, TotalTime: 100, Date: testNow - 2*testPeriod},
+		)
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(notifier.alerts) != 0 || store.rules[0].EvaluatedAt != 0 {
+			t.Errorf("Rule without data is evaluated: %+v", store.rules[0])
+		}
+	})
+
+	t.Run("Evaluate_departmentDrop", func(t *testing.T) {
+		evaluator, store, notifier := newTestEvaluator(
+			&models.AlertRule{Kind: models.AlertDepartmentDrop, Threshold: 0.25},
+			&models.Activity{TotalTime: 100, Date: testNow - testPeriod}, // border is counted in previous period
+			&models.Activity{TotalTime: 100, Date: testNow - testPeriod - 60},
+			&models.Activity{TotalTime: 150, Date: testNow},
+		)
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if rule := store.rules[0]; rule.State != models.AlertFiring || rule.Value != 0.25 {
+			t.Errorf("Unexpected rule after evaluation: %+v", rule)
+		}
+
+		if len(notifier.alerts) != 1 {
+			t.Errorf("Unexpected alerts: %+v", notifier.alerts)
+		}
+	})
+
+	t.Run("Evaluate_departmentGrowth", func(t *testing.T) {
+		evaluator, store, notifier := newTestEvaluator(
+			&models.AlertRule{Kind: models.AlertDepartmentDrop, Threshold: 0.25},
+			&models.Activity{TotalTime: 100, Date: testNow - testPeriod - 60},
+			&models.Activity{TotalTime: 200, Date: testNow - 60},
+		)
+
+		if err := evaluator.Evaluate(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if rule := store.rules[0]; rule.State != models.AlertOK || rule.Value != -1 || rule.EvaluatedAt != testNow {
+			t.Errorf("Unexpected rule after evaluation: %+v", rule)
+		}
+
+		if len(notifier.alerts) != 0 {
+			t.Errorf("Unexpected alerts: %+v", notifier.alerts)
+		}
+	})
+}
+
+func TestNotifiers(t *testing.T) {
+	t.Run("Notifiers_default", func(t *testing.T) {
+		if names := Notifiers(&models.AlertRule{Notifiers: " , "}); len(names) != 1 || names[0] != models.AlertNotifierLog {
+			t.Errorf("Unexpected notifiers: %v", names)
+		}
+	})
+
+	t.Run("Notifiers_list", func(t *testing.T) {
+		if names := Notifiers(&models.AlertRule{Notifiers: "log, webhook"}); len(names) != 2 || names[1] != "webhook" {
+			t.Errorf("Unexpected notifiers: %v", names)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"activity_api/common/models"
+	"context"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier - sends notification about state change of alert rule.
+type Notifier interface {
+	Notify(ctx context.Context, alert *models.Alert) error
+}
+
+// LogNotifier - writes alerts to service log.
+type LogNotifier struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogNotifier - returns new log notifier.
+func NewLogNotifier(logger logrus.FieldLogger) *LogNotifier {
+	return &LogNotifier{logger: logger.WithField("module", "LogNotifier")}
+}
+
+// Notify - logs firing alert as warning and resolved one as info.
+func (n *LogNotifier) Notify(_ context.Context, alert *models.Alert) error {
+	entry := n.logger.WithFields(logrus.Fields{
+		"func":   "Notify",
+		"rule":   alert.Rule.RuleID,
+		"kind":   alert.Rule.Kind,
+		"target": alert.Rule.TargetID,
+		"value":  alert.Value,
+	})
+
+	if alert.State == models.AlertFiring {
+		entry.Warnf("Alert %q is firing", alert.Rule.Name)
+
+		return nil
+	}
+
+	entry.Infof("Alert %q is resolved", alert.Rule.Name)
+
+	return nil
+}
+
+// Enqueuer - queues domain event for webhooks, implemented by webhook dispatcher.
+type Enqueuer interface {
+	Enqueue(ctx context.Context, event string, data interface{}) error
+}
+
+// WebhookNotifier - sends alerts to webhooks subscribed to alert.firing and alert.resolved events.
+type WebhookNotifier struct {
+	webhooks Enqueuer
+}
+
+// NewWebhookNotifier - returns new webhook notifier.
+func NewWebhookNotifier(webhooks Enqueuer) *WebhookNotifier {
+	return &WebhookNotifier{webhooks: webhooks}
+}
+
+// Notify - queues alert for webhooks, delivery is retried by dispatcher.
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *models.Alert) error {
+	event := models.WebhookAlertResolved
+
+	if alert.State == models.AlertFiring {
+		event = models.WebhookAlertFiring
+	}
+
+	return n.webhooks.Enqueue(ctx, event, alert)
+}
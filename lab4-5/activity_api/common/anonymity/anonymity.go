@@ -0,0 +1,163 @@
+package anonymity
+
+import (
+	"activity_api/common/models"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// DefaultMinGroup - min number of users of reported group, if it isn't set.
+const DefaultMinGroup = 5
+
+// DefaultNoise - default scale of noise in seconds, it hides about an hour of time of one user.
+const DefaultNoise = 3600
+
+// Anonymizer - turns department aggregates into anonymized ones: groups with fewer users than min group size
+// are suppressed, time of other groups gets Laplace noise. Noise depends only on the true value and its group,
+// not on requested period, so it's the same until restart for any request giving the same value,
+// and it can't be averaged out by repeating requests with shifted periods.
+type Anonymizer struct {
+	minGroup int64
+	noise    float64 // scale of Laplace noise in seconds, 0 - no noise
+	salt     uint64
+}
+
+// NewAnonymizer - returns new anonymizer with given min group size and scale of noise,
+// scale is sensitivity divided by privacy budget (epsilon), e.g. max daily time of one user.
+func NewAnonymizer(minGroup int64, noise float64) *Anonymizer {
+	if minGroup <= 0 {
+		minGroup = DefaultMinGroup
+	}
+
+	var salt [8]byte
+	// Salt only keeps noise unpredictable, zero salt if random source fails is still valid.
+	_, _ = rand.Read(salt[:])
+
+	return &Anonymizer{
+		minGroup: minGroup,
+		noise:    noise,
+		salt:     binary.LittleEndian.Uint64(salt[:]),
+	}
+}
+
+// Suppressed - returns true if group with given number of users is too small to be reported.
+func (a *Anonymizer) Suppressed(members int64) bool {
+	return members < a.minGroup
+}
+
+// TreeSuppressed - returns true if report of department tree could be used to get time of too small group
+// by subtracting reports of its parts. Members of departments with too few users are hidden in their own
+// reports, and the tree minus reported parts is their time, so it's suppressed unless there are enough of them.
+func (a *Anonymizer) TreeSuppressed(members []*models.DepartmentMember) bool {
+	departments := make(map[int64]map[int64]bool)
+
+	for _, member := range members {
+		if departments[member.DepartmentID] == nil {
+			departments[member.DepartmentID] = make(map[int64]bool)
+		}
+
+		departments[member.DepartmentID][member.UserID] = true
+	}
+
+	hidden := make(map[int64]bool)
+
+	for _, users := range departments {
+		if !a.Suppressed(int64(len(users))) {
+			continue
+		}
+
+		for user := range users {
+			hidden[user] = true
+		}
+	}
+
+	return len(hidden) > 0 && a.Suppressed(int64(len(hidden)))
+}
+
+// Time - returns given time with noise of value identified by key of its group, noisy time is never negative.
+// The same value of the same group always gets the same noise.
+func (a *Anonymizer) Time(value int64, key ...interface{}) int64 {
+	if a.noise <= 0 {
+		return value
+	}
+
+	hash := fnv.New64a()
+	_ = binary.Write(hash, binary.LittleEndian, a.salt)
+	_ = binary.Write(hash, binary.LittleEndian, value)
+	_, _ = fmt.Fprintf(hash, "%#v", key)
+	// Uniform value in (-0.5, 0.5) is turned into Laplace one by inverse of its distribution function.
+	u := (float64(hash.Sum64()>>11)+0.5)/(1<<53) - 0.5
+	noisy := float64(value) - a.noise*math.Copysign(math.Log(1-2*math.Abs(u)), u)
+
+	return int64(math.Max(0, math.Round(noisy)))
+}
+
+// DepartmentActivity - anonymizes activity of department, or of department tree with its descendants,
+// time of suppressed department is zero. Tree is suppressed if it's too small or its members are TreeSuppressed.
+func (a *Anonymizer) DepartmentActivity(
+	activity *models.DepartmentActivity,
+	tree bool,
+	members []*models.DepartmentMember,
+) {
+	if a.Suppressed(activity.Members) || tree && a.TreeSuppressed(members) {
+		*activity = models.DepartmentActivity{DepartmentID: activity.DepartmentID, Suppressed: true}
+
+		return
+	}
+
+	key := []interface{}{"department", activity.DepartmentID, tree}
+	activity.TotalTime = a.Time(activity.TotalTime, append(key, "total")...)
+	activity.ActiveTime = activity.TotalTime - a.Time(activity.TotalTime-activity.ActiveTime, append(key, "idle")...)
+	activity.ActiveTime = int64(math.Max(0, float64(activity.ActiveTime)))
+
+	if categories := activity.Categories; categories != nil {
+		categories.Productive = a.Time(categories.Productive, append(key, "productive")...)
+		categories.Neutral = a.Time(categories.Neutral, append(key, "neutral")...)
+		categories.Unproductive = a.Time(categories.Unproductive, append(key, "unproductive")...)
+	}
+}
+
+// DepartmentReport - anonymizes row of departments report, returns false if department is suppressed.
+// Rows are time of department users without descendants, they get the same noise as department activity.
+func (a *Anonymizer) DepartmentReport(report *models.DepartmentReport) bool {
+	if a.Suppressed(report.Members) {
+		return false
+	}
+
+	key := []interface{}{"department", report.DepartmentID, false}
+	report.TotalTime = a.Time(report.TotalTime, append(key, "total")...)
+	report.ActiveTime = report.TotalTime - a.Time(report.TotalTime-report.ActiveTime, append(key, "idle")...)
+	report.ActiveTime = int64(math.Max(0, float64(report.ActiveTime)))
+
+	return true
+}
+
+// DepartmentWorkReport - anonymizes work report of department: reports of users are removed,
+// time of department without enough users is zero. Expected time and counts of days are kept,
+// they come from schedules and time off.
+func (a *Anonymizer) DepartmentWorkReport(report *models.DepartmentWorkReport) {
+	members := int64(len(report.Users))
+	report.Users = nil
+
+	if a.Suppressed(members) {
+		report.TotalTime, report.ActiveTime, report.Utilization = 0, 0, 0
+		report.Overtime, report.OvertimeDays = 0, 0
+		report.Suppressed = true
+
+		return
+	}
+
+	key := []interface{}{"work", report.DepartmentID}
+	report.TotalTime = a.Time(report.TotalTime, append(key, "total")...)
+	report.ActiveTime = report.TotalTime - a.Time(report.TotalTime-report.ActiveTime, append(key, "idle")...)
+	report.ActiveTime = int64(math.Max(0, float64(report.ActiveTime)))
+	report.Overtime = a.Time(report.Overtime, append(key, "overtime")...)
+	report.Utilization = 0
+
+	if report.ExpectedTime > 0 {
+		report.Utilization = float64(report.TotalTime) / float64(report.ExpectedTime)
+	}
+}
@@ -0,0 +1,169 @@
+package anonymity
+
+import (
+	"activity_api/common/models"
+	"testing"
+)
+
+func Test_DepartmentActivity(t *testing.T) {
+	a := NewAnonymizer(3, 0)
+	small := &models.DepartmentActivity{DepartmentID: 1, Members: 2, TotalTime: 100, ActiveTime: 50}
+	a.DepartmentActivity(small, false, nil)
+
+	if !small.Suppressed || small.TotalTime != 0 || small.ActiveTime != 0 || small.DepartmentID != 1 {
+		t.Errorf("Small department isn't suppressed: %+v", small)
+	}
+
+	large := &models.DepartmentActivity{DepartmentID: 2, Members: 3, TotalTime: 100, ActiveTime: 50}
+	a.DepartmentActivity(large, false, nil)
+
+	if large.Suppressed || large.TotalTime != 100 || large.ActiveTime != 50 {
+		t.Errorf("Department changed without noise: %+v", large)
+	}
+	// Tree of parent with 1 own user and child with 3 users: tree minus child is time of 1 user.
+	members := membersOf(map[int64][]int64{1: {1}, 2: {2, 3, 4}})
+	tree := &models.DepartmentActivity{DepartmentID: 1, Members: 4, TotalTime: 100, ActiveTime: 50}
+	a.DepartmentActivity(tree, true, members)
+
+	if !tree.Suppressed || tree.TotalTime != 0 {
+		t.Errorf("Tree with small own group isn't suppressed: %+v", tree)
+	}
+}
+
+// membersOf - returns members of departments by department id.
+func membersOf(departments map[int64][]int64) []*models.DepartmentMember {
+	members := make([]*models.DepartmentMember, 0)
+
+	for departmentID, users := range departments {
+		for _, userID := range users {
+			members = append(members, &models.DepartmentMember{DepartmentID: departmentID, UserID: userID})
+		}
+	}
+
+	return members
+}
+
+func Test_TreeSuppressed(t *testing.T) {
+	a := NewAnonymizer(3, 0)
+	cases := []struct {
+		members    []*models.DepartmentMember
+		suppressed bool
+	}{
+		{members: nil},
+		// all groups are large enough
+		{members: membersOf(map[int64][]int64{1: {1, 2, 3}, 2: {4, 5, 6}})},
+		// no own users of parent
+		{members: membersOf(map[int64][]int64{2: {1, 2, 3}})},
+		// one own user of parent
+		{members: membersOf(map[int64][]int64{1: {1}, 2: {2, 3, 4}}), suppressed: true},
+		// small child, it's left out of reports, so parent tree minus parent is its time
+		{members: membersOf(map[int64][]int64{1: {1, 2, 3}, 2: {4}}), suppressed: true},
+		// small groups are large enough together
+		{members: membersOf(map[int64][]int64{1: {1, 2}, 2: {3, 4}})},
+		// user moved between small groups is counted once
+		{members: membersOf(map[int64][]int64{1: {1, 2}, 2: {2}, 3: {3, 4, 5}}), suppressed: true},
+	}
+
+	for i, c := range cases {
+		if suppressed := a.TreeSuppressed(c.members); suppressed != c.suppressed {
+			t.Errorf("Case %d: suppressed %t, expected %t", i, suppressed, c.suppressed)
+		}
+	}
+}
+
+// Test_ShiftedPeriodNoise - reports of shifted periods with the same true time get the same noise,
+// so repeating request with shifted period gives no new samples of noise.
+func Test_ShiftedPeriodNoise(t *testing.T) {
+	a := NewAnonymizer(1, 3600)
+	report := func(total int64) *models.DepartmentActivity {
+		activity := &models.DepartmentActivity{DepartmentID: 1, Members: 5, TotalTime: total, ActiveTime: total}
+		a.DepartmentActivity(activity, false, nil)
+
+		return activity
+	}
+
+	first := report(36000)
+
+	for shift := 1; shift < 100; shift++ {
+		if shifted := report(36000); *shifted != *first {
+			t.Fatalf("Report of period shifted by %d s: %+v, expected %+v", shift, shifted, first)
+		}
+	}
+
+	row := &models.DepartmentReport{DepartmentID: 1, Members: 5, TotalTime: 36000, ActiveTime: 36000}
+
+	if !a.DepartmentReport(row) || row.TotalTime != first.TotalTime {
+		t.Errorf("Report row got other noise than department activity: %+v, activity: %+v", row, first)
+	}
+
+	changed := 0
+
+	for total := int64(36001); total < 36101; total++ {
+		if report(total).TotalTime-total != first.TotalTime-36000 {
+			changed++
+		}
+	}
+
+	if changed < 90 {
+		t.Errorf("Noise of different values is the same for %d values of 100", 100-changed)
+	}
+}
+
+func Test_Time(t *testing.T) {
+	a := NewAnonymizer(0, 3600)
+	changed := 0
+
+	for i := int64(0); i < 100; i++ {
+		noisy := a.Time(36000, "total", i)
+
+		if noisy < 0 {
+			t.Fatalf("Negative noisy time: %d", noisy)
+		}
+
+		if noisy != a.Time(36000, "total", i) {
+			t.Fatalf("Noise of the same value differs")
+		}
+
+		if noisy != 36000 {
+			changed++
+		}
+	}
+
+	if changed < 90 {
+		t.Errorf("Noise is added to %d values of 100", changed)
+	}
+
+	if a.Time(36000, "total", 1) == a.Time(36000, "total", "1") {
+		t.Errorf("Keys of different types give the same noise")
+	}
+}
+
+func Test_DepartmentReport(t *testing.T) {
+	a := NewAnonymizer(2, 0)
+
+	if a.DepartmentReport(&models.DepartmentReport{Members: 1}) {
+		t.Errorf("Department with one user is reported")
+	}
+
+	if !a.DepartmentReport(&models.DepartmentReport{Members: 2}) {
+		t.Errorf("Department with enough users isn't reported")
+	}
+}
+
+func Test_DepartmentWorkReport(t *testing.T) {
+	a := NewAnonymizer(2, 0)
+	users := []*models.WorkReport{{UserID: 1}, {UserID: 2}}
+	report := &models.DepartmentWorkReport{DepartmentID: 1, Users: users[:1], TotalTime: 10, ExpectedTime: 20}
+	a.DepartmentWorkReport(report)
+
+	if !report.Suppressed || report.Users != nil || report.TotalTime != 0 || report.ExpectedTime != 20 {
+		t.Errorf("Report of one user isn't suppressed: %+v", report)
+	}
+
+	report = &models.DepartmentWorkReport{DepartmentID: 1, Users: users, TotalTime: 10, ExpectedTime: 20}
+	a.DepartmentWorkReport(report)
+
+	if report.Suppressed || report.Users != nil || report.TotalTime != 10 || report.Utilization != 0.5 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,895 @@
+// Package api_client - typed client of AAService api, its methods are named after OpenAPI operation IDs.
+package api_client
+
+import (
+	"activity_api/api/openapi"
+	"activity_api/common/error_manage"
+	"activity_api/common/models"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 15 * time.Second
+	// apiPrefix - api version used by client, health probes aren't versioned.
+	apiPrefix = "/v1"
+)
+
+// Error - error response of api.
+type Error struct {
+	StatusCode int
+	Message    string
+	RequestID  string          // ID of failed request, to find it in api log
+	Data       json.RawMessage // data describing the error, e.g. results of failed import, nil if not sent
+}
+
+// Error - returns error message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("error status code: %d, resp: %s, request id: %s", e.StatusCode, e.Message, e.RequestID)
+}
+
+// Client - AAService api client. Tokens received on login are used for next requests.
+type Client struct {
+	baseURL string
+	client  *http.Client
+
+	mtx    sync.RWMutex
+	tokens *models.Tokens
+}
+
+// NewClient - returns new api client for given base URL, e.g. https://localhost:9332.
+// tlsConfig is used for https requests, nil means system roots are trusted.
+func NewClient(baseURL string, tlsConfig *tls.Config) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			Timeout:   defaultTimeout,
+		},
+	}
+}
+
+// Tokens - returns tokens of logged in admin, nil if client isn't logged in.
+func (c *Client) Tokens() *models.Tokens {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.tokens
+}
+
+// SetTokens - sets tokens used for requests, nil removes them.
+func (c *Client) SetTokens(tokens *models.Tokens) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.tokens = tokens
+}
+
+// OpenAPI - returns OpenAPI document of the api.
+func (c *Client) OpenAPI(ctx context.Context) (*openapi.Document, error) {
+	doc := new(openapi.Document)
+
+	// Document isn't wrapped in envelope.
+	return doc, c.request(ctx, http.MethodGet, apiPrefix+"/openapi.json", nil, nil, doc)
+}
+
+// Live - calls liveness probe.
+func (c *Client) Live(ctx context.Context) (*models.Status, error) {
+	status := new(models.Status)
+
+	return status, c.do(ctx, http.MethodGet, "/health/live", nil, nil, status)
+}
+
+// Ready - calls readiness probe, error is returned while api isn't ready.
+func (c *Client) Ready(ctx context.Context) (*models.Status, error) {
+	status := new(models.Status)
+
+	return status, c.do(ctx, http.MethodGet, "/health/ready", nil, nil, status)
+}
+
+// Register - registers new admin, returns its ID.
+func (c *Client) Register(ctx context.Context, admin *models.Admin) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/register", admin)
+}
+
+// Login - logins with given admin, received tokens are used for next requests.
+func (c *Client) Login(ctx context.Context, admin *models.Admin) (*models.Tokens, error) {
+	tokens := new(models.Tokens)
+
+	if err := c.do(ctx, http.MethodPost, apiPrefix+"/login", nil, admin, tokens); err != nil {
+		return nil, err
+	}
+
+	c.SetTokens(tokens)
+
+	return tokens, nil
+}
+
+// Logout - deletes tokens of logged in admin.
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, apiPrefix+"/logout", nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.SetTokens(nil)
+
+	return nil
+}
+
+// Refresh - replaces tokens of logged in admin with new ones.
+func (c *Client) Refresh(ctx context.Context) (*models.Tokens, error) {
+	current := c.Tokens()
+
+	if current == nil {
+		return nil, fmt.Errorf("client isn't logged in")
+	}
+
+	tokens := new(models.Tokens)
+	body := &models.Tokens{RefreshToken: current.RefreshToken}
+
+	if err := c.do(ctx, http.MethodPost, apiPrefix+"/refresh", nil, body, tokens); err != nil {
+		return nil, err
+	}
+
+	c.SetTokens(tokens)
+
+	return tokens, nil
+}
+
+// Unregister - deletes logged in admin.
+func (c *Client) Unregister(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodDelete, apiPrefix+"/unregister", nil, nil, nil); err != nil {
+		return err
+	}
+
+	c.SetTokens(nil)
+
+	return nil
+}
+
+// CreateDepartment - creates department, returns its ID.
+func (c *Client) CreateDepartment(ctx context.Context, department *models.Department) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/departments", department)
+}
+
+// GetDepartments - returns all departments.
+func (c *Client) GetDepartments(ctx context.Context) ([]*models.Department, error) {
+	departments := make([]*models.Department, 0)
+
+	return departments, c.do(ctx, http.MethodGet, apiPrefix+"/departments", nil, nil, &departments)
+}
+
+// GetDepartment - returns department by ID.
+func (c *Client) GetDepartment(ctx context.Context, id int64) (*models.Department, error) {
+	department := new(models.Department)
+
+	return department, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/departments", id), nil, nil, department)
+}
+
+// DeleteDepartment - deletes department by ID, returns number of deleted rows.
+func (c *Client) DeleteDepartment(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/departments", id), nil)
+}
+
+// GetDepartmentsTree - returns departments tree in depth-first order, only subtree of root if it isn't 0.
+func (c *Client) GetDepartmentsTree(ctx context.Context, root int64) ([]*models.DepartmentNode, error) {
+	tree := make([]*models.DepartmentNode, 0)
+	var query url.Values
+
+	if root != 0 {
+		query = url.Values{"root": {strconv.FormatInt(root, 10)}}
+	}
+
+	return tree, c.do(ctx, http.MethodGet, apiPrefix+"/departments/tree", query, nil, &tree)
+}
+
+// MoveDepartment - moves department under parent, 0 parent makes it top level, returns moved department.
+func (c *Client) MoveDepartment(ctx context.Context, id, parentID int64) (*models.Department, error) {
+	department := new(models.Department)
+	path := objectPath(apiPrefix+"/departments", id) + "/move"
+
+	return department, c.do(ctx, http.MethodPost, path, nil, &models.DepartmentMove{ParentID: parentID}, department)
+}
+
+// CreateUser - creates user, returns its ID.
+func (c *Client) CreateUser(ctx context.Context, user *models.User) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/users", user)
+}
+
+// GetUsers - returns all users.
+func (c *Client) GetUsers(ctx context.Context) ([]*models.User, error) {
+	users := make([]*models.User, 0)
+
+	return users, c.do(ctx, http.MethodGet, apiPrefix+"/users", nil, nil, &users)
+}
+
+// GetUser - returns user by ID.
+func (c *Client) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	user := new(models.User)
+
+	return user, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/users", id), nil, nil, user)
+}
+
+// DeleteUser - deletes user by ID, returns number of deleted rows.
+func (c *Client) DeleteUser(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/users", id), nil)
+}
+
+// UpdateUser - updates profile of user with ID of given user, returns updated user.
+func (c *Client) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	updated := new(models.User)
+
+	return updated, c.do(ctx, http.MethodPut, objectPath(apiPrefix+"/users", user.UserID), nil, user, updated)
+}
+
+// TransferUser - moves user to department, returns membership history of the user.
+func (c *Client) TransferUser(ctx context.Context, id int64, transfer *models.UserTransfer) ([]*models.Membership, error) {
+	memberships := make([]*models.Membership, 0)
+	path := objectPath(apiPrefix+"/users", id) + "/transfer"
+
+	return memberships, c.do(ctx, http.MethodPost, path, nil, transfer, &memberships)
+}
+
+// GetUserDepartments - returns department membership history of user, oldest first.
+func (c *Client) GetUserDepartments(ctx context.Context, id int64) ([]*models.Membership, error) {
+	memberships := make([]*models.Membership, 0)
+	path := objectPath(apiPrefix+"/users", id) + "/departments"
+
+	return memberships, c.do(ctx, http.MethodGet, path, nil, nil, &memberships)
+}
+
+// CreateActivity - records activity, returns record ID.
+func (c *Client) CreateActivity(ctx context.Context, activity *models.Activity) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/activities", activity)
+}
+
+// GetActivities - returns all activity records.
+func (c *Client) GetActivities(ctx context.Context) ([]*models.Activity, error) {
+	activities := make([]*models.Activity, 0)
+
+	return activities, c.do(ctx, http.MethodGet, apiPrefix+"/activities", nil, nil, &activities)
+}
+
+// GetActivity - returns activity record by ID.
+func (c *Client) GetActivity(ctx context.Context, id int64) (*models.Activity, error) {
+	activity := new(models.Activity)
+
+	return activity, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/activities", id), nil, nil, activity)
+}
+
+// DeleteActivity - deletes activity record by ID, returns number of deleted rows.
+func (c *Client) DeleteActivity(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/activities", id), nil)
+}
+
+// GetUsersActivity - returns activity time of user between given unix times, 0 means time isn't limited.
+func (c *Client) GetUsersActivity(ctx context.Context, id, timeStart, timeEnd int64) (*models.UserActivity, error) {
+	activity := new(models.UserActivity)
+	path := objectPath(apiPrefix+"/control/user", id)
+
+	return activity, c.do(ctx, http.MethodGet, path, timeQuery(timeStart, timeEnd), nil, activity)
+}
+
+// GetDepartmentsActivity - returns activity time of department between given unix times, 0 means time isn't limited.
+// With rollup activity of all descendant departments is included.
+func (c *Client) GetDepartmentsActivity(
+	ctx context.Context,
+	id, timeStart, timeEnd int64,
+	rollup bool,
+) (*models.DepartmentActivity, error) {
+	activity := new(models.DepartmentActivity)
+	path := objectPath(apiPrefix+"/control/department", id)
+	query := timeQuery(timeStart, timeEnd)
+
+	if rollup {
+		query.Set("rollup", "true")
+	}
+
+	return activity, c.do(ctx, http.MethodGet, path, query, nil, activity)
+}
+
+// ImportUsers - imports departments and users from CSV, see csv_import package for columns.
+// Result of every row is returned also if import failed because of invalid rows.
+func (c *Client) ImportUsers(ctx context.Context, file io.Reader, dryRun bool) (*models.ImportResult, error) {
+	result := new(models.ImportResult)
+	query := url.Values{"dry_run": {strconv.FormatBool(dryRun)}}
+	err := c.do(ctx, http.MethodPost, apiPrefix+"/import/users", query, &rawBody{file, "text/csv"}, result)
+
+	var apiErr *Error
+
+	if errors.As(err, &apiErr) && len(apiErr.Data) > 0 {
+		if json.Unmarshal(apiErr.Data, result) == nil {
+			return result, err
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Events - reads live feed of created activity records and running totals over SSE, handle is called for every event.
+// Zero userID and departmentID mean feed isn't filtered. Returns nil when server closes the stream,
+// it's done before server write timeout or token expiry, so caller should reconnect.
+func (c *Client) Events(ctx context.Context, userID, departmentID int64, handle func(*models.Event) error) error {
+	query := make(url.Values)
+
+	if userID != 0 {
+		query.Set("userID", strconv.FormatInt(userID, 10))
+	}
+
+	if departmentID != 0 {
+		query.Set("departmentID", strconv.FormatInt(departmentID, 10))
+	}
+
+	return c.request(ctx, http.MethodGet, apiPrefix+"/events", query, nil, func(body io.Reader) error {
+		return readEvents(body, handle)
+	})
+}
+
+// CreateWebhook - creates webhook, returns it with secret, which isn't returned later.
+func (c *Client) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	created := new(models.Webhook)
+
+	return created, c.do(ctx, http.MethodPost, apiPrefix+"/webhooks", nil, webhook, created)
+}
+
+// GetWebhooks - returns all webhooks without secrets.
+func (c *Client) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks := make([]*models.Webhook, 0)
+
+	return webhooks, c.do(ctx, http.MethodGet, apiPrefix+"/webhooks", nil, nil, &webhooks)
+}
+
+// GetWebhook - returns webhook by ID without secret.
+func (c *Client) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	webhook := new(models.Webhook)
+
+	return webhook, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/webhooks", id), nil, nil, webhook)
+}
+
+// DeleteWebhook - deletes webhook and its deliveries by ID, returns number of deleted rows.
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/webhooks", id), nil)
+}
+
+// GetWebhookDeliveries - returns deliveries of webhook with given status, empty status means any.
+func (c *Client) GetWebhookDeliveries(ctx context.Context, id int64, status string) ([]*models.WebhookDelivery, error) {
+	deliveries := make([]*models.WebhookDelivery, 0)
+	query := make(url.Values)
+
+	if status != "" {
+		query.Set("status", status)
+	}
+
+	path := objectPath(apiPrefix+"/webhooks", id) + "/deliveries"
+
+	return deliveries, c.do(ctx, http.MethodGet, path, query, nil, &deliveries)
+}
+
+// GetDeadDeliveries - returns deliveries of all webhooks which failed every attempt.
+func (c *Client) GetDeadDeliveries(ctx context.Context) ([]*models.WebhookDelivery, error) {
+	deliveries := make([]*models.WebhookDelivery, 0)
+
+	return deliveries, c.do(ctx, http.MethodGet, apiPrefix+"/webhooks/deliveries/dead", nil, nil, &deliveries)
+}
+
+// RedeliverDelivery - queues delivered or dead delivery again, returns number of queued deliveries.
+func (c *Client) RedeliverDelivery(ctx context.Context, id int64) (int64, error) {
+	path := objectPath(apiPrefix+"/webhooks/deliveries", id) + "/redeliver"
+
+	return c.doID(ctx, http.MethodPost, path, nil)
+}
+
+// CreateAlertRule - creates alert rule, returns its ID.
+func (c *Client) CreateAlertRule(ctx context.Context, rule *models.AlertRule) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/alerts/rules", rule)
+}
+
+// GetAlertRules - returns alert rules in given state, empty state means any.
+func (c *Client) GetAlertRules(ctx context.Context, state string) ([]*models.AlertRule, error) {
+	rules := make([]*models.AlertRule, 0)
+	query := make(url.Values)
+
+	if state != "" {
+		query.Set("state", state)
+	}
+
+	return rules, c.do(ctx, http.MethodGet, apiPrefix+"/alerts/rules", query, nil, &rules)
+}
+
+// GetAlertRule - returns alert rule by ID.
+func (c *Client) GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error) {
+	rule := new(models.AlertRule)
+
+	return rule, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/alerts/rules", id), nil, nil, rule)
+}
+
+// DeleteAlertRule - deletes alert rule by ID, returns number of deleted rows.
+func (c *Client) DeleteAlertRule(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/alerts/rules", id), nil)
+}
+
+// CreateCategoryRule - creates activity category rule, returns its ID.
+func (c *Client) CreateCategoryRule(ctx context.Context, rule *models.CategoryRule) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/categories/rules", rule)
+}
+
+// GetCategoryRules - returns activity category rules.
+func (c *Client) GetCategoryRules(ctx context.Context) ([]*models.CategoryRule, error) {
+	rules := make([]*models.CategoryRule, 0)
+
+	return rules, c.do(ctx, http.MethodGet, apiPrefix+"/categories/rules", nil, nil, &rules)
+}
+
+// GetCategoryRule - returns activity category rule by ID.
+func (c *Client) GetCategoryRule(ctx context.Context, id int64) (*models.CategoryRule, error) {
+	rule := new(models.CategoryRule)
+
+	return rule, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/categories/rules", id), nil, nil, rule)
+}
+
+// DeleteCategoryRule - deletes activity category rule by ID, returns number of deleted rows.
+func (c *Client) DeleteCategoryRule(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/categories/rules", id), nil)
+}
+
+// CreateRetentionPolicy - creates retention policy of tenant or department, returns its ID.
+func (c *Client) CreateRetentionPolicy(ctx context.Context, policy *models.RetentionPolicy) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/retention/policies", policy)
+}
+
+// GetRetentionPolicies - returns retention policies.
+func (c *Client) GetRetentionPolicies(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	policies := make([]*models.RetentionPolicy, 0)
+
+	return policies, c.do(ctx, http.MethodGet, apiPrefix+"/retention/policies", nil, nil, &policies)
+}
+
+// GetRetentionPolicy - returns retention policy by ID.
+func (c *Client) GetRetentionPolicy(ctx context.Context, id int64) (*models.RetentionPolicy, error) {
+	policy := new(models.RetentionPolicy)
+
+	return policy, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/retention/policies", id), nil, nil, policy)
+}
+
+// DeleteRetentionPolicy - deletes retention policy by ID, returns number of deleted rows.
+func (c *Client) DeleteRetentionPolicy(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/retention/policies", id), nil)
+}
+
+// ExportUserData - writes zip archive of everything held about user to w.
+func (c *Client) ExportUserData(ctx context.Context, id int64, w io.Writer) error {
+	return c.request(ctx, http.MethodGet, objectPath(apiPrefix+"/users", id)+"/export", nil, nil, w)
+}
+
+// EraseUser - irreversibly erases user, returns erasure request.
+func (c *Client) EraseUser(ctx context.Context, id int64) (*models.SubjectRequest, error) {
+	request := new(models.SubjectRequest)
+
+	return request, c.do(ctx, http.MethodPost, objectPath(apiPrefix+"/users", id)+"/erase", nil, nil, request)
+}
+
+// GetSubjectRequests - returns export and erasure requests, of given user if userID isn't 0.
+func (c *Client) GetSubjectRequests(ctx context.Context, userID int64) ([]*models.SubjectRequest, error) {
+	requests := make([]*models.SubjectRequest, 0)
+	query := make(url.Values)
+
+	if userID != 0 {
+		query.Set("userID", strconv.FormatInt(userID, 10))
+	}
+
+	return requests, c.do(ctx, http.MethodGet, apiPrefix+"/subject-requests", query, nil, &requests)
+}
+
+// GetUserSchedule - returns own schedule of user.
+func (c *Client) GetUserSchedule(ctx context.Context, id int64) (*models.WorkSchedule, error) {
+	schedule := new(models.WorkSchedule)
+	path := objectPath(apiPrefix+"/users", id) + "/schedule"
+
+	return schedule, c.do(ctx, http.MethodGet, path, nil, nil, schedule)
+}
+
+// SetUserSchedule - sets schedule of user, returns it.
+func (c *Client) SetUserSchedule(ctx context.Context, schedule *models.WorkSchedule) (*models.WorkSchedule, error) {
+	set := new(models.WorkSchedule)
+	path := objectPath(apiPrefix+"/users", schedule.UserID) + "/schedule"
+
+	return set, c.do(ctx, http.MethodPut, path, nil, schedule, set)
+}
+
+// DeleteUserSchedule - deletes own schedule of user, returns number of deleted rows.
+func (c *Client) DeleteUserSchedule(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/users", id)+"/schedule", nil)
+}
+
+// GetDepartmentSchedule - returns own schedule of department.
+func (c *Client) GetDepartmentSchedule(ctx context.Context, id int64) (*models.WorkSchedule, error) {
+	schedule := new(models.WorkSchedule)
+	path := objectPath(apiPrefix+"/departments", id) + "/schedule"
+
+	return schedule, c.do(ctx, http.MethodGet, path, nil, nil, schedule)
+}
+
+// SetDepartmentSchedule - sets schedule of department, returns it.
+func (c *Client) SetDepartmentSchedule(
+	ctx context.Context,
+	schedule *models.WorkSchedule,
+) (*models.WorkSchedule, error) {
+	set := new(models.WorkSchedule)
+	path := objectPath(apiPrefix+"/departments", schedule.DepartmentID) + "/schedule"
+
+	return set, c.do(ctx, http.MethodPut, path, nil, schedule, set)
+}
+
+// DeleteDepartmentSchedule - deletes own schedule of department, returns number of deleted rows.
+func (c *Client) DeleteDepartmentSchedule(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/departments", id)+"/schedule", nil)
+}
+
+// CreateTimeOff - creates leave of user or holiday, returns its ID.
+func (c *Client) CreateTimeOff(ctx context.Context, timeOff *models.TimeOff) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/time-off", timeOff)
+}
+
+// GetTimeOffs - returns leaves of user and holidays, with 0 userID - time off of all users.
+func (c *Client) GetTimeOffs(ctx context.Context, userID int64) ([]*models.TimeOff, error) {
+	timeOffs := make([]*models.TimeOff, 0)
+	query := make(url.Values)
+
+	if userID != 0 {
+		query.Set("userID", strconv.FormatInt(userID, 10))
+	}
+
+	return timeOffs, c.do(ctx, http.MethodGet, apiPrefix+"/time-off", query, nil, &timeOffs)
+}
+
+// GetTimeOff - returns time off by ID.
+func (c *Client) GetTimeOff(ctx context.Context, id int64) (*models.TimeOff, error) {
+	timeOff := new(models.TimeOff)
+
+	return timeOff, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/time-off", id), nil, nil, timeOff)
+}
+
+// DeleteTimeOff - deletes time off by ID, returns number of deleted rows.
+func (c *Client) DeleteTimeOff(ctx context.Context, id int64) (int64, error) {
+	return c.doID(ctx, http.MethodDelete, objectPath(apiPrefix+"/time-off", id), nil)
+}
+
+// GetUsersWorkReport - returns expected versus actual work time of user over days of [timeStart, timeEnd).
+func (c *Client) GetUsersWorkReport(ctx context.Context, id, timeStart, timeEnd int64) (*models.WorkReport, error) {
+	report := new(models.WorkReport)
+	path := objectPath(apiPrefix+"/control/user", id) + "/utilization"
+
+	return report, c.do(ctx, http.MethodGet, path, periodQuery(timeStart, timeEnd), nil, report)
+}
+
+// GetDepartmentsWorkReport - returns expected versus actual work time of department users
+// over days of [timeStart, timeEnd).
+func (c *Client) GetDepartmentsWorkReport(
+	ctx context.Context,
+	id, timeStart, timeEnd int64,
+) (*models.DepartmentWorkReport, error) {
+	report := new(models.DepartmentWorkReport)
+	path := objectPath(apiPrefix+"/control/department", id) + "/utilization"
+
+	return report, c.do(ctx, http.MethodGet, path, periodQuery(timeStart, timeEnd), nil, report)
+}
+
+// GetJobs - returns background jobs.
+func (c *Client) GetJobs(ctx context.Context) ([]*models.Job, error) {
+	jobs := make([]*models.Job, 0)
+
+	return jobs, c.do(ctx, http.MethodGet, apiPrefix+"/jobs", nil, nil, &jobs)
+}
+
+// GetJob - returns background job with history of its runs.
+func (c *Client) GetJob(ctx context.Context, name string) (*models.Job, error) {
+	job := new(models.Job)
+
+	return job, c.do(ctx, http.MethodGet, apiPrefix+"/jobs/"+url.PathEscape(name), nil, nil, job)
+}
+
+// RunJob - starts background job out of its schedule, returns started run.
+func (c *Client) RunJob(ctx context.Context, name string) (*models.JobRun, error) {
+	run := new(models.JobRun)
+
+	return run, c.do(ctx, http.MethodPost, apiPrefix+"/jobs/"+url.PathEscape(name)+"/run", nil, nil, run)
+}
+
+// CreateTenant - creates tenant, returns its ID. Allowed to superadmin only.
+func (c *Client) CreateTenant(ctx context.Context, tenant *models.Tenant) (int64, error) {
+	return c.doID(ctx, http.MethodPost, apiPrefix+"/tenants", tenant)
+}
+
+// GetTenants - returns all tenants. Allowed to superadmin only.
+func (c *Client) GetTenants(ctx context.Context) ([]*models.Tenant, error) {
+	tenants := make([]*models.Tenant, 0)
+
+	return tenants, c.do(ctx, http.MethodGet, apiPrefix+"/tenants", nil, nil, &tenants)
+}
+
+// GetTenant - returns tenant by ID. Allowed to superadmin only.
+func (c *Client) GetTenant(ctx context.Context, id int64) (*models.Tenant, error) {
+	tenant := new(models.Tenant)
+
+	return tenant, c.do(ctx, http.MethodGet, objectPath(apiPrefix+"/tenants", id), nil, nil, tenant)
+}
+
+// SuspendTenant - suspends tenant by ID, returns updated tenant. Allowed to superadmin only.
+func (c *Client) SuspendTenant(ctx context.Context, id int64) (*models.Tenant, error) {
+	tenant := new(models.Tenant)
+
+	return tenant, c.do(ctx, http.MethodPost, objectPath(apiPrefix+"/tenants", id)+"/suspend", nil, nil, tenant)
+}
+
+// ResumeTenant - resumes suspended tenant by ID, returns updated tenant. Allowed to superadmin only.
+func (c *Client) ResumeTenant(ctx context.Context, id int64) (*models.Tenant, error) {
+	tenant := new(models.Tenant)
+
+	return tenant, c.do(ctx, http.MethodPost, objectPath(apiPrefix+"/tenants", id)+"/resume", nil, nil, tenant)
+}
+
+// CreateTenantAdmin - creates admin of tenant by ID, returns admin ID. Allowed to superadmin only.
+func (c *Client) CreateTenantAdmin(ctx context.Context, id int64, admin *models.Admin) (int64, error) {
+	return c.doID(ctx, http.MethodPost, objectPath(apiPrefix+"/tenants", id)+"/admins", admin)
+}
+
+// ExportUsers - writes export of users in given format (csv or xlsx) to w,
+// departmentID limits export to users of the department, 0 means all users.
+func (c *Client) ExportUsers(ctx context.Context, format string, departmentID int64, w io.Writer) error {
+	query := make(url.Values)
+
+	if departmentID != 0 {
+		query.Set("departmentID", strconv.FormatInt(departmentID, 10))
+	}
+
+	return c.export(ctx, apiPrefix+"/export/users", format, query, w)
+}
+
+// ExportDepartments - writes export of departments in given format (csv or xlsx) to w.
+func (c *Client) ExportDepartments(ctx context.Context, format string, w io.Writer) error {
+	return c.export(ctx, apiPrefix+"/export/departments", format, nil, w)
+}
+
+// ExportActivities - writes export of activity records in given format (csv or xlsx) to w.
+func (c *Client) ExportActivities(ctx context.Context, format string, w io.Writer) error {
+	return c.export(ctx, apiPrefix+"/export/activities", format, nil, w)
+}
+
+// ExportUsersReport - writes activity time of every user between given unix times in given format to w.
+func (c *Client) ExportUsersReport(ctx context.Context, format string, timeStart, timeEnd int64, w io.Writer) error {
+	return c.export(ctx, apiPrefix+"/export/reports/users", format, timeQuery(timeStart, timeEnd), w)
+}
+
+// ExportDepartmentsReport - writes activity time of every department between given unix times in given format to w.
+func (c *Client) ExportDepartmentsReport(
+	ctx context.Context,
+	format string,
+	timeStart, timeEnd int64,
+	w io.Writer,
+) error {
+	return c.export(ctx, apiPrefix+"/export/reports/departments", format, timeQuery(timeStart, timeEnd), w)
+}
+
+// export - makes export request, response body is copied to w as is.
+func (c *Client) export(ctx context.Context, path, format string, query url.Values, w io.Writer) error {
+	if query == nil {
+		query = make(url.Values)
+	}
+
+	query.Set("format", format)
+
+	return c.request(ctx, http.MethodGet, path, query, nil, w)
+}
+
+// doID - makes request which responds with object ID.
+func (c *Client) doID(ctx context.Context, method, path string, body interface{}) (int64, error) {
+	id := new(models.ObjectID)
+
+	if err := c.do(ctx, method, path, nil, body, id); err != nil {
+		return 0, err
+	}
+
+	return id.ID, nil
+}
+
+// do - makes request with given query and json body, decodes data of response envelope to result if it's not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	if result == nil {
+		return c.request(ctx, method, path, query, body, nil)
+	}
+
+	return c.request(ctx, method, path, query, body, &models.Envelope{Data: result})
+}
+
+// rawBody - request body which is sent as is, instead of json.
+type rawBody struct {
+	reader      io.Reader
+	contentType string
+}
+
+// request - makes request with given query and json body, decodes json response to result if it's not nil.
+// If result is io.Writer, response body is copied to it instead, if result is func(io.Reader) error - it reads body.
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	var reader io.Reader
+	contentType := "application/json"
+
+	switch b := body.(type) {
+	case nil:
+	case *rawBody:
+		reader, contentType = b.reader, b.contentType
+	default:
+		bts, err := json.Marshal(body)
+
+		if err != nil {
+			return fmt.Errorf("json.Marshal(): %w", err)
+		}
+
+		reader = bytes.NewReader(bts)
+	}
+
+	target := c.baseURL + path
+
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext(): %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if tokens := c.Tokens(); tokens != nil {
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	}
+
+	res, err := c.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Do(): %w", err)
+	}
+
+	defer closeResponse(res)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return responseError(res)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if consume, ok := result.(func(io.Reader) error); ok {
+		return consume(res.Body)
+	}
+
+	if w, ok := result.(io.Writer); ok {
+		if _, err := io.Copy(w, res.Body); err != nil {
+			return fmt.Errorf("io.Copy(): %w", err)
+		}
+
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("Decode(): %w", err)
+	}
+
+	return nil
+}
+
+// readEvents - parses Server-Sent Events stream, data of every event is json of models.Event.
+func readEvents(body io.Reader, handle func(*models.Event) error) error {
+	scanner := bufio.NewScanner(body)
+	data := new(bytes.Buffer)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Blank line ends event, other fields are repeated in event json.
+		if line != "" {
+			if strings.HasPrefix(line, "data:") {
+				data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+
+			continue
+		}
+
+		if data.Len() == 0 {
+			continue
+		}
+
+		event := new(models.Event)
+
+		if err := json.Unmarshal(data.Bytes(), event); err != nil {
+			return fmt.Errorf("json.Unmarshal(): %w", err)
+		}
+
+		data.Reset()
+
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanner.Err(): %w", err)
+	}
+
+	return nil
+}
+
+// responseError - returns api error from response.
+func responseError(res *http.Response) error {
+	bts, _ := ioutil.ReadAll(res.Body)
+	apiErr := &Error{StatusCode: res.StatusCode, Message: string(bts)}
+	envelope := &models.Envelope{Data: &apiErr.Data}
+
+	if err := json.Unmarshal(bts, envelope); err == nil && envelope.Error != nil {
+		apiErr.Message = envelope.Error.Message
+
+		if envelope.Meta != nil {
+			apiErr.RequestID = envelope.Meta.RequestID
+		}
+	}
+
+	return apiErr
+}
+
+// closeResponse - drains and closes response body, so connection could be reused.
+func closeResponse(res *http.Response) {
+	_, err := io.Copy(ioutil.Discard, res.Body)
+	// Usual Log() is used here, because this client won't be used in AAService
+	error_manage.ErrorWriter(err)
+
+	error_manage.ErrorWriter(res.Body.Close())
+}
+
+// objectPath - returns path of object with given ID.
+func objectPath(path string, id int64) string {
+	return path + "/" + strconv.FormatInt(id, 10)
+}
+
+// timeQuery - returns TimeStart and TimeEnd query, zero values are skipped.
+func timeQuery(timeStart, timeEnd int64) url.Values {
+	query := make(url.Values)
+
+	if timeStart != 0 {
+		query.Set("TimeStart", strconv.FormatInt(timeStart, 10))
+	}
+
+	if timeEnd != 0 {
+		query.Set("TimeEnd", strconv.FormatInt(timeEnd, 10))
+	}
+
+	return query
+}
+
+// periodQuery - returns required TimeStart and TimeEnd query of report.
+func periodQuery(timeStart, timeEnd int64) url.Values {
+	query := make(url.Values)
+	query.Set("TimeStart", strconv.FormatInt(timeStart, 10))
+	query.Set("TimeEnd", strconv.FormatInt(timeEnd, 10))
+
+	return query
+}
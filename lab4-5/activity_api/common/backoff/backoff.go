@@ -0,0 +1,49 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff - exponential backoff with jitter.
+// Delay for attempt N is Initial * Multiplier^N, limited by Max,
+// and then randomized in range [delay * (1 - Jitter), delay].
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64 // 0 - no jitter, 1 - full jitter
+
+	rnd *rand.Rand
+	mtx sync.Mutex // rand.Rand isn't safe for concurrent use
+}
+
+// NewBackoff - returns new backoff with given initial and max delays, multiplier 2 and half jitter.
+func NewBackoff(initial, max time.Duration) *Backoff {
+	return &Backoff{
+		Initial:    initial,
+		Max:        max,
+		Multiplier: 2,
+		Jitter:     0.5,
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Next - returns delay before given attempt (attempts are counted from 0).
+func (b *Backoff) Next(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	// Pow could overflow duration for big attempts, so limit is checked on float.
+	if delay > float64(b.Max) || math.IsInf(delay, 0) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		b.mtx.Lock()
+		delay -= delay * b.Jitter * b.rnd.Float64()
+		b.mtx.Unlock()
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,43 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBackoff_Next - tests that delays grow exponentially, are limited by max and jitter stays in range.
+func TestBackoff_Next(t *testing.T) {
+	t.Run("Next_noJitter", func(t *testing.T) {
+		b := NewBackoff(time.Second, time.Second*10)
+		b.Jitter = 0
+
+		expected := []time.Duration{
+			time.Second,
+			time.Second * 2,
+			time.Second * 4,
+			time.Second * 8,
+			time.Second * 10,
+			time.Second * 10,
+		}
+
+		for attempt, delay := range expected {
+			if got := b.Next(attempt); got != delay {
+				t.Fatalf("attempt %d: expected %v, got %v", attempt, delay, got)
+			}
+		}
+		// Huge attempt number shouldn't overflow.
+		if got := b.Next(10000); got != time.Second*10 {
+			t.Fatalf("expected max delay for huge attempt, got %v", got)
+		}
+	})
+
+	t.Run("Next_jitter", func(t *testing.T) {
+		b := NewBackoff(time.Second, time.Minute)
+
+		for i := 0; i < 100; i++ {
+			if got := b.Next(2); got < time.Second*2 || got > time.Second*4 {
+				t.Fatalf("delay %v is out of jitter range", got)
+			}
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State - circuit breaker state.
+type State int
+
+const (
+	// Closed - dependency is healthy, requests are allowed.
+	Closed State = iota
+	// Open - dependency is unavailable, requests fail fast.
+	Open
+	// HalfOpen - dependency is being probed, requests still fail fast until probe succeeds.
+	HalfOpen
+)
+
+// String - returns state name.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ErrOpen - returned by Allow while breaker isn't closed.
+var ErrOpen = errors.New("dependency unavailable")
+
+// Event - breaker state change event.
+type Event struct {
+	Name string // name of dependency behind the breaker
+	From State
+	To   State
+	Err  error // last failure, nil when breaker is closed
+	Time time.Time
+}
+
+// Breaker - circuit breaker for single dependency.
+// Breaker opens after threshold of consecutive failures, and closes after first success.
+type Breaker struct {
+	name      string
+	threshold int
+
+	state     State
+	failures  int
+	lastErr   error
+	listeners []func(Event)
+	mtx       sync.RWMutex
+}
+
+// NewBreaker - returns new closed breaker for dependency with given name.
+// threshold <= 0 is treated as 1.
+func NewBreaker(name string, threshold int) *Breaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+	}
+}
+
+// Name - returns name of dependency behind the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State - returns current breaker state.
+func (b *Breaker) State() State {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return b.state
+}
+
+// Allow - returns nil if requests to dependency are allowed, wrapped ErrOpen otherwise.
+func (b *Breaker) Allow() error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	if b.state == Closed {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", b.name, ErrOpen)
+}
+
+// Subscribe - adds listener for breaker state changes.
+// Listeners are called synchronously, so they should not block.
+func (b *Breaker) Subscribe(listener func(Event)) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.listeners = append(b.listeners, listener)
+}
+
+// Success - reports successful call to dependency, closes breaker.
+func (b *Breaker) Success() {
+	b.mtx.Lock()
+	b.failures = 0
+	b.lastErr = nil
+	event, changed := b.setState(Closed)
+	b.mtx.Unlock()
+
+	if changed {
+		b.emit(event)
+	}
+}
+
+// Failure - reports failed call to dependency, opens breaker when threshold is reached
+// or when probe in half-open state fails.
+func (b *Breaker) Failure(err error) {
+	b.mtx.Lock()
+	b.failures++
+	b.lastErr = err
+
+	var event Event
+	var changed bool
+
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		event, changed = b.setState(Open)
+	}
+	b.mtx.Unlock()
+
+	if changed {
+		b.emit(event)
+	}
+}
+
+// Probe - moves open breaker to half-open state, should be called before probing dependency.
+func (b *Breaker) Probe() {
+	b.mtx.Lock()
+
+	var event Event
+	var changed bool
+
+	if b.state == Open {
+		event, changed = b.setState(HalfOpen)
+	}
+	b.mtx.Unlock()
+
+	if changed {
+		b.emit(event)
+	}
+}
+
+// setState - sets new state, returns event and true if state was changed. Requires write lock.
+func (b *Breaker) setState(state State) (Event, bool) {
+	if b.state == state {
+		return Event{}, false
+	}
+
+	event := Event{
+		Name: b.name,
+		From: b.state,
+		To:   state,
+		Err:  b.lastErr,
+		Time: time.Now(),
+	}
+	b.state = state
+
+	return event, true
+}
+
+// emit - passes event to all listeners. Called without lock, so listeners are able to query breaker.
+func (b *Breaker) emit(event Event) {
+	b.mtx.RLock()
+	listeners := make([]func(Event), len(b.listeners))
+	copy(listeners, b.listeners)
+	b.mtx.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,76 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestBreaker - tests breaker state transitions and emitted events.
+func TestBreaker(t *testing.T) {
+	b := NewBreaker("test", 2)
+	events := make([]Event, 0)
+	b.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	t.Run("Breaker_threshold", func(t *testing.T) {
+		b.Failure(errors.New("first"))
+
+		if err := b.Allow(); err != nil {
+			t.Fatalf("breaker opened before threshold: %v", err)
+		}
+
+		b.Failure(errors.New("second"))
+
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Fatalf("expected ErrOpen, got: %v", err)
+		}
+	})
+
+	t.Run("Breaker_probeFailed", func(t *testing.T) {
+		b.Probe()
+
+		if state := b.State(); state != HalfOpen {
+			t.Fatalf("expected half-open state, got: %s", state)
+		}
+
+		if err := b.Allow(); !errors.Is(err, ErrOpen) {
+			t.Fatalf("half-open breaker should reject requests, got: %v", err)
+		}
+		// Single failure in half-open state opens breaker again.
+		b.Failure(errors.New("probe"))
+
+		if state := b.State(); state != Open {
+			t.Fatalf("expected open state, got: %s", state)
+		}
+	})
+
+	t.Run("Breaker_probeSucceeded", func(t *testing.T) {
+		b.Probe()
+		b.Success()
+
+		if err := b.Allow(); err != nil {
+			t.Fatalf("breaker should be closed, got: %v", err)
+		}
+	})
+
+	t.Run("Breaker_events", func(t *testing.T) {
+		expected := []struct{ from, to State }{
+			{Closed, Open},
+			{Open, HalfOpen},
+			{HalfOpen, Open},
+			{Open, HalfOpen},
+			{HalfOpen, Closed},
+		}
+
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+		}
+
+		for i, e := range expected {
+			if events[i].From != e.from || events[i].To != e.to || events[i].Name != "test" {
+				t.Fatalf("unexpected event %d: %+v", i, events[i])
+			}
+		}
+	})
+}
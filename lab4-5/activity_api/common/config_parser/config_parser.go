@@ -4,33 +4,59 @@ import (
 	"activity_api/control"
 	"activity_api/data_manager/db"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultConfigName - config file next to the binary, it's optional, unlike file set explicitly.
+	defaultConfigName = "config.json"
+	// envConfig - env variable with path to config file.
+	envConfig = "AAS_CONFIG"
+	// flagConfig - flag with path to config file.
+	flagConfig = "config"
+	// secretFilePrefix - secret fields with this prefix are read from file, e.g. "file:/run/secrets/redis".
+	secretFilePrefix = "file:"
 )
 
 // parseJSON - parses json by given path to data.
 func parseJSON(filePath string, data interface{}) error {
-	file, err := os.Open(filePath)
+	bytes, err := ioutil.ReadFile(filePath)
 
 	if err != nil {
-		return fmt.Errorf("os.Open(): %w", err)
-	}
-	// Wrap in lambda function is required, due to
-	defer func() {
-		// Since i don't want to pass logrus here,
-		// but i don't want to miss an error during config read,
-		// panic is used.
-		if err := file.Close(); err != nil {
-			panic(err)
-		}
-	}() // Check missing error
+		return fmt.Errorf("ioutil.ReadFile(): %w", err)
+	}
+
+	if err = json.Unmarshal(bytes, data); err != nil {
+		return fmt.Errorf("json.Unmarshal(): %w", err)
+	}
 
-	bytes, err := ioutil.ReadAll(file)
+	return nil
+}
+
+// parseYAML - parses yaml by given path to data.
+// YAML is converted to JSON first, so both formats share field names and custom unmarshalers.
+func parseYAML(filePath string, data interface{}) error {
+	bytes, err := ioutil.ReadFile(filePath)
 
 	if err != nil {
-		return fmt.Errorf("ioutil.ReadAll(): %w", err)
+		return fmt.Errorf("ioutil.ReadFile(): %w", err)
+	}
+
+	var raw interface{}
+
+	if err = yaml.Unmarshal(bytes, &raw); err != nil {
+		return fmt.Errorf("yaml.Unmarshal(): %w", err)
+	}
+
+	if bytes, err = json.Marshal(raw); err != nil {
+		return fmt.Errorf("json.Marshal(): %w", err)
 	}
 
 	if err = json.Unmarshal(bytes, data); err != nil {
@@ -40,9 +66,23 @@ func parseJSON(filePath string, data interface{}) error {
 	return nil
 }
 
-// Check if path is abs, if not - make it abs.
+// parseFile - parses config file, format is chosen by extension.
+func parseFile(filePath string, data interface{}) error {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return parseYAML(filePath, data)
+	default:
+		return parseJSON(filePath, data)
+	}
+}
+
+// Check if path is abs, if not - make it abs relative to the binary.
 // Required if app will run under system.
 func EnsureAbsPath(toCheck string) (string, error) {
+	if filepath.IsAbs(toCheck) {
+		return toCheck, nil
+	}
+
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 
 	if err != nil {
@@ -52,30 +92,145 @@ func EnsureAbsPath(toCheck string) (string, error) {
 	return filepath.Join(dir, toCheck), nil
 }
 
-// ParseConfig - parses AAServer config by given path.
-func ParseConfig(configName string) (*control.AAServiceConfig, error) {
-	// Make path abs
-	path, err := EnsureAbsPath(configName)
+// LoadConfig - loads AAServer config layer by layer, each next layer overrides previous one:
+// defaults, config file (JSON or YAML), AAS_* env variables, command line flags.
+// Then secret references are resolved and config is validated.
+func LoadConfig(args []string) (*control.AAServiceConfig, error) {
+	return loadConfig(args, os.LookupEnv)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("EnsureAbsPath(): %w", err)
+// loadConfig - LoadConfig implementation, env lookup is passed to make it testable.
+func loadConfig(args []string, lookupEnv func(string) (string, bool)) (*control.AAServiceConfig, error) {
+	config := control.DefaultConfig()
+	fields := configFields(config)
+
+	flags := flag.NewFlagSet("activity_api", flag.ContinueOnError)
+	configPath := flags.String(flagConfig, "", "path to config file (JSON or YAML), env: "+envConfig)
+	// Flag values are only stored here and applied after config file and env.
+	flagValues := make(map[string]string)
+
+	for _, field := range fields {
+		flags.Var(&flagValue{name: field.flagName(), values: flagValues}, field.flagName(), field.usage())
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("flags.Parse(): %w", err)
 	}
 
-	config := new(control.AAServiceConfig)
-	// Parse config
-	if err = parseJSON(path, config); err != nil {
-		return nil, fmt.Errorf("parseJSON(): %w", err)
+	if err := applyFile(config, *configPath, lookupEnv); err != nil {
+		return nil, fmt.Errorf("applyFile(): %w", err)
+	}
+	// Config file could replace nested structs, so fields are collected again.
+	fields = configFields(config)
+
+	for _, field := range fields {
+		if value, ok := lookupEnv(field.envName()); ok {
+			if err := field.set(value); err != nil {
+				return nil, fmt.Errorf("env %s: %w", field.envName(), err)
+			}
+		}
 	}
+
+	for _, field := range fields {
+		if value, ok := flagValues[field.flagName()]; ok {
+			if err := field.set(value); err != nil {
+				return nil, fmt.Errorf("flag -%s: %w", field.flagName(), err)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if err := field.resolveSecret(); err != nil {
+			return nil, fmt.Errorf("%s: %w", field.path, err)
+		}
+	}
+
+	if err := ensureAbsPaths(config); err != nil {
+		return nil, fmt.Errorf("ensureAbsPaths(): %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return config, nil
+}
+
+// flagValue - flag.Value that stores raw value of config field flag.
+type flagValue struct {
+	name   string
+	values map[string]string
+}
+
+// String - returns stored flag value.
+func (f *flagValue) String() string {
+	if f.values == nil {
+		return ""
+	}
+
+	return f.values[f.name]
+}
+
+// Set - stores flag value.
+func (f *flagValue) Set(value string) error {
+	f.values[f.name] = value
+
+	return nil
+}
+
+// applyFile - parses config file over given config. Path is taken from flag or env,
+// if neither is set, optional config.json next to the binary is used.
+func applyFile(config *control.AAServiceConfig, path string, lookupEnv func(string) (string, bool)) error {
+	if path == "" {
+		path, _ = lookupEnv(envConfig)
+	}
+
+	if path == "" {
+		defaultPath, err := EnsureAbsPath(defaultConfigName)
+
+		if err != nil {
+			return fmt.Errorf("EnsureAbsPath(): %w", err)
+		}
+		// Service could be configured by env and flags only.
+		if _, err := os.Stat(defaultPath); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		path = defaultPath
+	}
+
+	if err := parseFile(path, config); err != nil {
+		return fmt.Errorf("parseFile(%s): %w", path, err)
+	}
+
+	return nil
+}
+
+// ensureAbsPaths - makes file paths from config absolute relative to the binary.
+func ensureAbsPaths(config *control.AAServiceConfig) error {
+	paths := []*string{&config.LogFile, &config.ArchiveDir}
 	// Due to SQLite conn string is DB path - ensure that this path is abs
 	if config.DbType == db.SQLite {
-		conn, err := EnsureAbsPath(config.ConnString)
+		paths = append(paths, &config.ConnString)
+	}
+
+	if config.TLS != nil {
+		paths = append(paths, &config.TLS.CertFile, &config.TLS.KeyFile, &config.TLS.ClientCAFile)
+	}
+
+	for _, path := range paths {
+		if *path == "" {
+			continue
+		}
+
+		abs, err := EnsureAbsPath(*path)
 
 		if err != nil {
-			return nil, fmt.Errorf("EnsureAbsPath(): %w", err)
+			return fmt.Errorf("EnsureAbsPath(): %w", err)
 		}
 
-		config.ConnString = conn
+		*path = abs
 	}
 
-	return config, nil
+	return nil
 }
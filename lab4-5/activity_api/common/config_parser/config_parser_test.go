@@ -0,0 +1,158 @@
+package config_parser
+
+import (
+	"activity_api/data_manager/cache"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testEnv - returns env lookup function over given map.
+func testEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	}
+}
+
+// writeFile - writes test file to temp dir and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_LoadConfig(t *testing.T) {
+	jsonConfig := `{
+  "CacheType" : "mock",
+  "ConnString" : "/tmp/test.db",
+  "Addr" : "localhost:1000",
+  "LogLevel" : "warning",
+  "RateLimit" : 10,
+  "Cache" : {"Address" : "json:6379", "DB" : 1}
+}`
+
+	t.Run("LoadConfig_precedence", func(t *testing.T) {
+		path := writeFile(t, "config.json", jsonConfig)
+		env := testEnv(map[string]string{
+			envConfig:           path,
+			"AAS_ADDR":          "localhost:2000",
+			"AAS_CACHE_ADDRESS": "env:6379",
+			"AAS_LOG_LEVEL":     "debug",
+		})
+
+		config, err := loadConfig([]string{"-addr", "localhost:3000", "-rate-burst", "20"}, env)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.CacheType != cache.ICacheMock {
+			t.Errorf("cache type from file expected, got: %v", config.CacheType)
+		}
+
+		if config.Addr != "localhost:3000" {
+			t.Errorf("addr from flag expected, got: %s", config.Addr)
+		}
+
+		if config.Cache.Address != "env:6379" || config.Cache.DB != 1 {
+			t.Errorf("cache address from env and db from file expected, got: %+v", config.Cache)
+		}
+
+		if logrus.Level(config.LogLevel) != logrus.DebugLevel {
+			t.Errorf("log level from env expected, got: %v", config.LogLevel)
+		}
+
+		if config.RateLimit != 10 || config.RateBurst != 20 {
+			t.Errorf("rate limit from file and burst from flag expected, got: %v, %d", config.RateLimit, config.RateBurst)
+		}
+
+		if config.ShutdownTimeout != 15 {
+			t.Errorf("default shutdown timeout expected, got: %d", config.ShutdownTimeout)
+		}
+	})
+
+	t.Run("LoadConfig_yaml", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "CacheType: mock\nConnString: /tmp/test.db\nLogLevel: 2\nCache:\n  Address: yaml:6379\n")
+
+		config, err := loadConfig([]string{"-config", path}, testEnv(nil))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Cache.Address != "yaml:6379" || logrus.Level(config.LogLevel) != logrus.ErrorLevel {
+			t.Errorf("values from yaml expected, got: %+v, %v", config.Cache, config.LogLevel)
+		}
+	})
+
+	t.Run("LoadConfig_secret_file", func(t *testing.T) {
+		path := writeFile(t, "config.json", jsonConfig)
+		secret := writeFile(t, "redis_password", "s3cret\n")
+		env := testEnv(map[string]string{"AAS_CACHE_PASSWORD": secretFilePrefix + secret})
+
+		config, err := loadConfig([]string{"-config", path}, env)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Cache.Password != "s3cret" {
+			t.Errorf("password from secret file expected, got: %q", config.Cache.Password)
+		}
+	})
+
+	t.Run("LoadConfig_missing_file", func(t *testing.T) {
+		if _, err := loadConfig([]string{"-config", "/not/existing.json"}, testEnv(nil)); err == nil {
+			t.Error("error expected for missing config file")
+		}
+	})
+
+	t.Run("LoadConfig_invalid", func(t *testing.T) {
+		path := writeFile(t, "config.json", jsonConfig)
+		env := testEnv(map[string]string{"AAS_ADDR": "", "AAS_TLS_CERT_FILE": "/not/existing.crt"})
+
+		_, err := loadConfig([]string{"-config", path}, env)
+
+		if err == nil {
+			t.Fatal("validation error expected")
+		}
+		// All problems are reported at once.
+		if !strings.Contains(err.Error(), "Addr") || !strings.Contains(err.Error(), "TLS") {
+			t.Errorf("both addr and tls errors expected, got: %v", err)
+		}
+	})
+
+	t.Run("LoadConfig_bad_env_value", func(t *testing.T) {
+		path := writeFile(t, "config.json", jsonConfig)
+		env := testEnv(map[string]string{"AAS_PING_INTERVAL": "often"})
+
+		if _, err := loadConfig([]string{"-config", path}, env); err == nil {
+			t.Error("error expected for non numeric ping interval")
+		}
+	})
+}
+
+func Test_splitCamelCase(t *testing.T) {
+	cases := map[string]string{
+		"ConnString":   "conn string",
+		"ClientCAFile": "client ca file",
+		"TLS":          "tls",
+		"DB":           "db",
+		"DbType":       "db type",
+	}
+
+	for name, expected := range cases {
+		if got := strings.Join(splitCamelCase(name), " "); got != expected {
+			t.Errorf("splitCamelCase(%s): expected %q, got %q", name, expected, got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package config_parser
+
+import (
+	"encoding"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// field - leaf field of config, e.g. Cache.Address.
+type field struct {
+	path   []string // names of struct fields from config root
+	value  reflect.Value
+	secret bool // field is tagged with `secret:"true"` and could reference a file
+}
+
+// configFields - returns all leaf fields of given config (pointer to struct).
+// Nil pointers to nested structs are allocated, so they could be set from env or flags.
+func configFields(config interface{}) []*field {
+	return collectFields(reflect.ValueOf(config).Elem(), nil)
+}
+
+// collectFields - recursive configFields helper.
+func collectFields(value reflect.Value, path []string) []*field {
+	fields := make([]*field, 0)
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		structField := valueType.Field(i)
+
+		if structField.PkgPath != "" { // unexported
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		fieldPath := append(append([]string{}, path...), structField.Name)
+
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+
+			fields = append(fields, collectFields(fieldValue.Elem(), fieldPath)...)
+
+			continue
+		}
+
+		fields = append(fields, &field{
+			path:   fieldPath,
+			value:  fieldValue,
+			secret: structField.Tag.Get("secret") == "true",
+		})
+	}
+
+	return fields
+}
+
+// envName - returns env variable name of field, e.g. AAS_CACHE_ADDRESS.
+func (f *field) envName() string {
+	return "AAS_" + strings.ToUpper(strings.Join(f.words(), "_"))
+}
+
+// flagName - returns flag name of field, e.g. cache-address.
+func (f *field) flagName() string {
+	return strings.ToLower(strings.Join(f.words(), "-"))
+}
+
+// usage - returns flag usage.
+func (f *field) usage() string {
+	usage := fmt.Sprintf("%s, env: %s", strings.Join(f.path, "."), f.envName())
+
+	if f.secret {
+		usage += ", could reference file: " + secretFilePrefix + "<path>"
+	}
+
+	return usage
+}
+
+// words - splits field path to words: ["Cache", "DB"] -> [cache db], ClientCAFile -> [client ca file].
+func (f *field) words() []string {
+	words := make([]string, 0)
+
+	for _, name := range f.path {
+		words = append(words, splitCamelCase(name)...)
+	}
+
+	return words
+}
+
+// set - sets field value from string.
+func (f *field) set(value string) error {
+	if unmarshaler, ok := f.value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return fmt.Errorf("strconv.ParseBool(): %w", err)
+		}
+
+		f.value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, f.value.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("strconv.ParseInt(): %w", err)
+		}
+
+		f.value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, f.value.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("strconv.ParseUint(): %w", err)
+		}
+
+		f.value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, f.value.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("strconv.ParseFloat(): %w", err)
+		}
+
+		f.value.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field kind: %s", f.value.Kind())
+	}
+
+	return nil
+}
+
+// resolveSecret - replaces "file:<path>" value of secret field with content of the file.
+func (f *field) resolveSecret() error {
+	if !f.secret || f.value.Kind() != reflect.String {
+		return nil
+	}
+
+	value := f.value.String()
+
+	if !strings.HasPrefix(value, secretFilePrefix) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(strings.TrimPrefix(value, secretFilePrefix))
+
+	if err != nil {
+		return fmt.Errorf("secret ioutil.ReadFile(): %w", err)
+	}
+	// Secret files usually end with new line, which is not a part of the secret.
+	f.value.SetString(strings.TrimRight(string(data), "\r\n"))
+
+	return nil
+}
+
+// splitCamelCase - splits CamelCase name to lower case words, keeping acronyms together:
+// ConnString -> [conn string], ClientCAFile -> [client ca file], TLS -> [tls].
+func splitCamelCase(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if unicode.IsUpper(runes[i]) && (prevLower || acronymEnd) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+
+	return append(words, strings.ToLower(string(runes[start:])))
+}
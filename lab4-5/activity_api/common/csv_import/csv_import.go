@@ -0,0 +1,94 @@
+// Package csv_import - parses CSV file of users import.
+package csv_import
+
+import (
+	"activity_api/common/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Columns of import file. Header is required, columns could go in any order, unknown columns are ignored.
+const (
+	ColumnDepartment = "department_name"
+	ColumnUser       = "user_name"
+)
+
+// MaxRows - max number of rows in import file, larger files should be split.
+const MaxRows = 10000
+
+// bom - byte order mark, written by spreadsheet editors to the beginning of UTF-8 CSV.
+const bom = "\uFEFF"
+
+// Parse - reads import rows from CSV. Error is returned if file can't be imported at all,
+// e.g. header is missing, rows with missing columns are returned with error set.
+func Parse(r io.Reader) ([]*models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // rows are checked one by one, so all errors are reported at once
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty, header is required")
+		}
+
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, bom)))] = i
+	}
+
+	department, ok := columns[ColumnDepartment]
+
+	if !ok {
+		return nil, fmt.Errorf("header: column %q is required", ColumnDepartment)
+	}
+
+	user, ok := columns[ColumnUser]
+
+	if !ok {
+		user = -1 // file of departments only
+	}
+
+	rows := make([]*models.ImportRow, 0)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read row %d: %w", line, err)
+		}
+
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+
+		row := &models.ImportRow{Line: line}
+		rows = append(rows, row)
+
+		if department >= len(record) || user >= len(record) {
+			row.Error = fmt.Sprintf("expected %d columns, got %d", len(header), len(record))
+
+			continue
+		}
+
+		row.DepartmentName = strings.TrimSpace(record[department])
+
+		if user >= 0 {
+			row.UserName = strings.TrimSpace(record[user])
+		}
+	}
+
+	return rows, nil
+}
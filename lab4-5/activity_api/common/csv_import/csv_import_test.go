@@ -0,0 +1,68 @@
+package csv_import
+
+import (
+	"activity_api/common/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Run("Parse_rows", func(t *testing.T) {
+		file := "\uFEFFUser_Name, Department_Name,comment\n" +
+			"alice, sales,first\n" +
+			"\n" +
+			"\"bob, jr\",sales,\n" +
+			"carol\n" +
+			",support\n"
+
+		rows, err := Parse(strings.NewReader(file))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []*models.ImportRow{
+			{Line: 2, DepartmentName: "sales", UserName: "alice"},
+			{Line: 3, DepartmentName: "sales", UserName: "bob, jr"},
+			{Line: 4, Error: "expected 3 columns, got 1"},
+			{Line: 5, DepartmentName: "support"},
+		}
+
+		if !reflect.DeepEqual(rows, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rows)
+		}
+	})
+
+	t.Run("Parse_departments_only", func(t *testing.T) {
+		rows, err := Parse(strings.NewReader("department_name\nsales\n"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []*models.ImportRow{{Line: 2, DepartmentName: "sales"}}; !reflect.DeepEqual(rows, expected) {
+			t.Errorf("expected %+v, got %+v", expected, rows)
+		}
+	})
+
+	t.Run("Parse_invalid_file", func(t *testing.T) {
+		for name, file := range map[string]string{
+			"empty":          "",
+			"no_department":  "user_name\nalice\n",
+			"invalid_quotes": "department_name\n\"sales\n",
+		} {
+			if _, err := Parse(strings.NewReader(file)); err == nil {
+				t.Errorf("%s: error expected", name)
+			}
+		}
+	})
+
+	t.Run("Parse_max_rows", func(t *testing.T) {
+		file := "department_name\n" + strings.Repeat("sales\n", MaxRows+1)
+
+		if _, err := Parse(strings.NewReader(file)); err == nil {
+			t.Error("error expected")
+		}
+	})
+}
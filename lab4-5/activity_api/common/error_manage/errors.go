@@ -0,0 +1,36 @@
+package error_manage
+
+import "strings"
+
+// Errors - list of errors, used when several independent steps could fail
+// and none of the failures should be lost (e.g. stopping of several modules).
+type Errors []error
+
+// Append - appends given error to the list, nil errors are skipped.
+func (e Errors) Append(err error) Errors {
+	if err == nil {
+		return e
+	}
+
+	return append(e, err)
+}
+
+// Error - returns all errors joined with "; ".
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ErrOrNil - returns nil if there are no errors, so empty list isn't returned as non-nil error interface.
+func (e Errors) ErrOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,34 @@
+package error_manage
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestErrors - tests Errors aggregation.
+func TestErrors(t *testing.T) {
+	t.Run("Errors_empty", func(t *testing.T) {
+		var errs Errors
+
+		if err := errs.Append(nil).ErrOrNil(); err != nil {
+			t.Fatalf("expected nil error, got: %v", err)
+		}
+	})
+
+	t.Run("Errors_multiple", func(t *testing.T) {
+		var errs Errors
+		errs = errs.Append(errors.New("first"))
+		errs = errs.Append(nil)
+		errs = errs.Append(errors.New("second"))
+
+		err := errs.ErrOrNil()
+
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if err.Error() != "first; second" {
+			t.Fatalf("unexpected error message: %s", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// CSVWriter - streaming CSV table writer.
+type CSVWriter struct {
+	writer *csv.Writer
+	row    []string // reused row buffer
+}
+
+// NewCSVWriter - returns new CSV writer.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w)}
+}
+
+// WriteRow - writes row to CSV.
+func (c *CSVWriter) WriteRow(values ...interface{}) error {
+	c.row = c.row[:0]
+
+	for _, value := range values {
+		c.row = append(c.row, formatValue(value))
+	}
+
+	if err := c.writer.Write(c.row); err != nil {
+		return fmt.Errorf("csv Write(): %w", err)
+	}
+
+	return nil
+}
+
+// Flush - writes buffered rows.
+func (c *CSVWriter) Flush() error {
+	c.writer.Flush()
+
+	if err := c.writer.Error(); err != nil {
+		return fmt.Errorf("csv Flush(): %w", err)
+	}
+
+	return nil
+}
+
+// Close - flushes remaining rows.
+func (c *CSVWriter) Close() error {
+	return c.Flush()
+}
@@ -0,0 +1,133 @@
+// Package export - streaming table writers for spreadsheet exports.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Supported export formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Content types of export formats.
+const (
+	ContentTypeCSV  = "text/csv"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// contentTypes - format by content type.
+var contentTypes = map[string]string{
+	ContentTypeCSV:  FormatCSV,
+	ContentTypeXLSX: FormatXLSX,
+}
+
+// Writer - table writer, rows are written to underlying writer as they come, without buffering whole table.
+type Writer interface {
+	// WriteRow - writes row, values are strings or numbers.
+	WriteRow(values ...interface{}) error
+	// Flush - writes buffered rows to underlying writer.
+	Flush() error
+	// Close - finishes table, underlying writer isn't closed.
+	Close() error
+}
+
+// NewWriter - returns table writer of given format, sheet is used as sheet name where format supports it.
+func NewWriter(format string, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w, sheet)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %q", format)
+	}
+}
+
+// ContentType - returns content type of given format.
+func ContentType(format string) string {
+	for contentType, f := range contentTypes {
+		if f == format {
+			return contentType
+		}
+	}
+
+	return "application/octet-stream"
+}
+
+// Negotiate - returns export format by explicit format param, or by Accept header if param is empty.
+// CSV is used if Accept header doesn't prefer any supported format.
+func Negotiate(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+
+		if format != FormatCSV && format != FormatXLSX {
+			return "", fmt.Errorf("unsupported export format: %q, expected one of: csv, xlsx", format)
+		}
+
+		return format, nil
+	}
+
+	best, bestQuality := FormatCSV, 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+
+		if err != nil {
+			continue
+		}
+
+		f, ok := contentTypes[mediaType]
+
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = f, quality
+		}
+	}
+
+	return best, nil
+}
+
+// formatValue - returns string representation of cell value.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isNumber - returns true if value is written as number cell.
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int, int64, float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// sheet - cells of XLSX sheet, used to read written workbook back.
+type sheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func Test_Export(t *testing.T) {
+	t.Run("CSV_rows", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w := NewCSVWriter(buf)
+
+		if err := w.WriteRow("id", "name"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.WriteRow(int64(1), "a, \"b\""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := "id,name\n1,\"a, \"\"b\"\"\"\n"; buf.String() != expected {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	})
+
+	t.Run("XLSX_rows", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w, err := NewXLSXWriter(buf, "users/report")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.WriteRow("id", "name"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.WriteRow(int64(42), "<a & b>"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files := make(map[string][]byte)
+
+		for _, file := range archive.File {
+			r, err := file.Open()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if files[file.Name], err = ioutil.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+			if _, ok := files[name]; !ok {
+				t.Errorf("missing part %s", name)
+			}
+		}
+
+		if !bytes.Contains(files["xl/workbook.xml"], []byte(`name="users_report"`)) {
+			t.Errorf("unexpected workbook: %s", files["xl/workbook.xml"])
+		}
+
+		parsed := new(sheet)
+
+		if err := xml.Unmarshal(files[xlsxSheetPath], parsed); err != nil {
+			t.Fatal(err)
+		}
+
+		var cells [][]string
+
+		for _, row := range parsed.Rows {
+			var values []string
+
+			for _, cell := range row.Cells {
+				values = append(values, cell.Ref+"="+cell.Type+":"+cell.Value+cell.Inline)
+			}
+
+			cells = append(cells, values)
+		}
+
+		expected := [][]string{
+			{"A1=inlineStr:id", "B1=inlineStr:name"},
+			{"A2=:42", "B2=inlineStr:<a & b>"},
+		}
+
+		if !reflect.DeepEqual(cells, expected) {
+			t.Errorf("expected %v, got %v", expected, cells)
+		}
+	})
+
+	t.Run("ColumnName", func(t *testing.T) {
+		for index, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+			if name := ColumnName(index); name != expected {
+				t.Errorf("index %d: expected %s, got %s", index, expected, name)
+			}
+		}
+	})
+
+	t.Run("Negotiate", func(t *testing.T) {
+		cases := []struct {
+			format, accept, expected string
+			fails                    bool
+		}{
+			{"", "", FormatCSV, false},
+			{"XLSX", "text/csv", FormatXLSX, false},
+			{"", "application/json, " + ContentTypeXLSX, FormatXLSX, false},
+			{"", ContentTypeXLSX + ";q=0.5, text/csv", FormatCSV, false},
+			{"", "*/*", FormatCSV, false},
+			{"pdf", "", "", true},
+		}
+
+		for _, c := range cases {
+			format, err := Negotiate(c.format, c.accept)
+
+			if (err != nil) != c.fails || format != c.expected {
+				t.Errorf("Negotiate(%q, %q): expected %q (fails: %v), got %q, %v",
+					c.format, c.accept, c.expected, c.fails, format, err)
+			}
+		}
+	})
+}
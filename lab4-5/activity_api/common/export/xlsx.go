@@ -0,0 +1,207 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Static parts of single sheet workbook, see ECMA-376 (Office Open XML).
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+	xlsxContentTypes = xmlHeader +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRels = xmlHeader +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+		`Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	// xlsxWorkbook - sheet name is set with Sprintf.
+	xlsxWorkbook = xmlHeader +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = xmlHeader +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+		`Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetStart = xmlHeader +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+
+	xlsxSheetPath = "xl/worksheets/sheet1.xml"
+	// maxSheetName - Excel limit of sheet name length.
+	maxSheetName = 31
+)
+
+// XLSXWriter - streaming XLSX writer. Workbook has single sheet, which is written last,
+// so rows go to output as they come. Strings are written inline, without shared strings table.
+type XLSXWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+// NewXLSXWriter - returns new XLSX writer, static workbook parts are written immediately.
+func NewXLSXWriter(w io.Writer, sheet string) (*XLSXWriter, error) {
+	x := &XLSXWriter{zip: zip.NewWriter(w)}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escape(sheetName(sheet)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+
+	for _, part := range parts {
+		file, err := x.zip.Create(part.name)
+
+		if err != nil {
+			return nil, fmt.Errorf("zip Create(%s): %w", part.name, err)
+		}
+
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, fmt.Errorf("write %s: %w", part.name, err)
+		}
+	}
+
+	file, err := x.zip.Create(xlsxSheetPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("zip Create(%s): %w", xlsxSheetPath, err)
+	}
+
+	x.sheet = bufio.NewWriter(file)
+
+	if _, err := x.sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, fmt.Errorf("write sheet: %w", err)
+	}
+
+	return x, nil
+}
+
+// WriteRow - writes row to sheet, numbers are written as number cells.
+func (x *XLSXWriter) WriteRow(values ...interface{}) error {
+	x.rows++
+
+	row := strconv.Itoa(x.rows)
+	b := new(strings.Builder)
+	b.WriteString(`<row r="` + row + `">`)
+
+	for i, value := range values {
+		ref := ColumnName(i) + row
+
+		if isNumber(value) {
+			b.WriteString(`<c r="` + ref + `"><v>` + formatValue(value) + `</v></c>`)
+
+			continue
+		}
+
+		b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		b.WriteString(escape(formatValue(value)))
+		b.WriteString(`</t></is></c>`)
+	}
+
+	b.WriteString(`</row>`)
+
+	if _, err := x.sheet.WriteString(b.String()); err != nil {
+		return fmt.Errorf("write row: %w", err)
+	}
+
+	return nil
+}
+
+// Flush - writes buffered rows to zip stream and zip stream to underlying writer.
+func (x *XLSXWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return fmt.Errorf("sheet Flush(): %w", err)
+	}
+
+	if err := x.zip.Flush(); err != nil {
+		return fmt.Errorf("zip Flush(): %w", err)
+	}
+
+	return nil
+}
+
+// Close - finishes sheet and writes zip directory.
+func (x *XLSXWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return fmt.Errorf("write sheet end: %w", err)
+	}
+
+	if err := x.sheet.Flush(); err != nil {
+		return fmt.Errorf("sheet Flush(): %w", err)
+	}
+
+	if err := x.zip.Close(); err != nil {
+		return fmt.Errorf("zip Close(): %w", err)
+	}
+
+	return nil
+}
+
+// ColumnName - returns spreadsheet column name by zero based index: A, B, ..., Z, AA, AB, ...
+func ColumnName(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
+
+// sheetName - returns sheet name allowed by Excel.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	if name == "" {
+		return "Sheet1"
+	}
+
+	if runes := []rune(name); len(runes) > maxSheetName {
+		return string(runes[:maxSheetName])
+	}
+
+	return name
+}
+
+// escape - escapes text for XML.
+func escape(text string) string {
+	b := new(strings.Builder)
+	// strings.Builder never returns write errors.
+	_ = xml.EscapeText(b, []byte(text))
+
+	return b.String()
+}
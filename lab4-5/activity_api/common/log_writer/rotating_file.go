@@ -0,0 +1,139 @@
+package log_writer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultMaxSize    = 100 * 1024 * 1024 // 100 MB
+	defaultMaxBackups = 5
+	filePerm          = 0644
+)
+
+// RotatingFile - io.WriteCloser that writes to a file and rotates it when it reaches max size.
+// Rotated files are named <path>.1, <path>.2, ... where <path>.1 is the most recent one.
+type RotatingFile struct {
+	path       string
+	maxSize    int64 // size in bytes after which file is rotated
+	maxBackups int   // number of rotated files to keep
+
+	file *os.File
+	size int64
+	mtx  sync.Mutex
+}
+
+// NewRotatingFile - opens (or creates) log file by given path.
+// maxSizeMB <= 0 and maxBackups <= 0 are replaced with defaults.
+func NewRotatingFile(path string, maxSizeMB, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+
+	if r.maxSize <= 0 {
+		r.maxSize = defaultMaxSize
+	}
+
+	if r.maxBackups <= 0 {
+		r.maxBackups = defaultMaxBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll(): %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return nil, fmt.Errorf("open(): %w", err)
+	}
+
+	return r, nil
+}
+
+// Write - writes given bytes to file, rotates file before write if it would exceed max size.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.file == nil {
+		return 0, errors.New("log file is closed")
+	}
+	// Don't rotate empty file, otherwise single huge write would produce empty backups.
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, fmt.Errorf("rotate(): %w", err)
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Close - closes underlying file.
+func (r *RotatingFile) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.file == nil {
+		return errors.New("log file is already closed")
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+// open - opens log file in append mode and remembers its current size.
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+
+	if err != nil {
+		return fmt.Errorf("os.OpenFile(): %w", err)
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("file.Stat(): %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+
+	return nil
+}
+
+// rotate - shifts backups (<path>.N-1 -> <path>.N), moves current file to <path>.1 and opens new one.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("file.Close(): %w", err)
+	}
+
+	r.file = nil
+	// The oldest backup is overwritten by rename, so no explicit delete is required.
+	for i := r.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(r.backupName(i), r.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("os.Rename(): %w", err)
+		}
+	}
+
+	if err := os.Rename(r.path, r.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Rename(): %w", err)
+	}
+
+	return r.open()
+}
+
+// backupName - returns name of backup file with given index.
+func (r *RotatingFile) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
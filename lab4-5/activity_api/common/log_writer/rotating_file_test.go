@@ -0,0 +1,89 @@
+package log_writer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRotatingFile_Write - tests that file is rotated after max size is reached.
+func TestRotatingFile_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log_writer")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	w, err := NewRotatingFile(path, 1, 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set size in bytes directly, so test doesn't need to write megabytes.
+	w.maxSize = 10
+	line := []byte("123456\n")
+
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 4 writes with 7 bytes and 10 bytes limit - each write goes to new file,
+	// only 2 backups are kept.
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := ioutil.ReadFile(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, line) {
+			t.Fatalf("unexpected content of %s: %q", name, data)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("backup over limit exists: %v", err)
+	}
+}
+
+// TestRotatingFile_Append - tests that existing file size is taken into account.
+func TestRotatingFile_Append(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log_writer")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+
+	if err := ioutil.WriteFile(path, []byte("12345678\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewRotatingFile(path, 1, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.maxSize = 10
+
+	if _, err := w.Write([]byte("abc\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("file wasn't rotated: %v", err)
+	}
+}
@@ -1,57 +1,564 @@
 package models
 
+// Admin roles.
+const (
+	RoleAdmin      = "admin"      // full access
+	RoleAnalyst    = "analyst"    // anonymized department reports only
+	RoleSuperadmin = "superadmin" // full access and tenants management, created from config only
+)
+
 // Admin - admin user of AAService.
 type Admin struct {
-	Username string `db:"admin_name"`
+	Username string `db:"admin_name" json:"username"`
 	// Hash - password hash (written to DB with salt).
 	// Password should be hashed on the client side, and then it would be hashed again on the server side.
-	Hash string `db:"password_hash"`
+	Hash string `db:"password_hash" json:"password_hash"`
+	// TenantID - tenant admin belongs to, it's set by server and never taken from request.
+	TenantID int64 `db:"tenant_id" json:"-"`
+	// Role - one of RoleAdmin, RoleAnalyst, RoleSuperadmin, empty role of created admin means RoleAdmin.
+	Role string `db:"role" json:"role,omitempty"`
+}
+
+// Tenant - client organisation, every admin, department, user and activity record belongs to one tenant.
+type Tenant struct {
+	TenantID   int64  `db:"tenant_id" json:"tenant_id"`
+	TenantName string `db:"tenant_name" json:"tenant_name"`
+	// Suspended - admins of suspended tenant can't login, their tokens are rejected.
+	Suspended bool  `db:"suspended" json:"suspended"`
+	CreatedAt int64 `db:"created_at" json:"created_at"` // unix time
 }
 
 // Department - AAService Department.
 type Department struct {
-	DepartmentID   int64  `db:"department_id"`
-	DepartmentName string `db:"department_name"`
+	DepartmentID   int64  `db:"department_id" json:"department_id"`
+	DepartmentName string `db:"department_name" json:"department_name"`
+	// ParentID - department this one is part of (e.g. division of team), 0 - top level department.
+	ParentID int64 `db:"parent_id" json:"parent_id"`
+}
+
+// DepartmentNode - department in departments tree. Tree is returned as list in depth-first order:
+// every department is followed by its descendants, so it could be rebuilt by parent ids or depths.
+type DepartmentNode struct {
+	DepartmentID   int64   `json:"department_id"`
+	DepartmentName string  `json:"department_name"`
+	ParentID       int64   `json:"parent_id"`
+	Depth          int     `json:"depth"`    // 0 for root of returned tree
+	Children       []int64 `json:"children"` // ids of direct subdepartments
+}
+
+// DepartmentMove - new parent of moved department.
+type DepartmentMove struct {
+	ParentID int64 `json:"parent_id"` // 0 - department becomes top level one
 }
 
+// User statuses.
+const (
+	UserActive     = "active"
+	UserOnLeave    = "on_leave"
+	UserTerminated = "terminated"
+)
+
 // User - AAService User.
 type User struct {
-	UserID   int64  `db:"user_id"`
-	UserName string `db:"user_name"`
-	// DepartmentID - department in which the user participates.
-	DepartmentID int64 `db:"department_id"`
+	UserID   int64  `db:"user_id" json:"user_id"`
+	UserName string `db:"user_name" json:"user_name"`
+	// DepartmentID - department in which the user participates, it's changed by transfer only.
+	DepartmentID int64  `db:"department_id" json:"department_id"`
+	Email        string `db:"email" json:"email"`
+	// EmployeeNumber - unique in tenant, empty if not set.
+	EmployeeNumber string `db:"employee_number" json:"employee_number"`
+	JobTitle       string `db:"job_title" json:"job_title"`
+	// Status - one of UserActive, UserOnLeave, UserTerminated.
+	Status string `db:"status" json:"status"`
+	// EmploymentStart, EmploymentEnd - timestamps of employment period, 0 end - still employed.
+	EmploymentStart int64 `db:"employment_start" json:"employment_start"`
+	EmploymentEnd   int64 `db:"employment_end" json:"employment_end"`
+}
+
+// UserTransfer - new department of transferred user.
+type UserTransfer struct {
+	DepartmentID int64 `json:"department_id"`
+	// EffectiveFrom - timestamp from which activity counts toward new department, 0 - now.
+	EffectiveFrom int64 `json:"effective_from"`
+}
+
+// Membership - period of user membership in department.
+type Membership struct {
+	UserID       int64 `db:"user_id" json:"user_id"`
+	DepartmentID int64 `db:"department_id" json:"department_id"`
+	ValidFrom    int64 `db:"valid_from" json:"valid_from"`
+	// ValidTo - end of period (exclusive), 0 - current membership.
+	ValidTo int64 `db:"valid_to" json:"valid_to"`
 }
 
 // Activity - AAService activity.
 type Activity struct {
 	// UserID - id of user which activity were recorded.
-	UserID   int64 `db:"user_id"`
-	RecordID int64 `db:"record_id"`
+	UserID   int64 `db:"user_id" json:"user_id"`
+	RecordID int64 `db:"record_id" json:"record_id"`
 	// TotalTime - total user work time.
-	TotalTime int64 `db:"total_time"`
+	TotalTime int64 `db:"total_time" json:"total_time"`
 	// ActiveTime - active user time from all total time.
-	ActiveTime int64 `db:"active_time"`
+	ActiveTime int64 `db:"active_time" json:"active_time"`
 	// Date - time when activity record were taken.
-	Date int64 `db:"activity_date"`
+	Date int64 `db:"activity_date" json:"date"`
+	// Apps - optional breakdown of total time by application (or window class), sum must not exceed total time.
+	Apps []*AppTime `db:"-" json:"apps,omitempty"`
 }
 
-// UserActivity - data about user activity
-// Due to rows could be null (which would lead to an err during unmarshalling), pointer are used
+// AppTime - time spent in application within activity record.
+type AppTime struct {
+	App  string `db:"app" json:"app"` // application name or window class
+	Time int64  `db:"app_time" json:"time"`
+}
+
+// Activity categories.
+const (
+	CategoryProductive   = "productive"
+	CategoryNeutral      = "neutral"
+	CategoryUnproductive = "unproductive"
+)
+
+// CategoryRule - maps applications matching pattern to category.
+// If several rules match application, rule with the longest pattern wins, apps without rule are neutral.
+type CategoryRule struct {
+	RuleID int64 `db:"rule_id" json:"rule_id"`
+	// Pattern - case-insensitive glob of application name, e.g. "*chrome*".
+	Pattern   string `db:"pattern" json:"pattern"`
+	Category  string `db:"category" json:"category"`
+	CreatedAt int64  `db:"created_at" json:"created_at"`
+}
+
+// CategoryTime - time of app breakdowns by category, records without breakdown aren't counted.
+type CategoryTime struct {
+	Productive   int64 `db:"productive" json:"productive"`
+	Neutral      int64 `db:"neutral" json:"neutral"`
+	Unproductive int64 `db:"unproductive" json:"unproductive"`
+}
+
+// UserActivity - data about user activity.
+// Sums are zero if user has no activity records in requested period.
 type UserActivity struct {
-	UserID     *int64 `db:"user_id"`
-	ActiveTime *int64 `db:"active_time"`
-	TotalTime  *int64 `db:"total_time"`
+	UserID     int64         `db:"user_id" json:"user_id"`
+	ActiveTime int64         `db:"active_time" json:"active_time"`
+	TotalTime  int64         `db:"total_time" json:"total_time"`
+	Categories *CategoryTime `db:"-" json:"categories,omitempty"`
 }
 
-// DepartmentActivity - data about department activity
-// Due to rows could be null (which would lead to an err during unmarshalling), pointer are used
+// DepartmentActivity - data about department activity.
+// Sums are zero if department users have no activity records in requested period.
 type DepartmentActivity struct {
-	DepartmentID *int64 `db:"department_id"`
-	ActiveTime   *int64 `db:"active_time"`
-	TotalTime    *int64 `db:"total_time"`
+	DepartmentID int64         `db:"department_id" json:"department_id"`
+	ActiveTime   int64         `db:"active_time" json:"active_time"`
+	TotalTime    int64         `db:"total_time" json:"total_time"`
+	Categories   *CategoryTime `db:"-" json:"categories,omitempty"`
+	// Members - number of users with activity in requested period.
+	Members int64 `db:"members" json:"members"`
+	// Suppressed - department has too few members for anonymized report, its time isn't reported.
+	Suppressed bool `db:"-" json:"suppressed,omitempty"`
+}
+
+// DepartmentMember - user with activity in department while being its member in requested period.
+type DepartmentMember struct {
+	DepartmentID int64 `db:"department_id" json:"department_id"`
+	UserID       int64 `db:"user_id" json:"user_id"`
+}
+
+// UserReport - activity of user for report, users without records have zero sums.
+type UserReport struct {
+	UserID       int64  `db:"user_id" json:"user_id"`
+	UserName     string `db:"user_name" json:"user_name"`
+	DepartmentID int64  `db:"department_id" json:"department_id"`
+	ActiveTime   int64  `db:"active_time" json:"active_time"`
+	TotalTime    int64  `db:"total_time" json:"total_time"`
+}
+
+// DepartmentReport - activity of department users for report, departments without records have zero sums.
+type DepartmentReport struct {
+	DepartmentID   int64  `db:"department_id" json:"department_id"`
+	DepartmentName string `db:"department_name" json:"department_name"`
+	ActiveTime     int64  `db:"active_time" json:"active_time"`
+	TotalTime      int64  `db:"total_time" json:"total_time"`
+	Members        int64  `db:"members" json:"members"` // number of users with activity
+}
+
+// WorkSchedule - expected work time of user, or of users of department and its subdepartments.
+// Schedule of user overrides schedule of department, schedule of department - of its ancestors.
+type WorkSchedule struct {
+	// UserID, DepartmentID - owner of schedule, only one of them is set. They are set by server from route.
+	UserID       int64 `db:"user_id" json:"user_id"`
+	DepartmentID int64 `db:"department_id" json:"department_id"`
+	// Expected work time of every weekday, in seconds.
+	Monday    int64 `db:"monday" json:"monday"`
+	Tuesday   int64 `db:"tuesday" json:"tuesday"`
+	Wednesday int64 `db:"wednesday" json:"wednesday"`
+	Thursday  int64 `db:"thursday" json:"thursday"`
+	Friday    int64 `db:"friday" json:"friday"`
+	Saturday  int64 `db:"saturday" json:"saturday"`
+	Sunday    int64 `db:"sunday" json:"sunday"`
+	// UTCOffset - seconds east of UTC of schedule time zone, days of work report start at its midnight.
+	UTCOffset int64 `db:"utc_offset" json:"utc_offset"`
+}
+
+// TimeOff - days without expected work: leave of user, or holiday of all users of tenant.
+type TimeOff struct {
+	TimeOffID int64 `db:"time_off_id" json:"time_off_id"`
+	// UserID - user on leave, 0 - holiday.
+	UserID int64 `db:"user_id" json:"user_id"`
+	// From, To - first and last day of time off, YYYY-MM-DD.
+	From        string `db:"date_from" json:"from"`
+	To          string `db:"date_to" json:"to"`
+	Description string `db:"description" json:"description"`
+}
+
+// DailyActivity - activity of user in one day of schedule time zone.
+type DailyActivity struct {
+	Day        int64 `db:"day" json:"day"` // number of day since unix epoch
+	TotalTime  int64 `db:"total_time" json:"total_time"`
+	ActiveTime int64 `db:"active_time" json:"active_time"`
+}
+
+// WorkDay - expected and actual work time of user in one day.
+type WorkDay struct {
+	Date         string `json:"date"`          // YYYY-MM-DD in schedule time zone
+	ExpectedTime int64  `json:"expected_time"` // 0 on days off
+	TotalTime    int64  `json:"total_time"`
+	ActiveTime   int64  `json:"active_time"`
+	TimeOff      bool   `json:"time_off"`           // leave or holiday
+	Overtime     int64  `json:"overtime,omitempty"` // total time above expected time
+}
+
+// WorkReport - expected versus actual work time of user over whole days of period.
+type WorkReport struct {
+	UserID int64 `json:"user_id"`
+	// From, To - first and last day of report, YYYY-MM-DD.
+	From         string `json:"from"`
+	To           string `json:"to"`
+	ExpectedTime int64  `json:"expected_time"`
+	TotalTime    int64  `json:"total_time"`
+	ActiveTime   int64  `json:"active_time"`
+	// Utilization - total time to expected time ratio, 0 if no work is expected.
+	Utilization  float64       `json:"utilization"`
+	Overtime     int64         `json:"overtime"` // sum of daily overtime
+	OvertimeDays int           `json:"overtime_days"`
+	TimeOffDays  int           `json:"time_off_days"`
+	Schedule     *WorkSchedule `json:"schedule"` // schedule report is built by
+	Days         []*WorkDay    `json:"days"`
+}
+
+// DepartmentWorkReport - sums of work reports of department users, days of department
+// are days of its own schedule time zone.
+type DepartmentWorkReport struct {
+	DepartmentID int64         `json:"department_id"`
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	ExpectedTime int64         `json:"expected_time"`
+	TotalTime    int64         `json:"total_time"`
+	ActiveTime   int64         `json:"active_time"`
+	Utilization  float64       `json:"utilization"`
+	Overtime     int64         `json:"overtime"`
+	OvertimeDays int           `json:"overtime_days"`
+	TimeOffDays  int           `json:"time_off_days"`
+	Users        []*WorkReport `json:"users"` // reports of users over days of their membership
+	// Suppressed - department has too few users for anonymized report, its time isn't reported.
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// RetentionPolicy - how long raw activity records of tenant or department are kept. Older records are
+// archived to files and deleted, their time stays in daily rollups. Policy of the nearest department
+// of user wins, then policy of tenant, records of users without policy are kept forever.
+type RetentionPolicy struct {
+	PolicyID int64 `db:"policy_id" json:"policy_id"`
+	// DepartmentID - department of policy with its subdepartments, 0 - policy of whole tenant.
+	DepartmentID int64 `db:"department_id" json:"department_id"`
+	// Days - number of whole UTC days records are kept, today included.
+	Days      int64 `db:"days" json:"days"`
+	CreatedAt int64 `db:"created_at" json:"created_at"`
+}
+
+// ArchiveManifest - description of archive of activity records, it's written next to archive.
+// Archive is gzip-compressed NDJSON: one Activity with its apps per line.
+type ArchiveManifest struct {
+	File      string `json:"file"` // archive file name, in directory of manifest
+	TenantID  int64  `json:"tenant_id"`
+	CreatedAt int64  `json:"created_at"`
+	Records   int64  `json:"records"`
+	// DateFrom, DateTo - unix time of the oldest and the newest archived record.
+	DateFrom int64  `json:"date_from"`
+	DateTo   int64  `json:"date_to"`
+	SHA256   string `json:"sha256"` // checksum of archive file
+}
+
+// Kinds of data subject requests.
+const (
+	SubjectExport  = "export"
+	SubjectErasure = "erasure"
+)
+
+// SubjectRequest - record of export or erasure of data held about user, it's kept after erasure.
+type SubjectRequest struct {
+	RequestID int64 `db:"request_id" json:"request_id"`
+	// UserID - id of user at the time of request, erased user doesn't exist anymore.
+	UserID      int64  `db:"user_id" json:"user_id"`
+	Kind        string `db:"kind" json:"kind"`
+	RequestedBy string `db:"requested_by" json:"requested_by"` // name of admin
+	// Records - number of activity records exported or pseudonymized.
+	Records   int64 `db:"records" json:"records"`
+	CreatedAt int64 `db:"created_at" json:"created_at"`
+}
+
+// SubjectData - everything held about user, it's written to export archive with activity records
+// as separate NDJSON file.
+type SubjectData struct {
+	ExportedAt  int64         `json:"exported_at"`
+	User        *User         `json:"user"`
+	Memberships []*Membership `json:"memberships"`
+	// Schedule - own schedule of user, nil if user has schedule of department.
+	Schedule   *WorkSchedule `json:"schedule"`
+	TimeOffs   []*TimeOff    `json:"time_offs"`   // leaves of user, holidays aren't included
+	AlertRules []*AlertRule  `json:"alert_rules"` // rules watching user
+	// DailyActivity - activity by UTC days, including time of archived records.
+	DailyActivity []*DailyActivity  `json:"daily_activity"`
+	Requests      []*SubjectRequest `json:"requests"` // earlier export and erasure requests of user
+	Activities    []*Activity       `json:"-"`
+}
+
+// ImportRow - row of users import: user is created in department matched by name, or in new department.
+// Row without user name only creates or matches department.
+type ImportRow struct {
+	Line           int    `json:"line"` // number of row in imported file, header is row 1
+	DepartmentName string `json:"department_name"`
+	UserName       string `json:"user_name"`
+	Error          string `json:"error,omitempty"` // parse error, row isn't imported
+}
+
+// Import statuses of department.
+const (
+	ImportCreated = "created"
+	ImportMatched = "matched"
+)
+
+// ImportRowResult - result of single import row, IDs of created objects are zero in dry run.
+type ImportRowResult struct {
+	Line         int    `json:"line"`
+	DepartmentID int64  `json:"department_id,omitempty"`
+	Department   string `json:"department,omitempty"` // created or matched
+	UserID       int64  `json:"user_id,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ImportResult - result of users import. Import is committed only if every row succeeded.
+type ImportResult struct {
+	DryRun             bool               `json:"dry_run"`
+	Committed          bool               `json:"committed"`
+	DepartmentsCreated int                `json:"departments_created"`
+	DepartmentsMatched int                `json:"departments_matched"`
+	UsersCreated       int                `json:"users_created"`
+	Failed             int                `json:"failed"`
+	Rows               []*ImportRowResult `json:"rows"`
+}
+
+// Types of live feed events.
+const (
+	EventActivity = "activity" // activity record was created
+	EventTotals   = "totals"   // running totals of subscription
+)
+
+// Event - event of live activity feed.
+type Event struct {
+	ID   int64  `json:"id"` // sequence number of event in connection
+	Type string `json:"type"`
+	Time int64  `json:"time"` // unix time event was sent
+	// Activity - created record and department of its user, set for activity event.
+	Activity     *Activity `json:"activity,omitempty"`
+	DepartmentID int64     `json:"department_id,omitempty"`
+	// UserTotals - totals of user subscription, DepartmentTotals - of department or of every department.
+	UserTotals       *UserActivity         `json:"user_totals,omitempty"`
+	DepartmentTotals []*DepartmentActivity `json:"department_totals,omitempty"`
+	// Dropped - number of events dropped before this one, because client didn't read them in time.
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// Types of domain events sent to webhooks.
+const (
+	WebhookUserCreated     = "user.created"
+	WebhookUserDeleted     = "user.deleted"
+	WebhookActivityCreated = "activity.created"
+	WebhookAlertFiring     = "alert.firing"
+	WebhookAlertResolved   = "alert.resolved"
+)
+
+// Webhook - subscription of external system to domain events.
+type Webhook struct {
+	WebhookID int64  `db:"webhook_id" json:"webhook_id"`
+	URL       string `db:"url" json:"url"`
+	// Secret - key of payload HMAC signature, it's returned only when webhook is created.
+	Secret string `db:"secret" json:"secret,omitempty"`
+	// Events - comma separated types of events sent to webhook, empty - all events.
+	Events    string `db:"events" json:"events"`
+	CreatedAt int64  `db:"created_at" json:"created_at"`
+}
+
+// Statuses of webhook delivery.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // all attempts failed, delivery is in dead-letter list until redelivered
+)
+
+// WebhookDelivery - domain event queued for sending to webhook.
+type WebhookDelivery struct {
+	DeliveryID int64  `db:"delivery_id" json:"delivery_id"`
+	WebhookID  int64  `db:"webhook_id" json:"webhook_id"`
+	Event      string `db:"event" json:"event"`
+	Payload    string `db:"payload" json:"payload"` // json body sent to webhook
+	Status     string `db:"status" json:"status"`
+	Attempts   int    `db:"attempts" json:"attempts"`
+	// NextAttempt - unix time of next attempt of pending delivery.
+	NextAttempt int64  `db:"next_attempt" json:"next_attempt"`
+	LastError   string `db:"last_error" json:"last_error,omitempty"`
+	// LastStatus - http status code of last attempt, 0 if webhook didn't respond.
+	LastStatus int   `db:"last_status" json:"last_status,omitempty"`
+	CreatedAt  int64 `db:"created_at" json:"created_at"`
+	// URL and Secret of webhook, selected only for dispatch.
+	URL    string `db:"url" json:"-"`
+	Secret string `db:"secret" json:"-"`
+}
+
+// WebhookPayload - body of webhook request.
+type WebhookPayload struct {
+	Event string      `json:"event"`
+	Time  int64       `json:"time"` // unix time of event
+	Data  interface{} `json:"data"`
+}
+
+// Kinds of alert rules.
+const (
+	AlertUserRatio      = "user_ratio"      // active/total time ratio of user is below threshold
+	AlertDepartmentDrop = "department_drop" // department total time dropped by threshold part against previous period
+)
+
+// States of alert rule.
+const (
+	AlertOK     = "ok"
+	AlertFiring = "firing"
+)
+
+// Notifiers of alert rule state changes.
+const (
+	AlertNotifierLog     = "log"
+	AlertNotifierWebhook = "webhook" // alert.firing and alert.resolved events are sent to webhooks
+)
+
+// AlertRule - rule evaluated periodically against user or department activity.
+type AlertRule struct {
+	RuleID int64  `db:"rule_id" json:"rule_id"`
+	Name   string `db:"name" json:"name"`
+	Kind   string `db:"kind" json:"kind"`
+	// TargetID - id of user or department, depends on kind.
+	TargetID int64 `db:"target_id" json:"target_id"`
+	// Threshold - min active/total ratio of user, or max part of department total time drop, from 0 to 1.
+	Threshold float64 `db:"threshold" json:"threshold"`
+	// Period - seconds of evaluated period, department time is compared with the same period before it.
+	Period int64 `db:"period" json:"period"`
+	// Notifiers - comma separated notifiers of state changes, empty - log.
+	Notifiers string `db:"notifiers" json:"notifiers"`
+	State     string `db:"state" json:"state"`
+	// Value - ratio or drop of last evaluation, EvaluatedAt - its unix time, ChangedAt - unix time of last state change.
+	Value       float64 `db:"value" json:"value"`
+	EvaluatedAt int64   `db:"evaluated_at" json:"evaluated_at"`
+	ChangedAt   int64   `db:"changed_at" json:"changed_at"`
+	CreatedAt   int64   `db:"created_at" json:"created_at"`
+}
+
+// Alert - notification about state change of alert rule.
+type Alert struct {
+	Rule  *AlertRule `json:"rule"`
+	State string     `json:"state"` // firing or resolved (ok)
+	Value float64    `json:"value"`
+	Time  int64      `json:"time"`
+}
+
+// Triggers of job run.
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Statuses of job run.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobSkipped   = "skipped" // scheduled run is skipped, because previous run of the job isn't finished
+)
+
+// Job - background job registered in scheduler.
+type Job struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"` // cron expression, descriptor or @every interval
+	Running  bool   `json:"running"`
+	// NextRun - unix time of next scheduled run, 0 if scheduler isn't running.
+	NextRun int64   `json:"next_run"`
+	LastRun *JobRun `json:"last_run,omitempty"`
+	// History - recent runs, newest first. It isn't returned in jobs list.
+	History []*JobRun `json:"history,omitempty"`
+}
+
+// JobRun - single run of background job.
+type JobRun struct {
+	Job     string `json:"job"`
+	Trigger string `json:"trigger"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// StartedAt and FinishedAt - unix time, FinishedAt is 0 while job is running.
+	StartedAt  int64 `json:"started_at"`
+	FinishedAt int64 `json:"finished_at"`
 }
 
 // ObjectID - used when returning last inserted id or rows affected.
 type ObjectID struct {
-	ID int64
+	ID int64 `json:"id"`
+}
+
+// Tokens - access and refresh tokens returned on login and refresh.
+type Tokens struct {
+	AccessToken  string `json:"access_token" legacy:"access_token"`
+	RefreshToken string `json:"refresh_token" legacy:"refresh_token"`
+}
+
+// Status - health probe response.
+type Status struct {
+	Status string `json:"status"`
+}
+
+// Error - error response of legacy api, api with version prefix responds with Envelope.
+type Error struct {
+	Error string
+}
+
+// Envelope - response of api: either data or error, and meta.
+type Envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *ErrorBody  `json:"error,omitempty"`
+	Meta  *Meta       `json:"meta"`
+}
+
+// ErrorBody - error of request.
+type ErrorBody struct {
+	Code    int    `json:"code"` // http status code
+	Message string `json:"message"`
+}
+
+// Meta - response metadata.
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination - page of list response. Limit 0 means all records after offset.
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
 }
@@ -0,0 +1,138 @@
+package privacy
+
+import (
+	"activity_api/common/models"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// SubjectFile, ActivityFile - files of export archive: everything held about user except activity records,
+	// and activity records with their apps, one JSON object per line.
+	SubjectFile  = "subject.json"
+	ActivityFile = "activity.ndjson"
+	// ContentType - content type of export archive.
+	ContentType = "application/zip"
+)
+
+// Store - users and data held about them.
+type Store interface {
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	GetMemberships(ctx context.Context, userID string) ([]*models.Membership, error)
+	GetWorkSchedule(ctx context.Context, userID, departmentID int64) (*models.WorkSchedule, error)
+	GetTimeOffs(ctx context.Context, userID string) ([]*models.TimeOff, error)
+	GetAlertRules(ctx context.Context) ([]*models.AlertRule, error)
+	GetActivityDays(ctx context.Context, userID string) ([]*models.DailyActivity, error)
+	GetSubjectRequests(ctx context.Context, userID string) ([]*models.SubjectRequest, error)
+	GetUserActivities(ctx context.Context, userID string) ([]*models.Activity, error)
+}
+
+// Collect - returns everything held about user with given ID, nil if user doesn't exist.
+// Holidays and department schedules aren't included, they aren't data about user.
+func Collect(ctx context.Context, store Store, userID string, now int64) (*models.SubjectData, error) {
+	user, err := store.GetUser(ctx, userID)
+
+	if err != nil {
+		return nil, fmt.Errorf("store.GetUser(): %w", err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	data := &models.SubjectData{ExportedAt: now, User: user}
+
+	if data.Memberships, err = store.GetMemberships(ctx, userID); err != nil {
+		return nil, fmt.Errorf("store.GetMemberships(): %w", err)
+	}
+
+	if data.Schedule, err = store.GetWorkSchedule(ctx, user.UserID, 0); err != nil {
+		return nil, fmt.Errorf("store.GetWorkSchedule(): %w", err)
+	}
+
+	timeOffs, err := store.GetTimeOffs(ctx, userID)
+
+	if err != nil {
+		return nil, fmt.Errorf("store.GetTimeOffs(): %w", err)
+	}
+
+	data.TimeOffs = make([]*models.TimeOff, 0, len(timeOffs))
+
+	for _, timeOff := range timeOffs {
+		if timeOff.UserID == user.UserID {
+			data.TimeOffs = append(data.TimeOffs, timeOff)
+		}
+	}
+
+	rules, err := store.GetAlertRules(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("store.GetAlertRules(): %w", err)
+	}
+
+	data.AlertRules = make([]*models.AlertRule, 0)
+
+	for _, rule := range rules {
+		if rule.Kind == models.AlertUserRatio && rule.TargetID == user.UserID {
+			data.AlertRules = append(data.AlertRules, rule)
+		}
+	}
+
+	if data.DailyActivity, err = store.GetActivityDays(ctx, userID); err != nil {
+		return nil, fmt.Errorf("store.GetActivityDays(): %w", err)
+	}
+
+	if data.Requests, err = store.GetSubjectRequests(ctx, userID); err != nil {
+		return nil, fmt.Errorf("store.GetSubjectRequests(): %w", err)
+	}
+
+	if data.Activities, err = store.GetUserActivities(ctx, userID); err != nil {
+		return nil, fmt.Errorf("store.GetUserActivities(): %w", err)
+	}
+
+	return data, nil
+}
+
+// FileName - returns name of export archive of user.
+func FileName(userID int64) string {
+	return "user-" + strconv.FormatInt(userID, 10) + "-export.zip"
+}
+
+// WriteArchive - writes zip archive of given data to w.
+func WriteArchive(w io.Writer, data *models.SubjectData) error {
+	archive := zip.NewWriter(w)
+	file, err := archive.Create(SubjectFile)
+
+	if err != nil {
+		return fmt.Errorf("archive.Create(), %s: %w", SubjectFile, err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("Encode(), %s: %w", SubjectFile, err)
+	}
+
+	if file, err = archive.Create(ActivityFile); err != nil {
+		return fmt.Errorf("archive.Create(), %s: %w", ActivityFile, err)
+	}
+
+	encoder = json.NewEncoder(file)
+
+	for _, activity := range data.Activities {
+		if err := encoder.Encode(activity); err != nil {
+			return fmt.Errorf("Encode(), %s: %w", ActivityFile, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("archive.Close(): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package privacy
+
+import (
+	"activity_api/common/models"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+// fakeStore - data of users 1 and 2, user 2 has no data except its profile.
+type fakeStore struct{}
+
+func (fakeStore) GetUser(_ context.Context, userID string) (*models.User, error) {
+	if userID != "1" && userID != "2" {
+		return nil, nil
+	}
+
+	id, _ := strconv.ParseInt(userID, 10, 64)
+
+	return &models.User{UserID: id, UserName: "user " + userID, Email: "user@example.com"}, nil
+}
+
+func (fakeStore) GetMemberships(_ context.Context, userID string) ([]*models.Membership, error) {
+	return []*models.Membership{{UserID: 1, DepartmentID: 1}}, nil
+}
+
+func (fakeStore) GetWorkSchedule(_ context.Context, userID, _ int64) (*models.WorkSchedule, error) {
+	if userID != 1 {
+		return nil, nil
+	}
+
+	return &models.WorkSchedule{UserID: 1, Monday: 3600}, nil
+}
+
+func (fakeStore) GetTimeOffs(_ context.Context, userID string) ([]*models.TimeOff, error) {
+	return []*models.TimeOff{
+		{TimeOffID: 1, From: "2026-01-01", To: "2026-01-01"}, // holiday
+		{TimeOffID: 2, UserID: 1, From: "2026-02-01", To: "2026-02-10"},
+	}, nil
+}
+
+func (fakeStore) GetAlertRules(context.Context) ([]*models.AlertRule, error) {
+	return []*models.AlertRule{
+		{RuleID: 1, Kind: models.AlertUserRatio, TargetID: 1},
+		{RuleID: 2, Kind: models.AlertUserRatio, TargetID: 2},
+		{RuleID: 3, Kind: models.AlertDepartmentDrop, TargetID: 1},
+	}, nil
+}
+
+func (fakeStore) GetActivityDays(context.Context, string) ([]*models.DailyActivity, error) {
+	return []*models.DailyActivity{{Day: 1, TotalTime: 20, ActiveTime: 10}}, nil
+}
+
+func (fakeStore) GetSubjectRequests(context.Context, string) ([]*models.SubjectRequest, error) {
+	return []*models.SubjectRequest{{RequestID: 1, UserID: 1, Kind: models.SubjectExport}}, nil
+}
+
+func (fakeStore) GetUserActivities(context.Context, string) ([]*models.Activity, error) {
+	return []*models.Activity{
+		{RecordID: 1, UserID: 1, TotalTime: 10, ActiveTime: 5, Date: 86400},
+		{RecordID: 2, UserID: 1, TotalTime: 10, ActiveTime: 5, Date: 86401, Apps: []*models.AppTime{{App: "vim", Time: 5}}},
+	}, nil
+}
+
+func Test_Collect(t *testing.T) {
+	ctx := context.Background()
+
+	if data, err := Collect(ctx, fakeStore{}, "3", 100); err != nil || data != nil {
+		t.Fatalf("Data of unknown user: %+v, error: %v", data, err)
+	}
+
+	data, err := Collect(ctx, fakeStore{}, "1", 100)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.ExportedAt != 100 || data.User.UserID != 1 || data.Schedule == nil || len(data.Activities) != 2 {
+		t.Fatalf("Unexpected data: %+v", data)
+	}
+
+	if len(data.TimeOffs) != 1 || data.TimeOffs[0].TimeOffID != 2 {
+		t.Errorf("Only leaves of user expected, got: %+v", data.TimeOffs)
+	}
+
+	if len(data.AlertRules) != 1 || data.AlertRules[0].RuleID != 1 {
+		t.Errorf("Only rules watching user expected, got: %+v", data.AlertRules)
+	}
+}
+
+func Test_WriteArchive(t *testing.T) {
+	data, err := Collect(context.Background(), fakeStore{}, "1", 100)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := new(bytes.Buffer)
+
+	if err := WriteArchive(buffer, data); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archive.File) != 2 || archive.File[0].Name != SubjectFile || archive.File[1].Name != ActivityFile {
+		t.Fatalf("Unexpected archive files: %+v", archive.File)
+	}
+
+	subject, err := archive.File[0].Open()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer subject.Close()
+	decoded := new(models.SubjectData)
+
+	if err := json.NewDecoder(subject).Decode(decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.User.Email != data.User.Email || len(decoded.Requests) != 1 || len(decoded.DailyActivity) != 1 {
+		t.Errorf("Unexpected %s: %+v", SubjectFile, decoded)
+	}
+
+	activity, err := archive.File[1].Open()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer activity.Close()
+	scanner := bufio.NewScanner(activity)
+	records := make([]*models.Activity, 0)
+
+	for scanner.Scan() {
+		record := new(models.Activity)
+
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) != 2 || records[1].RecordID != 2 || len(records[1].Apps) != 1 {
+		t.Errorf("Unexpected %s records: %+v", ActivityFile, records)
+	}
+}
@@ -0,0 +1,409 @@
+package retention
+
+import (
+	"activity_api/common/models"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ArchiveExt, ManifestExt - extensions of archive and of its manifest, they share the same name.
+	ArchiveExt  = ".ndjson.gz"
+	ManifestExt = ".manifest.json"
+	// day - length of retention day in seconds, days are days of UTC like days of rollups.
+	day = int64(24 * time.Hour / time.Second)
+	// restoreBatch - number of records restored in one transaction.
+	restoreBatch = 1000
+	// nameLayout - layout of archive creation time in its name.
+	nameLayout = "20060102T150405.000000000Z"
+)
+
+// ErrCorruptedArchive - archive doesn't match its manifest.
+var ErrCorruptedArchive = errors.New("archive doesn't match manifest")
+
+// Store - retention policies, users and activity records they are applied to.
+type Store interface {
+	GetRetentionPolicies(ctx context.Context) ([]*models.RetentionPolicy, error)
+	GetDepartments(ctx context.Context) ([]*models.Department, error)
+	GetUsers(ctx context.Context, depID string) ([]*models.User, error)
+	GetArchivableActivity(ctx context.Context, userID string, before int64) ([]*models.Activity, error)
+	ArchiveActivity(ctx context.Context, userID string, before, lastRecordID int64) (int64, error)
+	RestoreActivity(ctx context.Context, activities []*models.Activity) (int64, error)
+}
+
+// Archiver - archives activity records older than retention policies to compressed files and deletes them,
+// it's run periodically by job scheduler. Archives of tenant are written to its own subdirectory.
+type Archiver struct {
+	store  Store
+	dir    string
+	logger logrus.FieldLogger
+}
+
+// NewArchiver - returns new archiver of records from given store to given directory.
+func NewArchiver(store Store, dir string, logger logrus.FieldLogger) *Archiver {
+	return &Archiver{
+		store:  store,
+		dir:    dir,
+		logger: logger.WithField("module", "Archiver"),
+	}
+}
+
+// Cutoff - returns unix time of the oldest kept record by policy of given days at given time:
+// start of UTC day days-1 days before today.
+func Cutoff(days int64, now time.Time) int64 {
+	return (now.Unix()/day - days + 1) * day
+}
+
+// Resolve - returns policy of user of given department: policy of the nearest department up the tree,
+// or policy of tenant. Nil if neither has policy.
+func Resolve(departmentID int64, policies []*models.RetentionPolicy, departs []*models.Department) *models.RetentionPolicy {
+	byDepartment := make(map[int64]*models.RetentionPolicy, len(policies))
+
+	for _, policy := range policies {
+		byDepartment[policy.DepartmentID] = policy
+	}
+
+	parents := make(map[int64]int64, len(departs))
+
+	for _, depart := range departs {
+		parents[depart.DepartmentID] = depart.ParentID
+	}
+	// Depth is limited by number of departments, so broken tree doesn't loop forever.
+	for i := 0; departmentID != 0 && i <= len(departs); i++ {
+		if policy, ok := byDepartment[departmentID]; ok {
+			return policy
+		}
+
+		departmentID = parents[departmentID]
+	}
+
+	return byDepartment[0]
+}
+
+// batch - archived records of single user.
+type batch struct {
+	userID       string
+	before       int64
+	lastRecordID int64
+}
+
+// Archive - writes records of tenant of context older than their policies at given time to new archive
+// with manifest, then deletes them. Returns manifest, nil if there was nothing to archive.
+// Records are deleted only after archive is written, so failed run only leaves extra archive:
+// records are archived again by next run, and restore skips records which exist.
+func (a *Archiver) Archive(ctx context.Context, tenantID int64, now time.Time) (*models.ArchiveManifest, error) {
+	entry := a.logger.WithField("func", "Archive").WithField("tenant", tenantID)
+	policies, err := a.store.GetRetentionPolicies(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetRetentionPolicies(): %w", err)
+	}
+
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	departs, err := a.store.GetDepartments(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetDepartments(): %w", err)
+	}
+
+	users, err := a.store.GetUsers(ctx, "")
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUsers(): %w", err)
+	}
+
+	dir := filepath.Join(a.dir, "tenant-"+strconv.FormatInt(tenantID, 10))
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll(): %w", err)
+	}
+
+	name := "activity-" + now.UTC().Format(nameLayout)
+	manifest := &models.ArchiveManifest{File: name + ArchiveExt, TenantID: tenantID, CreatedAt: now.Unix()}
+	batches, err := a.write(ctx, filepath.Join(dir, manifest.File), manifest, users, func(user *models.User) int64 {
+		if policy := Resolve(user.DepartmentID, policies, departs); policy != nil {
+			return Cutoff(policy.Days, now)
+		}
+
+		return 0
+	})
+
+	if err != nil || len(batches) == 0 {
+		return nil, err
+	}
+
+	if err := writeManifest(filepath.Join(dir, name+ManifestExt), manifest); err != nil {
+		return nil, err
+	}
+
+	for _, batch := range batches {
+		deleted, err := a.store.ArchiveActivity(ctx, batch.userID, batch.before, batch.lastRecordID)
+
+		if err != nil {
+			return manifest, fmt.Errorf("ArchiveActivity() user %s: %w", batch.userID, err)
+		}
+
+		entry.Debugf("User %s: %d records archived", batch.userID, deleted)
+	}
+
+	entry.Infof("Archived %d records to %s", manifest.Records, manifest.File)
+	return manifest, nil
+}
+
+// write - writes records of users before their cutoff to archive at given path, cutoff 0 - user has no policy.
+// Returns batches of written records, archive isn't created if there are no records.
+func (a *Archiver) write(
+	ctx context.Context,
+	path string,
+	manifest *models.ArchiveManifest,
+	users []*models.User,
+	cutoff func(*models.User) int64,
+) ([]*batch, error) {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.TempFile(): %w", err)
+	}
+	// Temp file is removed unless it's renamed to archive.
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	compressor := gzip.NewWriter(io.MultiWriter(file, hash))
+	encoder := json.NewEncoder(compressor)
+	batches := make([]*batch, 0)
+
+	for _, user := range users {
+		before := cutoff(user)
+
+		if before == 0 {
+			continue
+		}
+
+		userID := strconv.FormatInt(user.UserID, 10)
+		activities, err := a.store.GetArchivableActivity(ctx, userID, before)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetArchivableActivity() user %s: %w", userID, err)
+		}
+
+		if len(activities) == 0 {
+			continue
+		}
+
+		for _, activity := range activities {
+			if err := encoder.Encode(activity); err != nil {
+				return nil, fmt.Errorf("Encode(): %w", err)
+			}
+
+			if manifest.Records == 0 || activity.Date < manifest.DateFrom {
+				manifest.DateFrom = activity.Date
+			}
+
+			if manifest.Records == 0 || activity.Date > manifest.DateTo {
+				manifest.DateTo = activity.Date
+			}
+
+			manifest.Records++
+		}
+
+		batches = append(batches, &batch{
+			userID:       userID,
+			before:       before,
+			lastRecordID: activities[len(activities)-1].RecordID,
+		})
+	}
+
+	if len(batches) == 0 {
+		return nil, nil
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, fmt.Errorf("gzip Close(): %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("Sync(): %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("Close(): %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return nil, fmt.Errorf("os.Rename(): %w", err)
+	}
+
+	manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return batches, nil
+}
+
+// writeManifest - writes manifest to given path through temp file, so manifest is either complete or missing.
+func writeManifest(path string, manifest *models.ArchiveManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent(): %w", err)
+	}
+
+	if err := ioutil.WriteFile(path+".tmp", data, 0640); err != nil {
+		return fmt.Errorf("ioutil.WriteFile(): %w", err)
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("os.Rename(): %w", err)
+	}
+
+	return nil
+}
+
+// ReadManifest - reads manifest of archive at given path.
+func ReadManifest(archivePath string) (*models.ArchiveManifest, error) {
+	data, err := ioutil.ReadFile(strings.TrimSuffix(archivePath, ArchiveExt) + ManifestExt)
+
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile(): %w", err)
+	}
+
+	manifest := new(models.ArchiveManifest)
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal(): %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Restore - loads records of archive at given path back to tenant of context, it must be tenant of manifest.
+// Archive is checked against manifest before anything is restored. Records are restored in batches,
+// records which exist are skipped, so interrupted restore could be run again. Returns number of restored records.
+func (a *Archiver) Restore(ctx context.Context, archivePath string, manifest *models.ArchiveManifest) (int64, error) {
+	entry := a.logger.WithField("func", "Restore").WithField("tenant", manifest.TenantID)
+
+	if err := verify(archivePath, manifest); err != nil {
+		return 0, err
+	}
+
+	file, err := os.Open(archivePath)
+
+	if err != nil {
+		return 0, fmt.Errorf("os.Open(): %w", err)
+	}
+
+	defer file.Close()
+
+	decompressor, err := gzip.NewReader(bufio.NewReader(file))
+
+	if err != nil {
+		return 0, fmt.Errorf("gzip.NewReader(): %w", err)
+	}
+
+	decoder := json.NewDecoder(decompressor)
+	activities := make([]*models.Activity, 0, restoreBatch)
+	var restored int64
+
+	flush := func() error {
+		count, err := a.store.RestoreActivity(ctx, activities)
+
+		if err != nil {
+			return fmt.Errorf("RestoreActivity(): %w", err)
+		}
+
+		restored += count
+		activities = activities[:0]
+
+		return nil
+	}
+
+	for {
+		activity := new(models.Activity)
+
+		if err := decoder.Decode(activity); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return restored, fmt.Errorf("Decode(): %w", err)
+		}
+
+		if activities = append(activities, activity); len(activities) == restoreBatch {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return restored, err
+	}
+
+	entry.Infof("Restored %d of %d records from %s", restored, manifest.Records, manifest.File)
+	return restored, nil
+}
+
+// verify - checks checksum and number of records of archive against its manifest.
+func verify(archivePath string, manifest *models.ArchiveManifest) error {
+	file, err := os.Open(archivePath)
+
+	if err != nil {
+		return fmt.Errorf("os.Open(): %w", err)
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("io.Copy(): %w", err)
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != manifest.SHA256 {
+		return fmt.Errorf("%w: checksum %s, expected %s", ErrCorruptedArchive, checksum, manifest.SHA256)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Seek(): %w", err)
+	}
+
+	decompressor, err := gzip.NewReader(bufio.NewReader(file))
+
+	if err != nil {
+		return fmt.Errorf("gzip.NewReader(): %w", err)
+	}
+
+	var records int64
+	scanner := bufio.NewScanner(decompressor)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		records++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Scan(): %w", err)
+	}
+
+	if records != manifest.Records {
+		return fmt.Errorf("%w: %d records, expected %d", ErrCorruptedArchive, records, manifest.Records)
+	}
+
+	return nil
+}
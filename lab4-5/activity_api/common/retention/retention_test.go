@@ -0,0 +1,226 @@
+package retention
+
+import (
+	"activity_api/common/models"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// now - 2026-10-19 15:30 UTC.
+var now = time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+
+// fakeStore - store with records of single tenant in memory.
+type fakeStore struct {
+	policies []*models.RetentionPolicy
+	departs  []*models.Department
+	users    []*models.User
+	records  map[int64]*models.Activity
+}
+
+func (f *fakeStore) GetRetentionPolicies(context.Context) ([]*models.RetentionPolicy, error) {
+	return f.policies, nil
+}
+
+func (f *fakeStore) GetDepartments(context.Context) ([]*models.Department, error) {
+	return f.departs, nil
+}
+
+func (f *fakeStore) GetUsers(context.Context, string) ([]*models.User, error) {
+	return f.users, nil
+}
+
+func (f *fakeStore) GetArchivableActivity(_ context.Context, userID string, before int64) ([]*models.Activity, error) {
+	activities := make([]*models.Activity, 0)
+
+	for _, record := range f.records {
+		if strconv.FormatInt(record.UserID, 10) == userID && record.Date < before {
+			activities = append(activities, record)
+		}
+	}
+
+	sort.Slice(activities, func(i, j int) bool { return activities[i].RecordID < activities[j].RecordID })
+
+	return activities, nil
+}
+
+func (f *fakeStore) ArchiveActivity(_ context.Context, userID string, before, lastRecordID int64) (int64, error) {
+	var deleted int64
+
+	for id, record := range f.records {
+		if strconv.FormatInt(record.UserID, 10) == userID && record.Date < before && id <= lastRecordID {
+			delete(f.records, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (f *fakeStore) RestoreActivity(_ context.Context, activities []*models.Activity) (int64, error) {
+	var restored int64
+
+	for _, activity := range activities {
+		if _, ok := f.records[activity.RecordID]; !ok {
+			f.records[activity.RecordID] = activity
+			restored++
+		}
+	}
+
+	return restored, nil
+}
+
+func newArchiver(store Store, dir string) *Archiver {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	return NewArchiver(store, dir, logger)
+}
+
+func Test_Cutoff(t *testing.T) {
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC).Unix()
+
+	for days, expected := range map[int64]int64{1: today, 2: today - day, 30: today - 29*day} {
+		if cutoff := Cutoff(days, now); cutoff != expected {
+			t.Errorf("Cutoff of %d days: %d, expected %d", days, cutoff, expected)
+		}
+	}
+}
+
+func Test_Resolve(t *testing.T) {
+	departs := []*models.Department{
+		{DepartmentID: 1},
+		{DepartmentID: 2, ParentID: 1},
+		{DepartmentID: 3, ParentID: 2},
+		{DepartmentID: 4},
+	}
+	tenant := &models.RetentionPolicy{PolicyID: 1, Days: 365}
+	department := &models.RetentionPolicy{PolicyID: 2, DepartmentID: 2, Days: 30}
+
+	for departmentID, expected := range map[int64]*models.RetentionPolicy{
+		1: tenant,
+		2: department,
+		3: department, // policy of parent
+		4: tenant,
+	} {
+		if policy := Resolve(departmentID, []*models.RetentionPolicy{tenant, department}, departs); policy != expected {
+			t.Errorf("Department %d: policy %+v, expected %+v", departmentID, policy, expected)
+		}
+	}
+
+	if policy := Resolve(3, []*models.RetentionPolicy{department}, departs); policy != department {
+		t.Errorf("Policy of grandparent expected, got %+v", policy)
+	}
+
+	if policy := Resolve(4, []*models.RetentionPolicy{department}, departs); policy != nil {
+		t.Errorf("No policy expected, got %+v", policy)
+	}
+}
+
+func Test_ArchiveRestore(t *testing.T) {
+	store := &fakeStore{
+		policies: []*models.RetentionPolicy{{DepartmentID: 1, Days: 10}},
+		departs:  []*models.Department{{DepartmentID: 1}, {DepartmentID: 2}},
+		users:    []*models.User{{UserID: 1, DepartmentID: 1}, {UserID: 2, DepartmentID: 2}},
+		records:  make(map[int64]*models.Activity),
+	}
+	cutoff := Cutoff(10, now)
+	dates := []int64{cutoff - 3*day, cutoff - 1, cutoff, now.Unix()}
+
+	for i, date := range dates {
+		for _, userID := range []int64{1, 2} {
+			id := int64(len(store.records) + 1)
+			store.records[id] = &models.Activity{RecordID: id, UserID: userID, TotalTime: int64(i), Date: date}
+		}
+	}
+
+	store.records[1].Apps = []*models.AppTime{{App: "code", Time: 1}}
+	dir := t.TempDir()
+	archiver := newArchiver(store, dir)
+	manifest, err := archiver.Archive(context.Background(), 7, now)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only records of user with policy before cutoff are archived.
+	if manifest == nil || manifest.Records != 2 || manifest.DateFrom != dates[0] || manifest.DateTo != dates[1] {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+
+	if _, ok := store.records[1]; ok || len(store.records) != 6 {
+		t.Fatalf("Archived records aren't deleted: %d left", len(store.records))
+	}
+
+	path := filepath.Join(dir, "tenant-7", manifest.File)
+	read, err := ReadManifest(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *read != *manifest {
+		t.Fatalf("Manifest %+v is read as %+v", *manifest, *read)
+	}
+
+	if again, err := archiver.Archive(context.Background(), 7, now); err != nil || again != nil {
+		t.Fatalf("Nothing to archive expected, got %+v, error: %v", again, err)
+	}
+
+	for i, expected := range []int64{2, 0} {
+		restored, err := archiver.Restore(context.Background(), path, read)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if restored != expected {
+			t.Fatalf("Restore %d: %d records restored, expected %d", i+1, restored, expected)
+		}
+	}
+
+	if apps := store.records[1].Apps; len(apps) != 1 || *apps[0] != (models.AppTime{App: "code", Time: 1}) {
+		t.Fatalf("Apps of restored record: %+v", apps)
+	}
+
+	t.Run("Corrupted", func(t *testing.T) {
+		data, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data[len(data)/2] ^= 0xff
+
+		if err := ioutil.WriteFile(path, data, 0640); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := archiver.Restore(context.Background(), path, read); !errors.Is(err, ErrCorruptedArchive) {
+			t.Fatalf("ErrCorruptedArchive expected, got %v", err)
+		}
+	})
+
+	t.Run("No_temp_files", func(t *testing.T) {
+		files, err := ioutil.ReadDir(filepath.Join(dir, "tenant-7"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, file := range files {
+			if filepath.Ext(file.Name()) == ".tmp" {
+				t.Errorf("Temp file is left: %s", file.Name())
+			}
+		}
+
+		if len(files) != 2 {
+			t.Errorf("Archive and manifest expected, got %d files", len(files))
+		}
+	})
+}
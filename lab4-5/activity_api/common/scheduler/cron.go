@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch - schedule which doesn't match any time in this period is considered never matching,
+// e.g. "0 0 30 2 *".
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule - returns next activation time after given one, zero time if there is none.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// descriptors - shortcuts of common cron expressions.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field - bounds of cron expression field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 6},
+}
+
+// Parse - parses schedule spec: cron expression of 5 fields "minute hour day-of-month month day-of-week",
+// one of descriptors @yearly, @monthly, @weekly, @daily, @hourly, or interval "@every <duration>".
+// Field is "*", value, range "a-b", step "*/n" or "a-b/n", or comma separated list of them.
+// Day of week is 0-6, Sunday is 0 (7 is accepted as Sunday too).
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+
+		if err != nil {
+			return nil, fmt.Errorf("time.ParseDuration(): %w", err)
+		}
+
+		if every < time.Second {
+			return nil, fmt.Errorf("interval must be at least 1s, got %s", every)
+		}
+
+		return &everySchedule{every: every}, nil
+	}
+
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	parts := strings.Fields(spec)
+
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%d fields expected, got %d in %q", len(fields), len(parts), spec)
+	}
+
+	sets := make([]uint64, len(fields))
+
+	for i, part := range parts {
+		// Sunday could be written as 7, it's folded to 0 after parsing.
+		bounds := fields[i]
+
+		if i == 4 {
+			bounds.max = 7
+		}
+
+		set, err := parseField(part, bounds)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fields[i].name, err)
+		}
+
+		sets[i] = set
+	}
+
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField - returns bit set of values matched by field.
+func parseField(value string, bounds field) (uint64, error) {
+	var set uint64
+
+	for _, item := range strings.Split(value, ",") {
+		rangePart, step := item, 1
+
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			parsed, err := strconv.Atoi(item[i+1:])
+
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+
+			rangePart, step = item[:i], parsed
+		}
+
+		from, to := bounds.min, bounds.max
+
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			i := strings.IndexByte(rangePart, '-')
+			start, err := strconv.Atoi(rangePart[:i])
+
+			if err != nil {
+				return 0, fmt.Errorf("invalid range in %q", item)
+			}
+
+			end, err := strconv.Atoi(rangePart[i+1:])
+
+			if err != nil {
+				return 0, fmt.Errorf("invalid range in %q", item)
+			}
+
+			from, to = start, end
+		default:
+			parsed, err := strconv.Atoi(rangePart)
+
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", item)
+			}
+			// Single value with step means range from the value to max, like in cron.
+			from = parsed
+
+			if step == 1 {
+				to = parsed
+			}
+		}
+
+		if from < bounds.min || to > bounds.max || from > to {
+			return 0, fmt.Errorf("%q is out of range %d-%d", item, bounds.min, bounds.max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// cronSchedule - bit sets of matched values of every field.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Like in cron, if both day fields are restricted, day matches if any of them matches.
+	domStar, dowStar bool
+}
+
+// Next - returns first matching minute after given time, in location of given time.
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+
+			continue
+		}
+
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches - returns true if day of month and day of week of given time match schedule.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// everySchedule - activates with fixed interval.
+type everySchedule struct {
+	every time.Duration
+}
+
+// Next - returns given time plus interval, rounded down to second.
+func (e *everySchedule) Next(t time.Time) time.Time {
+	return t.Add(e.every).Truncate(time.Second)
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	base := time.Date(2021, time.March, 10, 14, 37, 20, 0, time.UTC) // Wednesday
+
+	cases := []struct {
+		name string
+		spec string
+		next time.Time
+	}{
+		{"Parse_everyMinute", "* * * * *", time.Date(2021, time.March, 10, 14, 38, 0, 0, time.UTC)},
+		{"Parse_step", "*/15 * * * *", time.Date(2021, time.March, 10, 14, 45, 0, 0, time.UTC)},
+		{"Parse_hourly", "@hourly", time.Date(2021, time.March, 10, 15, 0, 0, 0, time.UTC)},
+		{"Parse_daily", "@daily", time.Date(2021, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{"Parse_list", "5,40 14 * * *", time.Date(2021, time.March, 10, 14, 40, 0, 0, time.UTC)},
+		{"Parse_range", "0 9-17/4 * * *", time.Date(2021, time.March, 10, 17, 0, 0, 0, time.UTC)},
+		{"Parse_weekday", "30 3 * * 1-5", time.Date(2021, time.March, 11, 3, 30, 0, 0, time.UTC)},
+		{"Parse_sunday7", "0 0 * * 7", time.Date(2021, time.March, 14, 0, 0, 0, 0, time.UTC)},
+		{"Parse_month", "0 0 1 6 *", time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"Parse_leapDay", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields are restricted, so any of them matches: the 15th or Friday.
+		{"Parse_domOrDow", "0 0 15 * 5", time.Date(2021, time.March, 12, 0, 0, 0, 0, time.UTC)},
+		{"Parse_every", "@every 90s", time.Date(2021, time.March, 10, 14, 38, 50, 0, time.UTC)},
+		{"Parse_never", "0 0 30 2 *", time.Time{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			schedule, err := Parse(c.spec)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if next := schedule.Next(base); !next.Equal(c.next) {
+				t.Errorf("Next() of %q = %v, expected %v", c.spec, next, c.next)
+			}
+		})
+	}
+
+	t.Run("Parse_invalid", func(t *testing.T) {
+		for _, spec := range []string{
+			"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+			"*/0 * * * *", "5-1 * * * *", "a * * * *", "@every", "@every 10ms", "@every tomorrow", "@sometimes",
+		} {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Spec %q is parsed", spec)
+			}
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package scheduler
+
+import (
+	"activity_api/common/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistorySize = 20
+	// idleWait - wait of scheduler without jobs, it's woken up on registration anyway.
+	idleWait = time.Hour
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+	ErrStopped    = errors.New("scheduler isn't running")
+)
+
+// Job - periodic work, ctx is cancelled when scheduler is stopping.
+type Job func(ctx context.Context) error
+
+// task - registered job with its schedule and state.
+type task struct {
+	name     string
+	spec     string
+	schedule Schedule
+	job      Job
+	next     time.Time        // zero if scheduler isn't running or schedule never matches
+	running  bool             // job runs never overlap
+	history  []*models.JobRun // newest first
+}
+
+// Scheduler - runs registered jobs by their schedules or on demand. Run of a job is skipped
+// if its previous run isn't finished, every run is kept in job history.
+type Scheduler struct {
+	HistorySize int // runs kept in history of every job
+
+	tasks   map[string]*task
+	ctx     context.Context // context of Run, nil if scheduler isn't running
+	wake    chan struct{}   // wakes scheduler up when job is registered
+	mtx     sync.Mutex
+	running sync.WaitGroup // runs of jobs, Run waits for them before return
+	logger  logrus.FieldLogger
+}
+
+// NewScheduler - returns new scheduler without jobs.
+func NewScheduler(logger logrus.FieldLogger) *Scheduler {
+	return &Scheduler{
+		HistorySize: defaultHistorySize,
+		tasks:       make(map[string]*task),
+		wake:        make(chan struct{}, 1),
+		logger:      logger.WithField("module", "JobScheduler"),
+	}
+}
+
+// Register - adds job with given unique name and schedule spec, see Parse for spec format.
+func (s *Scheduler) Register(name, spec string, job Job) error {
+	schedule, err := Parse(spec)
+
+	if err != nil {
+		return fmt.Errorf("Parse(): %w", err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.tasks[name]; ok {
+		return fmt.Errorf("job %q is already registered", name)
+	}
+
+	t := &task{name: name, spec: spec, schedule: schedule, job: job}
+	s.tasks[name] = t
+
+	if s.ctx != nil {
+		t.next = schedule.Next(time.Now())
+		s.notify()
+	}
+
+	return nil
+}
+
+// Run - runs jobs by their schedules until ctx is done, then waits for running jobs to finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	entry := s.logger.WithField("func", "Run")
+	entry.Info("Starting job scheduler...")
+
+	s.mtx.Lock()
+	s.ctx = ctx
+	now := time.Now()
+
+	for _, t := range s.tasks {
+		t.next = t.schedule.Next(now)
+	}
+	s.mtx.Unlock()
+
+	timer := time.NewTimer(s.wait(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			entry.Info("Stopping job scheduler, waiting for running jobs...")
+			// New runs aren't started after context is reset, so wait group isn't incremented while waiting.
+			s.mtx.Lock()
+			s.ctx = nil
+
+			for _, t := range s.tasks {
+				t.next = time.Time{}
+			}
+			s.mtx.Unlock()
+			s.running.Wait()
+			entry.Info("Job scheduler stopped")
+
+			return
+		case now := <-timer.C:
+			s.runDue(now)
+		case <-s.wake:
+			if !timer.Stop() {
+				select { // drain fired timer, so reset doesn't get stale tick
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		timer.Reset(s.wait(time.Now()))
+	}
+}
+
+// Trigger - starts job with given name out of schedule, returns its run.
+func (s *Scheduler) Trigger(name string) (*models.JobRun, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t, ok := s.tasks[name]
+
+	if !ok {
+		return nil, ErrUnknownJob
+	}
+
+	if t.running {
+		return nil, ErrJobRunning
+	}
+
+	run, err := s.start(t, models.JobTriggerManual, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *run
+
+	return &copied, nil
+}
+
+// Jobs - returns registered jobs sorted by name, without history.
+func (s *Scheduler) Jobs() []*models.Job {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	jobs := make([]*models.Job, 0, len(s.tasks))
+
+	for _, t := range s.tasks {
+		jobs = append(jobs, t.describe(false))
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	return jobs
+}
+
+// Job - returns job with given name and its history, nil if it isn't registered.
+func (s *Scheduler) Job(name string) *models.Job {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t, ok := s.tasks[name]
+
+	if !ok {
+		return nil
+	}
+
+	return t.describe(true)
+}
+
+// wait - returns duration until the nearest scheduled run.
+func (s *Scheduler) wait(now time.Time) time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	wait := idleWait
+
+	for _, t := range s.tasks {
+		if t.next.IsZero() {
+			continue
+		}
+
+		if until := t.next.Sub(now); until < wait {
+			wait = until
+		}
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait
+}
+
+// runDue - starts jobs, which scheduled run is due at given time.
+// Missed runs aren't repeated: next run is scheduled after current time.
+func (s *Scheduler) runDue(now time.Time) {
+	entry := s.logger.WithField("func", "runDue")
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, t := range s.tasks {
+		if t.next.IsZero() || t.next.After(now) {
+			continue
+		}
+
+		t.next = t.schedule.Next(now)
+
+		if t.running {
+			entry.Warnf("Job %q is still running, scheduled run is skipped", t.name)
+			t.record(&models.JobRun{
+				Job:        t.name,
+				Trigger:    models.JobTriggerSchedule,
+				Status:     models.JobSkipped,
+				StartedAt:  now.Unix(),
+				FinishedAt: now.Unix(),
+			}, s.HistorySize)
+
+			continue
+		}
+
+		if _, err := s.start(t, models.JobTriggerSchedule, now); err != nil {
+			entry.Errorf("start() %q error: %v", t.name, err)
+		}
+	}
+}
+
+// start - starts run of given job, must be called under lock.
+func (s *Scheduler) start(t *task, trigger string, now time.Time) (*models.JobRun, error) {
+	if s.ctx == nil {
+		return nil, ErrStopped
+	}
+
+	run := &models.JobRun{Job: t.name, Trigger: trigger, Status: models.JobRunning, StartedAt: now.Unix()}
+	t.running = true
+	t.record(run, s.HistorySize)
+	s.running.Add(1)
+
+	go s.execute(s.ctx, t, run)
+
+	return run, nil
+}
+
+// execute - runs job and writes its result to the run, panic of job is reported as its error.
+func (s *Scheduler) execute(ctx context.Context, t *task, run *models.JobRun) {
+	defer s.running.Done()
+
+	entry := s.logger.WithFields(logrus.Fields{"func": "execute", "job": t.name, "trigger": run.Trigger})
+	entry.Debug("Starting job")
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		return t.job(ctx)
+	}()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t.running = false
+	run.FinishedAt = time.Now().Unix()
+	run.Status = models.JobSucceeded
+
+	if err != nil {
+		run.Status = models.JobFailed
+		run.Error = err.Error()
+		entry.Errorf("Job failed: %v", err)
+
+		return
+	}
+
+	entry.Debug("Job finished")
+}
+
+// notify - wakes scheduler up, so new job is scheduled.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default: // scheduler is already woken up
+	}
+}
+
+// record - adds run to the head of job history, oldest runs above size are dropped.
+func (t *task) record(run *models.JobRun, size int) {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	t.history = append([]*models.JobRun{run}, t.history...)
+
+	if len(t.history) > size {
+		t.history = t.history[:size]
+	}
+}
+
+// describe - returns copy of job state, runs are copied, because they are changed by running job.
+func (t *task) describe(history bool) *models.Job {
+	job := &models.Job{Name: t.name, Schedule: t.spec, Running: t.running}
+
+	if !t.next.IsZero() {
+		job.NextRun = t.next.Unix()
+	}
+
+	if len(t.history) > 0 {
+		last := *t.history[0]
+		job.LastRun = &last
+	}
+
+	if history {
+		job.History = make([]*models.JobRun, 0, len(t.history))
+
+		for _, run := range t.history {
+			copied := *run
+			job.History = append(job.History, &copied)
+		}
+	}
+
+	return job
+}
@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"activity_api/common/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// startScheduler - runs scheduler until returned cancel is called, waits for it to start.
+func startScheduler(t *testing.T, s *Scheduler, name string) (context.CancelFunc, <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); s.Job(name).NextRun == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Scheduler isn't started")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return cancel, stopped
+}
+
+// waitStatus - waits for last run of job to get given status.
+func waitStatus(t *testing.T, s *Scheduler, name, status string) *models.Job {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if job := s.Job(name); job.LastRun != nil && job.LastRun.Status == status {
+			return job
+		}
+	}
+
+	t.Fatalf("Last run of %q isn't %s: %+v", name, status, s.Job(name))
+
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	logger := &logrus.Logger{Level: logrus.FatalLevel}
+
+	t.Run("Register_invalid", func(t *testing.T) {
+		s := NewScheduler(logger)
+		job := func(context.Context) error { return nil }
+
+		if err := s.Register("job", "* *", job); err == nil {
+			t.Error("Job with invalid schedule is registered")
+		}
+
+		if err := s.Register("job", "@hourly", job); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Register("job", "@daily", job); err == nil {
+			t.Error("Job with duplicate name is registered")
+		}
+	})
+
+	t.Run("Trigger_notRunning", func(t *testing.T) {
+		s := NewScheduler(logger)
+
+		if err := s.Register("job", "@hourly", func(context.Context) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Trigger("job"); !errors.Is(err, ErrStopped) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if _, err := s.Trigger("unknown"); !errors.Is(err, ErrUnknownJob) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Trigger_noOverlap", func(t *testing.T) {
+		s := NewScheduler(logger)
+		release := make(chan struct{})
+
+		if err := s.Register("job", "@hourly", func(context.Context) error {
+			<-release
+
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel, stopped := startScheduler(t, s, "job")
+		defer func() { cancel(); <-stopped }()
+
+		run, err := s.Trigger("job")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if run.Trigger != models.JobTriggerManual || run.Status != models.JobRunning {
+			t.Errorf("Unexpected run: %+v", run)
+		}
+
+		if _, err := s.Trigger("job"); !errors.Is(err, ErrJobRunning) {
+			t.Errorf("Running job is triggered again, error: %v", err)
+		}
+		// Scheduled run of running job is skipped.
+		s.runDue(time.Now().Add(2 * time.Hour))
+
+		if job := s.Job("job"); job.LastRun.Status != models.JobSkipped || !job.Running {
+			t.Errorf("Scheduled run isn't skipped: %+v", job)
+		}
+
+		close(release)
+
+		for deadline := time.Now().Add(5 * time.Second); s.Job("job").Running; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("Job isn't finished")
+			}
+		}
+		// Skipped run is newer than the manual one.
+		if history := s.Job("job").History; len(history) != 2 || history[0].Status != models.JobSkipped ||
+			history[1].Status != models.JobSucceeded || history[1].FinishedAt == 0 {
+			t.Errorf("Unexpected history: %+v, %+v", history[0], history[1])
+		}
+	})
+
+	t.Run("Trigger_failed", func(t *testing.T) {
+		s := NewScheduler(logger)
+
+		if err := s.Register("error", "@hourly", func(context.Context) error { return errors.New("broken") }); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Register("panic", "@hourly", func(context.Context) error { panic("broken") }); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel, stopped := startScheduler(t, s, "error")
+		defer func() { cancel(); <-stopped }()
+
+		for _, name := range []string{"error", "panic"} {
+			if _, err := s.Trigger(name); err != nil {
+				t.Fatal(err)
+			}
+
+			if job := waitStatus(t, s, name, models.JobFailed); job.LastRun.Error == "" {
+				t.Errorf("Error of %q isn't kept: %+v", name, job.LastRun)
+			}
+		}
+	})
+
+	t.Run("Run_schedule", func(t *testing.T) {
+		s := NewScheduler(logger)
+		s.HistorySize = 2
+		runs := make(chan struct{}, 10)
+
+		if err := s.Register("job", "@every 1s", func(context.Context) error {
+			runs <- struct{}{}
+
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel, stopped := startScheduler(t, s, "job")
+		defer func() { cancel(); <-stopped }()
+
+		for i := 0; i < 3; i++ {
+			select {
+			case <-runs:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Job isn't run by schedule")
+			}
+		}
+
+		if job := waitStatus(t, s, "job", models.JobSucceeded); len(job.History) != 2 ||
+			job.LastRun.Trigger != models.JobTriggerSchedule {
+			t.Errorf("Unexpected job: %+v", job)
+		}
+	})
+
+	t.Run("Run_waitsForJobs", func(t *testing.T) {
+		s := NewScheduler(logger)
+		finished := false
+
+		if err := s.Register("job", "@hourly", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			finished = true
+
+			return ctx.Err()
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel, stopped := startScheduler(t, s, "job")
+
+		if _, err := s.Trigger("job"); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel()
+		<-stopped
+
+		if !finished {
+			t.Error("Scheduler is stopped before job finished")
+		}
+
+		if job := s.Job("job"); job.NextRun != 0 || job.LastRun.Status != models.JobFailed {
+			t.Errorf("Unexpected job after stop: %+v", job)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+// Local certificate authority for tests. It is not meant to be used in AAService itself:
+// keys are not protected in any way and certificates live only for one day.
+package test_ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// CA - test certificate authority.
+type CA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	CertPEM []byte // CA certificate, could be written to file and used as client CA or root CA
+}
+
+// NewCA - generates new self-signed test CA.
+func NewCA() (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa.GenerateKey(): %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "AAService test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	if err != nil {
+		return nil, fmt.Errorf("x509.CreateCertificate(): %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCertificate(): %w", err)
+	}
+
+	return &CA{
+		cert:    cert,
+		key:     key,
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// Pool - returns cert pool with CA certificate.
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return pool
+}
+
+// IssueServer - issues server certificate for given hosts (DNS names or IPs), returns cert and key PEM.
+func (ca *CA) IssueServer(hosts ...string) ([]byte, []byte, error) {
+	template := ca.template(hosts[0], x509.ExtKeyUsageServerAuth)
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return ca.issue(template)
+}
+
+// IssueClient - issues client certificate with given common name, returns cert and key PEM.
+func (ca *CA) IssueClient(commonName string) ([]byte, []byte, error) {
+	return ca.issue(ca.template(commonName, x509.ExtKeyUsageClientAuth))
+}
+
+// IssueClientCertificate - issues client certificate ready to be used in tls.Config.
+func (ca *CA) IssueClientCertificate(commonName string) (tls.Certificate, error) {
+	certPEM, keyPEM, err := ca.IssueClient(commonName)
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// template - returns leaf certificate template.
+func (ca *CA) template(commonName string, usage x509.ExtKeyUsage) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+}
+
+// issue - signs given template with CA key, returns cert and key PEM.
+func (ca *CA) issue(template *x509.Certificate) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("ecdsa.GenerateKey(): %w", err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.CreateCertificate(): %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.MarshalECPrivateKey(): %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
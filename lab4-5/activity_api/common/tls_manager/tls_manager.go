@@ -0,0 +1,239 @@
+package tls_manager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Possible client certificate auth modes.
+const (
+	ClientAuthNone     = "none"     // client certificates are not requested
+	ClientAuthOptional = "optional" // client certificate is verified if client sends it
+	ClientAuthRequire  = "require"  // every client has to send valid certificate
+
+	defaultReloadInterval = 10 * time.Second
+)
+
+// Config - TLS config of the service. TLS is enabled when both cert and key files are set.
+type Config struct {
+	CertFile       string // Server certificate (PEM), could contain intermediate certificates
+	KeyFile        string // Server private key (PEM)
+	ClientCAFile   string // CA bundle (PEM) to verify client certificates, required if ClientAuth isn't "none"
+	ClientAuth     string // Client certificate auth mode: "none" (default), "optional" or "require"
+	ReloadInterval int    // Seconds between checks of cert and key files for changes, 0 - default (10)
+}
+
+// Enabled - returns true if TLS is configured.
+func (c *Config) Enabled() bool {
+	return c != nil && (c.CertFile != "" || c.KeyFile != "")
+}
+
+// Validate - checks that config is consistent and all files exist.
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("both cert and key files are required")
+	}
+
+	files := []string{c.CertFile, c.KeyFile}
+
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthOptional, ClientAuthRequire:
+		if c.ClientCAFile == "" {
+			return fmt.Errorf("client CA file is required for client auth %q", c.ClientAuth)
+		}
+
+		files = append(files, c.ClientCAFile)
+	default:
+		return fmt.Errorf("unknown client auth %q, expected one of: none, optional, require", c.ClientAuth)
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("os.Stat(): %w", err)
+		}
+	}
+
+	if c.ReloadInterval < 0 {
+		return fmt.Errorf("negative reload interval: %d", c.ReloadInterval)
+	}
+
+	return nil
+}
+
+// NewServerConfig - returns server tls.Config for given config, nil if TLS isn't enabled.
+// Certificate is reloaded when cert or key file changes, so it could be renewed without restart.
+func NewServerConfig(config *Config, logger logrus.FieldLogger) (*tls.Config, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("Validate(): %w", err)
+	}
+
+	interval := time.Duration(config.ReloadInterval) * time.Second
+
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+
+	reloader, err := NewCertReloader(config.CertFile, config.KeyFile, interval, logger)
+
+	if err != nil {
+		return nil, fmt.Errorf("NewCertReloader(): %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if config.ClientAuth == "" || config.ClientAuth == ClientAuthNone {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.ClientCAs, err = LoadCertPool(config.ClientCAFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("LoadCertPool(): %w", err)
+	}
+
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	if config.ClientAuth == ClientAuthRequire {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// LoadCertPool - returns cert pool with certificates from given PEM file.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile(): %w", err)
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// CertReloader - keeps server certificate and reloads it when cert or key file is modified.
+// Files are checked lazily on handshake, not more often than once per interval.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	cert      *tls.Certificate
+	modTime   time.Time // latest modification time of cert and key files
+	lastCheck time.Time
+	mtx       sync.Mutex
+	logger    logrus.FieldLogger
+}
+
+// NewCertReloader - loads certificate and returns reloader for it.
+func NewCertReloader(certFile, keyFile string, interval time.Duration, logger logrus.FieldLogger) (*CertReloader, error) {
+	c := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+		logger:   logger.WithField("module", "CertReloader"),
+	}
+
+	modTime, err := c.latestModTime()
+
+	if err != nil {
+		return nil, fmt.Errorf("latestModTime(): %w", err)
+	}
+
+	if err := c.load(modTime); err != nil {
+		return nil, fmt.Errorf("load(): %w", err)
+	}
+
+	return c, nil
+}
+
+// GetCertificate - tls.Config GetCertificate callback.
+func (c *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if time.Since(c.lastCheck) < c.interval {
+		return c.cert, nil
+	}
+
+	c.lastCheck = time.Now()
+	entry := c.logger.WithField("func", "GetCertificate")
+	modTime, err := c.latestModTime()
+	// Old certificate is still valid for serving, so reload errors are only logged.
+	if err != nil {
+		entry.Errorf("Cert files check error: %v", err)
+
+		return c.cert, nil
+	}
+
+	if modTime.Equal(c.modTime) {
+		return c.cert, nil
+	}
+
+	entry.Info("Cert files changed, reloading certificate...")
+
+	if err := c.load(modTime); err != nil {
+		entry.Errorf("Certificate reload error: %v", err)
+	}
+
+	return c.cert, nil
+}
+
+// load - loads certificate from files. Requires lock (or exclusive access in constructor).
+func (c *CertReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+
+	if err != nil {
+		return fmt.Errorf("tls.LoadX509KeyPair(): %w", err)
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+
+	return nil
+}
+
+// latestModTime - returns latest modification time of cert and key files.
+func (c *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return time.Time{}, fmt.Errorf("os.Stat(): %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
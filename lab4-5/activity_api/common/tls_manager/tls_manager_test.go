@@ -0,0 +1,215 @@
+package tls_manager
+
+import (
+	"activity_api/common/test_ca"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = &logrus.Logger{
+	Level: logrus.FatalLevel,
+}
+
+// writeFiles - writes given files to dir.
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestServer - starts https test server with given config.
+func newTestServer(t *testing.T, config *Config) *httptest.Server {
+	tlsConfig, err := NewServerConfig(config, logger)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	// httptest adds its own certificate to config, and it's used when client doesn't send server name,
+	// so server is requested by host name to make sure certificate from config is used.
+	server.URL = strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	return server
+}
+
+// newClient - returns client that trusts only given roots.
+func newClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				Certificates: certs,
+			},
+		},
+		Timeout: time.Second * 5,
+	}
+}
+
+// TestNewServerConfig - tests TLS and mTLS server config with certificates from test CA.
+func TestNewServerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_manager")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, err := test_ca.NewCA()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := ca.IssueServer("localhost")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, dir, map[string][]byte{"server.crt": certPEM, "server.key": keyPEM, "ca.crt": ca.CertPEM})
+
+	config := &Config{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   ClientAuthRequire,
+	}
+
+	t.Run("NewServerConfig_mTLS", func(t *testing.T) {
+		server := newTestServer(t, config)
+		defer server.Close()
+
+		clientCert, err := ca.IssueClientCertificate("agent-1")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := newClient(ca.Pool(), clientCert).Get(server.URL)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		body, _ := ioutil.ReadAll(res.Body)
+
+		if string(body) != "agent-1" {
+			t.Fatalf("unexpected client certificate name: %q", body)
+		}
+		// Without client certificate handshake should fail.
+		if _, err := newClient(ca.Pool()).Get(server.URL); err == nil {
+			t.Fatal("request without client certificate succeeded")
+		}
+	})
+
+	t.Run("NewServerConfig_untrustedServer", func(t *testing.T) {
+		server := newTestServer(t, &Config{CertFile: config.CertFile, KeyFile: config.KeyFile})
+		defer server.Close()
+
+		otherCA, err := test_ca.NewCA()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := newClient(otherCA.Pool()).Get(server.URL); err == nil {
+			t.Fatal("client trusted certificate of unknown CA")
+		}
+	})
+
+	t.Run("NewServerConfig_invalid", func(t *testing.T) {
+		invalid := []*Config{
+			{CertFile: config.CertFile},
+			{CertFile: config.CertFile, KeyFile: config.KeyFile, ClientAuth: ClientAuthRequire},
+			{CertFile: config.CertFile, KeyFile: config.KeyFile, ClientAuth: "sometimes"},
+			{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: config.KeyFile},
+		}
+
+		for _, c := range invalid {
+			if _, err := NewServerConfig(c, logger); err == nil {
+				t.Fatalf("no error for invalid config: %+v", c)
+			}
+		}
+	})
+}
+
+// TestCertReloader - tests that certificate is reloaded after files change.
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_manager")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, err := test_ca.NewCA()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := ca.IssueServer("first.local")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, dir, map[string][]byte{"server.crt": certPEM, "server.key": keyPEM})
+	reloader, err := NewCertReloader(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), 0, logger)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err = ca.IssueServer("second.local")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, dir, map[string][]byte{"server.crt": certPEM, "server.key": keyPEM})
+	// Make sure modification time differs even on file systems with coarse timestamps.
+	future := time.Now().Add(time.Minute)
+
+	for _, name := range []string{"server.crt", "server.key"} {
+		if err := os.Chtimes(filepath.Join(dir, name), future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cert, err := reloader.GetCertificate(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leaf.Subject.CommonName != "second.local" {
+		t.Fatalf("certificate wasn't reloaded, common name: %s", leaf.Subject.CommonName)
+	}
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Headers of webhook request.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"  // delivery id, the same for every attempt, so receiver could deduplicate
+	HeaderTimestamp = "X-Webhook-Timestamp" // unix time of attempt, it's signed with body
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" + hex of HMAC-SHA256 of timestamp, "." and body
+)
+
+// signaturePrefix - algorithm prefix of signature header.
+const signaturePrefix = "sha256="
+
+// NewSecret - returns new random secret of webhook.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("rand.Read(): %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign - returns signature header value of body sent at given time.
+// Timestamp is signed, so receiver is able to reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify - checks signature and timestamp headers of received webhook body.
+func Verify(secret, timestamp, signature string, body []byte) error {
+	parsed, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("unsupported signature %q", signature)
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, parsed, body)), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"activity_api/common/backoff"
+	"activity_api/common/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultInterval    = time.Second
+	defaultBatch       = 100
+	defaultTimeout     = 10 * time.Second
+	// maxErrorBody - part of failed response body kept as delivery error.
+	maxErrorBody = 256
+)
+
+// Store - persistent queue of webhook deliveries.
+type Store interface {
+	EnqueueDeliveries(ctx context.Context, event, payload string, now int64) (int64, error)
+	GetDueDeliveries(ctx context.Context, now int64, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// Dispatcher - sends queued deliveries to webhooks. Failed delivery is retried with backoff,
+// after MaxAttempts it's moved to dead-letter list. Delivery is at least once: receiver could get
+// the same delivery again, if dispatcher is stopped before attempt result is written.
+type Dispatcher struct {
+	MaxAttempts int           // attempts before delivery is dead
+	Interval    time.Duration // interval between polls of queue
+	Batch       int           // max deliveries sent on one poll
+	Client      *http.Client
+
+	store   Store
+	backoff *backoff.Backoff // delays between attempts of failed delivery
+	wake    chan struct{}    // wakes dispatcher up when event is queued
+	logger  logrus.FieldLogger
+}
+
+// NewDispatcher - returns new dispatcher of given queue, zero maxAttempts means default (8).
+func NewDispatcher(store Store, backoff *backoff.Backoff, maxAttempts int, logger logrus.FieldLogger) *Dispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	return &Dispatcher{
+		MaxAttempts: maxAttempts,
+		Interval:    defaultInterval,
+		Batch:       defaultBatch,
+		Client:      &http.Client{Timeout: defaultTimeout},
+		store:       store,
+		backoff:     backoff,
+		wake:        make(chan struct{}, 1),
+		logger:      logger.WithField("module", "WebhookDispatcher"),
+	}
+}
+
+// Enqueue - queues event for every webhook subscribed to it and wakes dispatcher up.
+func (d *Dispatcher) Enqueue(ctx context.Context, event string, data interface{}) error {
+	now := time.Now().Unix()
+	payload, err := json.Marshal(&models.WebhookPayload{Event: event, Time: now, Data: data})
+
+	if err != nil {
+		return fmt.Errorf("json.Marshal(): %w", err)
+	}
+
+	queued, err := d.store.EnqueueDeliveries(ctx, event, string(payload), now)
+
+	if err != nil {
+		return fmt.Errorf("EnqueueDeliveries(): %w", err)
+	}
+
+	if queued > 0 {
+		d.Notify()
+	}
+
+	return nil
+}
+
+// Notify - wakes dispatcher up, so due deliveries are sent without waiting for next poll.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default: // dispatcher is already woken up
+	}
+}
+
+// Run - sends due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	entry := d.logger.WithField("func", "Run")
+	entry.Info("Starting webhook dispatcher...")
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			entry.Info("Stopping webhook dispatcher")
+
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// dispatch - sends due deliveries batch by batch, until there are no due deliveries left.
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	entry := d.logger.WithField("func", "dispatch")
+
+	for ctx.Err() == nil {
+		deliveries, err := d.store.GetDueDeliveries(ctx, time.Now().Unix(), d.Batch)
+
+		if err != nil {
+			entry.Errorf("GetDueDeliveries() error: %v", err)
+
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.attempt(ctx, delivery)
+		}
+
+		if len(deliveries) < d.Batch {
+			return
+		}
+	}
+}
+
+// attempt - sends delivery and writes result of attempt.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	entry := d.logger.WithFields(logrus.Fields{
+		"func":     "attempt",
+		"delivery": delivery.DeliveryID,
+		"webhook":  delivery.WebhookID,
+	})
+
+	status, err := d.send(ctx, delivery)
+	// Attempt interrupted by stop isn't counted, delivery is sent again on next start.
+	if ctx.Err() != nil {
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		entry.Debugf("Delivered %s on attempt %d", delivery.Event, delivery.Attempts)
+		delivery.Status = models.DeliveryDelivered
+	case delivery.Attempts >= d.MaxAttempts:
+		entry.Warnf("Delivery is dead after %d attempts, last error: %v", delivery.Attempts, err)
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delay := d.backoff.Next(delivery.Attempts - 1)
+		entry.Infof("Attempt %d failed, retrying in %v: %v", delivery.Attempts, delay, err)
+		delivery.NextAttempt = time.Now().Add(delay).Unix()
+		delivery.LastError = err.Error()
+	}
+
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		entry.Errorf("UpdateDelivery() error: %v", err)
+	}
+}
+
+// send - posts signed payload to webhook, returns response status code.
+// Any status except 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, fmt.Errorf("http.NewRequest(): %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, body))
+
+	res, err := d.Client.Do(req)
+
+	if err != nil {
+		return 0, fmt.Errorf("client.Do(): %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, res.Body) // drain body, so connection is reused
+
+		return res.StatusCode, nil
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+
+	return res.StatusCode, fmt.Errorf("webhook responded with %s: %s", res.Status, bytes.TrimSpace(message))
+}
@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"activity_api/common/backoff"
+	"activity_api/common/models"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testSecret = "secret"
+
+// memoryStore - in memory queue of deliveries to single webhook.
+type memoryStore struct {
+	url        string
+	deliveries []*models.WebhookDelivery
+	mtx        sync.Mutex
+}
+
+func (s *memoryStore) EnqueueDeliveries(_ context.Context, event, payload string, now int64) (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.deliveries = append(s.deliveries, &models.WebhookDelivery{
+		DeliveryID:  int64(len(s.deliveries) + 1),
+		WebhookID:   1,
+		Event:       event,
+		Payload:     payload,
+		Status:      models.DeliveryPending,
+		NextAttempt: now,
+		URL:         s.url,
+		Secret:      testSecret,
+	})
+
+	return 1, nil
+}
+
+func (s *memoryStore) GetDueDeliveries(_ context.Context, now int64, limit int) ([]*models.WebhookDelivery, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	due := make([]*models.WebhookDelivery, 0)
+
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending && delivery.NextAttempt <= now && len(due) < limit {
+			copied := *delivery
+			due = append(due, &copied)
+		}
+	}
+
+	return due, nil
+}
+
+func (s *memoryStore) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	copied := *delivery
+	s.deliveries[delivery.DeliveryID-1] = &copied
+
+	return nil
+}
+
+// delivery - returns delivery with given id.
+func (s *memoryStore) delivery(id int64) *models.WebhookDelivery {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.deliveries[id-1]
+}
+
+// newTestDispatcher - returns dispatcher of memory store, which sends deliveries to receiver
+// responding with given statuses one by one (the last one is repeated), and channel of received bodies.
+func newTestDispatcher(t *testing.T, statuses ...int) (*Dispatcher, *memoryStore, <-chan string) {
+	received := make(chan string, 10)
+	mtx := sync.Mutex{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if err := Verify(testSecret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body); err != nil {
+			t.Errorf("Verify() error: %v", err)
+		}
+
+		mtx.Lock()
+		status := statuses[0]
+
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		mtx.Unlock()
+
+		received <- string(body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	store := &memoryStore{url: server.URL}
+	// Zero backoff, so failed delivery is due again right away.
+	dispatcher := NewDispatcher(store, backoff.NewBackoff(0, 0), 3, &logrus.Logger{Level: logrus.FatalLevel})
+
+	return dispatcher, store, received
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Dispatcher_delivered", func(t *testing.T) {
+		dispatcher, store, received := newTestDispatcher(t, http.StatusOK)
+
+		if err := dispatcher.Enqueue(ctx, models.WebhookUserCreated, &models.User{UserID: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		dispatcher.dispatch(ctx)
+
+		if body := <-received; body == "" {
+			t.Error("Empty body received")
+		}
+
+		if delivery := store.delivery(1); delivery.Status != models.DeliveryDelivered || delivery.Attempts != 1 {
+			t.Errorf("Unexpected delivery: %+v", delivery)
+		}
+	})
+
+	t.Run("Dispatcher_retried", func(t *testing.T) {
+		dispatcher, store, received := newTestDispatcher(t, http.StatusInternalServerError, http.StatusNoContent)
+
+		if err := dispatcher.Enqueue(ctx, models.WebhookUserDeleted, &models.ObjectID{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		dispatcher.dispatch(ctx)
+
+		if delivery := store.delivery(1); delivery.Status != models.DeliveryPending ||
+			delivery.LastStatus != http.StatusInternalServerError || delivery.LastError == "" {
+			t.Errorf("Unexpected delivery after failed attempt: %+v", delivery)
+		}
+
+		dispatcher.dispatch(ctx)
+
+		if first, second := <-received, <-received; first != second {
+			t.Errorf("Payload changed on retry: %s != %s", first, second)
+		}
+
+		if delivery := store.delivery(1); delivery.Status != models.DeliveryDelivered || delivery.Attempts != 2 {
+			t.Errorf("Unexpected delivery: %+v", delivery)
+		}
+	})
+
+	t.Run("Dispatcher_dead", func(t *testing.T) {
+		dispatcher, store, _ := newTestDispatcher(t, http.StatusBadGateway)
+
+		if err := dispatcher.Enqueue(ctx, models.WebhookActivityCreated, &models.Activity{RecordID: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < dispatcher.MaxAttempts+1; i++ {
+			dispatcher.dispatch(ctx)
+		}
+
+		if delivery := store.delivery(1); delivery.Status != models.DeliveryDead ||
+			delivery.Attempts != dispatcher.MaxAttempts {
+			t.Errorf("Unexpected delivery: %+v", delivery)
+		}
+	})
+
+	t.Run("Dispatcher_run", func(t *testing.T) {
+		dispatcher, _, received := newTestDispatcher(t, http.StatusOK)
+		dispatcher.Interval = time.Hour // only enqueue wakes dispatcher up
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+
+		go func() {
+			dispatcher.Run(runCtx)
+			close(done)
+		}()
+
+		if err := dispatcher.Enqueue(ctx, models.WebhookUserCreated, &models.User{UserID: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("Delivery wasn't sent after enqueue")
+		}
+
+		cancel()
+		<-done
+	})
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"user.created"}`)
+	signature := Sign(testSecret, 1600000000, body)
+
+	t.Run("Verify_valid", func(t *testing.T) {
+		if err := Verify(testSecret, "1600000000", signature, body); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify_wrongSecret", func(t *testing.T) {
+		if err := Verify("other", "1600000000", signature, body); err == nil {
+			t.Error("Signature with wrong secret is valid")
+		}
+	})
+
+	t.Run("Verify_wrongTimestamp", func(t *testing.T) {
+		if err := Verify(testSecret, "1600000001", signature, body); err == nil {
+			t.Error("Signature with changed timestamp is valid")
+		}
+	})
+
+	t.Run("Verify_changedBody", func(t *testing.T) {
+		if err := Verify(testSecret, "1600000000", signature, []byte(`{}`)); err == nil {
+			t.Error("Signature of changed body is valid")
+		}
+	})
+}
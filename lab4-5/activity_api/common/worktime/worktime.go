@@ -0,0 +1,395 @@
+package worktime
+
+import (
+	"activity_api/common/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// DateLayout - layout of report days and time off dates.
+	DateLayout = "2006-01-02"
+	// MaxPeriod - max period of work report.
+	MaxPeriod = 366 * 24 * time.Hour
+	// day - length of day in seconds, time zones of schedules have fixed offset, so every day has the same length.
+	day = int64(24 * time.Hour / time.Second)
+	// workDay - expected work time of working day of default schedule.
+	workDay = int64(8 * time.Hour / time.Second)
+)
+
+// ErrInvalidPeriod - period of report is empty, or it's longer than MaxPeriod.
+var ErrInvalidPeriod = errors.New("invalid report period")
+
+// Store - users, schedules, time off and activity reports are built from.
+type Store interface {
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	GetUsers(ctx context.Context, depID string) ([]*models.User, error)
+	GetDepartment(ctx context.Context, departID string) (*models.Department, error)
+	GetDepartments(ctx context.Context) ([]*models.Department, error)
+	GetMemberships(ctx context.Context, userID string) ([]*models.Membership, error)
+	GetWorkSchedules(ctx context.Context) ([]*models.WorkSchedule, error)
+	GetTimeOffs(ctx context.Context, userID string) ([]*models.TimeOff, error)
+	GetDailyActivity(ctx context.Context, userID string, start, end, utcOffset int64) ([]*models.DailyActivity, error)
+}
+
+// Reporter - builds expected versus actual work time reports.
+type Reporter struct {
+	store Store
+}
+
+// NewReporter - returns new reporter of data from given store.
+func NewReporter(store Store) *Reporter {
+	return &Reporter{store: store}
+}
+
+// DefaultSchedule - schedule of users without own or department schedule: 8 hours from Monday to Friday, UTC.
+func DefaultSchedule() *models.WorkSchedule {
+	return &models.WorkSchedule{
+		Monday:    workDay,
+		Tuesday:   workDay,
+		Wednesday: workDay,
+		Thursday:  workDay,
+		Friday:    workDay,
+	}
+}
+
+// Expected - returns expected work time of weekday by schedule.
+func Expected(schedule *models.WorkSchedule, weekday time.Weekday) int64 {
+	return [...]int64{
+		schedule.Sunday,
+		schedule.Monday,
+		schedule.Tuesday,
+		schedule.Wednesday,
+		schedule.Thursday,
+		schedule.Friday,
+		schedule.Saturday,
+	}[weekday]
+}
+
+// Resolve - returns schedule of user: its own, or schedule of the nearest department up from its department,
+// or default schedule.
+func Resolve(user *models.User, schedules []*models.WorkSchedule, departs []*models.Department) *models.WorkSchedule {
+	byDepartment := make(map[int64]*models.WorkSchedule, len(schedules))
+
+	for _, schedule := range schedules {
+		if schedule.UserID == user.UserID && schedule.UserID != 0 {
+			return schedule
+		}
+
+		if schedule.DepartmentID != 0 {
+			byDepartment[schedule.DepartmentID] = schedule
+		}
+	}
+
+	return resolveDepartment(user.DepartmentID, byDepartment, departs)
+}
+
+// resolveDepartment - returns schedule of department or of its nearest ancestor, or default schedule.
+func resolveDepartment(
+	departID int64,
+	byDepartment map[int64]*models.WorkSchedule,
+	departs []*models.Department,
+) *models.WorkSchedule {
+	parents := make(map[int64]int64, len(departs))
+
+	for _, depart := range departs {
+		parents[depart.DepartmentID] = depart.ParentID
+	}
+	// Visited departments are tracked, so broken tree with cycle doesn't hang.
+	visited := make(map[int64]bool)
+
+	for id := departID; id != 0 && !visited[id]; id = parents[id] {
+		if schedule, ok := byDepartment[id]; ok {
+			return schedule
+		}
+
+		visited[id] = true
+	}
+
+	return DefaultSchedule()
+}
+
+// UserReport - returns work report of user over whole days of [start, end) in time zone of its schedule,
+// nil if user doesn't exist.
+func (r *Reporter) UserReport(ctx context.Context, userID string, start, end int64) (*models.WorkReport, error) {
+	if err := checkPeriod(start, end); err != nil {
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, userID)
+
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	schedules, departs, err := r.schedules(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.userReport(ctx, user, Resolve(user, schedules, departs), start, end, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report.UserID = user.UserID
+
+	return report, nil
+}
+
+// DepartmentReport - returns work report of current department users, every user is reported
+// over days of its membership in department only. Returns nil if department doesn't exist.
+func (r *Reporter) DepartmentReport(
+	ctx context.Context,
+	departID string,
+	start, end int64,
+) (*models.DepartmentWorkReport, error) {
+	if err := checkPeriod(start, end); err != nil {
+		return nil, err
+	}
+
+	depart, err := r.store.GetDepartment(ctx, departID)
+
+	if err != nil || depart == nil {
+		return nil, err
+	}
+
+	schedules, departs, err := r.schedules(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := r.store.GetUsers(ctx, departID)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUsers(): %w", err)
+	}
+
+	byDepartment := make(map[int64]*models.WorkSchedule, len(schedules))
+
+	for _, schedule := range schedules {
+		if schedule.DepartmentID != 0 {
+			byDepartment[schedule.DepartmentID] = schedule
+		}
+	}
+
+	from, to := period(resolveDepartment(depart.DepartmentID, byDepartment, departs), start, end)
+	report := &models.DepartmentWorkReport{
+		DepartmentID: depart.DepartmentID,
+		From:         from,
+		To:           to,
+		Users:        make([]*models.WorkReport, 0, len(users)),
+	}
+
+	for _, user := range users {
+		memberships, err := r.store.GetMemberships(ctx, strconv.FormatInt(user.UserID, 10))
+
+		if err != nil {
+			return nil, fmt.Errorf("GetMemberships(): %w", err)
+		}
+
+		userReport, err := r.userReport(
+			ctx,
+			user,
+			Resolve(user, schedules, departs),
+			start,
+			end,
+			memberOf(memberships, depart.DepartmentID),
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		userReport.UserID = user.UserID
+		report.Users = append(report.Users, userReport)
+
+		report.ExpectedTime += userReport.ExpectedTime
+		report.TotalTime += userReport.TotalTime
+		report.ActiveTime += userReport.ActiveTime
+		report.Overtime += userReport.Overtime
+		report.OvertimeDays += userReport.OvertimeDays
+		report.TimeOffDays += userReport.TimeOffDays
+	}
+
+	report.Utilization = utilization(report.TotalTime, report.ExpectedTime)
+
+	return report, nil
+}
+
+// schedules - returns all schedules and departments of tenant, they are needed to resolve user schedule.
+func (r *Reporter) schedules(ctx context.Context) ([]*models.WorkSchedule, []*models.Department, error) {
+	schedules, err := r.store.GetWorkSchedules(ctx)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetWorkSchedules(): %w", err)
+	}
+
+	departs, err := r.store.GetDepartments(ctx)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetDepartments(): %w", err)
+	}
+
+	return schedules, departs, nil
+}
+
+// userReport - reads time off and daily activity of user and builds its report.
+func (r *Reporter) userReport(
+	ctx context.Context,
+	user *models.User,
+	schedule *models.WorkSchedule,
+	start, end int64,
+	member func(dayStart int64) bool,
+) (*models.WorkReport, error) {
+	userID := strconv.FormatInt(user.UserID, 10)
+	timeOffs, err := r.store.GetTimeOffs(ctx, userID)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTimeOffs(): %w", err)
+	}
+
+	first, last := days(start, end, schedule.UTCOffset)
+	activity, err := r.store.GetDailyActivity(ctx, userID, first*day-schedule.UTCOffset,
+		(last+1)*day-schedule.UTCOffset, schedule.UTCOffset)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetDailyActivity(): %w", err)
+	}
+
+	return Build(schedule, timeOffs, activity, start, end, member), nil
+}
+
+// Build - returns report over whole days of [start, end) in time zone of schedule. Days off are days
+// without expected time by schedule and days of time off, all work time of days off is overtime.
+// If member isn't nil, only days for which it returns true for start of day are reported.
+func Build(
+	schedule *models.WorkSchedule,
+	timeOffs []*models.TimeOff,
+	activity []*models.DailyActivity,
+	start, end int64,
+	member func(dayStart int64) bool,
+) *models.WorkReport {
+	from, to := period(schedule, start, end)
+	report := &models.WorkReport{From: from, To: to, Schedule: schedule, Days: make([]*models.WorkDay, 0)}
+
+	byDay := make(map[int64]*models.DailyActivity, len(activity))
+
+	for _, daily := range activity {
+		byDay[daily.Day] = daily
+	}
+
+	first, last := days(start, end, schedule.UTCOffset)
+	zone := time.FixedZone("", int(schedule.UTCOffset))
+
+	for number := first; number <= last; number++ {
+		dayStart := number*day - schedule.UTCOffset
+
+		if member != nil && !member(dayStart) {
+			continue
+		}
+
+		date := time.Unix(dayStart, 0).In(zone)
+		workDay := &models.WorkDay{Date: date.Format(DateLayout)}
+
+		if isTimeOff(timeOffs, workDay.Date) {
+			workDay.TimeOff = true
+			report.TimeOffDays++
+		} else {
+			workDay.ExpectedTime = Expected(schedule, date.Weekday())
+		}
+
+		if daily, ok := byDay[number]; ok {
+			workDay.TotalTime = daily.TotalTime
+			workDay.ActiveTime = daily.ActiveTime
+		}
+
+		if workDay.TotalTime > workDay.ExpectedTime {
+			workDay.Overtime = workDay.TotalTime - workDay.ExpectedTime
+			report.Overtime += workDay.Overtime
+			report.OvertimeDays++
+		}
+
+		report.ExpectedTime += workDay.ExpectedTime
+		report.TotalTime += workDay.TotalTime
+		report.ActiveTime += workDay.ActiveTime
+		report.Days = append(report.Days, workDay)
+	}
+
+	report.Utilization = utilization(report.TotalTime, report.ExpectedTime)
+
+	return report
+}
+
+// period - returns first and last day of [start, end) in time zone of schedule.
+func period(schedule *models.WorkSchedule, start, end int64) (string, string) {
+	first, last := days(start, end, schedule.UTCOffset)
+	zone := time.FixedZone("", int(schedule.UTCOffset))
+
+	return time.Unix(first*day-schedule.UTCOffset, 0).In(zone).Format(DateLayout),
+		time.Unix(last*day-schedule.UTCOffset, 0).In(zone).Format(DateLayout)
+}
+
+// days - returns numbers since unix epoch of first and last day of [start, end) in time zone with given offset.
+func days(start, end, utcOffset int64) (int64, int64) {
+	return floorDiv(start+utcOffset, day), floorDiv(end-1+utcOffset, day)
+}
+
+// floorDiv - integer division rounded down, so days before epoch are numbered correctly.
+func floorDiv(a, b int64) int64 {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+
+	return a / b
+}
+
+// checkPeriod - returns ErrInvalidPeriod if period is empty or too long.
+func checkPeriod(start, end int64) error {
+	if end <= start || time.Duration(end-start)*time.Second > MaxPeriod {
+		return fmt.Errorf("%w: end must be after start, period must not exceed %v", ErrInvalidPeriod, MaxPeriod)
+	}
+
+	return nil
+}
+
+// isTimeOff - returns true if date is one of days of time off.
+// Dates are in YYYY-MM-DD format, so they are compared as strings.
+func isTimeOff(timeOffs []*models.TimeOff, date string) bool {
+	for _, timeOff := range timeOffs {
+		if timeOff.From <= date && date <= timeOff.To {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memberOf - returns function which checks if user was member of department at given time.
+func memberOf(memberships []*models.Membership, departID int64) func(int64) bool {
+	return func(at int64) bool {
+		for _, membership := range memberships {
+			if membership.DepartmentID == departID && membership.ValidFrom <= at &&
+				(membership.ValidTo == 0 || at < membership.ValidTo) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// utilization - returns total to expected time ratio, 0 if no work is expected.
+func utilization(total, expected int64) float64 {
+	if expected == 0 {
+		return 0
+	}
+
+	return float64(total) / float64(expected)
+}
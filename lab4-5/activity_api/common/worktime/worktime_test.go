@@ -0,0 +1,122 @@
+package worktime
+
+import (
+	"activity_api/common/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+const hour = int64(time.Hour / time.Second)
+
+// monday - 2026-10-19 00:00 UTC.
+var monday = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC).Unix()
+
+func Test_Build(t *testing.T) {
+	schedule := DefaultSchedule()
+	timeOffs := []*models.TimeOff{{From: "2026-10-21", To: "2026-10-21"}}
+	activity := []*models.DailyActivity{
+		{Day: monday / day, TotalTime: 9 * hour, ActiveTime: 7 * hour},
+		{Day: monday/day + 1, TotalTime: 4 * hour, ActiveTime: 4 * hour},
+		{Day: monday/day + 2, TotalTime: hour, ActiveTime: hour},     // time off
+		{Day: monday/day + 5, TotalTime: 2 * hour, ActiveTime: hour}, // saturday
+	}
+	// The whole week, from Monday to Sunday.
+	report := Build(schedule, timeOffs, activity, monday, monday+7*day, nil)
+
+	if report.From != "2026-10-19" || report.To != "2026-10-25" || len(report.Days) != 7 {
+		t.Fatalf("unexpected period: %s - %s, days: %d", report.From, report.To, len(report.Days))
+	}
+
+	if report.ExpectedTime != 4*workDay || report.TotalTime != 16*hour || report.ActiveTime != 13*hour {
+		t.Errorf("unexpected sums: %+v", report)
+	}
+
+	if report.TimeOffDays != 1 || !report.Days[2].TimeOff || report.Days[2].ExpectedTime != 0 {
+		t.Errorf("time off isn't excluded: %+v", report.Days[2])
+	}
+	// Monday 1 hour, time off 1 hour and Saturday 2 hours.
+	if report.Overtime != 4*hour || report.OvertimeDays != 3 || report.Days[1].Overtime != 0 {
+		t.Errorf("unexpected overtime: %d in %d days", report.Overtime, report.OvertimeDays)
+	}
+
+	if report.Utilization != 0.5 {
+		t.Errorf("utilization 0.5 expected, got %v", report.Utilization)
+	}
+
+	t.Run("Partial_days", func(t *testing.T) {
+		report := Build(schedule, nil, nil, monday+12*hour, monday+day+1, nil)
+
+		if report.From != "2026-10-19" || report.To != "2026-10-20" || report.ExpectedTime != 2*workDay {
+			t.Errorf("whole days of period expected, got: %+v", report)
+		}
+	})
+
+	t.Run("Time_zone", func(t *testing.T) {
+		zoned := DefaultSchedule()
+		zoned.UTCOffset = -5 * hour
+		// Monday 00:00 UTC is Sunday evening 5 hours west.
+		report := Build(zoned, nil, nil, monday, monday+day, nil)
+
+		if report.From != "2026-10-18" || report.To != "2026-10-19" || report.ExpectedTime != workDay {
+			t.Errorf("days of schedule time zone expected, got: %+v", report)
+		}
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		memberships := []*models.Membership{
+			{DepartmentID: 1, ValidFrom: 0, ValidTo: monday + 2*day},
+			{DepartmentID: 2, ValidFrom: monday + 2*day},
+		}
+		report := Build(schedule, nil, activity, monday, monday+7*day, memberOf(memberships, 1))
+
+		if len(report.Days) != 2 || report.TotalTime != 13*hour {
+			t.Errorf("only days of membership expected, got: %+v", report)
+		}
+	})
+}
+
+func Test_Resolve(t *testing.T) {
+	departs := []*models.Department{
+		{DepartmentID: 1},
+		{DepartmentID: 2, ParentID: 1},
+		{DepartmentID: 3, ParentID: 2},
+		{DepartmentID: 4},
+	}
+	schedules := []*models.WorkSchedule{
+		{DepartmentID: 1, Monday: 1},
+		{UserID: 10, Monday: 2},
+	}
+
+	cases := []struct {
+		name     string
+		user     *models.User
+		expected int64
+	}{
+		{"Own", &models.User{UserID: 10, DepartmentID: 3}, 2},
+		{"Ancestor", &models.User{UserID: 11, DepartmentID: 3}, 1},
+		{"Default", &models.User{UserID: 12, DepartmentID: 4}, workDay},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if schedule := Resolve(c.user, schedules, departs); schedule.Monday != c.expected {
+				t.Errorf("schedule with Monday %d expected, got: %+v", c.expected, schedule)
+			}
+		})
+	}
+}
+
+func Test_CheckPeriod(t *testing.T) {
+	if err := checkPeriod(monday, monday); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("empty period is accepted: %v", err)
+	}
+
+	if err := checkPeriod(monday, monday+367*day); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("too long period is accepted: %v", err)
+	}
+
+	if err := checkPeriod(monday, monday+day); err != nil {
+		t.Errorf("valid period is rejected: %v", err)
+	}
+}
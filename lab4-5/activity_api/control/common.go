@@ -2,12 +2,18 @@ package control
 
 import (
 	"activity_api/common/key_generator"
-	"activity_api/data_manager/cache"
+	"activity_api/common/log_writer"
 	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
 	"os"
 )
 
-const pingersNum = 2
+const (
+	pingersNum     = 2 // pingers of db and cache
+	dispatchersNum = 1 // webhook dispatcher
+	schedulersNum  = 1 // job scheduler
+)
 
 // iManageable - interface for service control.
 // If service is unavailable, pingers will try to recover service via Open\Close functions.
@@ -17,18 +23,43 @@ type iManageable interface {
 	OK() error
 }
 
-// AAServiceConfig - config for AAService
-type AAServiceConfig struct {
-	CacheType int
+// Possible log formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// newLogger - creates logger by config. If log file is set, log is written both to stdout and to the file,
+// returned closer should be closed on service stop.
+func newLogger(config *AAServiceConfig) (*logrus.Logger, io.Closer, error) {
+	logger := &logrus.Logger{
+		Formatter: new(logrus.TextFormatter),
+		Out:       os.Stdout,
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.Level(config.LogLevel),
+	}
+
+	switch config.LogFormat {
+	case "", LogFormatText:
+	case LogFormatJSON:
+		logger.Formatter = new(logrus.JSONFormatter)
+	default:
+		return logger, nil, fmt.Errorf("unsupported log format: %s", config.LogFormat)
+	}
 
-	DbType     int    // db type, 0 - SQLite
-	ConnString string // Conn string to DB
-	Addr       string // Addr of service to listen
+	if config.LogFile == "" {
+		return logger, nil, nil
+	}
+
+	file, err := log_writer.NewRotatingFile(config.LogFile, config.LogMaxSize, config.LogMaxBackups)
+
+	if err != nil {
+		return logger, nil, fmt.Errorf("NewRotatingFile(): %w", err)
+	}
 
-	LogLevel uint32 // Log level for logrus
-	LogFile  string // File to log in // temporary unused, I didn't write log to a file for now
+	logger.Out = io.MultiWriter(os.Stdout, file)
 
-	Cache *cache.ICacheConfig // Config for cache manager
+	return logger, file, nil
 }
 
 // Set keys to env of the project
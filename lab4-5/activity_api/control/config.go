@@ -0,0 +1,340 @@
+package control
+
+import (
+	"activity_api/common/anonymity"
+	"activity_api/common/error_manage"
+	"activity_api/common/tls_manager"
+	"activity_api/data_manager/cache"
+	"activity_api/data_manager/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultShutdownTimeout  = 15 * time.Second
+	defaultPingInterval     = 10 * time.Second
+	defaultBreakerThreshold = 3
+	defaultBackoffInitial   = time.Second
+	defaultBackoffMax       = time.Minute
+	defaultLegacySunset     = "2027-06-30"
+	defaultWebhookAttempts  = 8
+	defaultWebhookBackoff   = time.Hour
+	defaultAlertInterval    = time.Minute
+	defaultRetention        = 24 * time.Hour
+	// sunsetLayout - layout of LegacySunset date.
+	sunsetLayout = "2006-01-02"
+)
+
+// AAServiceConfig - config for AAService.
+// Fields marked as reloadable are applied on SIGHUP without restart, others require restart.
+type AAServiceConfig struct {
+	CacheType cache.Type // cache type: "redis" or "mock"
+
+	DbType     db.Type // db type: "sqlite"
+	ConnString string  // Conn string to DB
+	Addr       string  // Addr of service to listen
+
+	ShutdownTimeout int // Seconds to wait for in-flight requests on stop, 0 - default (15)
+	ShutdownDelay   int // Seconds to keep serving after readiness flipped to unhealthy on stop
+
+	PingInterval     int // Seconds between pings of healthy db and cache, 0 - default (10)
+	BreakerThreshold int // Failed pings in a row after which requests to dependency fail fast, 0 - default (3)
+	BackoffMax       int // Max seconds between restart attempts of unavailable dependency, 0 - default (60)
+
+	WebhookMaxAttempts int // Attempts of webhook delivery before it's moved to dead-letter list, 0 - default (8)
+	WebhookBackoffMax  int // Max seconds between attempts of webhook delivery, 0 - default (3600)
+
+	AlertInterval int // Seconds between evaluations of alert rules, 0 - default (60)
+
+	ArchiveDir        string // Directory of archives of activity records, empty - retention policies aren't applied
+	RetentionInterval int    // Seconds between runs of retention policies, 0 - default (86400)
+
+	LegacySunset string // Date (YYYY-MM-DD) after which routes without /v1 prefix could be removed, empty - not planned
+
+	// Superadmin is created (or its password is updated) on startup, its name can't be registered.
+	Superadmin         string // Name of default tenant admin allowed to create and suspend tenants, empty - nobody
+	SuperadminPassword string `secret:"true"` // Login password of Superadmin, could be set as "file:<path>"
+
+	// With ReportNoise 0 only min group protects users: time of one user is exact difference of reports
+	// of overlapping periods (e.g. before and after user joined), so 0 must be used for testing only.
+	ReportMinGroup int     // Min number of users of department in anonymized reports of analysts, 0 - default (5)
+	ReportNoise    float64 // Scale of Laplace noise added to time of anonymized reports, seconds, default - 3600
+
+	RateLimit float64 // Requests per second allowed for one client, 0 - unlimited (reloadable)
+	RateBurst int     // Requests allowed for one client at once, 0 - same as RateLimit (reloadable)
+
+	LogLevel      LogLevel // Log level for logrus: name ("info") or number (4) (reloadable)
+	LogFormat     string   // Log format: "text" (default) or "json"
+	LogFile       string   // File to log in, if empty - log is written to stdout only
+	LogMaxSize    int      // Max size of log file in MB before rotation, 0 - default (100 MB)
+	LogMaxBackups int      // Number of rotated log files to keep, 0 - default (5)
+
+	TLS   *tls_manager.Config // TLS config, if cert isn't set - api is served over plain http
+	Cache *cache.ICacheConfig // Config for cache manager
+}
+
+// DefaultConfig - returns config with default values, which are overridden by config file, env and flags.
+func DefaultConfig() *AAServiceConfig {
+	return &AAServiceConfig{
+		CacheType:          cache.Redis,
+		DbType:             db.SQLite,
+		ConnString:         "AAServiceDB.db",
+		Addr:               "0.0.0.0:9332",
+		ShutdownTimeout:    int(defaultShutdownTimeout / time.Second),
+		PingInterval:       int(defaultPingInterval / time.Second),
+		BreakerThreshold:   defaultBreakerThreshold,
+		BackoffMax:         int(defaultBackoffMax / time.Second),
+		WebhookMaxAttempts: defaultWebhookAttempts,
+		WebhookBackoffMax:  int(defaultWebhookBackoff / time.Second),
+		AlertInterval:      int(defaultAlertInterval / time.Second),
+		RetentionInterval:  int(defaultRetention / time.Second),
+		ReportMinGroup:     anonymity.DefaultMinGroup,
+		ReportNoise:        anonymity.DefaultNoise,
+		LogLevel:           LogLevel(logrus.InfoLevel),
+		LogFormat:          LogFormatText,
+		LegacySunset:       defaultLegacySunset,
+		TLS: &tls_manager.Config{
+			ClientAuth: tls_manager.ClientAuthNone,
+		},
+		Cache: &cache.ICacheConfig{
+			Address: "localhost:6379",
+		},
+	}
+}
+
+// Validate - checks config values, returns all found problems at once.
+func (c *AAServiceConfig) Validate() error {
+	var errs error_manage.Errors
+
+	if c.ConnString == "" {
+		errs = errs.Append(errors.New("ConnString: is empty"))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = errs.Append(fmt.Errorf("Addr: %w", err))
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		errs = errs.Append(fmt.Errorf("Addr: invalid port %q", port))
+	}
+
+	nonNegative := map[string]int{
+		"ShutdownTimeout":    c.ShutdownTimeout,
+		"ShutdownDelay":      c.ShutdownDelay,
+		"PingInterval":       c.PingInterval,
+		"BreakerThreshold":   c.BreakerThreshold,
+		"BackoffMax":         c.BackoffMax,
+		"WebhookMaxAttempts": c.WebhookMaxAttempts,
+		"WebhookBackoffMax":  c.WebhookBackoffMax,
+		"AlertInterval":      c.AlertInterval,
+		"RetentionInterval":  c.RetentionInterval,
+		"ReportMinGroup":     c.ReportMinGroup,
+		"RateBurst":          c.RateBurst,
+		"LogMaxSize":         c.LogMaxSize,
+		"LogMaxBackups":      c.LogMaxBackups,
+	}
+
+	for name, value := range nonNegative {
+		if value < 0 {
+			errs = errs.Append(fmt.Errorf("%s: must not be negative, got %d", name, value))
+		}
+	}
+
+	if _, err := c.legacySunset(); err != nil {
+		errs = errs.Append(fmt.Errorf("LegacySunset: %w", err))
+	}
+
+	if c.RateLimit < 0 {
+		errs = errs.Append(fmt.Errorf("RateLimit: must not be negative, got %v", c.RateLimit))
+	}
+
+	if c.Superadmin != "" && c.SuperadminPassword == "" {
+		errs = errs.Append(errors.New("SuperadminPassword: is required for superadmin"))
+	}
+
+	if c.ReportNoise < 0 {
+		errs = errs.Append(fmt.Errorf("ReportNoise: must not be negative, got %v", c.ReportNoise))
+	}
+
+	if c.LogFormat != LogFormatText && c.LogFormat != LogFormatJSON {
+		errs = errs.Append(fmt.Errorf("LogFormat: unknown format %q, expected one of: text, json", c.LogFormat))
+	}
+
+	if c.Cache == nil {
+		errs = errs.Append(errors.New("Cache: is not set"))
+	} else if c.CacheType == cache.Redis && c.Cache.Address == "" {
+		errs = errs.Append(errors.New("Cache.Address: is required for redis"))
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		errs = errs.Append(fmt.Errorf("TLS: %w", err))
+	}
+
+	return errs.ErrOrNil()
+}
+
+// shutdownTimeout - returns shutdown timeout from config, or default one if it isn't set.
+func (c *AAServiceConfig) shutdownTimeout() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+
+	return time.Duration(c.ShutdownTimeout) * time.Second
+}
+
+// pingInterval - returns ping interval from config, or default one if it isn't set.
+func (c *AAServiceConfig) pingInterval() time.Duration {
+	if c.PingInterval <= 0 {
+		return defaultPingInterval
+	}
+
+	return time.Duration(c.PingInterval) * time.Second
+}
+
+// breakerThreshold - returns breaker threshold from config, or default one if it isn't set.
+func (c *AAServiceConfig) breakerThreshold() int {
+	if c.BreakerThreshold <= 0 {
+		return defaultBreakerThreshold
+	}
+
+	return c.BreakerThreshold
+}
+
+// backoffMax - returns max backoff from config, or default one if it isn't set.
+func (c *AAServiceConfig) backoffMax() time.Duration {
+	if c.BackoffMax <= 0 {
+		return defaultBackoffMax
+	}
+
+	return time.Duration(c.BackoffMax) * time.Second
+}
+
+// webhookBackoffMax - returns max delay between webhook delivery attempts from config, or default one if it isn't set.
+func (c *AAServiceConfig) webhookBackoffMax() time.Duration {
+	if c.WebhookBackoffMax <= 0 {
+		return defaultWebhookBackoff
+	}
+
+	return time.Duration(c.WebhookBackoffMax) * time.Second
+}
+
+// alertInterval - returns interval between evaluations of alert rules from config, or default one if it isn't set.
+func (c *AAServiceConfig) alertInterval() time.Duration {
+	if c.AlertInterval <= 0 {
+		return defaultAlertInterval
+	}
+
+	return time.Duration(c.AlertInterval) * time.Second
+}
+
+// retentionInterval - returns interval between runs of retention policies from config, or default one if it isn't set.
+func (c *AAServiceConfig) retentionInterval() time.Duration {
+	if c.RetentionInterval <= 0 {
+		return defaultRetention
+	}
+
+	return time.Duration(c.RetentionInterval) * time.Second
+}
+
+// legacySunset - returns sunset date of deprecated routes, zero time if it isn't set.
+func (c *AAServiceConfig) legacySunset() (time.Time, error) {
+	if c.LegacySunset == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(sunsetLayout, c.LegacySunset)
+}
+
+// LogLevel - logrus log level. In config it could be set both by name ("debug") and by number (5),
+// numbers are kept for compatibility with old configs.
+type LogLevel uint32
+
+// String - returns log level name.
+func (l LogLevel) String() string {
+	return logrus.Level(l).String()
+}
+
+// MarshalText - marshals log level as its name.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText - parses log level from name or number.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	if number, err := strconv.ParseUint(string(text), 10, 32); err == nil {
+		if logrus.Level(number) > logrus.TraceLevel {
+			return fmt.Errorf("unknown log level %d, expected 0-%d", number, logrus.TraceLevel)
+		}
+
+		*l = LogLevel(number)
+
+		return nil
+	}
+
+	level, err := logrus.ParseLevel(string(text))
+
+	if err != nil {
+		return fmt.Errorf("logrus.ParseLevel(): %w", err)
+	}
+
+	*l = LogLevel(level)
+
+	return nil
+}
+
+// UnmarshalJSON - parses log level from JSON string or number.
+func (l *LogLevel) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		return l.UnmarshalText([]byte(name))
+	}
+
+	return l.UnmarshalText(data)
+}
+
+// restartRequired - returns names of changed settings which can't be applied without restart.
+func restartRequired(old, next *AAServiceConfig) []string {
+	changed := make([]string, 0)
+	settings := map[string][2]interface{}{
+		"CacheType":          {old.CacheType, next.CacheType},
+		"DbType":             {old.DbType, next.DbType},
+		"ConnString":         {old.ConnString, next.ConnString},
+		"Addr":               {old.Addr, next.Addr},
+		"ShutdownTimeout":    {old.ShutdownTimeout, next.ShutdownTimeout},
+		"ShutdownDelay":      {old.ShutdownDelay, next.ShutdownDelay},
+		"PingInterval":       {old.PingInterval, next.PingInterval},
+		"BreakerThreshold":   {old.BreakerThreshold, next.BreakerThreshold},
+		"BackoffMax":         {old.BackoffMax, next.BackoffMax},
+		"WebhookMaxAttempts": {old.WebhookMaxAttempts, next.WebhookMaxAttempts},
+		"WebhookBackoffMax":  {old.WebhookBackoffMax, next.WebhookBackoffMax},
+		"AlertInterval":      {old.AlertInterval, next.AlertInterval},
+		"ArchiveDir":         {old.ArchiveDir, next.ArchiveDir},
+		"RetentionInterval":  {old.RetentionInterval, next.RetentionInterval},
+		"Superadmin":         {old.Superadmin, next.Superadmin},
+		"SuperadminPassword": {old.SuperadminPassword, next.SuperadminPassword},
+		"ReportMinGroup":     {old.ReportMinGroup, next.ReportMinGroup},
+		"ReportNoise":        {old.ReportNoise, next.ReportNoise},
+		"LogFormat":          {old.LogFormat, next.LogFormat},
+		"LogFile":            {old.LogFile, next.LogFile},
+		"LogMaxSize":         {old.LogMaxSize, next.LogMaxSize},
+		"LogMaxBackups":      {old.LogMaxBackups, next.LogMaxBackups},
+		"TLS":                {old.TLS, next.TLS},
+		"Cache":              {old.Cache, next.Cache},
+	}
+
+	for name, values := range settings {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
@@ -2,13 +2,25 @@ package control
 
 import (
 	"activity_api/api"
+	"activity_api/api/auth"
+	"activity_api/common/alerting"
+	"activity_api/common/anonymity"
+	"activity_api/common/backoff"
+	"activity_api/common/breaker"
 	"activity_api/common/cancellation"
+	"activity_api/common/error_manage"
+	"activity_api/common/models"
+	"activity_api/common/retention"
+	"activity_api/common/scheduler"
+	"activity_api/common/tls_manager"
+	"activity_api/common/webhook"
 	"activity_api/data_manager/cache"
 	"activity_api/data_manager/db"
 	"activity_api/data_manager/db/core"
 	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"io"
 	"os"
 	"os/signal"
 	"sync"
@@ -16,33 +28,67 @@ import (
 	"time"
 )
 
-// TODO: implement HTTPS. I already have functions for JWT to generate valid keys, so in future I need to get .crt
+// ConfigLoader - loads config again on reload signal.
+type ConfigLoader func() (*AAServiceConfig, error)
+
 // AAService - config for service
 type AAService struct {
-	addr string // addr of service
-
-	api   *api.AApi           // service api
-	cache cache.ICacheManager // used for storing tokens in auth
-	db    core.ISQLDatabase   // SQL db for user data
-
-	logger logrus.FieldLogger
-	cancel *cancellation.Token // service cancellation token for cancel management
-	wg     sync.WaitGroup      // used to wait for all processes to finish
+	addr            string           // addr of service
+	shutdownTimeout time.Duration    // time to wait for in-flight requests on stop
+	config          *AAServiceConfig // config service was started (or last reloaded) with
+	configLoader    ConfigLoader     // used to reload config on SIGHUP, reload is disabled if nil
+
+	api      *api.AApi            // service api
+	webhooks *webhook.Dispatcher  // sends queued domain events to webhooks
+	jobs     *scheduler.Scheduler // runs periodic jobs
+	alerts   *alerting.Evaluator  // evaluates alert rules, run by jobs
+	archiver *retention.Archiver  // applies retention policies, run by jobs, nil if archive dir isn't set
+	cache    cache.ICacheManager  // used for storing tokens in auth
+	db       core.ISQLDatabase    // SQL db for user data
+
+	cacheBreaker *breaker.Breaker // opens while cache is unavailable
+	dbBreaker    *breaker.Breaker // opens while db is unavailable
+	pingInterval time.Duration    // interval between pings of healthy services
+	backoff      *backoff.Backoff // delays between restart attempts of unavailable services
+
+	logger     logrus.FieldLogger
+	baseLogger *logrus.Logger      // root logger, kept to change log level on reload
+	logFile    io.Closer           // log file, nil if log is written only to stdout
+	cancel     *cancellation.Token // service cancellation token for cancel management
+	wg         sync.WaitGroup      // used to wait for all processes to finish
 }
 
 // NewAAService - returns new AAService with given parameters
-func NewAAService(config *AAServiceConfig) *AAService {
-	logger := &logrus.Logger{
-		Formatter: new(logrus.TextFormatter),
-		Out:       os.Stdout,
-		Level:     logrus.Level(config.LogLevel),
+func NewAAService(config *AAServiceConfig) (*AAService, error) {
+	logger, logFile, err := newLogger(config)
+	// Service still can work without log file, so just report the problem to stdout.
+	if err != nil {
+		logger.WithField("func", "NewAAService").Errorf("newLogger() error: %v", err)
+	}
+	// Unlike log file, service must not silently fall back to plain http if TLS is configured.
+	tlsConfig, err := tls_manager.NewServerConfig(config.TLS, logger)
+
+	if err != nil {
+		return nil, fmt.Errorf("tls_manager.NewServerConfig(): %w", err)
+	}
+
+	legacySunset, err := config.legacySunset()
+
+	if err != nil {
+		return nil, fmt.Errorf("legacySunset(): %w", err)
 	}
 
 	aaService := &AAService{
-		addr: config.Addr,
+		addr:            config.Addr,
+		shutdownTimeout: config.shutdownTimeout(),
+		config:          config,
+		baseLogger:      logger,
+		pingInterval:    config.pingInterval(),
+		backoff:         backoff.NewBackoff(defaultBackoffInitial, config.backoffMax()),
+		logFile:         logFile,
 		cancel: cancellation.NewCustomToken(
 			context.Background(),
-			pingersNum, // pingersNum - number of pingers that will ping IManageable services
+			pingersNum+dispatchersNum+schedulersNum, // pingers of IManageable services, webhook dispatcher and jobs
 		),
 		db:     db.NewAADatabase(config.DbType, config.ConnString, logger),
 		logger: logger.WithField("module", "AAService"),
@@ -54,29 +100,95 @@ func NewAAService(config *AAServiceConfig) *AAService {
 		aaService.cancel.Context(),
 		logger,
 	)
+	// Breakers are named after services, so events and api errors show which dependency is down.
+	aaService.dbBreaker = breaker.NewBreaker(aaService.db.Describe(), config.breakerThreshold())
+	aaService.cacheBreaker = breaker.NewBreaker(aaService.cache.Describe(), config.breakerThreshold())
+	aaService.dbBreaker.Subscribe(aaService.onBreakerEvent)
+	aaService.cacheBreaker.Subscribe(aaService.onBreakerEvent)
+
+	aaService.webhooks = webhook.NewDispatcher(
+		aaService.db,
+		backoff.NewBackoff(defaultBackoffInitial, config.webhookBackoffMax()),
+		config.WebhookMaxAttempts,
+		logger,
+	)
+
+	aaService.alerts = alerting.NewEvaluator(aaService.db, logger).
+		Register(models.AlertNotifierLog, alerting.NewLogNotifier(logger)).
+		Register(models.AlertNotifierWebhook, alerting.NewWebhookNotifier(aaService.webhooks))
 
-	aaService.api = api.NewAApi(config.Addr, aaService.db, aaService.cache, aaService.cancel.Context(), logger)
+	if config.ArchiveDir != "" {
+		aaService.archiver = retention.NewArchiver(aaService.db, config.ArchiveDir, logger)
+	}
 
-	return aaService
+	aaService.jobs = scheduler.NewScheduler(logger)
+
+	if err := aaService.registerJobs(config); err != nil {
+		return nil, fmt.Errorf("registerJobs(): %w", err)
+	}
+
+	aaService.api = api.NewAApi(
+		&api.Config{
+			Addr:          config.Addr,
+			ShutdownDelay: time.Duration(config.ShutdownDelay) * time.Second,
+			TLS:           tlsConfig,
+			RateLimit:     config.RateLimit,
+			RateBurst:     config.RateBurst,
+			LegacySunset:  legacySunset,
+			Webhooks:      aaService.webhooks,
+			Jobs:          aaService.jobs,
+			Superadmin:    config.Superadmin,
+			Anonymizer:    anonymity.NewAnonymizer(int64(config.ReportMinGroup), config.ReportNoise),
+		},
+		aaService.db,
+		aaService.cache,
+		aaService.dbBreaker,
+		aaService.cacheBreaker,
+		aaService.cancel.Context(),
+		logger,
+	)
+
+	return aaService, nil
 }
 
-// stop - stops AAService modules.
-func (a *AAService) stop() (err error) {
-	a.logger.WithField("func", "stop").Info("Stopping AAService modules... ")
+// SetConfigLoader - sets function that loads config on SIGHUP.
+func (a *AAService) SetConfigLoader(loader ConfigLoader) *AAService {
+	a.configLoader = loader
+
+	return a
+}
+
+// stop - stops AAService modules. Api is stopped first and gracefully, so in-flight requests
+// are able to finish before db and cache are closed. All errors are collected, none of them is lost.
+func (a *AAService) stop() error {
+	entry := a.logger.WithField("func", "stop")
+	entry.Info("Stopping AAService modules... ")
+
+	var errs error_manage.Errors
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
 
-	if err = a.api.Close(); err != nil {
-		err = fmt.Errorf("AAService api Close(): %w", err)
+	if err := a.api.Shutdown(ctx); err != nil {
+		errs = errs.Append(fmt.Errorf("AAService api Shutdown(): %w", err))
 	}
 
-	if err = a.db.Close(); err != nil {
-		err = fmt.Errorf("AAService db Close(): %w", err)
+	if err := a.db.Close(); err != nil {
+		errs = errs.Append(fmt.Errorf("AAService db Close(): %w", err))
 	}
 
-	if err = a.cache.Close(); err != nil {
-		err = fmt.Errorf("AAService cache Close(): %w", err)
+	if err := a.cache.Close(); err != nil {
+		errs = errs.Append(fmt.Errorf("AAService cache Close(): %w", err))
 	}
+	// Log file is closed last, so all modules are able to log while stopping.
+	if a.logFile != nil {
+		entry.Info("Closing log file...")
 
-	return
+		if err := a.logFile.Close(); err != nil {
+			errs = errs.Append(fmt.Errorf("AAService log file Close(): %w", err))
+		}
+	}
+
+	return errs.ErrOrNil()
 }
 
 // Stop - stops AAService and waits for all processes to finish.
@@ -108,17 +220,17 @@ func (a *AAService) Run() {
 		entry.Fatalf("cache open error: %v", err)
 	}
 
-	go a.pinger(a.db)    // Start pinger for db
-	go a.pinger(a.cache) // Start pinger for redis
+	go a.pinger(a.db, a.dbBreaker)       // Start pinger for db
+	go a.pinger(a.cache, a.cacheBreaker) // Start pinger for redis
+	go a.dispatchWebhooks()
+	go a.runJobs()
 	go a.api.Start()
-	// Chanel for signal to wait interrupt, so ctrl + c could stop service.
+	// Chanel for signal to wait interrupt, so ctrl + c could stop service, SIGHUP reloads config.
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
 
-	go func() {
-		entry.Info("Incoming signal:", <-signals)
-		a.cancel.Cancel() // Cancel all processes
-	}()
+	go a.handleSignals(signals)
 
 	entry.Info("Awaiting stop signal...")
 	a.cancel.Await() // Wait for all services to stop.
@@ -129,6 +241,68 @@ func (a *AAService) Run() {
 	}
 }
 
+// handleSignals - reloads config on SIGHUP, cancels service on any other signal.
+func (a *AAService) handleSignals(signals <-chan os.Signal) {
+	entry := a.logger.WithField("func", "handleSignals")
+
+	for {
+		select {
+		case <-a.cancel.Cancelled():
+			return
+		case sig := <-signals:
+			entry.Info("Incoming signal:", sig)
+
+			if sig == syscall.SIGHUP {
+				a.reload()
+
+				continue
+			}
+
+			a.cancel.Cancel() // Cancel all processes
+
+			return
+		}
+	}
+}
+
+// reload - loads config again and applies settings that are safe to change without restart:
+// log level and rate limit. Changes of other settings are reported, but ignored until restart.
+func (a *AAService) reload() {
+	entry := a.logger.WithField("func", "reload")
+
+	if a.configLoader == nil {
+		entry.Warn("Config loader isn't set, reload is skipped")
+
+		return
+	}
+
+	config, err := a.configLoader()
+	// Invalid config is ignored entirely, service keeps working with previous one.
+	if err != nil {
+		entry.Errorf("Config reload error, keeping previous config: %v", err)
+
+		return
+	}
+
+	if config.LogLevel != a.config.LogLevel {
+		entry.Infof("Changing log level: %s -> %s", a.config.LogLevel, config.LogLevel)
+		a.baseLogger.SetLevel(logrus.Level(config.LogLevel))
+	}
+
+	if config.RateLimit != a.config.RateLimit || config.RateBurst != a.config.RateBurst {
+		a.api.SetRateLimit(config.RateLimit, config.RateBurst)
+	}
+
+	if restart := restartRequired(a.config, config); len(restart) > 0 {
+		entry.Warnf("Changed settings require restart and are ignored: %v", restart)
+	}
+	// Keep startup values of not reloadable settings, so they are reported again on next reload.
+	a.config.LogLevel = config.LogLevel
+	a.config.RateLimit = config.RateLimit
+	a.config.RateBurst = config.RateBurst
+	entry.Info("Config reloaded")
+}
+
 // initDatabase - opens db connection, and creates required tables for database if they don't exist.
 func (a *AAService) initDatabase() error {
 	a.logger.WithField("func", "initDatabase").Info("Preparing DB to work...")
@@ -137,21 +311,49 @@ func (a *AAService) initDatabase() error {
 		return fmt.Errorf("AAService db.Open(): %w", err)
 	}
 	// Create missing tables
-	if err := a.db.CreateDB(); err != nil {
+	if err := a.db.CreateDB(a.cancel.Context()); err != nil {
 		return fmt.Errorf("AAService db.Create(): %w", err)
 	}
 
+	if err := a.setSuperadmin(); err != nil {
+		return fmt.Errorf("AAService setSuperadmin(): %w", err)
+	}
+
 	return nil
 }
 
+// setSuperadmin - creates superadmin of default tenant from config, or updates its password.
+// Superadmin can't register, so its name can't be taken before service starts.
+func (a *AAService) setSuperadmin() error {
+	if a.config.Superadmin == "" {
+		return a.db.SetSuperadmin(a.cancel.Context(), nil)
+	}
+
+	hash, err := new(auth.PasswordManager).HashPassword(a.config.SuperadminPassword)
+
+	if err != nil {
+		return fmt.Errorf("HashPassword(): %w", err)
+	}
+
+	return a.db.SetSuperadmin(a.cancel.Context(), &models.Admin{
+		Username: a.config.Superadmin,
+		Hash:     hash,
+		TenantID: core.DefaultTenant,
+	})
+}
+
 // pinger - pings given service and tries to restart it if it crashes.
-func (a *AAService) pinger(service iManageable) {
+// Healthy service is pinged every ping interval, unavailable one is restarted with exponential backoff,
+// and its breaker stays open until restart succeeds.
+func (a *AAService) pinger(service iManageable, b *breaker.Breaker) {
 	entry := a.logger.WithField("func", "pinger")
 	entry.Info("Starting pinger for service:", service.Describe())
 
 	defer a.cancel.Done()
-	ticker := time.NewTicker(time.Second * 10)
-	defer ticker.Stop()
+	timer := time.NewTimer(a.pingInterval)
+	defer timer.Stop()
+
+	attempt := 0 // failed attempts in a row
 
 	for {
 		select {
@@ -159,21 +361,34 @@ func (a *AAService) pinger(service iManageable) {
 			entry.Info("Stopping for:", service.Describe())
 
 			return
-		case <-ticker.C: // ping db every tick.
-			a.ping(service)
+		case <-timer.C: // ping service every tick.
+			b.Probe() // no-op if breaker is closed
+
+			if err := a.ping(service); err != nil {
+				b.Failure(err)
+				timer.Reset(a.backoff.Next(attempt))
+				attempt++
+
+				continue
+			}
+
+			b.Success()
+			timer.Reset(a.pingInterval)
+			attempt = 0
 		}
 	}
 }
 
 // ping - checks service for connection, tries to reconnect if it crashes.
-func (a *AAService) ping(service iManageable) {
+// Returns error if service is still unavailable.
+func (a *AAService) ping(service iManageable) error {
 	entry := a.logger.WithField("func", "ping")
 
-	entry.Info("Doing ping for service:", service.Describe())
+	entry.Debug("Doing ping for service:", service.Describe())
 	err := service.OK()
 
 	if err == nil {
-		return
+		return nil
 	}
 
 	entry.Errorf("Ping %s, OK() error: %v", service.Describe(), err)
@@ -182,8 +397,48 @@ func (a *AAService) ping(service iManageable) {
 	if err = service.Restart(); err != nil {
 		entry.Errorf("Ping %s, Restart() error: %v", service.Describe(), err)
 
-		return
+		return fmt.Errorf("Restart(): %w", err)
+	}
+	// Some services (e.g. SQL) don't connect on open, so check that restarted service is actually available.
+	if err = service.OK(); err != nil {
+		entry.Errorf("Ping %s after restart, OK() error: %v", service.Describe(), err)
+
+		return fmt.Errorf("OK(): %w", err)
 	}
 
 	entry.Infof("Reconnect to %s successful!", service.Describe())
+
+	return nil
+}
+
+// dispatchWebhooks - sends queued webhook deliveries until service is stopping.
+func (a *AAService) dispatchWebhooks() {
+	defer a.cancel.Done()
+
+	a.webhooks.Run(a.cancel.Context())
+}
+
+// runJobs - runs periodic jobs until service is stopping, running jobs are awaited before db is closed.
+func (a *AAService) runJobs() {
+	defer a.cancel.Done()
+
+	a.jobs.Run(a.cancel.Context())
+}
+
+// onBreakerEvent - logs breaker state changes.
+func (a *AAService) onBreakerEvent(event breaker.Event) {
+	entry := a.logger.WithFields(logrus.Fields{
+		"func":    "onBreakerEvent",
+		"service": event.Name,
+		"from":    event.From.String(),
+		"to":      event.To.String(),
+	})
+
+	if event.To == breaker.Open {
+		entry.Errorf("Breaker opened, requests fail fast, last error: %v", event.Err)
+
+		return
+	}
+
+	entry.Info("Breaker state changed")
 }
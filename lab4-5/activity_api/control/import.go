@@ -0,0 +1,78 @@
+package control
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// ImportUsers - imports users of given rows to db of given config without starting service.
+// Log is written to stderr only, so results of import could be written to stdout.
+func ImportUsers(config *AAServiceConfig, rows []*models.ImportRow, dryRun bool) (*models.ImportResult, error) {
+	ctx := context.Background()
+	database, closeDB, err := openCLIDatabase(ctx, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeDB()
+
+	result, err := database.ImportUsers(ctx, rows, dryRun)
+
+	if err != nil {
+		return nil, fmt.Errorf("db ImportUsers(): %w", err)
+	}
+
+	return result, nil
+}
+
+// openCLIDatabase - opens db of given config for command run without starting service, its schema is created
+// or migrated. Log is written to stderr only. Returned function closes db.
+func openCLIDatabase(ctx context.Context, config *AAServiceConfig) (core.ISQLDatabase, func(), error) {
+	logger, err := newCLILogger(config)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	database := db.NewAADatabase(config.DbType, config.ConnString, logger)
+
+	if err := database.Open(); err != nil {
+		return nil, nil, fmt.Errorf("db Open(): %w", err)
+	}
+
+	closeDB := func() {
+		if err := database.Close(); err != nil {
+			logger.WithField("func", "openCLIDatabase").Warn("db Close() error:", err)
+		}
+	}
+
+	if err := database.CreateDB(ctx); err != nil {
+		closeDB()
+
+		return nil, nil, fmt.Errorf("db CreateDB(): %w", err)
+	}
+
+	return database, closeDB, nil
+}
+
+// newCLILogger - returns logger of command run without starting service, it writes to stderr only.
+func newCLILogger(config *AAServiceConfig) (*logrus.Logger, error) {
+	cliConfig := *config
+	cliConfig.LogFile = "" // log file belongs to service
+
+	logger, _, err := newLogger(&cliConfig)
+
+	if err != nil {
+		return nil, fmt.Errorf("newLogger(): %w", err)
+	}
+
+	logger.Out = os.Stderr
+
+	return logger, nil
+}
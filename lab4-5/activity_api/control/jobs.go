@@ -0,0 +1,83 @@
+package control
+
+import (
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Names of background jobs, they are used in job admin routes.
+const (
+	jobAlerts    = "alerts"    // evaluates alert rules
+	jobRetention = "retention" // archives activity records older than retention policies
+)
+
+// registerJobs - registers periodic jobs of the service in job scheduler.
+func (a *AAService) registerJobs(config *AAServiceConfig) error {
+	if err := a.jobs.Register(jobAlerts, "@every "+config.alertInterval().String(), a.evaluateAlerts); err != nil {
+		return fmt.Errorf("Register() %s: %w", jobAlerts, err)
+	}
+	// Retention job is registered only if there is directory for archives.
+	if a.archiver == nil {
+		return nil
+	}
+
+	if err := a.jobs.Register(jobRetention, "@every "+config.retentionInterval().String(), a.applyRetention); err != nil {
+		return fmt.Errorf("Register() %s: %w", jobRetention, err)
+	}
+
+	return nil
+}
+
+// evaluateAlerts - alerts job, evaluates alert rules of every active tenant at current time.
+// Failure of one tenant doesn't stop evaluation of others, the first error is returned.
+func (a *AAService) evaluateAlerts(ctx context.Context) error {
+	tenants, err := a.db.GetTenants(ctx)
+
+	if err != nil {
+		return fmt.Errorf("GetTenants(): %w", err)
+	}
+
+	var failed error
+	now := time.Now()
+
+	for _, tenant := range tenants {
+		if tenant.Suspended {
+			continue
+		}
+
+		if err := a.alerts.Evaluate(core.WithTenant(ctx, tenant.TenantID), now); err != nil && failed == nil {
+			failed = fmt.Errorf("Evaluate() tenant %d: %w", tenant.TenantID, err)
+		}
+	}
+
+	return failed
+}
+
+// applyRetention - retention job, archives records older than retention policies of every active tenant.
+// Failure of one tenant doesn't stop archiving of others, the first error is returned.
+func (a *AAService) applyRetention(ctx context.Context) error {
+	tenants, err := a.db.GetTenants(ctx)
+
+	if err != nil {
+		return fmt.Errorf("GetTenants(): %w", err)
+	}
+
+	var failed error
+	now := time.Now()
+
+	for _, tenant := range tenants {
+		if tenant.Suspended {
+			continue
+		}
+
+		_, err := a.archiver.Archive(core.WithTenant(ctx, tenant.TenantID), tenant.TenantID, now)
+
+		if err != nil && failed == nil {
+			failed = fmt.Errorf("Archive() tenant %d: %w", tenant.TenantID, err)
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,54 @@
+package control
+
+import (
+	"activity_api/common/models"
+	"activity_api/common/retention"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// RestoreArchive - loads records of archive at given path back to db of given config without starting service.
+// Records are restored to tenant of archive manifest, returns manifest and number of restored records.
+func RestoreArchive(config *AAServiceConfig, path string) (*models.ArchiveManifest, int64, error) {
+	manifest, err := retention.ReadManifest(path)
+
+	if err != nil {
+		return nil, -1, fmt.Errorf("retention.ReadManifest(): %w", err)
+	}
+
+	ctx := context.Background()
+	database, closeDB, err := openCLIDatabase(ctx, config)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	defer closeDB()
+
+	tenant, err := database.GetTenant(ctx, strconv.FormatInt(manifest.TenantID, 10))
+
+	if err != nil {
+		return nil, -1, fmt.Errorf("db GetTenant(): %w", err)
+	}
+
+	if tenant == nil {
+		return nil, -1, fmt.Errorf("tenant %d of archive doesn't exist", manifest.TenantID)
+	}
+
+	logger, err := newCLILogger(config)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	archiver := retention.NewArchiver(database, config.ArchiveDir, logger)
+	restored, err := archiver.Restore(core.WithTenant(ctx, manifest.TenantID), path, manifest)
+
+	if err != nil {
+		return nil, -1, fmt.Errorf("Restore(): %w", err)
+	}
+
+	return manifest, restored, nil
+}
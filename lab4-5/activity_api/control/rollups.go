@@ -0,0 +1,27 @@
+package control
+
+import (
+	"context"
+	"fmt"
+)
+
+// RebuildRollups - rebuilds daily activity rollups of all tenants in db of given config without starting service,
+// returns number of rollups.
+func RebuildRollups(config *AAServiceConfig) (int64, error) {
+	ctx := context.Background()
+	database, closeDB, err := openCLIDatabase(ctx, config)
+
+	if err != nil {
+		return -1, err
+	}
+
+	defer closeDB()
+
+	rebuilt, err := database.RebuildRollups(ctx)
+
+	if err != nil {
+		return -1, fmt.Errorf("db RebuildRollups(): %w", err)
+	}
+
+	return rebuilt, nil
+}
@@ -12,8 +12,8 @@ import (
 )
 
 const (
-	ICacheMock = iota
-	Redis      = iota
+	ICacheMock Type = iota
+	Redis
 	// Memcache
 	// ...
 )
@@ -34,13 +34,13 @@ type ICacheManager interface {
 // ICacheConfig - common config for cache services.
 type ICacheConfig struct {
 	Address  string
-	Password string
+	Password string `secret:"true"` // could be set as "file:<path>" to read it from file
 	DB       int
 }
 
 // NewCacheManager - returns new cache manager.
 func NewCacheManager(
-	cacheType int,
+	cacheType Type,
 	cacheConfig *ICacheConfig,
 	ctx context.Context,
 	logger logrus.FieldLogger,
@@ -52,7 +52,7 @@ func NewCacheManager(
 		return redis.NewRedisManager(cacheConfig.Address, cacheConfig.Password, cacheConfig.DB, ctx, logger)
 	default:
 		logger.WithField("func", "NewCacheManager").
-			Warnf("Unsupported cacheType: %d, using default: Redis", Redis)
+			Warnf("Unsupported cacheType: %s, using default: %s", cacheType, Redis)
 
 		return redis.NewRedisManager(cacheConfig.Address, cacheConfig.Password, cacheConfig.DB, ctx, logger)
 	}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type - cache backend type. In config it could be set both by name ("redis") and by number (1),
+// numbers are kept for compatibility with old configs.
+type Type int
+
+// typeNames - names of cache types used in config.
+var typeNames = map[Type]string{
+	ICacheMock: "mock",
+	Redis:      "redis",
+}
+
+// String - returns cache type name.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// MarshalText - marshals cache type as its name.
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText - parses cache type from name or number.
+func (t *Type) UnmarshalText(text []byte) error {
+	value := strings.ToLower(strings.TrimSpace(string(text)))
+
+	for cacheType, name := range typeNames {
+		if name == value {
+			*t = cacheType
+
+			return nil
+		}
+	}
+
+	if number, err := strconv.Atoi(value); err == nil {
+		if _, ok := typeNames[Type(number)]; ok {
+			*t = Type(number)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown cache type %q, expected one of: mock, redis", string(text))
+}
+
+// UnmarshalJSON - parses cache type from JSON string or number.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		return t.UnmarshalText([]byte(name))
+	}
+
+	return t.UnmarshalText(data)
+}
@@ -8,14 +8,14 @@ import (
 
 // All possible DB types. Service could easily migrate to another SQL db.
 const (
-	SQLite = iota
+	SQLite Type = iota
 	// MSSql
 	// MySql
 	// ...
 )
 
 // NewAADatabase - returns new AAService database interface.
-func NewAADatabase(dbType int, connString string, logger logrus.FieldLogger) core.ISQLDatabase {
+func NewAADatabase(dbType Type, connString string, logger logrus.FieldLogger) core.ISQLDatabase {
 	switch dbType {
 	case SQLite:
 		return sqlite.NewSQLite(connString, logger)
@@ -23,7 +23,7 @@ func NewAADatabase(dbType int, connString string, logger logrus.FieldLogger) cor
 	//	...
 	default:
 		logger.WithField("func", "NewAADatabase").
-			Warnf("Unsupported dbType: %d, using default SQLite", dbType)
+			Warnf("Unsupported dbType: %s, using default SQLite", dbType)
 
 		return sqlite.NewSQLite(connString, logger)
 	}
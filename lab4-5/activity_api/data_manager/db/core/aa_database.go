@@ -2,36 +2,181 @@ package core
 
 import (
 	"activity_api/common/models"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrParentNotFound - parent of created or moved department doesn't exist.
+	ErrParentNotFound = errors.New("parent department doesn't exist")
+	// ErrDepartmentCycle - department is moved under itself or its descendant.
+	ErrDepartmentCycle = errors.New("department can't be moved under itself or its descendant")
+	// ErrDepartmentNotFound - department of created or transferred user doesn't exist in tenant.
+	ErrDepartmentNotFound = errors.New("department doesn't exist")
+	// ErrUserNotFound - user of created activity record doesn't exist in tenant.
+	ErrUserNotFound = errors.New("user doesn't exist")
+	// ErrEmployeeNumberTaken - employee number of created or updated user belongs to other user of tenant.
+	ErrEmployeeNumberTaken = errors.New("employee number is taken by other user")
+	// ErrInvalidTransfer - user is transferred to current department, or before start of current membership.
+	ErrInvalidTransfer = errors.New("invalid transfer")
+	// ErrPolicyExists - tenant or department of created retention policy already has policy.
+	ErrPolicyExists = errors.New("retention policy already exists")
+	// ErrSuperadminTaken - name of superadmin belongs to admin registered without superadmin role.
+	ErrSuperadminTaken = errors.New("superadmin name is taken by other admin")
 )
 
-// TODO: Add "update" queries
 // TODO: Segregate interface into something like: UserManager, DepartmentManager, etc
-// ISQLDatabase - database interface for AAService
+// ISQLDatabase - database interface for AAService.
+// Tenant data is scoped by tenant of context (see WithTenant), methods fail with ErrNoTenant without it.
+// Admins, tenants and webhook deliveries queue aren't scoped.
 type ISQLDatabase interface {
 	ISQLCore
 
-	CreateDB() error
+	CreateDB(ctx context.Context) error
 	Describe() string
 
-	CreateAdmin(admin *models.Admin) (int64, error)
-	GetAdmin(name string) (*models.Admin, error)
-	DeleteAdmin(name string) (int64, error)
+	// CreateAdmin - creates admin in tenant of the admin, admin names are unique across tenants.
+	CreateAdmin(ctx context.Context, admin *models.Admin) (int64, error)
+	GetAdmin(ctx context.Context, name string) (*models.Admin, error)
+	DeleteAdmin(ctx context.Context, name string) (int64, error)
+	// SetSuperadmin - creates superadmin or updates its password, other admins lose superadmin role.
+	// Nil admin only removes superadmin role. ErrSuperadminTaken if name belongs to other admin,
+	// tokens of that admin would keep working, so it isn't taken over.
+	SetSuperadmin(ctx context.Context, admin *models.Admin) error
+
+	CreateTenant(ctx context.Context, tenant *models.Tenant) (int64, error)
+	GetTenants(ctx context.Context) ([]*models.Tenant, error)
+	GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error)
+	// SuspendTenant - suspends or resumes tenant, returns 0 if tenant doesn't exist.
+	SuspendTenant(ctx context.Context, tenantID string, suspended bool) (int64, error)
+
+	CreateDepartment(ctx context.Context, depart *models.Department) (int64, error)
+	GetDepartments(ctx context.Context) ([]*models.Department, error)
+	GetDepartment(ctx context.Context, departID string) (*models.Department, error)
+	// DeleteDepartment - deletes department with its schedule and retention policy,
+	// its subdepartments are moved to its parent.
+	DeleteDepartment(ctx context.Context, departID string) (int64, error)
+	// MoveDepartment - sets parent of department, returns 0 if department doesn't exist.
+	MoveDepartment(ctx context.Context, departID string, parentID int64) (int64, error)
+
+	// CreateUser - creates user, ErrDepartmentNotFound if department isn't of tenant of context.
+	CreateUser(ctx context.Context, user *models.User) (int64, error)
+	GetUsers(ctx context.Context, depID string) ([]*models.User, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	// UpdateUser - updates profile of user, department isn't changed. Returns 0 if user doesn't exist.
+	UpdateUser(ctx context.Context, user *models.User) (int64, error)
+	// DeleteUser - deletes user with its membership history, schedule and leaves.
+	DeleteUser(ctx context.Context, userID string) (int64, error)
+	// TransferUser - moves user to department from given time, earlier activity still counts toward
+	// previous department. Returns 0 if user doesn't exist.
+	TransferUser(ctx context.Context, userID string, departmentID, effectiveFrom int64) (int64, error)
+	// GetMemberships - returns department membership history of user, oldest first.
+	GetMemberships(ctx context.Context, userID string) ([]*models.Membership, error)
+
+	// CreateActivity - creates activity record with its app breakdown,
+	// ErrUserNotFound if user isn't of tenant of context.
+	CreateActivity(ctx context.Context, activity *models.Activity) (int64, error)
+	GetActivities(ctx context.Context) ([]*models.Activity, error)
+	GetActivity(ctx context.Context, activityID string) (*models.Activity, error)
+	DeleteActivity(ctx context.Context, activityID string) (int64, error)
+
+	// Activity of user and department is returned with time of app breakdowns by category (see CategoryRule).
+	// Zero start or end time means range isn't limited.
+	GetUserActivity(ctx context.Context, userID string, startTime, endTime time.Time) (*models.UserActivity, error)
+	// GetDepartmentActivity - returns activity of department users, with rollup - of users of all its descendants too.
+	GetDepartmentActivity(
+		ctx context.Context,
+		departID string,
+		startTime, endTime time.Time,
+		rollup bool,
+	) (*models.DepartmentActivity, error)
+	// GetDepartmentTreeMembers - returns users with activity in department and all its descendants,
+	// with department they were member of at the time of activity. Zero start or end time means range isn't limited.
+	GetDepartmentTreeMembers(
+		ctx context.Context,
+		departID string,
+		startTime, endTime time.Time,
+	) ([]*models.DepartmentMember, error)
+	// RebuildRollups - rebuilds daily activity rollups of all tenants from records, returns number of rollups.
+	RebuildRollups(ctx context.Context) (int64, error)
+
+	// CreateRetentionPolicy - creates policy of tenant or department, ErrPolicyExists if it already has one,
+	// ErrDepartmentNotFound if department isn't of tenant of context.
+	CreateRetentionPolicy(ctx context.Context, policy *models.RetentionPolicy) (int64, error)
+	GetRetentionPolicies(ctx context.Context) ([]*models.RetentionPolicy, error)
+	GetRetentionPolicy(ctx context.Context, policyID string) (*models.RetentionPolicy, error)
+	DeleteRetentionPolicy(ctx context.Context, policyID string) (int64, error)
+	// GetArchivableActivity - returns records of user with their apps created before given time, oldest first.
+	GetArchivableActivity(ctx context.Context, userID string, before int64) ([]*models.Activity, error)
+	// ArchiveActivity - deletes records of user before given time up to given record, their time stays in rollups.
+	ArchiveActivity(ctx context.Context, userID string, before, lastRecordID int64) (int64, error)
+	// RestoreActivity - inserts archived records back, existing records and records of unknown users are skipped.
+	RestoreActivity(ctx context.Context, activities []*models.Activity) (int64, error)
+
+	// GetUserActivities - returns live records of user with their apps, oldest first.
+	GetUserActivities(ctx context.Context, userID string) ([]*models.Activity, error)
+	// GetActivityDays - returns rollups of user by UTC days, they include time of archived records.
+	GetActivityDays(ctx context.Context, userID string) ([]*models.DailyActivity, error)
+	CreateSubjectRequest(ctx context.Context, request *models.SubjectRequest) (int64, error)
+	// GetSubjectRequests - returns export and erasure requests of user, with empty userID - of all users.
+	GetSubjectRequests(ctx context.Context, userID string) ([]*models.SubjectRequest, error)
+	// EraseUser - deletes user with its profile data and moves its activity to new pseudonymous user
+	// of the same department, records erasure request. Returns nil if user doesn't exist.
+	EraseUser(ctx context.Context, userID string, request *models.SubjectRequest) (*models.SubjectRequest, error)
+
+	CreateCategoryRule(ctx context.Context, rule *models.CategoryRule) (int64, error)
+	GetCategoryRules(ctx context.Context) ([]*models.CategoryRule, error)
+	GetCategoryRule(ctx context.Context, ruleID string) (*models.CategoryRule, error)
+	DeleteCategoryRule(ctx context.Context, ruleID string) (int64, error)
+
+	// SetWorkSchedule - sets schedule of user or department, returns 0 if it doesn't exist.
+	SetWorkSchedule(ctx context.Context, schedule *models.WorkSchedule) (int64, error)
+	GetWorkSchedules(ctx context.Context) ([]*models.WorkSchedule, error)
+	// GetWorkSchedule, DeleteWorkSchedule - work with own schedule of user or department, the other id is 0.
+	GetWorkSchedule(ctx context.Context, userID, departmentID int64) (*models.WorkSchedule, error)
+	DeleteWorkSchedule(ctx context.Context, userID, departmentID int64) (int64, error)
+
+	// CreateTimeOff - creates leave or holiday, ErrUserNotFound if user of leave isn't of tenant of context.
+	CreateTimeOff(ctx context.Context, timeOff *models.TimeOff) (int64, error)
+	// GetTimeOffs - returns leaves of user and holidays, with empty userID - time off of all users.
+	GetTimeOffs(ctx context.Context, userID string) ([]*models.TimeOff, error)
+	GetTimeOff(ctx context.Context, timeOffID string) (*models.TimeOff, error)
+	DeleteTimeOff(ctx context.Context, timeOffID string) (int64, error)
+	// GetDailyActivity - returns activity of user in [start, end) summed by days of time zone with given offset.
+	GetDailyActivity(ctx context.Context, userID string, start, end, utcOffset int64) ([]*models.DailyActivity, error)
+
+	// ImportUsers - creates users and departments of given rows in single transaction, dry run is always rolled back.
+	ImportUsers(ctx context.Context, rows []*models.ImportRow, dryRun bool) (*models.ImportResult, error)
 
-	CreateDepartment(depart *models.Department) (int64, error)
-	GetDepartments() ([]*models.Department, error)
-	GetDepartment(departID string) (*models.Department, error)
-	DeleteDepartment(departID string) (int64, error)
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (int64, error)
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID string) (int64, error)
 
-	CreateUser(user *models.User) (int64, error)
-	GetUsers(depID string) ([]*models.User, error)
-	GetUser(userID string) (*models.User, error)
-	DeleteUser(userID string) (int64, error)
+	// Webhook deliveries are persistent queue, so events are delivered after restart.
+	// Events are queued for webhooks of tenant of context, due deliveries of all tenants are sent by dispatcher.
+	EnqueueDeliveries(ctx context.Context, event, payload string, now int64) (int64, error)
+	GetDueDeliveries(ctx context.Context, now int64, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookID, status string) ([]*models.WebhookDelivery, error)
+	RedeliverDelivery(ctx context.Context, deliveryID string, now int64) (int64, error)
 
-	CreateActivity(activity *models.Activity) (int64, error)
-	GetActivities() ([]*models.Activity, error)
-	GetActivity(activityID string) (*models.Activity, error)
-	DeleteActivity(activityID string) (int64, error)
+	CreateAlertRule(ctx context.Context, rule *models.AlertRule) (int64, error)
+	GetAlertRules(ctx context.Context) ([]*models.AlertRule, error)
+	GetAlertRule(ctx context.Context, ruleID string) (*models.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, ruleID string) (int64, error)
+	// UpdateAlertRuleState - writes state, value and evaluation times of rule.
+	UpdateAlertRuleState(ctx context.Context, rule *models.AlertRule) error
 
-	GetUserActivity(userID, timeBefore, timeAfter string) (*models.UserActivity, error)
-	GetDepartmentActivity(departID, timeBefore, timeAfter string) (*models.DepartmentActivity, error)
+	// Stream methods call given function for every record without loading all of them in memory.
+	StreamDepartments(ctx context.Context, f func(*models.Department) error) error
+	StreamUsers(ctx context.Context, depID string, f func(*models.User) error) error
+	StreamActivities(ctx context.Context, f func(*models.Activity) error) error
+	StreamUsersReport(ctx context.Context, startTime, endTime time.Time, f func(*models.UserReport) error) error
+	StreamDepartmentsReport(
+		ctx context.Context,
+		startTime, endTime time.Time,
+		f func(*models.DepartmentReport) error,
+	) error
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,10 +13,13 @@ import (
 // ISQLCore - common interface for all SQL databases.
 // With it, a lot of copy-paste code would be avoided during integration of new SQL DB,
 // like MySQL, MSSQL, etc
+// All queries take context, so they are cancelled when client disconnects or service is stopping.
 type ISQLCore interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Get(dest interface{}, query string, args ...interface{}) error
-	Pick(dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Stream(ctx context.Context, query string, each func(scan ScanFunc) error, args ...interface{}) error
+	Tx(ctx context.Context, f func(tx ISQLTx) error) error
 
 	Open() error
 	Close() error
@@ -23,6 +27,15 @@ type ISQLCore interface {
 	OK() error
 }
 
+// ISQLTx - queries of single transaction.
+type ISQLTx interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// ScanFunc - scans current row of streamed query to given struct.
+type ScanFunc func(dest interface{}) error
+
 // SQL core struct
 type SQL struct {
 	driver     string       // Driver of given SQL DB
@@ -126,7 +139,7 @@ func (s *SQL) OK() (err error) {
 }
 
 // Exec - runs given query on db
-func (s *SQL) Exec(query string, args ...interface{}) (sql.Result, error) {
+func (s *SQL) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
@@ -136,7 +149,7 @@ func (s *SQL) Exec(query string, args ...interface{}) (sql.Result, error) {
 
 	s.logger.WithField("func", "Exec").Debugf("Executing query: %s", query)
 
-	result, err := s.db.Exec(query, args...)
+	result, err := s.db.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, fmt.Errorf("SQL Exec(): %w", err)
@@ -146,7 +159,7 @@ func (s *SQL) Exec(query string, args ...interface{}) (sql.Result, error) {
 }
 
 // Get - writes result of query to given interface.
-func (s *SQL) Get(dest interface{}, query string, args ...interface{}) error {
+func (s *SQL) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
@@ -156,7 +169,7 @@ func (s *SQL) Get(dest interface{}, query string, args ...interface{}) error {
 
 	s.logger.WithField("func", "Get").Debugf("Get query: %s", query)
 
-	if err := s.db.Select(dest, query, args...); err != nil {
+	if err := s.db.SelectContext(ctx, dest, query, args...); err != nil {
 		return fmt.Errorf("SQL conn.Select(): %w", err)
 	}
 
@@ -164,7 +177,7 @@ func (s *SQL) Get(dest interface{}, query string, args ...interface{}) error {
 }
 
 // Get - writes single object from sql query to given interface.
-func (s *SQL) Pick(dest interface{}, query string, args ...interface{}) error {
+func (s *SQL) Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
@@ -174,9 +187,119 @@ func (s *SQL) Pick(dest interface{}, query string, args ...interface{}) error {
 
 	s.logger.WithField("func", "Pick").Debugf("Pick query: %s", query)
 
-	if err := s.db.Get(dest, query, args...); err != nil {
+	if err := s.db.GetContext(ctx, dest, query, args...); err != nil {
 		return fmt.Errorf("SQL conn.Get(): %w", err)
 	}
 
 	return nil
 }
+
+// Stream - calls each for every row of query result, so large results are not buffered in memory.
+// Iteration stops on first error returned by each.
+func (s *SQL) Stream(ctx context.Context, query string, each func(scan ScanFunc) error, args ...interface{}) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if s.db == nil {
+		return errors.New("sql connection doesn't exist")
+	}
+
+	entry := s.logger.WithField("func", "Stream")
+	entry.Debugf("Stream query: %s", query)
+
+	rows, err := s.db.QueryxContext(ctx, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("SQL conn.Queryx(): %w", err)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			entry.Warn("Rows close error:", err)
+		}
+	}()
+
+	for rows.Next() {
+		if err := each(rows.StructScan); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("SQL rows.Err(): %w", err)
+	}
+
+	return nil
+}
+
+// Tx - runs f in transaction, which is committed if f succeeds and rolled back otherwise.
+// Other writes wait until transaction is finished.
+func (s *SQL) Tx(ctx context.Context, f func(tx ISQLTx) error) (err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.db == nil {
+		return errors.New("sql connection doesn't exist")
+	}
+
+	entry := s.logger.WithField("func", "Tx")
+	entry.Debug("Starting transaction...")
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("SQL BeginTxx(): %w", err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		entry.Debug("Rolling back transaction...")
+
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			entry.Warn("Rollback error:", rbErr)
+		}
+	}()
+
+	if err = f(&sqlTx{tx: tx, logger: entry}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("SQL Commit(): %w", err)
+	}
+
+	return nil
+}
+
+// sqlTx - ISQLTx implementation over sqlx transaction.
+type sqlTx struct {
+	tx     *sqlx.Tx
+	logger logrus.FieldLogger
+}
+
+// Exec - runs given query in transaction.
+func (t *sqlTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.logger.Debugf("Executing query: %s", query)
+
+	result, err := t.tx.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("SQL tx Exec(): %w", err)
+	}
+
+	return result, nil
+}
+
+// Pick - writes single object from sql query in transaction to given interface.
+func (t *sqlTx) Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	t.logger.Debugf("Pick query: %s", query)
+
+	if err := t.tx.GetContext(ctx, dest, query, args...); err != nil {
+		return fmt.Errorf("SQL tx Get(): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultTenant - tenant of data created before tenants were added,
+// admins registered without tenant and agents authorized with client certificate belong to it.
+const DefaultTenant int64 = 1
+
+// ErrNoTenant - query of tenant data is run with context without tenant.
+var ErrNoTenant = errors.New("tenant isn't set in context")
+
+// tenantKey - context key of tenant ID.
+type tenantKey struct{}
+
+// WithTenant - returns copy of ctx with given tenant, queries run with it are scoped by the tenant.
+func WithTenant(ctx context.Context, tenantID int64) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantID - returns tenant of ctx, ErrNoTenant if it isn't set.
+func TenantID(ctx context.Context) (int64, error) {
+	if tenantID, ok := ctx.Value(tenantKey{}).(int64); ok && tenantID > 0 {
+		return tenantID, nil
+	}
+
+	return 0, ErrNoTenant
+}
@@ -2,32 +2,61 @@ package sqlite
 
 import (
 	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 )
 
-// CreateActivity - writes given activity record to SQLite db.
-func (s *SQLite) CreateActivity(activity *models.Activity) (int64, error) {
+// activityApp - app time of activity record.
+type activityApp struct {
+	RecordID int64 `db:"record_id"`
+	models.AppTime
+}
+
+// CreateActivity - writes given activity record with its app breakdown to SQLite db,
+// user must be of the same tenant.
+func (s *SQLite) CreateActivity(ctx context.Context, activity *models.Activity) (int64, error) {
 	entry := s.logger.WithField("func", "CreateDB")
 	entry.Debugf("Creating activity: %+v", activity)
 
-	result, err := s.Exec(
-		activityCreate,
-		activity.UserID,
-		activity.ActiveTime,
-		activity.TotalTime,
-		activity.Date,
-	)
+	var id int64
 
-	if err != nil {
-		return -1, fmt.Errorf("SQLite s.Exec(): %w", err)
-	}
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		result, err := tx.Exec(ctx,
+			activityCreate,
+			activity.UserID,
+			activity.ActiveTime,
+			activity.TotalTime,
+			activity.Date,
+		)
+
+		if err != nil {
+			return fmt.Errorf("activityCreate: %w", err)
+		}
+
+		if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+			err = notInserted(err, core.ErrUserNotFound)
+
+			return fmt.Errorf("activityCreate, user %d: %w", activity.UserID, err)
+		}
 
-	id, err := result.LastInsertId()
+		if id, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("LastInsertId(): %w", err)
+		}
+
+		for _, app := range activity.Apps {
+			if _, err := tx.Exec(ctx, activityAppCreate, id, app.App, app.Time); err != nil {
+				return fmt.Errorf("activityAppCreate, app %q: %w", app.App, err)
+			}
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(): %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), CreateActivity: %w", err)
 	}
 
 	entry.Debugf("Created activity id: %d", id)
@@ -35,28 +64,46 @@ func (s *SQLite) CreateActivity(activity *models.Activity) (int64, error) {
 }
 
 // GetActivity - returns all activity records from SQLite db.
-func (s *SQLite) GetActivities() ([]*models.Activity, error) {
+func (s *SQLite) GetActivities(ctx context.Context) ([]*models.Activity, error) {
 	entry := s.logger.WithField("func", "GetActivities")
 
 	entry.Debug("Getting activities...")
 	activities := make([]*models.Activity, 0)
 
-	if err := s.Get(&activities, activitiesGet); err != nil {
+	if err := s.Get(ctx, &activities, activitiesGet); err != nil {
 		return nil, fmt.Errorf("SQLite s.Get(): %w", err)
 	}
 
+	apps := make([]*activityApp, 0)
+
+	if err := s.Get(ctx, &apps, activitiesAppsGet); err != nil {
+		return nil, fmt.Errorf("SQLite s.Get(), activitiesAppsGet: %w", err)
+	}
+
+	records := make(map[int64]*models.Activity, len(activities))
+
+	for _, activity := range activities {
+		records[activity.RecordID] = activity
+	}
+
+	for _, app := range apps {
+		if activity, ok := records[app.RecordID]; ok {
+			activity.Apps = append(activity.Apps, &app.AppTime)
+		}
+	}
+
 	entry.Debugf("Retrieved activities: %d", len(activities))
 	return activities, nil
 }
 
 // GetActivity - returns activity record with given ID from SQLite db.
-func (s *SQLite) GetActivity(activityID string) (*models.Activity, error) {
+func (s *SQLite) GetActivity(ctx context.Context, activityID string) (*models.Activity, error) {
 	entry := s.logger.WithField("func", "GetActivity")
 
 	entry.Debugf("Getting activity with id: %s", activityID)
 	activity := new(models.Activity)
 
-	if err := s.Pick(activity, activityGet, activityID); err != nil {
+	if err := s.Pick(ctx, activity, activityGet, activityID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -64,25 +111,52 @@ func (s *SQLite) GetActivity(activityID string) (*models.Activity, error) {
 		return nil, fmt.Errorf("SQLite s.Pick(): %w", err)
 	}
 
+	if err := s.Get(ctx, &activity.Apps, activityAppsGet, activityID); err != nil {
+		return nil, fmt.Errorf("SQLite s.Get(), activityAppsGet: %w", err)
+	}
+
 	s.logger.Debugf("Retrieved activity with id %s: %+v", activityID, *activity)
 	return activity, nil
 }
 
-// DeleteActivity - deletes activity record with given ID from SQLite db.
-func (s *SQLite) DeleteActivity(activityID string) (int64, error) {
+// DeleteActivity - deletes activity record with given ID and its app breakdown from SQLite db.
+func (s *SQLite) DeleteActivity(ctx context.Context, activityID string) (int64, error) {
 	entry := s.logger.WithField("func", "DeleteActivity")
 
 	entry.Debugf("Deleting activity with id: %s", activityID)
-	result, err := s.Exec(activityDelete, activityID)
+	var id int64
 
-	if err != nil {
-		return -1, fmt.Errorf("SQLite s.Exec(): %w", err)
-	}
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if _, err := tx.Exec(ctx, activityAppsDelete, activityID); err != nil {
+			return fmt.Errorf("activityAppsDelete: %w", err)
+		}
+
+		// Restored record is archived, its time is subtracted here, triggers don't do it.
+		for _, table := range []string{"activity_daily_archived", "activity_daily"} {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(archivedActivitySubtract, table), activityID); err != nil {
+				return fmt.Errorf("archivedActivitySubtract %s: %w", table, err)
+			}
+
+			if _, err := tx.Exec(ctx, fmt.Sprintf(archivedActivityEmptyDelete, table)); err != nil {
+				return fmt.Errorf("archivedActivityEmptyDelete %s: %w", table, err)
+			}
+		}
+
+		result, err := tx.Exec(ctx, activityDelete, activityID)
+
+		if err != nil {
+			return fmt.Errorf("activityDelete: %w", err)
+		}
+
+		if id, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
 
-	id, err := result.RowsAffected()
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(): %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), DeleteActivity: %w", err)
 	}
 
 	entry.Debugf("Activity with id %s deleted successfully, rows affected: %d", activityID, id)
@@ -2,66 +2,238 @@ package sqlite
 
 import (
 	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
 	"fmt"
+	"time"
 )
 
+// secondsInDay - length of rollup day, rollup days are days of UTC.
+const secondsInDay = 24 * 60 * 60
+
 // GetUserActivity - returns data about users activity between 2 dates (timestamps).
-func (s *SQLite) GetUserActivity(userID, startTime, endTime string) (*models.UserActivity, error) {
+// Time of whole days is taken from daily rollups, app categories are summed from records.
+func (s *SQLite) GetUserActivity(
+	ctx context.Context,
+	userID string,
+	startTime, endTime time.Time,
+) (*models.UserActivity, error) {
 	entry := s.logger.WithField("func", "GetUserActivity")
 	entry.Debugf(
-		"Retrieving user activity data, user id - %s, start time - %s, end time - %s",
+		"Retrieving user activity data, user id - %s, start time - %v, end time - %v",
 		userID,
 		startTime,
 		endTime,
 	)
 
+	bounds := rollupBounds(startTime, endTime)
 	userActivity := new(models.UserActivity)
-	query := s.buildActivityTimeQuery(getUsersActivity, startTime, endTime)
 
-	if err := s.Pick(userActivity, query, userID); err != nil {
+	if err := s.Pick(ctx, userActivity, getUsersActivity, append([]interface{}{userID}, bounds...)...); err != nil {
 		return nil, fmt.Errorf("SQLite s.Pick(), activityGet: %w", err)
 	}
 
+	categories, err := s.getCategoriesTime(ctx, getUsersCategories, userID, startTime, endTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userActivity.Categories = categories
+
 	entry.Debugf("Retrieved user (id: %s) activity data: %+v", userID, *userActivity)
 	return userActivity, nil
 }
 
 // GetDepartmentActivity - returns data about users activity between 2 dates (timestamps).
-func (s *SQLite) GetDepartmentActivity(departID, startTime, endTime string) (*models.DepartmentActivity, error) {
+// With rollup activity of users of all descendant departments is summed too.
+func (s *SQLite) GetDepartmentActivity(
+	ctx context.Context,
+	departID string,
+	startTime, endTime time.Time,
+	rollup bool,
+) (*models.DepartmentActivity, error) {
 	entry := s.logger.WithField("func", "GetDepartmentActivity")
 	entry.Debugf(
-		"Retrieving department activity data, department id - %s, start time - %s, end time - %s",
+		"Retrieving department activity data, department id - %s, start time - %v, end time - %v, rollup - %t",
 		departID,
 		startTime,
 		endTime,
+		rollup,
 	)
 
+	query, categoriesQuery := getDepartmentsActivity, getDepartmentsCategories
+
+	if rollup {
+		query, categoriesQuery = getDepartmentsTreeActivity, getDepartmentsTreeCategories
+	}
+
+	bounds := rollupBounds(startTime, endTime)
 	departmentActivity := new(models.DepartmentActivity)
-	query := s.buildActivityTimeQuery(getDepartmentsActivity, startTime, endTime)
 
-	if err := s.Pick(departmentActivity, query, departID); err != nil {
+	if err := s.Pick(ctx, departmentActivity, query, append([]interface{}{departID}, bounds...)...); err != nil {
 		return nil, fmt.Errorf("SQLite s.Pick(), activityGet: %w", err)
 	}
 
+	categories, err := s.getCategoriesTime(ctx, categoriesQuery, departID, startTime, endTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	departmentActivity.Categories = categories
+
 	entry.Debugf("Retrieved department (id: %s) activity data: %+v", departID, *departmentActivity)
 	return departmentActivity, nil
 }
 
-// buildActivityTimeQuery - appends time check to query.
-func (s *SQLite) buildActivityTimeQuery(query, timeBefore, timeAfter string) string {
-	entry := s.logger.WithField("func", "buildActivityTimeQuery")
-	entry.Debugf("Adding time check to query %s, start - %s, end - %s", query, timeBefore, timeAfter)
+// GetDepartmentTreeMembers - returns users with activity in department and its descendants between 2 dates,
+// with department they were member of at the time of activity.
+func (s *SQLite) GetDepartmentTreeMembers(
+	ctx context.Context,
+	departID string,
+	startTime, endTime time.Time,
+) ([]*models.DepartmentMember, error) {
+	entry := s.logger.WithField("func", "GetDepartmentTreeMembers")
+	entry.Debugf(
+		"Retrieving department tree members, department id - %s, start time - %v, end time - %v",
+		departID,
+		startTime,
+		endTime,
+	)
+
+	members := make([]*models.DepartmentMember, 0)
+	args := append([]interface{}{departID}, rollupBounds(startTime, endTime)...)
+
+	if err := s.Get(ctx, &members, getDepartmentsTreeMembers, args...); err != nil {
+		return nil, fmt.Errorf("SQLite s.Get(), getDepartmentsTreeMembers: %w", err)
+	}
+
+	entry.Debugf("Retrieved department (id: %s) tree members: %d", departID, len(members))
+	return members, nil
+}
+
+// getCategoriesTime - returns time of app breakdowns of user or department by category between 2 dates.
+func (s *SQLite) getCategoriesTime(
+	ctx context.Context,
+	query, id string,
+	startTime, endTime time.Time,
+) (*models.CategoryTime, error) {
+	categories := new(models.CategoryTime)
+	filter, args := activityTimeFilter(2, startTime, endTime)
+	args = append([]interface{}{id}, args...)
+
+	if err := s.Pick(ctx, categories, query+filter+categoriesTimeEnd, args...); err != nil {
+		return nil, fmt.Errorf("SQLite s.Pick(), categoriesTime: %w", err)
+	}
+
+	return categories, nil
+}
+
+// rollupBounds - returns parameters of rollup query for records after start and before end time,
+// zero time means range isn't limited. Whole days inside the range are summed from rollups,
+// records of partial days at range edges - from records.
+func rollupBounds(startTime, endTime time.Time) []interface{} {
+	// Parameters: first and last whole day, head and tail edges [start, end), see rollupDays.
+	bounds := make([]interface{}, 6)
+
+	var start, end *int64 // records in [start, end), nil - not limited
+
+	if !startTime.IsZero() {
+		after := startTime.Unix() + 1 // records strictly after start time
+		start = &after
+	}
+
+	if !endTime.IsZero() {
+		before := endTime.Unix()
+		end = &before
+	}
+
+	var firstDay, lastDay int64
+
+	if start != nil {
+		firstDay = -floorDiv(-*start, secondsInDay) // the first day starting not before start
+		bounds[0] = firstDay
+	}
+
+	if end != nil {
+		lastDay = floorDiv(*end, secondsInDay) - 1 // the last day ending not after end
+		bounds[1] = lastDay
+	}
+
+	if start != nil && end != nil && firstDay > lastDay {
+		// No whole days in range: empty days range, the whole range is the head edge.
+		bounds[0], bounds[1] = int64(1), int64(0)
+		bounds[2], bounds[3] = *start, *end
+
+		return bounds
+	}
+
+	if start != nil {
+		bounds[2], bounds[3] = *start, firstDay*secondsInDay
+	}
+
+	if end != nil {
+		bounds[4], bounds[5] = (lastDay+1)*secondsInDay, *end
+	}
+
+	return bounds
+}
+
+// floorDiv - integer division rounded down, so dates before epoch are in right days.
+func floorDiv(a, b int64) int64 {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+
+	return a / b
+}
+
+// activityTimeFilter - returns time check of records after start and before end time and its parameters,
+// zero time isn't checked. Parameters are numbered from first, so they follow parameters of query.
+func activityTimeFilter(first int, startTime, endTime time.Time) (string, []interface{}) {
+	filter, args := "", make([]interface{}, 0, 2)
+
+	if !startTime.IsZero() {
+		args = append(args, startTime.Unix())
+		// Only number of parameter is formatted, time is bound.
+		filter += fmt.Sprintf(activityTimeStart, first+len(args)-1)
+	}
 
-	if timeBefore != "" {
-		// This sprintf is safe for SQL injection due to activityTimeStart enclosed in quotes ''
-		query += fmt.Sprintf(activityTimeStart, timeBefore)
+	if !endTime.IsZero() {
+		args = append(args, endTime.Unix())
+		filter += fmt.Sprintf(activityTimeEnd, first+len(args)-1)
 	}
 
-	if timeAfter != "" {
-		// Same as above
-		query += fmt.Sprintf(activityTimeEnd, timeAfter)
+	return filter, args
+}
+
+// RebuildRollups - rebuilds daily activity rollups of all tenants from records, returns number of rollups.
+// Rollups are maintained by triggers, rebuild is needed only if records were changed with triggers disabled.
+func (s *SQLite) RebuildRollups(ctx context.Context) (int64, error) {
+	entry := s.logger.WithField("func", "RebuildRollups")
+	entry.Info("Rebuilding daily activity rollups...")
+
+	var rebuilt int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		result, err := tx.Exec(ctx, activityDailyRebuild)
+
+		if err != nil {
+			return fmt.Errorf("activityDailyRebuild: %w", err)
+		}
+
+		if rebuilt, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), RebuildRollups: %w", err)
 	}
 
-	entry.Debugf("Result query with time check: %s", query)
-	return query
+	entry.Infof("Daily activity rollups rebuilt: %d", rebuilt)
+	return rebuilt, nil
 }
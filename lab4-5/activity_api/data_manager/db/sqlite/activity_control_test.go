@@ -0,0 +1,321 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// base - 2020-01-01 00:00 UTC.
+const base = int64(1577836800)
+
+// newTestSQLite - returns SQLite with created schema in temp dir and context of default tenant.
+func newTestSQLite(t *testing.T) (*SQLite, context.Context) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	s := NewSQLite(filepath.Join(t.TempDir(), "test.db"), logger).(*SQLite)
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	ctx := core.WithTenant(context.Background(), core.DefaultTenant)
+
+	if err := s.CreateDB(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return s, ctx
+}
+
+// activityFixture - departments A > B and C, users moved between them at midnight and in the middle of days,
+// and random activity around these moves. Returns ids of departments and users.
+func activityFixture(t *testing.T, s *SQLite, ctx context.Context) ([]int64, []int64) {
+	departs := make([]int64, 0)
+
+	for _, name := range []string{"A", "B", "C"} {
+		depart := &models.Department{DepartmentName: name}
+
+		if name == "B" {
+			depart.ParentID = departs[0]
+		}
+
+		id, err := s.CreateDepartment(ctx, depart)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		departs = append(departs, id)
+	}
+
+	users := make([]int64, 0)
+
+	for _, departID := range []int64{departs[0], departs[1]} {
+		id, err := s.CreateUser(ctx, &models.User{UserName: "user", DepartmentID: departID})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users = append(users, id)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	records := make([]int64, 0)
+
+	for i := 0; i < 400; i++ {
+		date := base - 2*secondsInDay + random.Int63n(12*secondsInDay)
+
+		switch i % 10 {
+		case 0: // day edges
+			date = base + random.Int63n(10)*secondsInDay - random.Int63n(2)
+		case 1: // before epoch
+			date = -random.Int63n(3 * secondsInDay)
+		}
+
+		id, err := s.CreateActivity(ctx, &models.Activity{
+			UserID:     users[i%len(users)],
+			TotalTime:  random.Int63n(1000),
+			ActiveTime: random.Int63n(1000),
+			Date:       date,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, id)
+	}
+	// Rollups follow deleted and updated records.
+	for _, id := range records[:40] {
+		if _, err := s.DeleteActivity(ctx, strconv.FormatInt(id, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.Exec(ctx, `UPDATE user_activity SET activity_date = activity_date + 43200, total_time = 7
+WHERE record_id % 7 = 0;`); err != nil {
+		t.Fatal(err)
+	}
+
+	transfers := []struct {
+		user, depart, from int64
+	}{
+		{users[0], departs[2], base + 3*secondsInDay + 13*3600},
+		{users[0], departs[0], base + 6*secondsInDay},
+		{users[1], departs[2], base + 5*secondsInDay + 1},
+		{users[1], departs[1], base + 5*secondsInDay + 20*3600},
+	}
+
+	for _, transfer := range transfers {
+		if _, err := s.TransferUser(ctx, strconv.FormatInt(transfer.user, 10), transfer.depart, transfer.from); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return departs, users
+}
+
+// activityRanges - time ranges of activity queries, zero time isn't limited.
+func activityRanges() [][2]time.Time {
+	unix := func(sec int64) time.Time { return time.Unix(sec, 0) }
+	ranges := [][2]time.Time{
+		{{}, {}},
+		{unix(base), {}},
+		{{}, unix(base + 4*secondsInDay)},
+		{unix(-1), unix(1)},
+		{unix(-secondsInDay - 1), unix(secondsInDay)},
+		{unix(base - 1), unix(base + secondsInDay)},
+		{unix(base - 1), unix(base + secondsInDay + 1)},
+		{unix(base + 100), unix(base + 200)},
+		{unix(base + 5*secondsInDay), unix(base + 2*secondsInDay)},
+	}
+	random := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 30; i++ {
+		start := base - 3*secondsInDay + random.Int63n(14*secondsInDay)
+		end := start + random.Int63n(8*secondsInDay)
+		ranges = append(ranges, [2]time.Time{unix(start), unix(end)})
+	}
+
+	return ranges
+}
+
+func Test_RollupActivity(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+	departs, users := activityFixture(t, s, ctx)
+
+	for _, period := range activityRanges() {
+		start, end := period[0], period[1]
+
+		for _, userID := range users {
+			id := strconv.FormatInt(userID, 10)
+			expected := new(models.UserActivity)
+
+			filter, args := activityTimeFilter(2, start, end)
+
+			if err := s.Pick(ctx, expected, getUsersActivityRaw+filter, append([]interface{}{id}, args...)...); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := s.GetUserActivity(ctx, id, start, end)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual.TotalTime != expected.TotalTime || actual.ActiveTime != expected.ActiveTime {
+				t.Errorf("user %s, range (%s, %s): rollup %+v, records %+v", id, start, end, *actual, *expected)
+			}
+		}
+
+		for _, departID := range departs {
+			for query, rollup := range map[string]bool{
+				getDepartmentsActivityRaw:     false,
+				getDepartmentsTreeActivityRaw: true,
+			} {
+				id := strconv.FormatInt(departID, 10)
+				expected := new(models.DepartmentActivity)
+
+				filter, args := activityTimeFilter(2, start, end)
+
+				if err := s.Pick(ctx, expected, query+filter, append([]interface{}{id}, args...)...); err != nil {
+					t.Fatal(err)
+				}
+
+				actual, err := s.GetDepartmentActivity(ctx, id, start, end, rollup)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if actual.TotalTime != expected.TotalTime || actual.ActiveTime != expected.ActiveTime ||
+					actual.Members != expected.Members {
+					t.Errorf("department %s, tree %t, range (%s, %s): rollup %+v, records %+v",
+						id, rollup, start, end, *actual, *expected)
+				}
+			}
+			// Tree members are the same users as members of tree activity.
+			id := strconv.FormatInt(departID, 10)
+			tree, err := s.GetDepartmentActivity(ctx, id, start, end, true)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			members, err := s.GetDepartmentTreeMembers(ctx, id, start, end)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			users := make(map[int64]bool)
+
+			for _, member := range members {
+				users[member.UserID] = true
+			}
+
+			if int64(len(users)) != tree.Members {
+				t.Errorf("department %s, range (%s, %s): %d tree members, %d members of tree activity",
+					id, start, end, len(users), tree.Members)
+			}
+		}
+	}
+}
+
+func Test_RebuildRollups(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+	_, users := activityFixture(t, s, ctx)
+	// Archived records are restored and part of them is deleted, their time is subtracted from rollups.
+	userID := strconv.FormatInt(users[0], 10)
+	totalQuery := `SELECT COALESCE(SUM(total_time), 0) FROM activity_daily WHERE user_id = ?;`
+	var total, left int64
+
+	if err := s.Pick(ctx, &total, totalQuery, users[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	archived, err := s.GetArchivableActivity(ctx, userID, base+3*secondsInDay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archived) == 0 {
+		t.Fatal("No records to archive")
+	}
+
+	if _, err := s.ArchiveActivity(ctx, userID, base+3*secondsInDay, archived[len(archived)-1].RecordID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.RestoreActivity(ctx, archived); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(archived); i += 2 {
+		deleted, err := s.DeleteActivity(ctx, strconv.FormatInt(archived[i].RecordID, 10))
+
+		if err != nil || deleted != 1 {
+			t.Fatalf("Restored record %d: %d deleted, error: %v", archived[i].RecordID, deleted, err)
+		}
+
+		total -= archived[i].TotalTime
+	}
+
+	if err := s.Pick(ctx, &left, totalQuery, users[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if left != total {
+		t.Fatalf("Total time in rollups after delete of restored records: %d, expected %d", left, total)
+	}
+
+	type daily struct {
+		UserID     int64 `db:"user_id"`
+		Day        int64 `db:"day"`
+		TotalTime  int64 `db:"total_time"`
+		ActiveTime int64 `db:"active_time"`
+		Records    int64 `db:"records"`
+	}
+
+	query := `SELECT user_id, day, total_time, active_time, records FROM activity_daily ORDER BY user_id, day;`
+	maintained := make([]*daily, 0)
+
+	if err := s.Get(ctx, &maintained, query); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt, err := s.RebuildRollups(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := make([]*daily, 0)
+
+	if err := s.Get(ctx, &fresh, query); err != nil {
+		t.Fatal(err)
+	}
+
+	if rebuilt != int64(len(maintained)) || len(fresh) != len(maintained) {
+		t.Fatalf("%d rollups maintained, %d rebuilt, %d read", len(maintained), rebuilt, len(fresh))
+	}
+
+	for i := range maintained {
+		if *fresh[i] != *maintained[i] {
+			t.Fatalf("maintained rollup %+v differs from rebuilt", *maintained[i])
+		}
+	}
+}
@@ -2,17 +2,19 @@ package sqlite
 
 import (
 	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 )
 
-// CreateAdmin - creates service admin with given data.
-func (s *SQLite) CreateAdmin(admin *models.Admin) (int64, error) {
+// CreateAdmin - creates service admin with given data in tenant of the admin.
+func (s *SQLite) CreateAdmin(ctx context.Context, admin *models.Admin) (int64, error) {
 	entry := s.logger.WithField("func", "CreateAdmin")
 
-	entry.Debugf("Creating admin with name: %s", admin.Username)
-	result, err := s.Exec(adminCreate, admin.Username, admin.Hash)
+	entry.Debugf("Creating admin with name %s in tenant %d, role: %s", admin.Username, admin.TenantID, admin.Role)
+	result, err := s.Exec(ctx, adminCreate, admin.Username, admin.Hash, admin.TenantID, admin.Role)
 
 	if err != nil {
 		return -1, fmt.Errorf("SQLite Exec(): %w", err)
@@ -29,13 +31,13 @@ func (s *SQLite) CreateAdmin(admin *models.Admin) (int64, error) {
 }
 
 // GetAdmin - returns admin with given name.
-func (s *SQLite) GetAdmin(name string) (*models.Admin, error) {
+func (s *SQLite) GetAdmin(ctx context.Context, name string) (*models.Admin, error) {
 	entry := s.logger.WithField("func", "GetAdmin")
 
 	entry.Debugf("Getting admin with name %s", name)
 	admin := new(models.Admin)
 
-	if err := s.Pick(admin, adminFind, name); err != nil {
+	if err := s.Pick(ctx, admin, adminFind, name); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -48,11 +50,11 @@ func (s *SQLite) GetAdmin(name string) (*models.Admin, error) {
 }
 
 // DeleteAdmin - deletes admin with given name.
-func (s *SQLite) DeleteAdmin(name string) (int64, error) {
+func (s *SQLite) DeleteAdmin(ctx context.Context, name string) (int64, error) {
 	entry := s.logger.WithField("func", "DeleteAdmin")
 
 	entry.Debugf("Deleting admin with name: %s", name)
-	result, err := s.Exec(adminDelete, name)
+	result, err := s.Exec(ctx, adminDelete, name)
 
 	if err != nil {
 		return -1, fmt.Errorf("SQLite Exec(): %w", err)
@@ -67,3 +69,57 @@ func (s *SQLite) DeleteAdmin(name string) (int64, error) {
 	entry.Debugf("Admin with name %s deleted successfully, rows affected: %d", name, id)
 	return id, nil
 }
+
+// SetSuperadmin - creates superadmin with given data or updates its password and tenant,
+// superadmin role of other admins is removed. Nil admin only removes superadmin role.
+func (s *SQLite) SetSuperadmin(ctx context.Context, admin *models.Admin) error {
+	entry := s.logger.WithField("func", "SetSuperadmin")
+
+	name := ""
+
+	if admin != nil {
+		name = admin.Username
+	}
+
+	entry.Debugf("Setting superadmin: %s", name)
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if _, err := tx.Exec(ctx, superadminsDemote, name); err != nil {
+			return fmt.Errorf("tx.Exec() superadminsDemote: %w", err)
+		}
+
+		if admin == nil {
+			return nil
+		}
+
+		existing := new(models.Admin)
+
+		if err := tx.Pick(ctx, existing, adminFind, admin.Username); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("tx.Pick() adminFind: %w", err)
+			}
+
+			if _, err := tx.Exec(ctx, adminCreate, admin.Username, admin.Hash, admin.TenantID, models.RoleSuperadmin); err != nil {
+				return fmt.Errorf("tx.Exec() adminCreate: %w", err)
+			}
+
+			return nil
+		}
+		// Admin registered with this name could have tokens, so it can't become superadmin.
+		if existing.Role != models.RoleSuperadmin {
+			return core.ErrSuperadminTaken
+		}
+
+		if _, err := tx.Exec(ctx, superadminUpdate, admin.Hash, admin.TenantID, admin.Username); err != nil {
+			return fmt.Errorf("tx.Exec() superadminUpdate: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Tx(), SetSuperadmin: %w", err)
+	}
+
+	entry.Debugf("Superadmin %s set successfully", name)
+	return nil
+}
@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"errors"
+	"testing"
+)
+
+func Test_SetSuperadmin(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+
+	if _, err := s.CreateAdmin(ctx, &models.Admin{Username: "taken", Hash: "hash", TenantID: core.DefaultTenant,
+		Role: models.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		hash string
+		err  error
+	}{
+		{"root", "first", nil},
+		{"root", "second", nil},
+		{"taken", "hash", core.ErrSuperadminTaken},
+		{"other", "third", nil},
+	} {
+		err := s.SetSuperadmin(ctx, &models.Admin{Username: test.name, Hash: test.hash, TenantID: core.DefaultTenant})
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("Superadmin %s: error %v, expected %v", test.name, err, test.err)
+		}
+	}
+	// Admin registered with name of superadmin keeps its role, previous superadmin loses it.
+	for name, role := range map[string]string{
+		"taken": models.RoleAdmin,
+		"root":  models.RoleAdmin,
+		"other": models.RoleSuperadmin,
+	} {
+		admin, err := s.GetAdmin(ctx, name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if admin == nil || admin.Role != role {
+			t.Errorf("Admin %s: %+v, expected role %s", name, admin, role)
+		}
+	}
+
+	root, err := s.GetAdmin(ctx, "root")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Hash != "second" {
+		t.Errorf("Password of superadmin isn't updated: %s", root.Hash)
+	}
+
+	if err := s.SetSuperadmin(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := s.GetAdmin(ctx, "other")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.Role != models.RoleAdmin {
+		t.Errorf("Superadmin role isn't removed: %+v", other)
+	}
+}
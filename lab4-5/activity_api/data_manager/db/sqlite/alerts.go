@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateAlertRule - writes given alert rule to SQLite db.
+func (s *SQLite) CreateAlertRule(ctx context.Context, rule *models.AlertRule) (int64, error) {
+	entry := s.logger.WithField("func", "CreateAlertRule")
+
+	entry.Debugf("Creating alert rule: %+v", rule)
+	result, err := s.Exec(ctx, alertRuleCreate,
+		rule.Name,
+		rule.Kind,
+		rule.TargetID,
+		rule.Threshold,
+		rule.Period,
+		rule.Notifiers,
+		rule.State,
+		rule.ChangedAt,
+		rule.CreatedAt,
+	)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), alertRuleCreate: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), alertRuleCreate: %w", err)
+	}
+
+	entry.Debugf("Created alert rule id: %d", id)
+	return id, nil
+}
+
+// GetAlertRules - returns all alert rules from SQLite db.
+func (s *SQLite) GetAlertRules(ctx context.Context) ([]*models.AlertRule, error) {
+	entry := s.logger.WithField("func", "GetAlertRules")
+
+	entry.Debug("Getting alert rules")
+	rules := make([]*models.AlertRule, 0)
+
+	if err := s.Get(ctx, &rules, alertRulesGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), alertRulesGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved alert rules num: %d", len(rules))
+	return rules, nil
+}
+
+// GetAlertRule - returns alert rule with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetAlertRule(ctx context.Context, ruleID string) (*models.AlertRule, error) {
+	entry := s.logger.WithField("func", "GetAlertRule")
+
+	entry.Debugf("Getting alert rule with id: %s", ruleID)
+	rule := new(models.AlertRule)
+
+	if err := s.Pick(ctx, rule, alertRuleGet, ruleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), alertRuleGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved alert rule with id %s: %+v", ruleID, *rule)
+	return rule, nil
+}
+
+// DeleteAlertRule - deletes alert rule with given ID from SQLite db.
+func (s *SQLite) DeleteAlertRule(ctx context.Context, ruleID string) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteAlertRule")
+
+	entry.Debugf("Deleting alert rule with id: %s", ruleID)
+	result, err := s.Exec(ctx, alertRuleDelete, ruleID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), alertRuleDelete: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), alertRuleDelete: %w", err)
+	}
+
+	entry.Debugf("Alert rule with id %s deleted successfully, rows affected: %d", ruleID, id)
+	return id, nil
+}
+
+// UpdateAlertRuleState - writes state and last evaluation of given rule to SQLite db.
+func (s *SQLite) UpdateAlertRuleState(ctx context.Context, rule *models.AlertRule) error {
+	entry := s.logger.WithField("func", "UpdateAlertRuleState")
+
+	entry.Debugf("Updating alert rule %d, state: %s, value: %v", rule.RuleID, rule.State, rule.Value)
+	_, err := s.Exec(ctx, alertRuleUpdateState, rule.State, rule.Value, rule.EvaluatedAt, rule.ChangedAt, rule.RuleID)
+
+	if err != nil {
+		return fmt.Errorf("SQLite Exec(), alertRuleUpdateState: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateCategoryRule - writes given category rule to SQLite db.
+func (s *SQLite) CreateCategoryRule(ctx context.Context, rule *models.CategoryRule) (int64, error) {
+	entry := s.logger.WithField("func", "CreateCategoryRule")
+
+	entry.Debugf("Creating category rule: %+v", rule)
+	result, err := s.Exec(ctx, categoryRuleCreate, rule.Pattern, rule.Category, rule.CreatedAt)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), categoryRuleCreate: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), categoryRuleCreate: %w", err)
+	}
+
+	entry.Debugf("Created category rule id: %d", id)
+	return id, nil
+}
+
+// GetCategoryRules - returns all category rules from SQLite db.
+func (s *SQLite) GetCategoryRules(ctx context.Context) ([]*models.CategoryRule, error) {
+	entry := s.logger.WithField("func", "GetCategoryRules")
+
+	entry.Debug("Getting category rules")
+	rules := make([]*models.CategoryRule, 0)
+
+	if err := s.Get(ctx, &rules, categoryRulesGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), categoryRulesGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved category rules num: %d", len(rules))
+	return rules, nil
+}
+
+// GetCategoryRule - returns category rule with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetCategoryRule(ctx context.Context, ruleID string) (*models.CategoryRule, error) {
+	entry := s.logger.WithField("func", "GetCategoryRule")
+
+	entry.Debugf("Getting category rule with id: %s", ruleID)
+	rule := new(models.CategoryRule)
+
+	if err := s.Pick(ctx, rule, categoryRuleGet, ruleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), categoryRuleGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved category rule with id %s: %+v", ruleID, *rule)
+	return rule, nil
+}
+
+// DeleteCategoryRule - deletes category rule with given ID from SQLite db.
+func (s *SQLite) DeleteCategoryRule(ctx context.Context, ruleID string) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteCategoryRule")
+
+	entry.Debugf("Deleting category rule with id: %s", ruleID)
+	result, err := s.Exec(ctx, categoryRuleDelete, ruleID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), categoryRuleDelete: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), categoryRuleDelete: %w", err)
+	}
+
+	entry.Debugf("Category rule with id %s deleted successfully, rows affected: %d", ruleID, id)
+	return id, nil
+}
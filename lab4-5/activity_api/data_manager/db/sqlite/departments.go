@@ -2,40 +2,115 @@ package sqlite
 
 import (
 	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 )
 
-// CreateDepartment - writes given department record to SQLite db.
-func (s *SQLite) CreateDepartment(depart *models.Department) (int64, error) {
+// CreateDepartment - writes given department record to SQLite db, parent department must exist.
+func (s *SQLite) CreateDepartment(ctx context.Context, depart *models.Department) (int64, error) {
 	entry := s.logger.WithField("func", "CreateDepartment")
 
 	entry.Debugf("Creating department: %+v", depart)
-	result, err := s.Exec(departmentCreate, depart.DepartmentName)
+	var id int64
 
-	if err != nil {
-		return -1, fmt.Errorf("SQLite s.Exec(), departmentCreate: %w", err)
-	}
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if err := checkParent(ctx, tx, depart.ParentID); err != nil {
+			return err
+		}
+
+		created, err := insert(ctx, tx, departmentCreate, depart.DepartmentName, depart.ParentID)
+
+		if err != nil {
+			return fmt.Errorf("departmentCreate: %w", err)
+		}
 
-	id, err := result.LastInsertId()
+		id = created
+
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(), activityCreate: %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), CreateDepartment: %w", err)
 	}
 
 	entry.Debugf("Created department id: %d", id)
 	return id, nil
 }
 
+// MoveDepartment - sets parent of department with given ID, 0 parent makes it top level department.
+// Department can't be moved under itself or its descendant. Returns number of moved departments.
+func (s *SQLite) MoveDepartment(ctx context.Context, departID string, parentID int64) (int64, error) {
+	entry := s.logger.WithField("func", "MoveDepartment")
+
+	entry.Debugf("Moving department with id %s to parent: %d", departID, parentID)
+	var moved int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if err := checkParent(ctx, tx, parentID); err != nil {
+			return err
+		}
+
+		if parentID != 0 {
+			var inSubtree int
+
+			if err := tx.Pick(ctx, &inSubtree, departmentInSubtree, departID, parentID); err != nil {
+				return fmt.Errorf("departmentInSubtree: %w", err)
+			}
+
+			if inSubtree > 0 {
+				return core.ErrDepartmentCycle
+			}
+		}
+
+		result, err := tx.Exec(ctx, departmentMove, departID, parentID)
+
+		if err != nil {
+			return fmt.Errorf("departmentMove: %w", err)
+		}
+
+		if moved, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), MoveDepartment: %w", err)
+	}
+
+	entry.Debugf("Department with id %s moved, rows affected: %d", departID, moved)
+	return moved, nil
+}
+
+// checkParent - returns ErrParentNotFound if parent department with given ID doesn't exist, 0 is no parent.
+func checkParent(ctx context.Context, tx core.ISQLTx, parentID int64) error {
+	if parentID == 0 {
+		return nil
+	}
+
+	if err := tx.Pick(ctx, new(models.Department), departmentGet, parentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", core.ErrParentNotFound, parentID)
+		}
+
+		return fmt.Errorf("departmentGet: %w", err)
+	}
+
+	return nil
+}
+
 // GetDepartments - returns all department records from SQLite db.
-func (s *SQLite) GetDepartments() ([]*models.Department, error) {
+func (s *SQLite) GetDepartments(ctx context.Context) ([]*models.Department, error) {
 	entry := s.logger.WithField("func", "GetDepartments")
 
 	entry.Debug("Getting departments...")
 	departments := make([]*models.Department, 0)
 
-	if err := s.Get(&departments, departmentsGet); err != nil {
+	if err := s.Get(ctx, &departments, departmentsGet); err != nil {
 		return nil, fmt.Errorf("SQLite s.Get(), departmentCreate: %w", err)
 	}
 
@@ -44,13 +119,13 @@ func (s *SQLite) GetDepartments() ([]*models.Department, error) {
 }
 
 // GetDepartment - returns department record with given ID from SQLite db.
-func (s *SQLite) GetDepartment(departID string) (*models.Department, error) {
+func (s *SQLite) GetDepartment(ctx context.Context, departID string) (*models.Department, error) {
 	entry := s.logger.WithField("func", "GetDepartment")
 
 	entry.Debugf("Getting department with id: %s", departID)
 	department := new(models.Department)
 
-	if err := s.Pick(department, departmentGet, departID); err != nil {
+	if err := s.Pick(ctx, department, departmentGet, departID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -62,21 +137,41 @@ func (s *SQLite) GetDepartment(departID string) (*models.Department, error) {
 	return department, nil
 }
 
-// DeleteDepartment - deletes department record with given ID from SQLite db.
-func (s *SQLite) DeleteDepartment(departID string) (int64, error) {
+// DeleteDepartment - deletes department record with given ID, its schedule and retention policy from SQLite db.
+func (s *SQLite) DeleteDepartment(ctx context.Context, departID string) (int64, error) {
 	entry := s.logger.WithField("func", "DeleteDepartment")
 
 	entry.Debugf("Deleting department with id: %s", departID)
-	result, err := s.Exec(departmentDelete, departID)
+	var id int64
+	// Children are moved before delete, because their new parent is read from deleted department.
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if _, err := tx.Exec(ctx, departmentChildrenReparent, departID); err != nil {
+			return fmt.Errorf("departmentChildrenReparent: %w", err)
+		}
 
-	if err != nil {
-		return -1, fmt.Errorf("SQLite s.Exec(), departmentCreate: %w", err)
-	}
+		if _, err := tx.Exec(ctx, departmentWorkScheduleDelete, departID); err != nil {
+			return fmt.Errorf("departmentWorkScheduleDelete: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, departmentRetentionPolicyDelete, departID); err != nil {
+			return fmt.Errorf("departmentRetentionPolicyDelete: %w", err)
+		}
+
+		result, err := tx.Exec(ctx, departmentDelete, departID)
+
+		if err != nil {
+			return fmt.Errorf("departmentDelete: %w", err)
+		}
+
+		if id, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
 
-	id, err := result.RowsAffected()
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(), activityCreate: %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), DeleteDepartment: %w", err)
 	}
 
 	entry.Debugf("Department with id %s deleted successfully, rows affected: %d", departID, id)
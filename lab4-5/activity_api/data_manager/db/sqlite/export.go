@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+	"time"
+)
+
+// StreamDepartments - calls f for every department record from SQLite db.
+func (s *SQLite) StreamDepartments(ctx context.Context, f func(*models.Department) error) error {
+	s.logger.WithField("func", "StreamDepartments").Debug("Streaming departments...")
+
+	err := s.Stream(ctx, departmentsGet, func(scan core.ScanFunc) error {
+		department := new(models.Department)
+
+		if err := scan(department); err != nil {
+			return fmt.Errorf("scan(): %w", err)
+		}
+
+		return f(department)
+	})
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Stream(), departmentsGet: %w", err)
+	}
+
+	return nil
+}
+
+// StreamUsers - calls f for every user record from SQLite db, if depID is set - only for users of the department.
+func (s *SQLite) StreamUsers(ctx context.Context, depID string, f func(*models.User) error) error {
+	s.logger.WithField("func", "StreamUsers").Debugf("Streaming users, department id: %s", depID)
+
+	query, args := usersGet, make([]interface{}, 0)
+
+	if depID != "" {
+		query, args = userDepartmentGet, append(args, depID)
+	}
+
+	err := s.Stream(ctx, query, func(scan core.ScanFunc) error {
+		user := new(models.User)
+
+		if err := scan(user); err != nil {
+			return fmt.Errorf("scan(): %w", err)
+		}
+
+		return f(user)
+	}, args...)
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Stream(), usersGet: %w", err)
+	}
+
+	return nil
+}
+
+// StreamActivities - calls f for every activity record from SQLite db.
+func (s *SQLite) StreamActivities(ctx context.Context, f func(*models.Activity) error) error {
+	s.logger.WithField("func", "StreamActivities").Debug("Streaming activities...")
+
+	err := s.Stream(ctx, activitiesGet, func(scan core.ScanFunc) error {
+		activity := new(models.Activity)
+
+		if err := scan(activity); err != nil {
+			return fmt.Errorf("scan(): %w", err)
+		}
+
+		return f(activity)
+	})
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Stream(), activitiesGet: %w", err)
+	}
+
+	return nil
+}
+
+// StreamUsersReport - calls f for activity of every user between 2 dates (timestamps).
+func (s *SQLite) StreamUsersReport(
+	ctx context.Context,
+	startTime, endTime time.Time,
+	f func(*models.UserReport) error,
+) error {
+	s.logger.WithField("func", "StreamUsersReport").
+		Debugf("Streaming users report, start time - %v, end time - %v", startTime, endTime)
+
+	filter, args := activityTimeFilter(1, startTime, endTime)
+	query := fmt.Sprintf(usersReport, filter)
+
+	err := s.Stream(ctx, query, func(scan core.ScanFunc) error {
+		report := new(models.UserReport)
+
+		if err := scan(report); err != nil {
+			return fmt.Errorf("scan(): %w", err)
+		}
+
+		return f(report)
+	}, args...)
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Stream(), usersReport: %w", err)
+	}
+
+	return nil
+}
+
+// StreamDepartmentsReport - calls f for activity of every department between 2 dates (timestamps).
+func (s *SQLite) StreamDepartmentsReport(
+	ctx context.Context,
+	startTime, endTime time.Time,
+	f func(*models.DepartmentReport) error,
+) error {
+	s.logger.WithField("func", "StreamDepartmentsReport").
+		Debugf("Streaming departments report, start time - %v, end time - %v", startTime, endTime)
+
+	filter, args := activityTimeFilter(1, startTime, endTime)
+	query := fmt.Sprintf(departmentsReport, filter)
+
+	err := s.Stream(ctx, query, func(scan core.ScanFunc) error {
+		report := new(models.DepartmentReport)
+
+		if err := scan(report); err != nil {
+			return fmt.Errorf("scan(): %w", err)
+		}
+
+		return f(report)
+	}, args...)
+
+	if err != nil {
+		return fmt.Errorf("SQLite s.Stream(), departmentsReport: %w", err)
+	}
+
+	return nil
+}
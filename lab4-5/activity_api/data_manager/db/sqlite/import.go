@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// errDryRun - returned from dry run transaction, so it's rolled back.
+	errDryRun = errors.New("dry run")
+	// errRowsFailed - returned from import transaction if any row failed, so it's rolled back.
+	errRowsFailed = errors.New("import rows failed")
+)
+
+// importDepartment - department used by import.
+type importDepartment struct {
+	id      int64
+	created bool // created by this import
+}
+
+// ImportUsers - creates users of given rows, departments are matched by name or created.
+// All rows are imported in single transaction, which is committed only if every row succeeded.
+// Dry run executes the same queries, so it reports db errors as well, but transaction is always rolled back.
+func (s *SQLite) ImportUsers(ctx context.Context, rows []*models.ImportRow, dryRun bool) (*models.ImportResult, error) {
+	entry := s.logger.WithField("func", "ImportUsers")
+	entry.Debugf("Importing %d rows, dry run: %v", len(rows), dryRun)
+
+	var result *models.ImportResult
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		result = &models.ImportResult{DryRun: dryRun, Rows: make([]*models.ImportRowResult, 0, len(rows))}
+		departments := make(map[string]*importDepartment)
+
+		for _, row := range rows {
+			rowResult, err := s.importRow(ctx, tx, row, departments)
+
+			if err != nil {
+				return err
+			}
+
+			result.Rows = append(result.Rows, rowResult)
+
+			if rowResult.Error != "" {
+				result.Failed++
+			} else if rowResult.UserID != 0 {
+				result.UsersCreated++
+			}
+		}
+
+		for _, department := range departments {
+			if department.created {
+				result.DepartmentsCreated++
+			} else {
+				result.DepartmentsMatched++
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+
+		if result.Failed > 0 {
+			return errRowsFailed
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) && !errors.Is(err, errRowsFailed) {
+		return nil, fmt.Errorf("SQLite s.Tx(): %w", err)
+	}
+
+	result.Committed = err == nil
+	// IDs of rolled back objects don't exist.
+	if !result.Committed {
+		for _, row := range result.Rows {
+			row.UserID = 0
+
+			if row.Department == models.ImportCreated {
+				row.DepartmentID = 0
+			}
+		}
+	}
+
+	entry.Debugf("Import finished: %+v", *result)
+	return result, nil
+}
+
+// importRow - imports single row. Invalid row is reported in result, error is returned only if import can't go on.
+func (s *SQLite) importRow(
+	ctx context.Context,
+	tx core.ISQLTx,
+	row *models.ImportRow,
+	departments map[string]*importDepartment,
+) (*models.ImportRowResult, error) {
+	result := &models.ImportRowResult{Line: row.Line}
+
+	if row.Error != "" {
+		result.Error = row.Error
+
+		return result, nil
+	}
+
+	if row.DepartmentName == "" {
+		result.Error = "department name is empty"
+
+		return result, nil
+	}
+
+	department, err := s.importDepartment(ctx, tx, row.DepartmentName, departments)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		result.Error = err.Error()
+
+		return result, nil
+	}
+
+	result.DepartmentID = department.id
+	result.Department = models.ImportMatched
+
+	if department.created {
+		result.Department = models.ImportCreated
+	}
+
+	if row.UserName == "" {
+		return result, nil
+	}
+
+	user := &models.User{UserName: row.UserName, DepartmentID: department.id, Status: models.UserActive}
+
+	if result.UserID, err = createUser(ctx, tx, user); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		result.Error = fmt.Sprintf("create user: %v", err)
+	}
+
+	return result, nil
+}
+
+// importDepartment - returns department with given name, creates it if it doesn't exist.
+func (s *SQLite) importDepartment(
+	ctx context.Context,
+	tx core.ISQLTx,
+	name string,
+	departments map[string]*importDepartment,
+) (*importDepartment, error) {
+	if department, ok := departments[name]; ok {
+		return department, nil
+	}
+
+	existing := new(models.Department)
+	err := tx.Pick(ctx, existing, departmentFind, name)
+
+	switch {
+	case err == nil:
+		departments[name] = &importDepartment{id: existing.DepartmentID}
+	case errors.Is(err, sql.ErrNoRows):
+		id, err := insert(ctx, tx, departmentCreate, name, 0)
+
+		if err != nil {
+			return nil, fmt.Errorf("create department: %w", err)
+		}
+
+		departments[name] = &importDepartment{id: id, created: true}
+	default:
+		return nil, fmt.Errorf("find department: %w", err)
+	}
+
+	return departments[name], nil
+}
+
+// insert - executes insert query in transaction and returns id of inserted row.
+func insert(ctx context.Context, tx core.ISQLTx, query string, args ...interface{}) (int64, error) {
+	result, err := tx.Exec(ctx, query, args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, fmt.Errorf("LastInsertId(): %w", err)
+	}
+
+	return id, nil
+}
+
+// notInserted - returns error of insert which inserted no rows: RowsAffected() error if it failed, or notFound.
+func notInserted(err, notFound error) error {
+	if err != nil {
+		return fmt.Errorf("RowsAffected(): %w", err)
+	}
+
+	return notFound
+}
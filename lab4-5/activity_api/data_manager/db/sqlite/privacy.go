@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"fmt"
+)
+
+// erasedUserName - name of pseudonymous user which gets activity of erased user.
+const erasedUserName = "erased user"
+
+// GetUserActivities - returns all live activity records of user with their app breakdown.
+func (s *SQLite) GetUserActivities(ctx context.Context, userID string) ([]*models.Activity, error) {
+	entry := s.logger.WithField("func", "GetUserActivities")
+
+	entry.Debugf("Getting activities of user %s", userID)
+	activities := make([]*models.Activity, 0)
+
+	if err := s.Get(ctx, &activities, userActivitiesGet, userID); err != nil {
+		return nil, fmt.Errorf("s.Get(), userActivitiesGet: %w", err)
+	}
+
+	apps := make([]*activityApp, 0)
+
+	if err := s.Get(ctx, &apps, userAppsGet, userID); err != nil {
+		return nil, fmt.Errorf("s.Get(), userAppsGet: %w", err)
+	}
+
+	attachApps(activities, apps)
+
+	entry.Debugf("Retrieved activities of user %s: %d", userID, len(activities))
+	return activities, nil
+}
+
+// GetActivityDays - returns daily rollups of user, they include time of archived records.
+func (s *SQLite) GetActivityDays(ctx context.Context, userID string) ([]*models.DailyActivity, error) {
+	entry := s.logger.WithField("func", "GetActivityDays")
+
+	entry.Debugf("Getting activity days of user %s", userID)
+	days := make([]*models.DailyActivity, 0)
+
+	if err := s.Get(ctx, &days, activityDaysGet, userID); err != nil {
+		return nil, fmt.Errorf("s.Get(), activityDaysGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved activity days of user %s: %d", userID, len(days))
+	return days, nil
+}
+
+// CreateSubjectRequest - writes given data subject request to SQLite db.
+func (s *SQLite) CreateSubjectRequest(ctx context.Context, request *models.SubjectRequest) (int64, error) {
+	entry := s.logger.WithField("func", "CreateSubjectRequest")
+
+	entry.Debugf("Creating subject request: %+v", request)
+	result, err := s.Exec(ctx,
+		subjectRequestCreate,
+		request.UserID,
+		request.Kind,
+		request.RequestedBy,
+		request.Records,
+		request.CreatedAt,
+	)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), subjectRequestCreate: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), subjectRequestCreate: %w", err)
+	}
+
+	entry.Debugf("Created subject request id: %d", id)
+	return id, nil
+}
+
+// GetSubjectRequests - returns data subject requests of user from SQLite db, empty userID - of all users.
+func (s *SQLite) GetSubjectRequests(ctx context.Context, userID string) ([]*models.SubjectRequest, error) {
+	entry := s.logger.WithField("func", "GetSubjectRequests")
+
+	entry.Debugf("Getting subject requests, user id: %q", userID)
+	requests := make([]*models.SubjectRequest, 0)
+
+	if err := s.Get(ctx, &requests, subjectRequestsGet, userID); err != nil {
+		return nil, fmt.Errorf("s.Get(), subjectRequestsGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved subject requests num: %d", len(requests))
+	return requests, nil
+}
+
+// EraseUser - erases user in single transaction: activity records, rollups and memberships are moved
+// to new pseudonymous user of the same department, so department totals of any period don't change.
+// Profile, own schedule, leaves and alert rules of user are deleted, erasure is recorded with given request.
+// Archive files still have records with id of erased user, they aren't restored since user doesn't exist.
+func (s *SQLite) EraseUser(
+	ctx context.Context,
+	userID string,
+	request *models.SubjectRequest,
+) (*models.SubjectRequest, error) {
+	entry := s.logger.WithField("func", "EraseUser")
+
+	entry.Debugf("Erasing user with id: %s", userID)
+	var erased *models.SubjectRequest
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		result, err := tx.Exec(ctx, userPseudonymCreate, userID, erasedUserName)
+
+		if err != nil {
+			return fmt.Errorf("userPseudonymCreate: %w", err)
+		}
+
+		inserted, err := result.RowsAffected()
+
+		if err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		if inserted == 0 {
+			return nil
+		}
+
+		pseudonymID, err := result.LastInsertId()
+
+		if err != nil {
+			return fmt.Errorf("LastInsertId(): %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, membershipsReassign, userID, pseudonymID); err != nil {
+			return fmt.Errorf("membershipsReassign: %w", err)
+		}
+
+		result, err = tx.Exec(ctx, activityReassign, userID, pseudonymID)
+
+		if err != nil {
+			return fmt.Errorf("activityReassign: %w", err)
+		}
+
+		records, err := result.RowsAffected()
+
+		if err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		for _, query := range []struct {
+			name, query string
+		}{
+			{"activityDailyReassign", activityDailyReassign},
+			{"activityDailyArchivedReassign", activityDailyArchivedReassign},
+		} {
+			if _, err := tx.Exec(ctx, query.query, userID, pseudonymID); err != nil {
+				return fmt.Errorf("%s: %w", query.name, err)
+			}
+		}
+
+		for _, query := range []struct {
+			name, query string
+		}{
+			{"userActivityDailyDelete", userActivityDailyDelete},
+			{"userWorkScheduleDelete", userWorkScheduleDelete},
+			{"userTimeOffsDelete", userTimeOffsDelete},
+			{"userAlertRulesDelete", userAlertRulesDelete},
+			{"userDelete", userDelete},
+		} {
+			if _, err := tx.Exec(ctx, query.query, userID); err != nil {
+				return fmt.Errorf("%s: %w", query.name, err)
+			}
+		}
+
+		result, err = tx.Exec(ctx,
+			subjectRequestCreate,
+			request.UserID,
+			models.SubjectErasure,
+			request.RequestedBy,
+			records,
+			request.CreatedAt,
+		)
+
+		if err != nil {
+			return fmt.Errorf("subjectRequestCreate: %w", err)
+		}
+
+		erased = &models.SubjectRequest{
+			UserID:      request.UserID,
+			Kind:        models.SubjectErasure,
+			RequestedBy: request.RequestedBy,
+			Records:     records,
+			CreatedAt:   request.CreatedAt,
+		}
+
+		if erased.RequestID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("LastInsertId(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("SQLite s.Tx(), EraseUser: %w", err)
+	}
+
+	entry.Debugf("User with id %s erased, request: %+v", userID, erased)
+	return erased, nil
+}
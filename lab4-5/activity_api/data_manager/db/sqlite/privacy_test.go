@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"strconv"
+	"testing"
+)
+
+func Test_EraseUser(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+	departs, users := activityFixture(t, s, ctx)
+	userID := strconv.FormatInt(users[0], 10)
+	// Part of records is archived, so time of archived records is moved too.
+	archived, err := s.GetArchivableActivity(ctx, userID, base+3*secondsInDay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ArchiveActivity(ctx, userID, base+3*secondsInDay, archived[len(archived)-1].RecordID); err != nil {
+		t.Fatal(err)
+	}
+
+	activities, err := s.GetUserActivities(ctx, userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	departmentActivity := func() []models.DepartmentActivity {
+		result := make([]models.DepartmentActivity, 0)
+
+		for _, period := range activityRanges() {
+			for _, departID := range departs {
+				for _, rollup := range []bool{false, true} {
+					activity, err := s.GetDepartmentActivity(ctx, strconv.FormatInt(departID, 10), period[0], period[1], rollup)
+
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					result = append(result, *activity)
+				}
+			}
+		}
+
+		return result
+	}
+	checkActivity := func(stage string, expected []models.DepartmentActivity) {
+		for i, actual := range departmentActivity() {
+			if actual.TotalTime != expected[i].TotalTime || actual.ActiveTime != expected[i].ActiveTime {
+				t.Fatalf("%s: department activity %+v, expected %+v", stage, actual, expected[i])
+			}
+		}
+	}
+
+	expected := departmentActivity()
+	request := &models.SubjectRequest{UserID: users[0], RequestedBy: "admin", CreatedAt: base}
+	erased, err := s.EraseUser(ctx, userID, request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if erased == nil || erased.Kind != models.SubjectErasure || erased.Records != int64(len(activities)) {
+		t.Fatalf("Erasure %+v, expected %d records", erased, len(activities))
+	}
+
+	checkActivity("erase", expected)
+
+	if _, err := s.RebuildRollups(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkActivity("rebuild after erase", expected)
+
+	if user, err := s.GetUser(ctx, userID); err != nil || user != nil {
+		t.Fatalf("Erased user %+v, error: %v", user, err)
+	}
+
+	for _, check := range []func() (int, error){
+		func() (int, error) { r, err := s.GetUserActivities(ctx, userID); return len(r), err },
+		func() (int, error) { r, err := s.GetActivityDays(ctx, userID); return len(r), err },
+		func() (int, error) { r, err := s.GetMemberships(ctx, userID); return len(r), err },
+	} {
+		if n, err := check(); err != nil || n != 0 {
+			t.Fatalf("%d rows of erased user left, error: %v", n, err)
+		}
+	}
+	// Archived records of erased user aren't restored.
+	if restored, err := s.RestoreActivity(ctx, archived); err != nil || restored != 0 {
+		t.Fatalf("%d archived records of erased user restored, error: %v", restored, err)
+	}
+
+	requests, err := s.GetSubjectRequests(ctx, userID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 1 || *requests[0] != *erased {
+		t.Fatalf("Subject requests %+v, expected %+v", requests, *erased)
+	}
+
+	if erased, err := s.EraseUser(ctx, userID, request); err != nil || erased != nil {
+		t.Fatalf("Second erasure %+v, error: %v", erased, err)
+	}
+}
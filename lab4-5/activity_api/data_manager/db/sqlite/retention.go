@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateRetentionPolicy - writes given retention policy to SQLite db, department must be of the same tenant.
+// Tenant and every department have at most one policy.
+func (s *SQLite) CreateRetentionPolicy(ctx context.Context, policy *models.RetentionPolicy) (int64, error) {
+	entry := s.logger.WithField("func", "CreateRetentionPolicy")
+
+	entry.Debugf("Creating retention policy: %+v", policy)
+	var id int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		var exists int
+
+		if err := tx.Pick(ctx, &exists, retentionPolicyExists, policy.DepartmentID); err != nil {
+			return fmt.Errorf("retentionPolicyExists: %w", err)
+		}
+
+		if exists != 0 {
+			return fmt.Errorf("department %d: %w", policy.DepartmentID, core.ErrPolicyExists)
+		}
+
+		result, err := tx.Exec(ctx, retentionPolicyCreate, policy.DepartmentID, policy.Days, policy.CreatedAt)
+
+		if err != nil {
+			return fmt.Errorf("retentionPolicyCreate: %w", err)
+		}
+
+		if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+			err = notInserted(err, core.ErrDepartmentNotFound)
+
+			return fmt.Errorf("retentionPolicyCreate, department %d: %w", policy.DepartmentID, err)
+		}
+
+		if id, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("LastInsertId(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), CreateRetentionPolicy: %w", err)
+	}
+
+	entry.Debugf("Created retention policy id: %d", id)
+	return id, nil
+}
+
+// GetRetentionPolicies - returns all retention policies from SQLite db.
+func (s *SQLite) GetRetentionPolicies(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	entry := s.logger.WithField("func", "GetRetentionPolicies")
+
+	entry.Debug("Getting retention policies")
+	policies := make([]*models.RetentionPolicy, 0)
+
+	if err := s.Get(ctx, &policies, retentionPoliciesGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), retentionPoliciesGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved retention policies num: %d", len(policies))
+	return policies, nil
+}
+
+// GetRetentionPolicy - returns retention policy with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetRetentionPolicy(ctx context.Context, policyID string) (*models.RetentionPolicy, error) {
+	entry := s.logger.WithField("func", "GetRetentionPolicy")
+
+	entry.Debugf("Getting retention policy with id: %s", policyID)
+	policy := new(models.RetentionPolicy)
+
+	if err := s.Pick(ctx, policy, retentionPolicyGet, policyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), retentionPolicyGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved retention policy with id %s: %+v", policyID, *policy)
+	return policy, nil
+}
+
+// DeleteRetentionPolicy - deletes retention policy with given ID from SQLite db.
+func (s *SQLite) DeleteRetentionPolicy(ctx context.Context, policyID string) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteRetentionPolicy")
+
+	entry.Debugf("Deleting retention policy with id: %s", policyID)
+	result, err := s.Exec(ctx, retentionPolicyDelete, policyID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), retentionPolicyDelete: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), retentionPolicyDelete: %w", err)
+	}
+
+	entry.Debugf("Retention policy with id %s deleted successfully, rows affected: %d", policyID, id)
+	return id, nil
+}
+
+// GetArchivableActivity - returns activity records of user created before given time with their app breakdown.
+func (s *SQLite) GetArchivableActivity(ctx context.Context, userID string, before int64) ([]*models.Activity, error) {
+	entry := s.logger.WithField("func", "GetArchivableActivity")
+
+	entry.Debugf("Getting activity of user %s before %d", userID, before)
+	activities := make([]*models.Activity, 0)
+
+	if err := s.Get(ctx, &activities, archivableActivityGet, userID, before); err != nil {
+		return nil, fmt.Errorf("s.Get(), archivableActivityGet: %w", err)
+	}
+
+	apps := make([]*activityApp, 0)
+
+	if err := s.Get(ctx, &apps, archivableAppsGet, userID, before); err != nil {
+		return nil, fmt.Errorf("s.Get(), archivableAppsGet: %w", err)
+	}
+
+	attachApps(activities, apps)
+
+	entry.Debugf("Retrieved archivable activities: %d", len(activities))
+	return activities, nil
+}
+
+// ArchiveActivity - deletes activity records of user created before given time with ids up to lastRecordID,
+// so records created after they were read for archive are kept. Time of deleted records is kept
+// in archived daily summaries, daily rollups aren't changed.
+func (s *SQLite) ArchiveActivity(ctx context.Context, userID string, before, lastRecordID int64) (int64, error) {
+	entry := s.logger.WithField("func", "ArchiveActivity")
+
+	entry.Debugf("Archiving activity of user %s before %d, last record id: %d", userID, before, lastRecordID)
+	var deleted int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		// Records are marked archived before delete, so triggers don't subtract them from rollups.
+		for _, query := range []struct {
+			name, query string
+		}{
+			{"activityArchiveSummarize", activityArchiveSummarize},
+			{"activityArchiveMark", activityArchiveMark},
+			{"archivedAppsDelete", archivedAppsDelete},
+		} {
+			if _, err := tx.Exec(ctx, query.query, userID, before, lastRecordID); err != nil {
+				return fmt.Errorf("%s: %w", query.name, err)
+			}
+		}
+
+		result, err := tx.Exec(ctx, archivedActivityDelete, userID, before, lastRecordID)
+
+		if err != nil {
+			return fmt.Errorf("archivedActivityDelete: %w", err)
+		}
+
+		if deleted, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), ArchiveActivity: %w", err)
+	}
+
+	entry.Debugf("Archived activity of user %s, records deleted: %d", userID, deleted)
+	return deleted, nil
+}
+
+// RestoreActivity - writes archived activity records with their ids and app breakdown back to SQLite db
+// in single transaction. Records which exist, or which user isn't of the same tenant, are skipped.
+// Restored records stay archived, their time is already in rollups.
+func (s *SQLite) RestoreActivity(ctx context.Context, activities []*models.Activity) (int64, error) {
+	entry := s.logger.WithField("func", "RestoreActivity")
+
+	entry.Debugf("Restoring activities: %d", len(activities))
+	var restored int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		for _, activity := range activities {
+			result, err := tx.Exec(ctx,
+				activityRestore,
+				activity.RecordID,
+				activity.UserID,
+				activity.ActiveTime,
+				activity.TotalTime,
+				activity.Date,
+			)
+
+			if err != nil {
+				return fmt.Errorf("activityRestore, record %d: %w", activity.RecordID, err)
+			}
+
+			inserted, err := result.RowsAffected()
+
+			if err != nil {
+				return fmt.Errorf("RowsAffected(): %w", err)
+			}
+
+			if inserted == 0 {
+				continue
+			}
+
+			for _, app := range activity.Apps {
+				if _, err := tx.Exec(ctx, activityAppCreate, activity.RecordID, app.App, app.Time); err != nil {
+					return fmt.Errorf("activityAppCreate, record %d, app %q: %w", activity.RecordID, app.App, err)
+				}
+			}
+
+			restored++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), RestoreActivity: %w", err)
+	}
+
+	entry.Debugf("Restored activities: %d", restored)
+	return restored, nil
+}
+
+// attachApps - appends apps to breakdowns of their activity records.
+func attachApps(activities []*models.Activity, apps []*activityApp) {
+	records := make(map[int64]*models.Activity, len(activities))
+
+	for _, activity := range activities {
+		records[activity.RecordID] = activity
+	}
+
+	for _, app := range apps {
+		if activity, ok := records[app.RecordID]; ok {
+			activity.Apps = append(activity.Apps, &app.AppTime)
+		}
+	}
+}
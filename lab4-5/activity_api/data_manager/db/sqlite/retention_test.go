@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func Test_ArchiveActivity(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+	_, users := activityFixture(t, s, ctx)
+	userID := strconv.FormatInt(users[0], 10)
+	before := base + 5*secondsInDay
+
+	type daily struct {
+		UserID     int64 `db:"user_id"`
+		Day        int64 `db:"day"`
+		TotalTime  int64 `db:"total_time"`
+		ActiveTime int64 `db:"active_time"`
+		Records    int64 `db:"records"`
+	}
+
+	rollups := func() []daily {
+		result := make([]daily, 0)
+
+		if err := s.Get(ctx, &result, `SELECT user_id, day, total_time, active_time, records
+FROM activity_daily ORDER BY user_id, day;`); err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+	checkRollups := func(stage string, expected []daily) {
+		actual := rollups()
+
+		if len(actual) != len(expected) {
+			t.Fatalf("%s: %d rollups, expected %d", stage, len(actual), len(expected))
+		}
+
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf("%s: rollup %+v, expected %+v", stage, actual[i], expected[i])
+			}
+		}
+	}
+
+	expected := rollups()
+	archived, err := s.GetArchivableActivity(ctx, userID, before)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archived) == 0 {
+		t.Fatal("No records to archive")
+	}
+	// Record created after records were read isn't deleted, even if it's old.
+	late, err := s.CreateActivity(ctx, &models.Activity{UserID: users[0], TotalTime: 5, ActiveTime: 5, Date: base})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = rollups()
+	deleted, err := s.ArchiveActivity(ctx, userID, before, archived[len(archived)-1].RecordID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != int64(len(archived)) {
+		t.Fatalf("%d records deleted, expected %d", deleted, len(archived))
+	}
+
+	left, err := s.GetArchivableActivity(ctx, userID, before)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(left) != 1 || left[0].RecordID != late {
+		t.Fatalf("Records left before cutoff: %+v, expected only record %d", left, late)
+	}
+
+	checkRollups("archive", expected)
+
+	if _, err := s.RebuildRollups(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRollups("rebuild after archive", expected)
+
+	for i := 0; i < 2; i++ {
+		restored, err := s.RestoreActivity(ctx, archived)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 && restored != int64(len(archived)) || i == 1 && restored != 0 {
+			t.Fatalf("Restore %d: %d records restored of %d", i+1, restored, len(archived))
+		}
+
+		checkRollups("restore", expected)
+	}
+
+	if _, err := s.RebuildRollups(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRollups("rebuild after restore", expected)
+	// Restored records could be archived again without counting them twice.
+	if _, err := s.ArchiveActivity(ctx, userID, before, late); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.RebuildRollups(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRollups("rebuild after second archive", expected)
+}
+
+func Test_CreateRetentionPolicy(t *testing.T) {
+	s, ctx := newTestSQLite(t)
+	departs, _ := activityFixture(t, s, ctx)
+
+	for _, test := range []struct {
+		departmentID int64
+		err          error
+	}{
+		{0, nil},
+		{departs[1], nil},
+		{0, core.ErrPolicyExists},
+		{departs[1], core.ErrPolicyExists},
+		{departs[len(departs)-1] + 1, core.ErrDepartmentNotFound},
+	} {
+		_, err := s.CreateRetentionPolicy(ctx, &models.RetentionPolicy{DepartmentID: test.departmentID, Days: 30})
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("Department %d: error %v, expected %v", test.departmentID, err, test.err)
+		}
+	}
+	// Policy is deleted with its department.
+	if _, err := s.DeleteDepartment(ctx, strconv.FormatInt(departs[1], 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	policies, err := s.GetRetentionPolicies(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(policies) != 1 || policies[0].DepartmentID != 0 {
+		t.Fatalf("Unexpected policies: %+v", policies)
+	}
+}
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"activity_api/data_manager/db/core"
+	"context"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
@@ -9,7 +10,20 @@ import (
 
 const serviceName = "SQLite"
 
-// SQLite - sqlite service
+// migrations - schema changes of existing tables, applied once in order by CreateDB.
+var migrations = []string{
+	migrationDepartmentParent,
+	migrationTenants,
+	migrationUserProfiles,
+	migrationActivityApps,
+	migrationWorkTime,
+	migrationActivityDaily,
+	migrationRetention,
+	migrationSubjectRequests,
+	migrationAdminRoles,
+}
+
+// SQLite - sqlite service, queries of tenant data are scoped by tenant of context (see tenantCore).
 type SQLite struct {
 	core.ISQLCore
 	logger logrus.FieldLogger
@@ -18,43 +32,108 @@ type SQLite struct {
 // NewSQLite - returns new SQLite DB
 func NewSQLite(connString string, logger logrus.FieldLogger) core.ISQLDatabase {
 	return &SQLite{
-		ISQLCore: core.NewSQL("sqlite3", connString, logger),
+		ISQLCore: &tenantCore{ISQLCore: core.NewSQL("sqlite3", connString, logger)},
 		logger:   logger.WithField("module", "SQLite"),
 	}
 }
 
 // CreateDB - creates required tables for AAService (CREATE IF NOT EXIST)
-func (s *SQLite) CreateDB() error {
+func (s *SQLite) CreateDB(ctx context.Context) error {
 	entry := s.logger.WithField("func", "CreateDB")
 
 	entry.Info("Initializing admins table...")
-	_, err := s.Exec(createAdminsTable)
+	_, err := s.Exec(ctx, createAdminsTable)
 
 	if err != nil {
 		return fmt.Errorf("CreateDB(), createAdminsTable: %w", err)
 	}
 
 	entry.Info("Initializing departments table")
-	_, err = s.Exec(createDepartmentsTable)
+	_, err = s.Exec(ctx, createDepartmentsTable)
 
 	if err != nil {
 		return fmt.Errorf("CreateDB(), createDepartmentsTable: %w", err)
 	}
 
 	entry.Info("Initializing users table")
-	_, err = s.Exec(createUsersTable)
+	_, err = s.Exec(ctx, createUsersTable)
 
 	if err != nil {
 		return fmt.Errorf("CreateDB(), createUsersTable: %w", err)
 	}
 
 	entry.Info("Initializing activity table")
-	_, err = s.Exec(createActivityTable)
+	_, err = s.Exec(ctx, createActivityTable)
 
 	if err != nil {
 		return fmt.Errorf("CreateDB(), createActivityTable: %w", err)
 	}
 
+	entry.Info("Initializing webhooks table")
+	_, err = s.Exec(ctx, createWebhooksTable)
+
+	if err != nil {
+		return fmt.Errorf("CreateDB(), createWebhooksTable: %w", err)
+	}
+
+	entry.Info("Initializing webhook deliveries table")
+	_, err = s.Exec(ctx, createDeliveriesTable)
+
+	if err != nil {
+		return fmt.Errorf("CreateDB(), createDeliveriesTable: %w", err)
+	}
+
+	_, err = s.Exec(ctx, createDeliveriesIndex)
+
+	if err != nil {
+		return fmt.Errorf("CreateDB(), createDeliveriesIndex: %w", err)
+	}
+
+	entry.Info("Initializing alert rules table")
+	_, err = s.Exec(ctx, createAlertRulesTable)
+
+	if err != nil {
+		return fmt.Errorf("CreateDB(), createAlertRulesTable: %w", err)
+	}
+
+	if err := s.migrate(ctx); err != nil {
+		return fmt.Errorf("CreateDB(), migrate(): %w", err)
+	}
+
+	return nil
+}
+
+// migrate - applies migrations which aren't applied yet. Every migration is applied in its own transaction
+// together with schema version update, so failed migration is retried on next start.
+func (s *SQLite) migrate(ctx context.Context) error {
+	entry := s.logger.WithField("func", "migrate")
+
+	var version int
+
+	if err := s.Pick(ctx, &version, schemaVersionGet); err != nil {
+		return fmt.Errorf("s.Pick(), schemaVersionGet: %w", err)
+	}
+
+	for ; version < len(migrations); version++ {
+		entry.Infof("Applying migration %d...", version+1)
+		err := s.Tx(ctx, func(tx core.ISQLTx) error {
+			if _, err := tx.Exec(ctx, migrations[version]); err != nil {
+				return fmt.Errorf("tx.Exec(), migration %d: %w", version+1, err)
+			}
+			// Pragma doesn't accept parameters, version is a number, so formatting is safe.
+			if _, err := tx.Exec(ctx, fmt.Sprintf(schemaVersionSet, version+1)); err != nil {
+				return fmt.Errorf("tx.Exec(), schemaVersionSet: %w", err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	entry.Debugf("Schema version: %d", version)
 	return nil
 }
 
@@ -31,63 +31,583 @@ CREATE TABLE IF NOT EXISTS user_activity (
 	CONSTRAINT user_activity_FK FOREIGN KEY (user_id) REFERENCES user_list(user_id)
 );`
 
+	createWebhooksTable = `
+CREATE TABLE IF NOT EXISTS webhooks (
+	webhook_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);`
+
+	createDeliveriesTable = `
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	delivery_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	last_status INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL,
+	CONSTRAINT webhook_deliveries_FK FOREIGN KEY (webhook_id) REFERENCES webhooks(webhook_id)
+);`
+
+	createDeliveriesIndex = `
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt);`
+
+	createAlertRulesTable = `
+CREATE TABLE IF NOT EXISTS alert_rules (
+	rule_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	target_id INTEGER NOT NULL,
+	threshold REAL NOT NULL,
+	period INTEGER NOT NULL,
+	notifiers TEXT NOT NULL,
+	state TEXT NOT NULL DEFAULT 'ok',
+	value REAL NOT NULL DEFAULT 0,
+	evaluated_at INTEGER NOT NULL DEFAULT 0,
+	changed_at INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);`
+
+	// Migrations are applied in order after tables are created, see migrations in sqlite.go.
+	// Applied migration must never be changed, every schema change is a new migration.
+	migrationDepartmentParent = `
+ALTER TABLE department_list ADD COLUMN parent_id INTEGER REFERENCES department_list(department_id);
+CREATE INDEX IF NOT EXISTS department_list_parent ON department_list (parent_id);`
+
+	// Existing data belongs to default tenant (see core.DefaultTenant), new rows always set tenant explicitly.
+	migrationTenants = `
+CREATE TABLE IF NOT EXISTS tenants (
+	tenant_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	tenant_name TEXT NOT NULL UNIQUE,
+	suspended INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
+INSERT OR IGNORE INTO tenants (tenant_id, tenant_name, created_at)
+VALUES (1, 'default', CAST(strftime('%s', 'now') AS INTEGER));
+ALTER TABLE admins ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+ALTER TABLE department_list ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+ALTER TABLE user_list ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+ALTER TABLE user_activity ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+ALTER TABLE webhooks ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+ALTER TABLE alert_rules ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(tenant_id);
+CREATE INDEX IF NOT EXISTS department_list_tenant ON department_list (tenant_id);
+CREATE INDEX IF NOT EXISTS user_list_tenant ON user_list (tenant_id);
+CREATE INDEX IF NOT EXISTS user_activity_tenant ON user_activity (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS webhooks_tenant ON webhooks (tenant_id);
+CREATE INDEX IF NOT EXISTS alert_rules_tenant ON alert_rules (tenant_id);`
+
+	// Empty employee number is stored as NULL, so it doesn't violate uniqueness.
+	// Existing users are members of their department since the beginning of time.
+	migrationUserProfiles = `
+ALTER TABLE user_list ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_list ADD COLUMN employee_number TEXT;
+ALTER TABLE user_list ADD COLUMN job_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_list ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE user_list ADD COLUMN employment_start INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_list ADD COLUMN employment_end INTEGER NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS user_list_employee_number ON user_list (tenant_id, employee_number);
+CREATE TABLE IF NOT EXISTS department_membership (
+	membership_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES user_list(user_id),
+	department_id INTEGER NOT NULL REFERENCES department_list(department_id),
+	valid_from INTEGER NOT NULL,
+	valid_to INTEGER NOT NULL DEFAULT 0,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id)
+);
+INSERT INTO department_membership (user_id, department_id, valid_from, tenant_id)
+SELECT user_id, department_id, 0, tenant_id FROM user_list;
+CREATE INDEX IF NOT EXISTS department_membership_user ON department_membership (user_id, valid_from);
+CREATE INDEX IF NOT EXISTS department_membership_department ON department_membership (department_id);`
+
+	// Existing activity records have no app breakdown, so they aren't counted in category time.
+	migrationActivityApps = `
+CREATE TABLE IF NOT EXISTS activity_apps (
+	record_id INTEGER NOT NULL REFERENCES user_activity(record_id),
+	app TEXT NOT NULL,
+	app_time INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id),
+	PRIMARY KEY (record_id, app)
+);
+CREATE TABLE IF NOT EXISTS category_rules (
+	rule_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	pattern TEXT NOT NULL,
+	category TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id)
+);
+CREATE INDEX IF NOT EXISTS activity_apps_tenant ON activity_apps (tenant_id);
+CREATE INDEX IF NOT EXISTS category_rules_tenant ON category_rules (tenant_id);`
+
+	// Schedule belongs either to user or to department, the other id is 0.
+	migrationWorkTime = `
+CREATE TABLE IF NOT EXISTS work_schedules (
+	user_id INTEGER NOT NULL DEFAULT 0,
+	department_id INTEGER NOT NULL DEFAULT 0,
+	monday INTEGER NOT NULL,
+	tuesday INTEGER NOT NULL,
+	wednesday INTEGER NOT NULL,
+	thursday INTEGER NOT NULL,
+	friday INTEGER NOT NULL,
+	saturday INTEGER NOT NULL,
+	sunday INTEGER NOT NULL,
+	utc_offset INTEGER NOT NULL DEFAULT 0,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id),
+	PRIMARY KEY (tenant_id, user_id, department_id)
+);
+CREATE TABLE IF NOT EXISTS time_off (
+	time_off_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL DEFAULT 0,
+	date_from TEXT NOT NULL,
+	date_to TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id)
+);
+CREATE INDEX IF NOT EXISTS time_off_tenant ON time_off (tenant_id, user_id);`
+
+	// Rollups are maintained by triggers, so every change of activity records is counted, whatever code made it.
+	// Day of record is unix day in UTC, division is rounded down for dates before epoch too.
+	migrationActivityDaily = `
+CREATE TABLE IF NOT EXISTS activity_daily (
+	user_id INTEGER NOT NULL,
+	day INTEGER NOT NULL,
+	total_time INTEGER NOT NULL,
+	active_time INTEGER NOT NULL,
+	records INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id),
+	PRIMARY KEY (tenant_id, user_id, day)
+);
+CREATE INDEX IF NOT EXISTS user_activity_date ON user_activity (tenant_id, user_id, activity_date);
+CREATE TRIGGER IF NOT EXISTS activity_daily_insert AFTER INSERT ON user_activity
+BEGIN` + activityDailyAdd + `
+END;
+CREATE TRIGGER IF NOT EXISTS activity_daily_delete AFTER DELETE ON user_activity
+BEGIN` + activityDailySubtract + `
+END;
+CREATE TRIGGER IF NOT EXISTS activity_daily_update
+AFTER UPDATE OF user_id, total_time, active_time, activity_date, tenant_id ON user_activity
+BEGIN` + activityDailySubtract + activityDailyAdd + `
+END;` + activityDailyFill
+
+	activityDailyAdd = `
+	INSERT INTO activity_daily (user_id, day, total_time, active_time, records, tenant_id)
+	VALUES (
+		NEW.user_id
+		, NEW.activity_date / 86400 - (NEW.activity_date < 0 AND NEW.activity_date % 86400 != 0)
+		, NEW.total_time
+		, NEW.active_time
+		, 1
+		, NEW.tenant_id
+	)
+	ON CONFLICT (tenant_id, user_id, day) DO UPDATE
+	SET total_time = total_time + excluded.total_time
+		, active_time = active_time + excluded.active_time
+		, records = records + 1;`
+
+	activityDailySubtract = `
+	UPDATE activity_daily
+	SET total_time = total_time - OLD.total_time
+		, active_time = active_time - OLD.active_time
+		, records = records - 1
+	WHERE tenant_id = OLD.tenant_id AND user_id = OLD.user_id
+		AND day = OLD.activity_date / 86400 - (OLD.activity_date < 0 AND OLD.activity_date % 86400 != 0);
+	DELETE FROM activity_daily
+	WHERE tenant_id = OLD.tenant_id AND user_id = OLD.user_id AND records = 0;`
+
+	activityDailyFill = `
+INSERT INTO activity_daily (user_id, day, total_time, active_time, records, tenant_id)
+SELECT user_id
+    , activity_date / 86400 - (activity_date < 0 AND activity_date % 86400 != 0) AS day
+    , SUM(total_time)
+    , SUM(active_time)
+    , COUNT(*)
+    , tenant_id
+FROM user_activity
+GROUP BY tenant_id, user_id, day;`
+
+	// Archived records are deleted from user_activity, but their time stays in rollups: triggers skip
+	// archived records, and summaries of archived records are kept for rollups rebuild.
+	// Restored records are inserted already archived, so they aren't counted twice,
+	// and their time is subtracted by archivedActivitySubtract when they are deleted.
+	migrationRetention = `
+ALTER TABLE user_activity ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS activity_daily_archived (
+	user_id INTEGER NOT NULL,
+	day INTEGER NOT NULL,
+	total_time INTEGER NOT NULL,
+	active_time INTEGER NOT NULL,
+	records INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id),
+	PRIMARY KEY (tenant_id, user_id, day)
+);
+CREATE TABLE IF NOT EXISTS retention_policies (
+	policy_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	department_id INTEGER NOT NULL DEFAULT 0,
+	days INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS retention_policies_department ON retention_policies (tenant_id, department_id);
+DROP TRIGGER IF EXISTS activity_daily_insert;
+DROP TRIGGER IF EXISTS activity_daily_delete;
+DROP TRIGGER IF EXISTS activity_daily_update;
+CREATE TRIGGER activity_daily_insert AFTER INSERT ON user_activity
+WHEN NEW.archived = 0
+BEGIN` + activityDailyAdd + `
+END;
+CREATE TRIGGER activity_daily_delete AFTER DELETE ON user_activity
+WHEN OLD.archived = 0
+BEGIN` + activityDailySubtract + `
+END;
+CREATE TRIGGER activity_daily_update
+AFTER UPDATE OF user_id, total_time, active_time, activity_date, tenant_id ON user_activity
+WHEN OLD.archived = 0
+BEGIN` + activityDailySubtract + activityDailyAdd + `
+END;`
+
+	// Requests are kept after erasure of user, they have no foreign key to user_list.
+	migrationSubjectRequests = `
+CREATE TABLE IF NOT EXISTS subject_requests (
+	request_id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	requested_by TEXT NOT NULL,
+	records INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(tenant_id)
+);
+CREATE INDEX IF NOT EXISTS subject_requests_tenant ON subject_requests (tenant_id, user_id);`
+
+	// Existing admins keep full access.
+	migrationAdminRoles = `
+ALTER TABLE admins ADD COLUMN role TEXT NOT NULL DEFAULT 'admin';`
+
+	// Rollups of all tenants are rebuilt from summaries of archived records and from live records.
+	activityDailyRebuild = `
+DELETE FROM activity_daily;
+INSERT INTO activity_daily (user_id, day, total_time, active_time, records, tenant_id)
+SELECT user_id, day, SUM(total_time), SUM(active_time), SUM(records), tenant_id
+FROM (
+	SELECT user_id, day, total_time, active_time, records, tenant_id
+	FROM activity_daily_archived
+	UNION ALL
+	SELECT user_id
+	    , activity_date / 86400 - (activity_date < 0 AND activity_date % 86400 != 0)
+	    , total_time
+	    , active_time
+	    , 1
+	    , tenant_id
+	FROM user_activity
+	WHERE archived = 0
+)
+GROUP BY tenant_id, user_id, day;`
+
+	// Schema version is number of applied migrations.
+	schemaVersionGet = `PRAGMA user_version;`
+	schemaVersionSet = `PRAGMA user_version = %d;`
+
+	// Admin names are unique across tenants, so admin logs in without tenant.
 	adminFind = `
 SELECT admin_name
     , password_hash
+    , tenant_id
+    , role
 FROM admins 
 WHERE admin_name = ?;`
 
 	adminCreate = `
-INSERT INTO admins (admin_name, password_hash)
-VALUES(?, ?);`
+INSERT INTO admins (admin_name, password_hash, tenant_id, role)
+VALUES(?, ?, ?, ?);`
+
+	// Superadmin is created from config, so it's the only admin with superadmin role.
+	superadminUpdate = `
+UPDATE admins
+SET password_hash = ?
+    , tenant_id = ?
+WHERE admin_name = ?;`
+
+	superadminsDemote = `
+UPDATE admins
+SET role = 'admin'
+WHERE role = 'superadmin' AND admin_name != ?;`
+
+	// Tenants aren't scoped, they are managed by superadmin.
+	tenantCreate = `
+INSERT INTO tenants (tenant_name, created_at)
+VALUES (?, ?);`
+
+	tenantsGet = `
+SELECT tenant_id
+    , tenant_name
+    , suspended
+    , created_at
+FROM tenants`
+
+	tenantGet = tenantsGet + `
+WHERE tenant_id = ?;`
+
+	tenantSuspend = `
+UPDATE tenants
+SET suspended = ?
+WHERE tenant_id = ?;`
 
 	adminDelete = `
 DELETE FROM admins 
 WHERE admin_name = ?;`
 
 	departmentsGet = `
-SELECT department_id, department_name 
-FROM department_list`
+SELECT department_id, department_name, COALESCE(parent_id, 0) AS parent_id
+FROM department_list
+WHERE tenant_id = :tenant_id`
 
 	departmentGet = departmentsGet + `
-WHERE department_id = ?;`
+AND department_id = ?;`
+
+	// Names aren't unique, so the oldest department is matched.
+	departmentFind = departmentsGet + `
+AND department_name = ?
+ORDER BY department_id
+LIMIT 1;`
 
 	departmentCreate = `
-INSERT INTO department_list (department_name)
-VALUES (?);`
+INSERT INTO department_list (department_name, parent_id, tenant_id)
+VALUES (?, NULLIF(?, 0), :tenant_id);`
+
+	departmentMove = `
+UPDATE department_list
+SET parent_id = NULLIF(?2, 0)
+WHERE department_id = ?1 AND tenant_id = :tenant_id;`
+
+	// Children of deleted department are moved to its parent, so they stay in the tree.
+	departmentChildrenReparent = `
+UPDATE department_list
+SET parent_id = (SELECT parent_id FROM department_list WHERE department_id = ?1 AND tenant_id = :tenant_id)
+WHERE parent_id = ?1 AND tenant_id = :tenant_id;`
+
+	// Department ?1 and all its descendants. UNION drops repeated rows, so recursion stops even on cycle.
+	departmentSubtree = `
+WITH RECURSIVE subtree(department_id) AS (
+	SELECT department_id FROM department_list WHERE department_id = ?1 AND tenant_id = :tenant_id
+	UNION
+	SELECT dl.department_id
+	FROM department_list dl
+	INNER JOIN subtree st
+	ON dl.parent_id = st.department_id
+	WHERE dl.tenant_id = :tenant_id
+)`
+
+	departmentInSubtree = departmentSubtree + `
+SELECT COUNT(*) FROM subtree WHERE department_id = ?2;`
 
 	departmentDelete = `
 DELETE FROM department_list 
-WHERE department_id = ?;`
+WHERE department_id = ? AND tenant_id = :tenant_id;`
 
+	// User is inserted only if department is of the same tenant, so no rows are inserted otherwise.
 	userCreate = `
-INSERT INTO user_list (user_name, department_id)
-VALUES (?, ?);`
+INSERT INTO user_list (
+	user_name
+    , department_id
+    , email
+    , employee_number
+    , job_title
+    , status
+    , employment_start
+    , employment_end
+    , tenant_id
+)
+SELECT ?1, department_id, ?3, NULLIF(?4, ''), ?5, ?6, ?7, ?8, tenant_id
+FROM department_list
+WHERE department_id = ?2 AND tenant_id = :tenant_id;`
 
 	usersGet = `
 SELECT user_id
     , user_name 
     , department_id 
-FROM user_list`
+    , email
+    , COALESCE(employee_number, '') AS employee_number
+    , job_title
+    , status
+    , employment_start
+    , employment_end
+FROM user_list
+WHERE tenant_id = :tenant_id`
 
 	userGet = usersGet + `
-WHERE user_id = ?;`
+AND user_id = ?;`
 
 	userDepartmentGet = usersGet + `
-WHERE department_id = ?;`
+AND department_id = ?;`
 
 	userDelete = `
 DELETE FROM user_list 
-WHERE user_id = ?;`
+WHERE user_id = ? AND tenant_id = :tenant_id;`
+
+	// Department of user is changed by transfer only, see userTransfer.
+	userUpdate = `
+UPDATE user_list
+SET user_name = ?
+    , email = ?
+    , employee_number = NULLIF(?, '')
+    , job_title = ?
+    , status = ?
+    , employment_start = ?
+    , employment_end = ?
+WHERE user_id = ? AND tenant_id = :tenant_id;`
+
+	userTransfer = `
+UPDATE user_list
+SET department_id = ?
+WHERE user_id = ? AND tenant_id = :tenant_id;`
+
+	employeeNumberTaken = `
+SELECT COUNT(*)
+FROM user_list
+WHERE employee_number = ? AND user_id != ? AND tenant_id = :tenant_id;`
+
+	// Membership periods are [valid_from, valid_to), 0 valid_to - current membership.
+	membershipCreate = `
+INSERT INTO department_membership (user_id, department_id, valid_from, tenant_id)
+VALUES (?, ?, ?, :tenant_id);`
+
+	membershipsGet = `
+SELECT user_id
+    , department_id
+    , valid_from
+    , valid_to
+FROM department_membership
+WHERE user_id = ? AND tenant_id = :tenant_id`
+
+	membershipsOrdered = membershipsGet + `
+ORDER BY valid_from, membership_id;`
+
+	membershipCurrent = membershipsGet + `
+AND valid_to = 0;`
+
+	membershipClose = `
+UPDATE department_membership
+SET valid_to = ?
+WHERE user_id = ? AND valid_to = 0 AND tenant_id = :tenant_id;`
+
+	membershipsDelete = `
+DELETE FROM department_membership
+WHERE user_id = ? AND tenant_id = :tenant_id;`
+
+	// Schedule is written only if its user or department is of the same tenant, existing schedule is replaced.
+	workScheduleSet = `
+INSERT INTO work_schedules (
+	user_id
+    , department_id
+    , monday
+    , tuesday
+    , wednesday
+    , thursday
+    , friday
+    , saturday
+    , sunday
+    , utc_offset
+    , tenant_id
+)
+SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, :tenant_id
+WHERE (?1 <> 0 AND EXISTS (SELECT 1 FROM user_list WHERE user_id = ?1 AND tenant_id = :tenant_id))
+    OR (?2 <> 0 AND EXISTS (SELECT 1 FROM department_list WHERE department_id = ?2 AND tenant_id = :tenant_id))
+ON CONFLICT (tenant_id, user_id, department_id) DO UPDATE
+SET monday = excluded.monday
+    , tuesday = excluded.tuesday
+    , wednesday = excluded.wednesday
+    , thursday = excluded.thursday
+    , friday = excluded.friday
+    , saturday = excluded.saturday
+    , sunday = excluded.sunday
+    , utc_offset = excluded.utc_offset;`
+
+	workSchedulesGet = `
+SELECT user_id
+    , department_id
+    , monday
+    , tuesday
+    , wednesday
+    , thursday
+    , friday
+    , saturday
+    , sunday
+    , utc_offset
+FROM work_schedules
+WHERE tenant_id = :tenant_id`
+
+	workScheduleGet = workSchedulesGet + `
+AND user_id = ? AND department_id = ?;`
+
+	workScheduleDelete = `
+DELETE FROM work_schedules
+WHERE user_id = ? AND department_id = ? AND tenant_id = :tenant_id;`
+
+	// Leave is created only for user of the same tenant, holiday (0 user) - always.
+	timeOffCreate = `
+INSERT INTO time_off (user_id, date_from, date_to, description, tenant_id)
+SELECT ?1, ?2, ?3, ?4, :tenant_id
+WHERE ?1 = 0 OR EXISTS (SELECT 1 FROM user_list WHERE user_id = ?1 AND tenant_id = :tenant_id);`
+
+	timeOffsGet = `
+SELECT time_off_id
+    , user_id
+    , date_from
+    , date_to
+    , description
+FROM time_off
+WHERE tenant_id = :tenant_id`
 
+	// Empty user id matches time off of all users, otherwise leaves of the user and holidays are matched.
+	timeOffsFind = timeOffsGet + `
+AND (?1 = '' OR user_id = 0 OR user_id = ?1)
+ORDER BY date_from, time_off_id;`
+
+	timeOffGet = timeOffsGet + `
+AND time_off_id = ?;`
+
+	timeOffDelete = `
+DELETE FROM time_off
+WHERE time_off_id = ? AND tenant_id = :tenant_id;`
+
+	userTimeOffsDelete = `
+DELETE FROM time_off
+WHERE user_id = ? AND user_id <> 0 AND tenant_id = :tenant_id;`
+
+	userWorkScheduleDelete = `
+DELETE FROM work_schedules
+WHERE user_id = ? AND department_id = 0 AND tenant_id = :tenant_id;`
+
+	departmentWorkScheduleDelete = `
+DELETE FROM work_schedules
+WHERE department_id = ? AND user_id = 0 AND tenant_id = :tenant_id;`
+
+	// Day is number of day since epoch in time zone with offset ?2, records are in [?3, ?4).
+	dailyActivityGet = `
+SELECT (ua.activity_date + ?2) / 86400 AS day
+    , SUM(ua.total_time) AS total_time
+    , SUM(ua.active_time) AS active_time
+FROM user_activity ua
+WHERE ua.user_id = ?1 AND ua.tenant_id = :tenant_id AND ua.activity_date >= ?3 AND ua.activity_date < ?4
+GROUP BY day
+ORDER BY day;`
+
+	// Activity is inserted only if user is of the same tenant, so no rows are inserted otherwise.
 	activityCreate = `
 INSERT INTO user_activity (
 	user_id
     , active_time
     , total_time
     , activity_date
+    , tenant_id
 )
-VALUES (?, ?, ?, ?);`
+SELECT user_id, ?2, ?3, ?4, tenant_id
+FROM user_list
+WHERE user_id = ?1 AND tenant_id = :tenant_id;`
 
 	activitiesGet = `
 SELECT record_id 
@@ -95,36 +615,547 @@ SELECT record_id
     , active_time
     , total_time
     , activity_date
-FROM user_activity`
+FROM user_activity
+WHERE tenant_id = :tenant_id`
 
 	activityGet = activitiesGet + `
-WHERE record_id = ?;`
+AND record_id = ?;`
 
 	activityDelete = `
 DELETE FROM user_activity 
-WHERE record_id = ?;`
+WHERE record_id = ? AND tenant_id = :tenant_id;`
 
-	getUsersActivity = `
-SELECT ua.user_id AS user_id 
-	, SUM(ua.total_time) AS total_time 
-    , SUM(ua.active_time) AS active_time
-FROM user_activity ua
-WHERE ua.user_id = ?`
+	// Triggers skip archived records, so time of deleted archived (restored) record is subtracted
+	// from archived daily summaries and daily rollups by these queries, before record is deleted.
+	archivedActivitySubtract = `
+UPDATE %[1]s
+SET total_time = total_time - (
+		SELECT total_time FROM user_activity WHERE record_id = ?1 AND tenant_id = :tenant_id
+	)
+	, active_time = active_time - (
+		SELECT active_time FROM user_activity WHERE record_id = ?1 AND tenant_id = :tenant_id
+	)
+	, records = records - 1
+WHERE EXISTS (
+	SELECT 1
+	FROM user_activity
+	WHERE record_id = ?1 AND archived = 1 AND tenant_id = :tenant_id
+		AND user_activity.tenant_id = %[1]s.tenant_id
+		AND user_activity.user_id = %[1]s.user_id
+		AND activity_date / 86400 - (activity_date < 0 AND activity_date %% 86400 != 0) = %[1]s.day
+);`
 
-	getDepartmentsActivity = `
-SELECT dl.department_id AS user_id 
-    , SUM(ua.total_time) AS total_time 
-    , SUM(ua.active_time) AS active_time
+	archivedActivityEmptyDelete = `
+DELETE FROM %s
+WHERE records = 0 AND tenant_id = :tenant_id;`
+
+	activityAppCreate = `
+INSERT INTO activity_apps (record_id, app, app_time, tenant_id)
+VALUES (?, ?, ?, :tenant_id);`
+
+	activitiesAppsGet = `
+SELECT record_id
+    , app
+    , app_time
+FROM activity_apps
+WHERE tenant_id = :tenant_id
+ORDER BY record_id, app;`
+
+	activityAppsGet = `
+SELECT app
+    , app_time
+FROM activity_apps
+WHERE record_id = ? AND tenant_id = :tenant_id
+ORDER BY app;`
+
+	activityAppsDelete = `
+DELETE FROM activity_apps
+WHERE record_id = ? AND tenant_id = :tenant_id;`
+
+	// Records of user before ?2 are archived, only records read for archive (up to ?3) are touched.
+	archivableActivityGet = activitiesGet + `
+AND user_id = ?1 AND activity_date < ?2
+ORDER BY record_id;`
+
+	archivableAppsGet = `
+SELECT aa.record_id
+    , aa.app
+    , aa.app_time
+FROM activity_apps aa
+JOIN user_activity ua ON ua.record_id = aa.record_id
+WHERE ua.user_id = ?1 AND ua.activity_date < ?2 AND ua.tenant_id = :tenant_id
+ORDER BY aa.record_id, aa.app;`
+
+	activityArchiveSummarize = `
+INSERT INTO activity_daily_archived (user_id, day, total_time, active_time, records, tenant_id)
+SELECT user_id
+    , activity_date / 86400 - (activity_date < 0 AND activity_date % 86400 != 0) AS day
+    , SUM(total_time)
+    , SUM(active_time)
+    , COUNT(*)
+    , tenant_id
+FROM user_activity
+WHERE user_id = ?1 AND activity_date < ?2 AND record_id <= ?3 AND archived = 0 AND tenant_id = :tenant_id
+GROUP BY tenant_id, user_id, day
+ON CONFLICT (tenant_id, user_id, day) DO UPDATE
+SET total_time = total_time + excluded.total_time
+    , active_time = active_time + excluded.active_time
+    , records = records + excluded.records;`
+
+	activityArchiveMark = `
+UPDATE user_activity
+SET archived = 1
+WHERE user_id = ?1 AND activity_date < ?2 AND record_id <= ?3 AND tenant_id = :tenant_id;`
+
+	archivedAppsDelete = `
+DELETE FROM activity_apps
+WHERE tenant_id = :tenant_id AND record_id IN (
+	SELECT record_id
+	FROM user_activity
+	WHERE user_id = ?1 AND activity_date < ?2 AND record_id <= ?3 AND archived = 1 AND tenant_id = :tenant_id
+);`
+
+	archivedActivityDelete = `
+DELETE FROM user_activity
+WHERE user_id = ?1 AND activity_date < ?2 AND record_id <= ?3 AND archived = 1 AND tenant_id = :tenant_id;`
+
+	// Record is restored with its id only if it doesn't exist and its user is of the same tenant.
+	activityRestore = `
+INSERT OR IGNORE INTO user_activity (
+	record_id
+    , user_id
+    , active_time
+    , total_time
+    , activity_date
+    , archived
+    , tenant_id
+)
+SELECT ?1, user_id, ?3, ?4, ?5, 1, tenant_id
+FROM user_list
+WHERE user_id = ?2 AND tenant_id = :tenant_id;`
+
+	// Policy of department is created only if department is of the same tenant, 0 - policy of tenant.
+	retentionPolicyCreate = `
+INSERT INTO retention_policies (department_id, days, created_at, tenant_id)
+SELECT ?1, ?2, ?3, :tenant_id
+WHERE ?1 = 0 OR EXISTS (SELECT 1 FROM department_list WHERE department_id = ?1 AND tenant_id = :tenant_id);`
+
+	retentionPolicyExists = `
+SELECT COUNT(*)
+FROM retention_policies
+WHERE department_id = ? AND tenant_id = :tenant_id;`
+
+	retentionPoliciesGet = `
+SELECT policy_id
+    , department_id
+    , days
+    , created_at
+FROM retention_policies
+WHERE tenant_id = :tenant_id`
+
+	retentionPolicyGet = retentionPoliciesGet + `
+AND policy_id = ?;`
+
+	retentionPolicyDelete = `
+DELETE FROM retention_policies
+WHERE policy_id = ? AND tenant_id = :tenant_id;`
+
+	departmentRetentionPolicyDelete = `
+DELETE FROM retention_policies
+WHERE department_id = ? AND department_id <> 0 AND tenant_id = :tenant_id;`
+
+	userActivitiesGet = activitiesGet + `
+AND user_id = ?
+ORDER BY record_id;`
+
+	userAppsGet = `
+SELECT aa.record_id
+    , aa.app
+    , aa.app_time
+FROM activity_apps aa
+JOIN user_activity ua ON ua.record_id = aa.record_id
+WHERE ua.user_id = ? AND ua.tenant_id = :tenant_id
+ORDER BY aa.record_id, aa.app;`
+
+	activityDaysGet = `
+SELECT day
+    , total_time
+    , active_time
+FROM activity_daily
+WHERE user_id = ? AND tenant_id = :tenant_id
+ORDER BY day;`
+
+	subjectRequestCreate = `
+INSERT INTO subject_requests (user_id, kind, requested_by, records, created_at, tenant_id)
+VALUES (?, ?, ?, ?, ?, :tenant_id);`
+
+	// Empty user id matches requests of all users.
+	subjectRequestsGet = `
+SELECT request_id
+    , user_id
+    , kind
+    , requested_by
+    , records
+    , created_at
+FROM subject_requests
+WHERE tenant_id = :tenant_id AND (?1 = '' OR user_id = ?1)
+ORDER BY request_id;`
+
+	// Pseudonym is user without profile in department of erased user, so its activity still counts toward
+	// department. Mapping between erased user and pseudonym isn't stored anywhere.
+	userPseudonymCreate = `
+INSERT INTO user_list (user_name, department_id, status, tenant_id)
+SELECT ?2, department_id, status, tenant_id
+FROM user_list
+WHERE user_id = ?1 AND tenant_id = :tenant_id;`
+
+	membershipsReassign = `
+UPDATE department_membership
+SET user_id = ?2
+WHERE user_id = ?1 AND tenant_id = :tenant_id;`
+
+	// Rollups of live records are moved by activity_daily_update trigger.
+	activityReassign = `
+UPDATE user_activity
+SET user_id = ?2
+WHERE user_id = ?1 AND tenant_id = :tenant_id;`
+
+	// Rollups left after reassign of records are time of archived records.
+	activityDailyReassign = `
+INSERT INTO activity_daily (user_id, day, total_time, active_time, records, tenant_id)
+SELECT ?2, day, total_time, active_time, records, tenant_id
+FROM activity_daily
+WHERE user_id = ?1 AND tenant_id = :tenant_id
+ON CONFLICT (tenant_id, user_id, day) DO UPDATE
+SET total_time = total_time + excluded.total_time
+	, active_time = active_time + excluded.active_time
+	, records = records + excluded.records;`
+
+	userActivityDailyDelete = `
+DELETE FROM activity_daily
+WHERE user_id = ? AND tenant_id = :tenant_id;`
+
+	activityDailyArchivedReassign = `
+UPDATE activity_daily_archived
+SET user_id = ?2
+WHERE user_id = ?1 AND tenant_id = :tenant_id;`
+
+	userAlertRulesDelete = `
+DELETE FROM alert_rules
+WHERE kind = 'user_ratio' AND target_id = ? AND tenant_id = :tenant_id;`
+
+	categoryRuleCreate = `
+INSERT INTO category_rules (pattern, category, created_at, tenant_id)
+VALUES (?, ?, ?, :tenant_id);`
+
+	categoryRulesGet = `
+SELECT rule_id
+    , pattern
+    , category
+    , created_at
+FROM category_rules
+WHERE tenant_id = :tenant_id`
+
+	categoryRuleGet = categoryRulesGet + `
+AND rule_id = ?;`
+
+	categoryRuleDelete = `
+DELETE FROM category_rules
+WHERE rule_id = ? AND tenant_id = :tenant_id;`
+
+	webhookCreate = `
+INSERT INTO webhooks (url, secret, events, created_at, tenant_id)
+VALUES (?, ?, ?, ?, :tenant_id);`
+
+	webhooksGet = `
+SELECT webhook_id
+    , url
+    , secret
+    , events
+    , created_at
+FROM webhooks
+WHERE tenant_id = :tenant_id`
+
+	webhookGet = webhooksGet + `
+AND webhook_id = ?;`
+
+	webhookDelete = `
+DELETE FROM webhooks
+WHERE webhook_id = ? AND tenant_id = :tenant_id;`
+
+	webhookDeliveriesDelete = `
+DELETE FROM webhook_deliveries
+WHERE webhook_id IN (SELECT webhook_id FROM webhooks WHERE webhook_id = ? AND tenant_id = :tenant_id);`
+
+	// Delivery is queued for every webhook of tenant subscribed to event, events are matched as comma separated list.
+	deliveriesEnqueue = `
+INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt, created_at)
+SELECT webhook_id, ?1, ?2, 'pending', ?3, ?3
+FROM webhooks
+WHERE tenant_id = :tenant_id AND (events = '' OR ',' || events || ',' LIKE '%,' || ?1 || ',%');`
+
+	deliveriesGet = `
+SELECT wd.delivery_id
+    , wd.webhook_id
+    , wd.event
+    , wd.payload
+    , wd.status
+    , wd.attempts
+    , wd.next_attempt
+    , wd.last_error
+    , wd.last_status
+    , wd.created_at
+    , w.url
+    , w.secret
+FROM webhook_deliveries wd
+INNER JOIN webhooks w
+ON wd.webhook_id = w.webhook_id`
+
+	// Due deliveries of all tenants are sent by dispatcher, so they aren't scoped.
+	deliveriesDue = deliveriesGet + `
+WHERE wd.status = 'pending' AND wd.next_attempt <= ?
+ORDER BY wd.next_attempt, wd.delivery_id
+LIMIT ?;`
+
+	// Empty webhook id or status matches any.
+	deliveriesFind = deliveriesGet + `
+WHERE w.tenant_id = :tenant_id AND (?1 = '' OR wd.webhook_id = ?1) AND (?2 = '' OR wd.status = ?2)
+ORDER BY wd.delivery_id;`
+
+	deliveryUpdate = `
+UPDATE webhook_deliveries
+SET status = ?
+    , attempts = ?
+    , next_attempt = ?
+    , last_error = ?
+    , last_status = ?
+WHERE delivery_id = ?;`
+
+	// Delivered and dead deliveries are queued again with reset attempts.
+	deliveryRedeliver = `
+UPDATE webhook_deliveries
+SET status = 'pending'
+    , attempts = 0
+    , next_attempt = ?
+WHERE delivery_id = ? AND status <> 'pending'
+    AND webhook_id IN (SELECT webhook_id FROM webhooks WHERE tenant_id = :tenant_id);`
+
+	alertRuleCreate = `
+INSERT INTO alert_rules (name, kind, target_id, threshold, period, notifiers, state, changed_at, created_at, tenant_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, :tenant_id);`
+
+	alertRulesGet = `
+SELECT rule_id
+    , name
+    , kind
+    , target_id
+    , threshold
+    , period
+    , notifiers
+    , state
+    , value
+    , evaluated_at
+    , changed_at
+    , created_at
+FROM alert_rules
+WHERE tenant_id = :tenant_id`
+
+	alertRuleGet = alertRulesGet + `
+AND rule_id = ?;`
+
+	alertRuleDelete = `
+DELETE FROM alert_rules
+WHERE rule_id = ? AND tenant_id = :tenant_id;`
+
+	alertRuleUpdateState = `
+UPDATE alert_rules
+SET state = ?
+    , value = ?
+    , evaluated_at = ?
+    , changed_at = ?
+WHERE rule_id = ? AND tenant_id = :tenant_id;`
+
+	// Requested id is selected as parameter, so it's returned even if there are no records.
+	// Raw queries sum records, they are reference of rollup queries below, which must give the same results.
+	getUsersActivityRaw = `
+SELECT CAST(?1 AS INTEGER) AS user_id 
+	, COALESCE(SUM(ua.total_time), 0) AS total_time 
+    , COALESCE(SUM(ua.active_time), 0) AS active_time
 FROM user_activity ua
-INNER JOIN user_list ul 
-ON ua.user_id = ul.user_id 
+WHERE ua.user_id = ?1 AND ua.tenant_id = :tenant_id`
+
+	// Activity counts toward department user was member of at the time of record.
+	inMembership = `
+AND ua.activity_date >= dm.valid_from AND (dm.valid_to = 0 OR ua.activity_date < dm.valid_to)`
+
+	membershipJoin = `
+INNER JOIN department_membership dm
+ON dm.user_id = ua.user_id` + inMembership + `
 INNER JOIN department_list dl 
-ON ul.department_id = dl.department_id 
-WHERE dl.department_id = ?`
+ON dm.department_id = dl.department_id `
+
+	departmentsActivity = `
+SELECT CAST(?1 AS INTEGER) AS department_id 
+    , COALESCE(SUM(ua.total_time), 0) AS total_time 
+    , COALESCE(SUM(ua.active_time), 0) AS active_time
+    , COUNT(DISTINCT ua.user_id) AS members
+FROM user_activity ua` + membershipJoin
+
+	departmentActivityFilter = `
+WHERE dl.department_id = ?1 AND dl.tenant_id = :tenant_id AND ua.tenant_id = :tenant_id`
+
+	// Roll-up over department and all its descendants.
+	departmentTreeActivityFilter = `
+WHERE dl.department_id IN (SELECT department_id FROM subtree) AND ua.tenant_id = :tenant_id`
+
+	getDepartmentsActivityRaw = departmentsActivity + departmentActivityFilter
+
+	getDepartmentsTreeActivityRaw = departmentSubtree + departmentsActivity + departmentTreeActivityFilter
+
+	// Rollup queries sum daily rollups of whole days of range and records of range edges.
+	// Parameters: ?1 - id, ?2, ?3 - first and last whole day, NULL if range isn't limited,
+	// ?4, ?5 - head edge [start, end), ?6, ?7 - tail edge [start, end), NULL if there is no edge.
+	rollupDays = `
+AND (?2 IS NULL OR ad.day >= ?2) AND (?3 IS NULL OR ad.day <= ?3)`
+
+	rollupEdges = `
+AND (ua.activity_date >= ?4 AND ua.activity_date < ?5 OR ua.activity_date >= ?6 AND ua.activity_date < ?7)`
+
+	rollupActivity = `
+    , COALESCE(SUM(total_time), 0) AS total_time
+    , COALESCE(SUM(active_time), 0) AS active_time
+FROM (
+SELECT ad.user_id AS user_id
+    , ad.total_time AS total_time
+    , ad.active_time AS active_time
+FROM activity_daily ad`
+
+	rollupRecords = `
+UNION ALL
+SELECT ua.user_id
+    , ua.total_time
+    , ua.active_time
+FROM user_activity ua`
 
+	getUsersActivity = `
+SELECT CAST(?1 AS INTEGER) AS user_id` + rollupActivity + `
+WHERE ad.user_id = ?1 AND ad.tenant_id = :tenant_id` + rollupDays + rollupRecords + `
+WHERE ua.user_id = ?1 AND ua.tenant_id = :tenant_id` + rollupEdges + `
+);`
+
+	// Rollup counts toward department only if user was its member for the whole day. Records of days
+	// when membership started or ended are summed from records.
+	rollupMembershipJoin = `
+INNER JOIN department_membership dm
+ON dm.user_id = ad.user_id AND ad.day * 86400 >= dm.valid_from AND (dm.valid_to = 0 OR (ad.day + 1) * 86400 <= dm.valid_to)
+INNER JOIN department_list dl
+ON dm.department_id = dl.department_id`
+
+	rollupMembershipEdges = `
+AND (
+	ua.activity_date >= ?4 AND ua.activity_date < ?5 OR ua.activity_date >= ?6 AND ua.activity_date < ?7
+	OR (?2 IS NULL OR ua.activity_date >= ?2 * 86400) AND (?3 IS NULL OR ua.activity_date < (?3 + 1) * 86400)
+	AND (
+		ua.activity_date < (dm.valid_from + 86399) / 86400 * 86400
+		OR dm.valid_to != 0 AND ua.activity_date >= dm.valid_to / 86400 * 86400
+	)
+)`
+
+	// Members are users with activity in range, they are group of department for anonymized reports.
+	departmentsRollupActivity = `
+SELECT CAST(?1 AS INTEGER) AS department_id
+    , COUNT(DISTINCT user_id) AS members` + rollupActivity + rollupMembershipJoin
+
+	getDepartmentsActivity = departmentsRollupActivity + `
+WHERE dl.department_id = ?1 AND dl.tenant_id = :tenant_id AND ad.tenant_id = :tenant_id` + rollupDays +
+		rollupRecords + membershipJoin + departmentActivityFilter + rollupMembershipEdges + `
+);`
+
+	getDepartmentsTreeActivity = departmentSubtree + departmentsRollupActivity + `
+WHERE dl.department_id IN (SELECT department_id FROM subtree) AND ad.tenant_id = :tenant_id` + rollupDays +
+		rollupRecords + membershipJoin + departmentTreeActivityFilter + rollupMembershipEdges + `
+);`
+
+	// Members are counted from the same rollups and records as activity of department tree.
+	getDepartmentsTreeMembers = departmentSubtree + `
+SELECT DISTINCT department_id, user_id
+FROM (
+SELECT dl.department_id AS department_id
+    , ad.user_id AS user_id
+FROM activity_daily ad` + rollupMembershipJoin + `
+WHERE dl.department_id IN (SELECT department_id FROM subtree) AND ad.tenant_id = :tenant_id` + rollupDays + `
+UNION ALL
+SELECT dl.department_id
+    , ua.user_id
+FROM user_activity ua` + membershipJoin + departmentTreeActivityFilter + rollupMembershipEdges + `
+)
+ORDER BY department_id, user_id;`
+
+	// Category of app is category of the longest matching rule pattern, apps without matching rule are neutral.
+	appCategory = `
+COALESCE((
+	SELECT cr.category
+	FROM category_rules cr
+	WHERE cr.tenant_id = :tenant_id AND lower(aa.app) GLOB lower(cr.pattern)
+	ORDER BY length(cr.pattern) DESC, cr.rule_id
+	LIMIT 1
+), 'neutral')`
+
+	// Apps of records are categorized in subquery, which is closed by categoriesTimeEnd after time check.
+	categoriesTime = `
+SELECT COALESCE(SUM(CASE WHEN category = 'productive' THEN app_time END), 0) AS productive
+    , COALESCE(SUM(CASE WHEN category = 'neutral' THEN app_time END), 0) AS neutral
+    , COALESCE(SUM(CASE WHEN category = 'unproductive' THEN app_time END), 0) AS unproductive
+FROM (
+SELECT aa.app_time AS app_time
+    , ` + appCategory + ` AS category
+FROM activity_apps aa
+INNER JOIN user_activity ua
+ON ua.record_id = aa.record_id`
+
+	categoriesTimeEnd = `
+);`
+
+	getUsersCategories = categoriesTime + `
+WHERE ua.user_id = ?1 AND ua.tenant_id = :tenant_id`
+
+	getDepartmentsCategories = categoriesTime + membershipJoin + departmentActivityFilter
+
+	getDepartmentsTreeCategories = departmentSubtree + categoriesTime + membershipJoin + departmentTreeActivityFilter
+
+	// Time checks of records, %d is number of parameter.
 	activityTimeStart = `
-AND ua.activity_date > '%s'`
+AND ua.activity_date > ?%d`
 
 	activityTimeEnd = `
-AND ua.activity_date < '%s'`
+AND ua.activity_date < ?%d`
+
+	// Time conditions are added to join, so users and departments without records are reported too.
+	usersReport = `
+SELECT ul.user_id AS user_id
+    , ul.user_name AS user_name
+    , ul.department_id AS department_id
+    , COALESCE(SUM(ua.total_time), 0) AS total_time
+    , COALESCE(SUM(ua.active_time), 0) AS active_time
+FROM user_list ul
+LEFT JOIN user_activity ua
+ON ua.user_id = ul.user_id%s
+WHERE ul.tenant_id = :tenant_id
+GROUP BY ul.user_id, ul.user_name, ul.department_id
+ORDER BY ul.user_id;`
+
+	departmentsReport = `
+SELECT dl.department_id AS department_id
+    , dl.department_name AS department_name
+    , COALESCE(SUM(ua.total_time), 0) AS total_time
+    , COALESCE(SUM(ua.active_time), 0) AS active_time
+    , COUNT(DISTINCT ua.user_id) AS members
+FROM department_list dl
+LEFT JOIN department_membership dm
+ON dm.department_id = dl.department_id
+LEFT JOIN user_activity ua
+ON ua.user_id = dm.user_id` + inMembership + `%s
+WHERE dl.tenant_id = :tenant_id
+GROUP BY dl.department_id, dl.department_name
+ORDER BY dl.department_id;`
 )
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"activity_api/data_manager/db/core"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tenantParam - placeholder of tenant of context in queries.
+// Every query of tenant data has to be scoped by it, e.g. `WHERE tenant_id = :tenant_id`.
+const tenantParam = ":tenant_id"
+
+// tenantCore - ISQLCore which sets tenant of context to tenantParam of queries,
+// so query of tenant data fails if context has no tenant. Queries without the parameter are run as is.
+type tenantCore struct {
+	core.ISQLCore
+}
+
+// Exec - runs query scoped by tenant of ctx.
+func (c *tenantCore) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ISQLCore.Exec(ctx, query, args...)
+}
+
+// Get - writes result of query scoped by tenant of ctx to dest.
+func (c *tenantCore) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	return c.ISQLCore.Get(ctx, dest, query, args...)
+}
+
+// Pick - writes single row of query scoped by tenant of ctx to dest.
+func (c *tenantCore) Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	return c.ISQLCore.Pick(ctx, dest, query, args...)
+}
+
+// Stream - calls each for every row of query scoped by tenant of ctx.
+func (c *tenantCore) Stream(
+	ctx context.Context,
+	query string,
+	each func(scan core.ScanFunc) error,
+	args ...interface{},
+) error {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	return c.ISQLCore.Stream(ctx, query, each, args...)
+}
+
+// Tx - runs f in transaction, its queries are scoped by tenant of their ctx too.
+func (c *tenantCore) Tx(ctx context.Context, f func(tx core.ISQLTx) error) error {
+	return c.ISQLCore.Tx(ctx, func(tx core.ISQLTx) error {
+		return f(&tenantTx{ISQLTx: tx})
+	})
+}
+
+// tenantTx - ISQLTx which sets tenant of context to tenantParam of queries.
+type tenantTx struct {
+	core.ISQLTx
+}
+
+// Exec - runs query scoped by tenant of ctx in transaction.
+func (t *tenantTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.ISQLTx.Exec(ctx, query, args...)
+}
+
+// Pick - writes single row of query scoped by tenant of ctx to dest in transaction.
+func (t *tenantTx) Pick(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	query, err := scope(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	return t.ISQLTx.Pick(ctx, dest, query, args...)
+}
+
+// scope - sets tenant of ctx to query scoped by tenant. Tenant is written to query instead of binding it,
+// because named parameter would shift numbers of positional ones. It's a number, so formatting is safe.
+func scope(ctx context.Context, query string) (string, error) {
+	if !strings.Contains(query, tenantParam) {
+		return query, nil
+	}
+
+	tenantID, err := core.TenantID(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("SQLite scope(): %w", err)
+	}
+
+	return strings.ReplaceAll(query, tenantParam, strconv.FormatInt(tenantID, 10)), nil
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateTenant - writes given tenant to SQLite db.
+func (s *SQLite) CreateTenant(ctx context.Context, tenant *models.Tenant) (int64, error) {
+	entry := s.logger.WithField("func", "CreateTenant")
+
+	entry.Debugf("Creating tenant: %s", tenant.TenantName)
+	result, err := s.Exec(ctx, tenantCreate, tenant.TenantName, tenant.CreatedAt)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), tenantCreate: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), tenantCreate: %w", err)
+	}
+
+	entry.Debugf("Created tenant id: %d", id)
+	return id, nil
+}
+
+// GetTenants - returns all tenants from SQLite db.
+func (s *SQLite) GetTenants(ctx context.Context) ([]*models.Tenant, error) {
+	entry := s.logger.WithField("func", "GetTenants")
+
+	entry.Debug("Getting tenants")
+	tenants := make([]*models.Tenant, 0)
+
+	if err := s.Get(ctx, &tenants, tenantsGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), tenantsGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved tenants num: %d", len(tenants))
+	return tenants, nil
+}
+
+// GetTenant - returns tenant with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	entry := s.logger.WithField("func", "GetTenant")
+
+	entry.Debugf("Getting tenant with id: %s", tenantID)
+	tenant := new(models.Tenant)
+
+	if err := s.Pick(ctx, tenant, tenantGet, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), tenantGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved tenant with id %s: %+v", tenantID, *tenant)
+	return tenant, nil
+}
+
+// SuspendTenant - suspends or resumes tenant with given ID, returns number of updated tenants.
+func (s *SQLite) SuspendTenant(ctx context.Context, tenantID string, suspended bool) (int64, error) {
+	entry := s.logger.WithField("func", "SuspendTenant")
+
+	entry.Debugf("Setting suspended of tenant %s to %t", tenantID, suspended)
+	result, err := s.Exec(ctx, tenantSuspend, suspended, tenantID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), tenantSuspend: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), tenantSuspend: %w", err)
+	}
+
+	entry.Debugf("Tenant with id %s updated, rows affected: %d", tenantID, id)
+	return id, nil
+}
@@ -2,26 +2,35 @@ package sqlite
 
 import (
 	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 )
 
-// CreateActivity - writes given user record to SQLite db.
-func (s *SQLite) CreateUser(user *models.User) (int64, error) {
+// CreateUser - writes given user record to SQLite db, department must be of the same tenant.
+// User becomes member of the department since the beginning of time, so all its activity counts toward it.
+func (s *SQLite) CreateUser(ctx context.Context, user *models.User) (int64, error) {
 	entry := s.logger.WithField("func", "CreateUser")
 
 	entry.Debugf("Creating user: %+v", user)
-	result, err := s.Exec(userCreate, user.UserName, user.DepartmentID)
+	var id int64
 
-	if err != nil {
-		return -1, fmt.Errorf("SQLite Exec(), userCreate: %w", err)
-	}
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		created, err := createUser(ctx, tx, user)
 
-	id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = created
+
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(), activityCreate: %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), CreateUser: %w", err)
 	}
 
 	entry.Debugf("Created user id: %d", id)
@@ -29,18 +38,18 @@ func (s *SQLite) CreateUser(user *models.User) (int64, error) {
 }
 
 // GetUsers - returns all users records from SQLite db.
-func (s *SQLite) GetUsers(depID string) ([]*models.User, error) {
+func (s *SQLite) GetUsers(ctx context.Context, depID string) ([]*models.User, error) {
 	entry := s.logger.WithField("func", "GetUsers")
 
 	entry.Debugf("Getting users, department id: %s", depID)
 	users := make([]*models.User, 0)
 
 	if depID != "" {
-		if err := s.Get(&users, userDepartmentGet, depID); err != nil {
+		if err := s.Get(ctx, &users, userDepartmentGet, depID); err != nil {
 			return nil, fmt.Errorf("s.Get() userDepartmentGet : %w", err)
 		}
 	} else {
-		if err := s.Get(&users, usersGet); err != nil {
+		if err := s.Get(ctx, &users, usersGet); err != nil {
 			return nil, fmt.Errorf("s.Get() userGet : %w", err)
 		}
 	}
@@ -50,13 +59,13 @@ func (s *SQLite) GetUsers(depID string) ([]*models.User, error) {
 }
 
 // GetUser - returns user record with given ID from SQLite db.
-func (s *SQLite) GetUser(userID string) (*models.User, error) {
+func (s *SQLite) GetUser(ctx context.Context, userID string) (*models.User, error) {
 	entry := s.logger.WithField("func", "GetUser")
 
 	entry.Debugf("Getting user with id: %s", userID)
 	user := new(models.User)
 
-	if err := s.Pick(user, userGet, userID); err != nil {
+	if err := s.Pick(ctx, user, userGet, userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -68,23 +77,231 @@ func (s *SQLite) GetUser(userID string) (*models.User, error) {
 	return user, nil
 }
 
-// DeleteUser - deletes user record with given ID from SQLite db.
-func (s *SQLite) DeleteUser(userID string) (int64, error) {
+// UpdateUser - updates profile of user with ID of given user in SQLite db, department isn't changed.
+func (s *SQLite) UpdateUser(ctx context.Context, user *models.User) (int64, error) {
+	entry := s.logger.WithField("func", "UpdateUser")
+
+	entry.Debugf("Updating user: %+v", user)
+	var updated int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if err := checkEmployeeNumber(ctx, tx, user.EmployeeNumber, user.UserID); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(
+			ctx,
+			userUpdate,
+			user.UserName,
+			user.Email,
+			user.EmployeeNumber,
+			user.JobTitle,
+			user.Status,
+			user.EmploymentStart,
+			user.EmploymentEnd,
+			user.UserID,
+		)
+
+		if err != nil {
+			return fmt.Errorf("userUpdate: %w", err)
+		}
+
+		if updated, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), UpdateUser: %w", err)
+	}
+
+	entry.Debugf("User with id %d updated, rows affected: %d", user.UserID, updated)
+	return updated, nil
+}
+
+// DeleteUser - deletes user record with given ID, its membership history, schedule and leaves from SQLite db.
+func (s *SQLite) DeleteUser(ctx context.Context, userID string) (int64, error) {
 	entry := s.logger.WithField("func", "DeleteUser")
 
 	entry.Debugf("Deleting user with id: %s", userID)
-	result, err := s.Exec(userDelete, userID)
+	var id int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if _, err := tx.Exec(ctx, membershipsDelete, userID); err != nil {
+			return fmt.Errorf("membershipsDelete: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, userWorkScheduleDelete, userID); err != nil {
+			return fmt.Errorf("userWorkScheduleDelete: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, userTimeOffsDelete, userID); err != nil {
+			return fmt.Errorf("userTimeOffsDelete: %w", err)
+		}
+
+		result, err := tx.Exec(ctx, userDelete, userID)
+
+		if err != nil {
+			return fmt.Errorf("userDelete: %w", err)
+		}
+
+		if id, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite s.Tx(), DeleteUser: %w", err)
+	}
+
+	entry.Debugf("User with id %s deleted successfully, rows affected: %d", userID, id)
+	return id, nil
+}
+
+// TransferUser - closes current membership of user at effectiveFrom and opens membership in given department,
+// so activity recorded before effectiveFrom still counts toward previous department.
+func (s *SQLite) TransferUser(ctx context.Context, userID string, departmentID, effectiveFrom int64) (int64, error) {
+	entry := s.logger.WithField("func", "TransferUser")
+
+	entry.Debugf("Transferring user with id %s to department %d from: %d", userID, departmentID, effectiveFrom)
+	var transferred int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		current := new(models.Membership)
+
+		if err := tx.Pick(ctx, current, membershipCurrent, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+
+			return fmt.Errorf("membershipCurrent: %w", err)
+		}
+
+		if current.DepartmentID == departmentID {
+			return fmt.Errorf("%w: user is already member of department %d", core.ErrInvalidTransfer, departmentID)
+		}
+
+		if effectiveFrom <= current.ValidFrom {
+			return fmt.Errorf("%w: current membership starts at %d, transfer is at %d",
+				core.ErrInvalidTransfer, current.ValidFrom, effectiveFrom)
+		}
+
+		department := new(models.Department)
+
+		if err := tx.Pick(ctx, department, departmentGet, departmentID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("department %d: %w", departmentID, core.ErrDepartmentNotFound)
+			}
+
+			return fmt.Errorf("departmentGet: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, membershipClose, effectiveFrom, userID); err != nil {
+			return fmt.Errorf("membershipClose: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, membershipCreate, userID, departmentID, effectiveFrom); err != nil {
+			return fmt.Errorf("membershipCreate: %w", err)
+		}
+
+		result, err := tx.Exec(ctx, userTransfer, departmentID, userID)
+
+		if err != nil {
+			return fmt.Errorf("userTransfer: %w", err)
+		}
+
+		if transferred, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("RowsAffected(): %w", err)
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite Exec(), userDelete: %w", err)
+		return -1, fmt.Errorf("SQLite s.Tx(), TransferUser: %w", err)
+	}
+
+	entry.Debugf("User with id %s transferred, rows affected: %d", userID, transferred)
+	return transferred, nil
+}
+
+// GetMemberships - returns department membership history of user with given ID from SQLite db, oldest first.
+func (s *SQLite) GetMemberships(ctx context.Context, userID string) ([]*models.Membership, error) {
+	entry := s.logger.WithField("func", "GetMemberships")
+
+	entry.Debugf("Getting memberships of user with id: %s", userID)
+	memberships := make([]*models.Membership, 0)
+
+	if err := s.Get(ctx, &memberships, membershipsOrdered, userID); err != nil {
+		return nil, fmt.Errorf("SQLite s.Get(), membershipsOrdered: %w", err)
 	}
 
-	id, err := result.RowsAffected()
+	entry.Debugf("Retrieved memberships num: %d", len(memberships))
+	return memberships, nil
+}
+
+// createUser - inserts user and its first membership in transaction.
+func createUser(ctx context.Context, tx core.ISQLTx, user *models.User) (int64, error) {
+	if err := checkEmployeeNumber(ctx, tx, user.EmployeeNumber, 0); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(
+		ctx,
+		userCreate,
+		user.UserName,
+		user.DepartmentID,
+		user.Email,
+		user.EmployeeNumber,
+		user.JobTitle,
+		user.Status,
+		user.EmploymentStart,
+		user.EmploymentEnd,
+	)
 
 	if err != nil {
-		return -1, fmt.Errorf("SQLite LastInsertId(), activityCreate: %w", err)
+		return 0, fmt.Errorf("userCreate: %w", err)
+	}
+
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		err = notInserted(err, core.ErrDepartmentNotFound)
+
+		return 0, fmt.Errorf("userCreate, department %d: %w", user.DepartmentID, err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, fmt.Errorf("LastInsertId(): %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, membershipCreate, id, user.DepartmentID, 0); err != nil {
+		return 0, fmt.Errorf("membershipCreate: %w", err)
 	}
 
-	entry.Debugf("User with id %s deleted successfully, rows affected: %d", userID, id)
 	return id, nil
 }
+
+// checkEmployeeNumber - returns ErrEmployeeNumberTaken if other user of tenant has given employee number.
+// Empty number isn't checked, userID is 0 for new user.
+func checkEmployeeNumber(ctx context.Context, tx core.ISQLTx, number string, userID int64) error {
+	if number == "" {
+		return nil
+	}
+
+	var taken int
+
+	if err := tx.Pick(ctx, &taken, employeeNumberTaken, number, userID); err != nil {
+		return fmt.Errorf("employeeNumberTaken: %w", err)
+	}
+
+	if taken > 0 {
+		return fmt.Errorf("%q: %w", number, core.ErrEmployeeNumberTaken)
+	}
+
+	return nil
+}
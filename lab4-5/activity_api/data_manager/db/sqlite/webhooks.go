@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateWebhook - writes given webhook to SQLite db.
+func (s *SQLite) CreateWebhook(ctx context.Context, webhook *models.Webhook) (int64, error) {
+	entry := s.logger.WithField("func", "CreateWebhook")
+
+	entry.Debugf("Creating webhook for url: %s, events: %q", webhook.URL, webhook.Events)
+	result, err := s.Exec(ctx, webhookCreate, webhook.URL, webhook.Secret, webhook.Events, webhook.CreatedAt)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), webhookCreate: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), webhookCreate: %w", err)
+	}
+
+	entry.Debugf("Created webhook id: %d", id)
+	return id, nil
+}
+
+// GetWebhooks - returns all webhooks from SQLite db.
+func (s *SQLite) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	entry := s.logger.WithField("func", "GetWebhooks")
+
+	entry.Debug("Getting webhooks")
+	webhooks := make([]*models.Webhook, 0)
+
+	if err := s.Get(ctx, &webhooks, webhooksGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), webhooksGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved webhooks num: %d", len(webhooks))
+	return webhooks, nil
+}
+
+// GetWebhook - returns webhook with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error) {
+	entry := s.logger.WithField("func", "GetWebhook")
+
+	entry.Debugf("Getting webhook with id: %s", webhookID)
+	webhook := new(models.Webhook)
+
+	if err := s.Pick(ctx, webhook, webhookGet, webhookID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), webhookGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved webhook with id %s", webhookID)
+	return webhook, nil
+}
+
+// DeleteWebhook - deletes webhook with given ID and its deliveries from SQLite db.
+func (s *SQLite) DeleteWebhook(ctx context.Context, webhookID string) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteWebhook")
+
+	entry.Debugf("Deleting webhook with id: %s", webhookID)
+
+	var deleted int64
+
+	err := s.Tx(ctx, func(tx core.ISQLTx) error {
+		if _, err := tx.Exec(ctx, webhookDeliveriesDelete, webhookID); err != nil {
+			return fmt.Errorf("tx.Exec(), webhookDeliveriesDelete: %w", err)
+		}
+
+		result, err := tx.Exec(ctx, webhookDelete, webhookID)
+
+		if err != nil {
+			return fmt.Errorf("tx.Exec(), webhookDelete: %w", err)
+		}
+
+		deleted, err = result.RowsAffected()
+
+		if err != nil {
+			return fmt.Errorf("RowsAffected(), webhookDelete: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Tx(): %w", err)
+	}
+
+	entry.Debugf("Webhook with id %s deleted successfully, rows affected: %d", webhookID, deleted)
+	return deleted, nil
+}
+
+// EnqueueDeliveries - queues delivery of event for every webhook subscribed to it, returns number of deliveries.
+func (s *SQLite) EnqueueDeliveries(ctx context.Context, event, payload string, now int64) (int64, error) {
+	entry := s.logger.WithField("func", "EnqueueDeliveries")
+
+	entry.Debugf("Queueing deliveries of event: %s", event)
+	result, err := s.Exec(ctx, deliveriesEnqueue, event, payload, now)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), deliveriesEnqueue: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), deliveriesEnqueue: %w", err)
+	}
+
+	entry.Debugf("Queued deliveries of event %s: %d", event, queued)
+	return queued, nil
+}
+
+// GetDueDeliveries - returns up to limit pending deliveries, which next attempt is due by now.
+func (s *SQLite) GetDueDeliveries(ctx context.Context, now int64, limit int) ([]*models.WebhookDelivery, error) {
+	entry := s.logger.WithField("func", "GetDueDeliveries")
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+
+	if err := s.Get(ctx, &deliveries, deliveriesDue, now, limit); err != nil {
+		return nil, fmt.Errorf("s.Get(), deliveriesDue: %w", err)
+	}
+
+	entry.Debugf("Retrieved due deliveries num: %d", len(deliveries))
+	return deliveries, nil
+}
+
+// UpdateDelivery - writes status and attempts of given delivery to SQLite db.
+func (s *SQLite) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	entry := s.logger.WithField("func", "UpdateDelivery")
+
+	entry.Debugf("Updating delivery %d, status: %s, attempts: %d",
+		delivery.DeliveryID, delivery.Status, delivery.Attempts)
+
+	_, err := s.Exec(ctx, deliveryUpdate,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttempt,
+		delivery.LastError,
+		delivery.LastStatus,
+		delivery.DeliveryID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("SQLite Exec(), deliveryUpdate: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries - returns deliveries of webhook with given status, empty webhookID or status - any.
+func (s *SQLite) GetDeliveries(ctx context.Context, webhookID, status string) ([]*models.WebhookDelivery, error) {
+	entry := s.logger.WithField("func", "GetDeliveries")
+
+	entry.Debugf("Getting deliveries of webhook: %q, status: %q", webhookID, status)
+	deliveries := make([]*models.WebhookDelivery, 0)
+
+	if err := s.Get(ctx, &deliveries, deliveriesFind, webhookID, status); err != nil {
+		return nil, fmt.Errorf("s.Get(), deliveriesFind: %w", err)
+	}
+
+	entry.Debugf("Retrieved deliveries num: %d", len(deliveries))
+	return deliveries, nil
+}
+
+// RedeliverDelivery - queues delivered or dead delivery again, returns number of queued deliveries.
+func (s *SQLite) RedeliverDelivery(ctx context.Context, deliveryID string, now int64) (int64, error) {
+	entry := s.logger.WithField("func", "RedeliverDelivery")
+
+	entry.Debugf("Redelivering delivery with id: %s", deliveryID)
+	result, err := s.Exec(ctx, deliveryRedeliver, now, deliveryID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), deliveryRedeliver: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), deliveryRedeliver: %w", err)
+	}
+
+	entry.Debugf("Delivery with id %s queued again, rows affected: %d", deliveryID, queued)
+	return queued, nil
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"activity_api/common/models"
+	"activity_api/data_manager/db/core"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SetWorkSchedule - writes schedule of user or department to SQLite db, replaces existing one.
+// Returns 0 if user or department doesn't exist.
+func (s *SQLite) SetWorkSchedule(ctx context.Context, schedule *models.WorkSchedule) (int64, error) {
+	entry := s.logger.WithField("func", "SetWorkSchedule")
+
+	entry.Debugf("Setting work schedule: %+v", schedule)
+	result, err := s.Exec(ctx, workScheduleSet,
+		schedule.UserID,
+		schedule.DepartmentID,
+		schedule.Monday,
+		schedule.Tuesday,
+		schedule.Wednesday,
+		schedule.Thursday,
+		schedule.Friday,
+		schedule.Saturday,
+		schedule.Sunday,
+		schedule.UTCOffset,
+	)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), workScheduleSet: %w", err)
+	}
+
+	set, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), workScheduleSet: %w", err)
+	}
+
+	entry.Debugf("Work schedule set, rows affected: %d", set)
+	return set, nil
+}
+
+// GetWorkSchedules - returns schedules of all users and departments from SQLite db.
+func (s *SQLite) GetWorkSchedules(ctx context.Context) ([]*models.WorkSchedule, error) {
+	entry := s.logger.WithField("func", "GetWorkSchedules")
+
+	entry.Debug("Getting work schedules")
+	schedules := make([]*models.WorkSchedule, 0)
+
+	if err := s.Get(ctx, &schedules, workSchedulesGet); err != nil {
+		return nil, fmt.Errorf("s.Get(), workSchedulesGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved work schedules num: %d", len(schedules))
+	return schedules, nil
+}
+
+// GetWorkSchedule - returns own schedule of user or department from SQLite db, nil if it isn't set.
+func (s *SQLite) GetWorkSchedule(ctx context.Context, userID, departmentID int64) (*models.WorkSchedule, error) {
+	entry := s.logger.WithField("func", "GetWorkSchedule")
+
+	entry.Debugf("Getting work schedule, user id: %d, department id: %d", userID, departmentID)
+	schedule := new(models.WorkSchedule)
+
+	if err := s.Pick(ctx, schedule, workScheduleGet, userID, departmentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), workScheduleGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved work schedule: %+v", *schedule)
+	return schedule, nil
+}
+
+// DeleteWorkSchedule - deletes own schedule of user or department from SQLite db.
+func (s *SQLite) DeleteWorkSchedule(ctx context.Context, userID, departmentID int64) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteWorkSchedule")
+
+	entry.Debugf("Deleting work schedule, user id: %d, department id: %d", userID, departmentID)
+	result, err := s.Exec(ctx, workScheduleDelete, userID, departmentID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), workScheduleDelete: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), workScheduleDelete: %w", err)
+	}
+
+	entry.Debugf("Work schedule deleted successfully, rows affected: %d", id)
+	return id, nil
+}
+
+// CreateTimeOff - writes given leave or holiday to SQLite db, user of leave must be of the same tenant.
+func (s *SQLite) CreateTimeOff(ctx context.Context, timeOff *models.TimeOff) (int64, error) {
+	entry := s.logger.WithField("func", "CreateTimeOff")
+
+	entry.Debugf("Creating time off: %+v", timeOff)
+	result, err := s.Exec(ctx, timeOffCreate, timeOff.UserID, timeOff.From, timeOff.To, timeOff.Description)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), timeOffCreate: %w", err)
+	}
+
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		err = notInserted(err, core.ErrUserNotFound)
+
+		return -1, fmt.Errorf("SQLite timeOffCreate, user %d: %w", timeOff.UserID, err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite LastInsertId(), timeOffCreate: %w", err)
+	}
+
+	entry.Debugf("Created time off id: %d", id)
+	return id, nil
+}
+
+// GetTimeOffs - returns leaves of user and holidays from SQLite db, with empty userID - time off of all users.
+func (s *SQLite) GetTimeOffs(ctx context.Context, userID string) ([]*models.TimeOff, error) {
+	entry := s.logger.WithField("func", "GetTimeOffs")
+
+	entry.Debugf("Getting time off, user id: %q", userID)
+	timeOffs := make([]*models.TimeOff, 0)
+
+	if err := s.Get(ctx, &timeOffs, timeOffsFind, userID); err != nil {
+		return nil, fmt.Errorf("s.Get(), timeOffsFind: %w", err)
+	}
+
+	entry.Debugf("Retrieved time off num: %d", len(timeOffs))
+	return timeOffs, nil
+}
+
+// GetTimeOff - returns time off with given ID from SQLite db, nil if it doesn't exist.
+func (s *SQLite) GetTimeOff(ctx context.Context, timeOffID string) (*models.TimeOff, error) {
+	entry := s.logger.WithField("func", "GetTimeOff")
+
+	entry.Debugf("Getting time off with id: %s", timeOffID)
+	timeOff := new(models.TimeOff)
+
+	if err := s.Pick(ctx, timeOff, timeOffGet, timeOffID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("s.Pick(), timeOffGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved time off with id %s: %+v", timeOffID, *timeOff)
+	return timeOff, nil
+}
+
+// DeleteTimeOff - deletes time off with given ID from SQLite db.
+func (s *SQLite) DeleteTimeOff(ctx context.Context, timeOffID string) (int64, error) {
+	entry := s.logger.WithField("func", "DeleteTimeOff")
+
+	entry.Debugf("Deleting time off with id: %s", timeOffID)
+	result, err := s.Exec(ctx, timeOffDelete, timeOffID)
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite Exec(), timeOffDelete: %w", err)
+	}
+
+	id, err := result.RowsAffected()
+
+	if err != nil {
+		return -1, fmt.Errorf("SQLite RowsAffected(), timeOffDelete: %w", err)
+	}
+
+	entry.Debugf("Time off with id %s deleted successfully, rows affected: %d", timeOffID, id)
+	return id, nil
+}
+
+// GetDailyActivity - returns activity of user in [start, end) from SQLite db summed by days
+// of time zone with given offset. Days without records aren't returned.
+func (s *SQLite) GetDailyActivity(
+	ctx context.Context,
+	userID string,
+	start, end, utcOffset int64,
+) ([]*models.DailyActivity, error) {
+	entry := s.logger.WithField("func", "GetDailyActivity")
+
+	entry.Debugf("Getting daily activity of user %s, start: %d, end: %d, offset: %d", userID, start, end, utcOffset)
+	daily := make([]*models.DailyActivity, 0)
+
+	if err := s.Get(ctx, &daily, dailyActivityGet, userID, utcOffset, start, end); err != nil {
+		return nil, fmt.Errorf("s.Get(), dailyActivityGet: %w", err)
+	}
+
+	entry.Debugf("Retrieved days with activity: %d", len(daily))
+	return daily, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type - database backend type. In config it could be set both by name ("sqlite") and by number (0),
+// numbers are kept for compatibility with old configs.
+type Type int
+
+// typeNames - names of db types used in config.
+var typeNames = map[Type]string{
+	SQLite: "sqlite",
+}
+
+// String - returns db type name.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// MarshalText - marshals db type as its name.
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText - parses db type from name or number.
+func (t *Type) UnmarshalText(text []byte) error {
+	value := strings.ToLower(strings.TrimSpace(string(text)))
+
+	for dbType, name := range typeNames {
+		if name == value {
+			*t = dbType
+
+			return nil
+		}
+	}
+
+	if number, err := strconv.Atoi(value); err == nil {
+		if _, ok := typeNames[Type(number)]; ok {
+			*t = Type(number)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown db type %q, expected one of: sqlite", string(text))
+}
+
+// UnmarshalJSON - parses db type from JSON string or number.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		return t.UnmarshalText([]byte(name))
+	}
+
+	return t.UnmarshalText(data)
+}
@@ -12,6 +12,8 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.5
 	github.com/sirupsen/logrus v1.7.0
 	golang.org/x/crypto v0.0.0-20201124201722-c8d3bf9c5392
+	golang.org/x/net v0.0.0-20201006153459-a7d1128ccaa0
 	google.golang.org/appengine v1.6.7 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"activity_api/common/config_parser"
+	"activity_api/common/csv_import"
+	"activity_api/common/models"
+	"activity_api/control"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// importCommand - name of command that imports users from CSV file instead of starting service.
+const importCommand = "import"
+
+// Exit codes of import command.
+const (
+	importOK     = 0
+	importFailed = 1 // some rows are invalid, nothing is imported
+	importError  = 2 // import couldn't run
+)
+
+// runImport - runs import command and returns exit code. Usage:
+// import [-dry-run] file.csv [service config flags]
+func runImport(args []string) int {
+	flags := flag.NewFlagSet(importCommand, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate file against db without writing")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [-dry-run] file.csv [config flags]\n", os.Args[0], importCommand)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return importError
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return importError
+	}
+
+	config, err := config_parser.LoadConfig(flags.Args()[1:])
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Load config error:", err)
+
+		return importError
+	}
+
+	rows, err := parseImportFile(flags.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Read file error:", err)
+
+		return importError
+	}
+
+	result, err := control.ImportUsers(config, rows, *dryRun)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Import error:", err)
+
+		return importError
+	}
+
+	if err := printImportResult(os.Stdout, result); err != nil {
+		fmt.Fprintln(os.Stderr, "Write result error:", err)
+	}
+
+	if result.Failed > 0 {
+		return importFailed
+	}
+
+	return importOK
+}
+
+// parseImportFile - reads import rows from CSV file.
+func parseImportFile(path string) ([]*models.ImportRow, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("os.Open(): %w", err)
+	}
+
+	defer file.Close()
+
+	rows, err := csv_import.Parse(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("csv_import.Parse(): %w", err)
+	}
+
+	return rows, nil
+}
+
+// printImportResult - writes per-row results and summary of import as table.
+func printImportResult(w io.Writer, result *models.ImportResult) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ROW\tDEPARTMENT ID\tDEPARTMENT\tUSER ID\tERROR")
+
+	for _, row := range result.Rows {
+		fmt.Fprintf(table, "%d\t%d\t%s\t%d\t%s\n", row.Line, row.DepartmentID, row.Department, row.UserID, row.Error)
+	}
+
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("Flush(): %w", err)
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"\nDry run: %v, committed: %v, departments created: %d, matched: %d, users created: %d, failed rows: %d\n",
+		result.DryRun,
+		result.Committed,
+		result.DepartmentsCreated,
+		result.DepartmentsMatched,
+		result.UsersCreated,
+		result.Failed,
+	)
+
+	return err
+}
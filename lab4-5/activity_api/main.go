@@ -3,16 +3,36 @@ package main
 import (
 	"activity_api/common/config_parser"
 	"activity_api/control"
+	"os"
 )
 
 func main() {
-	// Get config from root directory
-	config, err := config_parser.ParseConfig("config.json")
+	// Import command imports users from CSV file to db and exits, service isn't started
+	if len(os.Args) > 1 && os.Args[1] == importCommand {
+		os.Exit(runImport(os.Args[2:]))
+	}
+	// Rebuild command rebuilds daily activity rollups from records and exits
+	if len(os.Args) > 1 && os.Args[1] == rollupsCommand {
+		os.Exit(runRebuildRollups(os.Args[2:]))
+	}
+	// Restore command loads activity records from archive of retention job back to db and exits
+	if len(os.Args) > 1 && os.Args[1] == restoreCommand {
+		os.Exit(runRestoreArchive(os.Args[2:]))
+	}
+	// Load config from defaults, config file, env and flags
+	config, err := config_parser.LoadConfig(os.Args[1:])
 
 	if err != nil {
 		panic(err)
 	}
 
-	// Run AAService
-	control.NewAAService(config).Run()
+	srv, err := control.NewAAService(config)
+
+	if err != nil {
+		panic(err)
+	}
+	// Run AAService, config is loaded again on SIGHUP
+	srv.SetConfigLoader(func() (*control.AAServiceConfig, error) {
+		return config_parser.LoadConfig(os.Args[1:])
+	}).Run()
 }
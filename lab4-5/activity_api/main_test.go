@@ -5,153 +5,140 @@
 package main
 
 import (
-	"activity_api/common/http_client"
+	"activity_api/common/anonymity"
+	"activity_api/common/api_client"
 	"activity_api/common/models"
+	"activity_api/common/test_ca"
+	"activity_api/common/tls_manager"
+	"activity_api/common/webhook"
 	"activity_api/control"
 	"activity_api/data_manager/cache"
+	"activity_api/data_manager/db"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
-	"os"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 // Predefined config for AAService
 var config = control.AAServiceConfig{
-	CacheType: 0, // CacheMock
-	DbType:    0,
+	CacheType: cache.ICacheMock,
+	DbType:    db.SQLite,
 	// // Will be generated randomly to ensure clean DB
 	// ConnString: "functional_test_db.db",
 	Addr:     "localhost:9332",
-	LogLevel: 4, // Info level
+	LogLevel: control.LogLevel(logrus.InfoLevel),
+	// Failed webhook delivery goes to dead-letter list right away, so redelivery could be checked.
+	WebhookMaxAttempts: 1,
+	// Superadmin is created on startup, tenants check logs in with it.
+	Superadmin:         superadmin,
+	SuperadminPassword: superadminPassword,
 	// Cache Mock doesn't need config
 	Cache: &cache.ICacheConfig{},
 }
 
+// baseURL - address of AAService under test, it's served over TLS with certificate of test CA.
+const baseURL = "https://localhost:9332"
+
+// superadmin - name of admin allowed to manage tenants.
+const superadmin = "smoke-superadmin"
+
+// superadminPassword - password hash superadmin logs in with.
+const superadminPassword = "smoke-superadmin-password"
+
+// clientTLS - TLS config of test clients, it trusts test CA only.
+var clientTLS *tls.Config
+
 type testRunner func(data *loadData)
 
 type loadData struct {
-	deps    []*models.Department
-	users   []*models.User
-	act     []*models.Activity
-	headers map[string]string
+	deps  []*models.Department
+	users []*models.User
+	act   []*models.Activity
 }
 
 // smokeTest - base smoke test realisation
 type smokeTest struct {
-	client *http_client.NetHTTP
+	client *api_client.Client
+	ctx    context.Context
 	t      *testing.T
 }
 
 // newSmokeTest - create new smoke tester
-func newSmokeTest(client *http_client.NetHTTP, t *testing.T) *smokeTest {
+func newSmokeTest(client *api_client.Client, t *testing.T) *smokeTest {
 	return &smokeTest{
 		client: client,
+		ctx:    context.Background(),
 		t:      t,
 	}
 }
 
 // getTestAdmin - returns randomly created admin.
-func (s *smokeTest) getTestAdmin() []byte {
+func (s *smokeTest) getTestAdmin() *models.Admin {
 	log.Println("TEST: TEST: getting test admin")
 
-	u := models.Admin{
+	return &models.Admin{
 		Username: uuid.New().String(),
 		// I didn't truly create hash here, because it's only a test,
 		// but if sometime client would be implemented - has should be calculated on the client side.
 		Hash: uuid.New().String(),
 	}
-
-	bts, err := json.Marshal(u)
-
-	if err != nil {
-		s.t.Fatal(err)
-	}
-
-	return bts
 }
 
 // login - attempts to login with given admin.
-func (s *smokeTest) login(user []byte) (map[string]string, error) {
+func (s *smokeTest) login(admin *models.Admin) error {
 	log.Println("TEST: log in API")
 
-	bts, err := s.client.MakeRequest(http.MethodPost, "http://localhost:9332/login", nil, user)
-
-	if err != nil {
-		return nil, err
-	}
-
-	m := make(map[string]string)
-
-	if err = json.Unmarshal(bts, &m); err != nil {
-		s.t.Fatal(err)
-	}
-
-	return m, nil
-}
-
-// postObject - posts given data on given handler.
-func (s *smokeTest) postObject(headers map[string]string, path string, data interface{}) int64 {
-	bts, err := json.Marshal(data)
-	if err != nil {
-		s.t.Fatal(err)
-	}
-
-	bts, err = s.client.MakeRequest(http.MethodPost, path, headers, bts)
-	if err != nil {
-		s.t.Fatal(err)
-	}
-
-	id := new(models.ObjectID)
+	_, err := s.client.Login(s.ctx, admin)
 
-	if err = json.Unmarshal(bts, &id); err != nil {
-		s.t.Fatal(err)
-	}
-
-	return id.ID
+	return err
 }
 
-// registerAndLogin - register given admin and logins it, returns auth token
-func (s *smokeTest) registerAndLogin(userBts []byte) map[string]string {
+// registerAndLogin - register given admin and logins it, client keeps received tokens.
+func (s *smokeTest) registerAndLogin(admin *models.Admin) {
 	log.Println("TEST: Registering and login")
 
-	_, err := s.client.MakeRequest(http.MethodPost, "http://localhost:9332/register", nil, userBts)
-
-	if err != nil {
+	if _, err := s.client.Register(s.ctx, admin); err != nil {
 		s.t.Fatal(err)
 	}
 
-	token, err := s.login(userBts)
-	if err != nil {
+	if err := s.login(admin); err != nil {
 		s.t.Fatal(err)
 	}
-
-	headers := map[string]string{
-		"Authorization": "Bearer " + token["access_token"],
-	}
-
-	return headers
 }
 
-// unregister - unregister given admin.
-func (s *smokeTest) unregister(headers map[string]string, userBts []byte) {
+// unregister - unregister logged in admin.
+func (s *smokeTest) unregister() {
 	log.Println("TEST: Unregistering")
-	_, err := s.client.MakeRequest(http.MethodDelete, "http://localhost:9332/unregister", headers, userBts)
 
-	if err != nil {
+	if err := s.client.Unregister(s.ctx); err != nil {
 		s.t.Fatal(err)
 	}
 }
 
 // addDepartments - adds randomly generated departments.
-func (s *smokeTest) addDepartments(headers map[string]string) []*models.Department {
+func (s *smokeTest) addDepartments() []*models.Department {
 	log.Println("TEST: Adding random departments")
 	deps := make([]*models.Department, 0)
 
@@ -160,7 +147,12 @@ func (s *smokeTest) addDepartments(headers map[string]string) []*models.Departme
 			DepartmentName: uuid.New().String(),
 		}
 
-		id := s.postObject(headers, "http://localhost:9332/departments", dep)
+		id, err := s.client.CreateDepartment(s.ctx, dep)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
 		dep.DepartmentID = id
 		deps = append(deps, dep)
 	}
@@ -169,18 +161,28 @@ func (s *smokeTest) addDepartments(headers map[string]string) []*models.Departme
 }
 
 // addUsers - adds randomly generated users to given departments.
-func (s *smokeTest) addUsers(headers map[string]string, deps []*models.Department) []*models.User {
+func (s *smokeTest) addUsers(deps []*models.Department) []*models.User {
 	log.Println("TEST: Adding random users")
 	users := make([]*models.User, 0)
 
 	for _, dep := range deps {
 		for i := 0; i < rand.Intn(10); i++ {
+			name := uuid.New().String()
 			user := &models.User{
-				UserName:     uuid.New().String(),
-				DepartmentID: dep.DepartmentID,
+				UserName:        name,
+				DepartmentID:    dep.DepartmentID,
+				Email:           name + "@example.com",
+				EmployeeNumber:  name,
+				JobTitle:        "engineer",
+				Status:          models.UserActive,
+				EmploymentStart: time.Now().Unix(),
 			}
 
-			id := s.postObject(headers, "http://localhost:9332/users", user)
+			id, err := s.client.CreateUser(s.ctx, user)
+
+			if err != nil {
+				s.t.Fatal(err)
+			}
 
 			user.UserID = id
 			users = append(users, user)
@@ -191,7 +193,7 @@ func (s *smokeTest) addUsers(headers map[string]string, deps []*models.Departmen
 }
 
 // addActivities - adds randomly generated activities to given users.
-func (s *smokeTest) addActivities(headers map[string]string, users []*models.User) []*models.Activity {
+func (s *smokeTest) addActivities(users []*models.User) []*models.Activity {
 	log.Println("TEST: Adding random activities")
 
 	activities := make([]*models.Activity, 0)
@@ -207,7 +209,11 @@ func (s *smokeTest) addActivities(headers map[string]string, users []*models.Use
 				Date:       time.Now().Unix() + rand.Int63n(max),
 			}
 
-			id := s.postObject(headers, "http://localhost:9332/activities", user)
+			id, err := s.client.CreateActivity(s.ctx, user)
+
+			if err != nil {
+				s.t.Fatal(err)
+			}
 
 			user.RecordID = id
 			activities = append(activities, user)
@@ -255,7 +261,7 @@ func (s *smokeTest) getUserTiming(act []*models.Activity, userID int64) (int64,
 }
 
 // checkUserActivityTime - checks manually calculated user activity with activity calculated by api.
-func (s *smokeTest) checkUserActivityTime(act []*models.Activity, headers map[string]string) {
+func (s *smokeTest) checkUserActivityTime(act []*models.Activity) {
 	log.Println("TEST: Requesting user activity from API")
 
 	userID := act[0].UserID
@@ -264,24 +270,12 @@ func (s *smokeTest) checkUserActivityTime(act []*models.Activity, headers map[st
 	var user = map[int64]bool{userID: true}
 	activeTime, totalTime := s.manualTimeCalc(act, user, minTime, maxTime)
 
-	path := fmt.Sprintf("http://localhost:9332/control/user/%d?TimeStart=%d&TimeEnd=%d",
-		userID,
-		minTime,
-		maxTime,
-	)
-
-	bts, err := s.client.MakeRequest(http.MethodGet, path, headers, nil)
+	data, err := s.client.GetUsersActivity(s.ctx, userID, minTime, maxTime)
 
 	if err != nil {
 		s.t.Fatal(err)
 	}
 
-	data := new(models.DepartmentActivity)
-
-	if err = json.Unmarshal(bts, &data); err != nil {
-		s.t.Fatal(err)
-	}
-
 	s.checkTime(data.TotalTime, totalTime)
 	s.checkTime(data.ActiveTime, activeTime)
 }
@@ -325,75 +319,43 @@ func (s *smokeTest) getDepartTiming(act []*models.Activity, depUsers map[int64]b
 }
 
 // manualDepartCalc - checks manually calculated department activity with activity calculated by api.
-func (s *smokeTest) checkDepartActivityTime(
-	act []*models.Activity,
-	users []*models.User,
-	depID int64,
-	headers map[string]string,
-) {
+func (s *smokeTest) checkDepartActivityTime(act []*models.Activity, users []*models.User, depID int64) {
 	log.Println("TEST: Requesting depart activity from API")
 
 	depUsers := s.getDepUsers(users, depID)
 	minTime, maxTime := s.getDepartTiming(act, depUsers)
 	activeTime, totalTime := s.manualTimeCalc(act, depUsers, minTime, maxTime)
 
-	path := fmt.Sprintf("http://localhost:9332/control/user/%d?TimeStart=%d&TimeEnd=%d",
-		depID,
-		minTime,
-		maxTime,
-	)
-
-	bts, err := s.client.MakeRequest(http.MethodGet, path, headers, nil)
+	data, err := s.client.GetDepartmentsActivity(s.ctx, depID, minTime, maxTime, false)
 
 	if err != nil {
 		s.t.Fatal(err)
 	}
 
-	data := new(models.UserActivity)
-
-	if err = json.Unmarshal(bts, &data); err != nil {
-		s.t.Fatal(err)
-	}
-
 	s.checkTime(data.TotalTime, totalTime)
 	s.checkTime(data.ActiveTime, activeTime)
 }
 
 // checkTime - compares to times.
 // First - time received from api.
-func (s *smokeTest) checkTime(first *int64, second int64) {
-	if first != nil {
-		if *first != second {
-			s.t.Fatalf("invalid time, expected: %d, got %d", second, *first)
-		}
-
-		return
-	}
-
-	if second != 0 {
-		s.t.Fatalf("invalid time, expected: %d, got %d", second, 0)
+func (s *smokeTest) checkTime(first int64, second int64) {
+	if first != second {
+		s.t.Fatalf("invalid time, expected: %d, got %d", second, first)
 	}
 }
 
-// deleteByIds - deletes given ids from given handler.
-func (s *smokeTest) deleteByIds(headers map[string]string, path string, ids []int64) {
-	log.Println("TEST: Deleting objects by ID in path: " + path)
+// deleteByIds - deletes given ids with given delete function.
+func (s *smokeTest) deleteByIds(name string, ids []int64, del func(context.Context, int64) (int64, error)) {
+	log.Println("TEST: Deleting objects: " + name)
 
 	for _, id := range ids {
-		path := fmt.Sprintf("%s/%d", path, id)
-		bts, err := s.client.MakeRequest(http.MethodDelete, path, headers, nil)
+		affected, err := del(s.ctx, id)
 
 		if err != nil {
 			s.t.Fatal(err)
 		}
 
-		id := new(models.ObjectID)
-
-		if err := json.Unmarshal(bts, id); err != nil {
-			s.t.Fatal(err)
-		}
-
-		if id.ID > 1 {
+		if affected > 1 {
 			s.t.Fatal("More than 1 object were affected")
 		}
 	}
@@ -409,7 +371,7 @@ func (s *smokeTest) deleteObjects(ld *loadData) {
 		depsID[id] = d.DepartmentID
 	}
 
-	s.deleteByIds(ld.headers, "http://localhost:9332/departments", depsID)
+	s.deleteByIds("departments", depsID, s.client.DeleteDepartment)
 
 	usersID := make([]int64, len(ld.users))
 
@@ -417,7 +379,7 @@ func (s *smokeTest) deleteObjects(ld *loadData) {
 		usersID[id] = u.UserID
 	}
 
-	s.deleteByIds(ld.headers, "http://localhost:9332/users", usersID)
+	s.deleteByIds("users", usersID, s.client.DeleteUser)
 
 	actIds := make([]int64, len(ld.act))
 
@@ -425,24 +387,18 @@ func (s *smokeTest) deleteObjects(ld *loadData) {
 		actIds[id] = act.RecordID
 	}
 
-	s.deleteByIds(ld.headers, "http://localhost:9332/activities", actIds)
+	s.deleteByIds("activities", actIds, s.client.DeleteActivity)
 }
 
 // checkDeparts - checks if returned departs are equal to loaded departs.
-func (s *smokeTest) checkDeparts(deps []*models.Department, headers map[string]string) {
+func (s *smokeTest) checkDeparts(deps []*models.Department) {
 	log.Println("Checking if returned departs are equal to loaded departs.")
-	bts, err := s.client.MakeRequest(http.MethodGet, "http://localhost:9332/departments", headers, nil)
+	data, err := s.client.GetDepartments(s.ctx)
 
 	if err != nil {
 		s.t.Fatal(err)
 	}
 
-	data := make([]*models.Department, 0)
-
-	if err = json.Unmarshal(bts, &data); err != nil {
-		s.t.Fatal(err)
-	}
-
 	sort.Slice(data, func(i, j int) bool {
 		return data[i].DepartmentID < data[j].DepartmentID
 	})
@@ -457,20 +413,14 @@ func (s *smokeTest) checkDeparts(deps []*models.Department, headers map[string]s
 }
 
 // checkDeparts - checks if returned users are equal to loaded users.
-func (s *smokeTest) checkUsers(users []*models.User, headers map[string]string) {
+func (s *smokeTest) checkUsers(users []*models.User) {
 	log.Println("Checking if returned users are equal to loaded users.")
-	bts, err := s.client.MakeRequest(http.MethodGet, "http://localhost:9332/users", headers, nil)
+	data, err := s.client.GetUsers(s.ctx)
 
 	if err != nil {
 		s.t.Fatal(err)
 	}
 
-	data := make([]*models.User, 0)
-
-	if err = json.Unmarshal(bts, &data); err != nil {
-		s.t.Fatal(err)
-	}
-
 	sort.Slice(data, func(i, j int) bool {
 		return data[i].UserID < data[j].UserID
 	})
@@ -485,20 +435,14 @@ func (s *smokeTest) checkUsers(users []*models.User, headers map[string]string)
 }
 
 // checkDeparts - checks if returned activities are equal to loaded activities.
-func (s *smokeTest) checkActivities(act []*models.Activity, headers map[string]string) {
+func (s *smokeTest) checkActivities(act []*models.Activity) {
 	log.Println("Checking if returned activities are equal to loaded activities.")
-	bts, err := s.client.MakeRequest(http.MethodGet, "http://localhost:9332/activities", headers, nil)
+	data, err := s.client.GetActivities(s.ctx)
 
 	if err != nil {
 		s.t.Fatal(err)
 	}
 
-	data := make([]*models.Activity, 0)
-
-	if err = json.Unmarshal(bts, &data); err != nil {
-		s.t.Fatal(err)
-	}
-
 	sort.Slice(data, func(i, j int) bool {
 		return data[i].RecordID < data[j].RecordID
 	})
@@ -512,100 +456,1353 @@ func (s *smokeTest) checkActivities(act []*models.Activity, headers map[string]s
 	}
 }
 
-// TestRunner - generates random data, passes it to test scenario, and executes it
-// Deletes all data afterwards
-func (s *smokeTest) TestRunner(testCase testRunner) {
-	log.Println("TEST: Starting test runner...")
+// checkExport - checks users report exported as CSV with manually calculated activity,
+// and that XLSX export is a readable workbook.
+func (s *smokeTest) checkExport(ld *loadData) {
+	log.Println("Checking exported users report.")
 
-	userBts := s.getTestAdmin()
-	headers := s.registerAndLogin(userBts)
+	buf := new(bytes.Buffer)
 
-	deps := s.addDepartments(headers)
-	users := s.addUsers(headers, deps)
-	activities := s.addActivities(headers, users)
+	if err := s.client.ExportUsersReport(s.ctx, "csv", 0, 0, buf); err != nil {
+		s.t.Fatal(err)
+	}
 
-	ld := &loadData{
-		deps:    deps,
-		users:   users,
-		act:     activities,
-		headers: headers,
+	rows, err := csv.NewReader(buf).ReadAll()
+
+	if err != nil {
+		s.t.Fatal(err)
 	}
 
-	testCase(ld)
+	if len(rows) != len(ld.users)+1 {
+		s.t.Fatalf("Users report rows doesn't match, lenRecieved: %d, lenLoaded: %d", len(rows)-1, len(ld.users))
+	}
+	// Columns: user_id, user_name, department_id, active_time, total_time
+	for _, row := range rows[1:] {
+		userID, _ := strconv.ParseInt(row[0], 10, 64)
+		activeTime, totalTime := s.manualTimeCalc(ld.act, map[int64]bool{userID: true}, math.MinInt64, math.MaxInt64)
+		exportedActive, _ := strconv.ParseInt(row[3], 10, 64)
+		exportedTotal, _ := strconv.ParseInt(row[4], 10, 64)
+
+		s.checkTime(exportedActive, activeTime)
+		s.checkTime(exportedTotal, totalTime)
+	}
 
-	s.deleteObjects(ld)
-	s.unregister(headers, userBts)
+	log.Println("Checking exported departments workbook.")
+	buf.Reset()
 
-	_, err := s.login(userBts)
-	if err == nil {
-		s.t.Fatal("User wasn't unregistered")
+	if err := s.client.ExportDepartments(s.ctx, "xlsx", buf); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		s.t.Fatal(err)
 	}
 }
 
-// TestTimeCalc - checks time calculations.
-// No need to return errors, could just t.Fatal(), to get full trace.
-func (s *smokeTest) TestTimeCalc(ld *loadData) {
-	log.Println("TEST: Starting time test...")
+// checkImport - checks that dry run and failed import report rows, but don't write anything.
+func (s *smokeTest) checkImport(ld *loadData) {
+	log.Println("Checking import dry run and rollback.")
 
-	s.checkUserActivityTime(ld.act, ld.headers)
-	s.checkDepartActivityTime(ld.act, ld.users, ld.deps[0].DepartmentID, ld.headers)
-}
+	file := "department_name,user_name\n" +
+		ld.deps[0].DepartmentName + ",imported user\n" +
+		"imported department,imported user\n" +
+		"imported department,\n"
 
-// TestGet - tests all GET handlers.
-// No need to return errors, could just t.Fatal(), to get full trace.
-func (s *smokeTest) TestGet(ld *loadData) {
-	log.Println("TEST: Starting GET/DELETE check...")
+	result, err := s.client.ImportUsers(s.ctx, strings.NewReader(file), true)
 
-	s.checkDeparts(ld.deps, ld.headers)
-	s.checkUsers(ld.users, ld.headers)
-	s.checkActivities(ld.act, ld.headers)
-}
+	if err != nil {
+		s.t.Fatal(err)
+	}
 
-// RunMultiple - allows to wait for multiple routines to exit
-func (s *smokeTest) RunMultiple(wg *sync.WaitGroup, testCase testRunner) {
-	defer wg.Done()
-	s.TestRunner(testCase)
+	if result.Committed || result.Failed != 0 || result.UsersCreated != 2 ||
+		result.DepartmentsCreated != 1 || result.DepartmentsMatched != 1 {
+		s.t.Fatalf("Unexpected dry run result: %+v", *result)
+	}
+
+	if result.Rows[0].DepartmentID != ld.deps[0].DepartmentID || result.Rows[1].DepartmentID != 0 {
+		s.t.Fatalf("Unexpected dry run departments: %+v, %+v", *result.Rows[0], *result.Rows[1])
+	}
+	// Last row has no department, so whole import is rolled back.
+	result, err = s.client.ImportUsers(s.ctx, strings.NewReader(file+",user without department\n"), false)
+
+	var apiErr *api_client.Error
+
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("Import with invalid row must fail with 422, got: %v", err)
+	}
+
+	if result == nil || result.Committed || result.Failed != 1 || result.Rows[3].Error == "" {
+		s.t.Fatalf("Unexpected failed import result: %+v", result)
+	}
+	// Nothing is written, so loaded data must still match.
+	s.checkDeparts(ld.deps)
+	s.checkUsers(ld.users)
 }
 
-func RunSmokeTest(t *testing.T) {
-	// Wait group to sync diff clients.
-	var wg sync.WaitGroup
+// checkWebhooks - checks that created user is sent to webhook receiver with valid signature,
+// failed delivery is moved to dead-letter list and could be redelivered.
+func (s *smokeTest) checkWebhooks(ld *loadData) {
+	log.Println("Checking webhooks delivery, dead-letter list and redelivery.")
 
-	for i := 0; i < 3; i++ {
-		testName := fmt.Sprintf("Smoke_test_%d", i)
-		client := http_client.NewHTTPClient()
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	failed := int32(0)
+	// Receiver fails the first delivery, so it becomes dead.
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.CompareAndSwapInt32(&failed, 0, 1) {
+			w.WriteHeader(http.StatusServiceUnavailable)
 
-		wg.Add(1)
+			return
+		}
 
-		// Run clients
-		go t.Run(testName, func(t *testing.T) {
-			test := newSmokeTest(client, t)
-			test.RunMultiple(&wg, test.TestTimeCalc)
-		})
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	hook, err := s.client.CreateWebhook(s.ctx, &models.Webhook{
+		URL:    receiver.URL,
+		Events: models.WebhookUserCreated,
+	})
+
+	if err != nil {
+		s.t.Fatal(err)
 	}
-	// Wait for clients.
-	wg.Wait()
 
-	// No need for DELETE test, if not all objects were deleted in prev test - this test fill fall
-	t.Run("DET/DELETE_test", func(t *testing.T) {
-		test := newSmokeTest(http_client.NewHTTPClient(), t)
-		test.TestRunner(test.TestGet)
+	if hook.Secret == "" {
+		s.t.Fatal("Secret of created webhook isn't returned")
+	}
+
+	if _, err := s.client.CreateWebhook(s.ctx, &models.Webhook{URL: "ftp://localhost", Events: "user.moved"}); err == nil {
+		s.t.Fatal("Webhook with invalid url and events is created")
+	}
+
+	userID, err := s.client.CreateUser(s.ctx, &models.User{
+		UserName:     "webhook user",
+		DepartmentID: ld.deps[0].DepartmentID,
 	})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var dead *models.WebhookDelivery
+
+	for deadline := time.Now().Add(10 * time.Second); dead == nil && time.Now().Before(deadline); {
+		deliveries, err := s.client.GetDeadDeliveries(s.ctx)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		for _, delivery := range deliveries {
+			if delivery.WebhookID == hook.WebhookID {
+				dead = delivery
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if dead == nil || dead.LastStatus != http.StatusServiceUnavailable {
+		s.t.Fatalf("Failed delivery isn't in dead-letter list: %+v", dead)
+	}
+
+	if _, err := s.client.RedeliverDelivery(s.ctx, dead.DeliveryID); err != nil {
+		s.t.Fatal(err)
+	}
+
+	select {
+	case r := <-received:
+		body := <-bodies
+
+		if err := webhook.Verify(
+			hook.Secret,
+			r.Header.Get(webhook.HeaderTimestamp),
+			r.Header.Get(webhook.HeaderSignature),
+			body,
+		); err != nil {
+			s.t.Fatal(err)
+		}
+
+		payload := &models.WebhookPayload{Data: new(models.User)}
+
+		if err := json.Unmarshal(body, payload); err != nil {
+			s.t.Fatal(err)
+		}
+
+		if user := payload.Data.(*models.User); payload.Event != models.WebhookUserCreated || user.UserID != userID {
+			s.t.Fatalf("Unexpected webhook payload: %s", body)
+		}
+	case <-time.After(10 * time.Second):
+		s.t.Fatal("Redelivered webhook isn't received")
+	}
+
+	s.deleteByIds("users", []int64{userID}, s.client.DeleteUser)
+	s.deleteByIds("webhooks", []int64{hook.WebhookID}, s.client.DeleteWebhook)
 }
 
-// Base smoke test.
-func Test_AAPI(t *testing.T) {
-	// Since conn string for SQLite is it's path - generate tmp db in curr dir, and delete it after test
-	config.ConnString = uuid.New().String() + ".db"
-	defer func() {
-		if err := os.Remove(config.ConnString); err != nil {
-			t.Error(err)
+// checkAlertRules - checks alert rules creation, validation, state filter and deletion.
+func (s *smokeTest) checkAlertRules(ld *loadData) {
+	log.Println("Checking alert rules.")
+
+	ruleID, err := s.client.CreateAlertRule(s.ctx, &models.AlertRule{
+		Name:      "low activity",
+		Kind:      models.AlertUserRatio,
+		TargetID:  ld.users[0].UserID,
+		Threshold: 0.5,
+		Notifiers: "log, webhook",
+	})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	rule, err := s.client.GetAlertRule(s.ctx, ruleID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if rule.State != models.AlertOK || rule.Period <= 0 || rule.Notifiers != "log,webhook" {
+		s.t.Fatalf("Unexpected created alert rule: %+v", rule)
+	}
+
+	if _, err := s.client.CreateAlertRule(s.ctx, &models.AlertRule{
+		Kind:      models.AlertDepartmentDrop,
+		TargetID:  ld.deps[0].DepartmentID,
+		Threshold: 2,
+	}); err == nil {
+		s.t.Fatal("Alert rule with invalid threshold is created")
+	}
+
+	rules, err := s.client.GetAlertRules(s.ctx, models.AlertOK)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	found := false
+
+	for _, listed := range rules {
+		found = found || listed.RuleID == ruleID
+	}
+
+	if !found {
+		s.t.Fatalf("Created alert rule %d isn't listed: %+v", ruleID, rules)
+	}
+
+	if _, err := s.client.GetAlertRules(s.ctx, "unknown"); err == nil {
+		s.t.Fatal("Alert rules are listed with unknown state")
+	}
+
+	s.deleteByIds("alert rules", []int64{ruleID}, s.client.DeleteAlertRule)
+}
+
+// checkCategories - checks that activity app breakdown is categorized by rules in user and department reports.
+func (s *smokeTest) checkCategories() {
+	log.Println("Checking activity categories.")
+
+	departmentID, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	userID, err := s.client.CreateUser(s.ctx, &models.User{UserName: uuid.New().String(), DepartmentID: departmentID})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	rules := make([]int64, 0)
+
+	for _, rule := range []*models.CategoryRule{
+		{Pattern: "*code*", Category: models.CategoryProductive},
+		{Pattern: "vscode-insiders", Category: models.CategoryNeutral},
+		{Pattern: "*Games*", Category: models.CategoryUnproductive},
+	} {
+		id, err := s.client.CreateCategoryRule(s.ctx, rule)
+
+		if err != nil {
+			s.t.Fatal(err)
 		}
-	}()
-	// Run service for test
-	srv := control.NewAAService(&config)
+
+		rules = append(rules, id)
+	}
+
+	if _, err := s.client.CreateCategoryRule(s.ctx, &models.CategoryRule{Pattern: "*", Category: "fun"}); err == nil {
+		s.t.Fatal("Category rule with unknown category is created")
+	}
+
+	if _, err := s.client.CreateActivity(s.ctx, &models.Activity{
+		UserID:    userID,
+		TotalTime: 10,
+		Apps:      []*models.AppTime{{App: "code", Time: 11}},
+	}); err == nil {
+		s.t.Fatal("Activity with app time exceeding total time is created")
+	}
+
+	activity := &models.Activity{
+		UserID:     userID,
+		TotalTime:  100,
+		ActiveTime: 90,
+		Date:       time.Now().Unix(),
+		Apps: []*models.AppTime{
+			{App: "VSCode", Time: 30},
+			{App: "vscode-insiders", Time: 20},
+			{App: "steam-games", Time: 15},
+			{App: "VSCode", Time: 5},
+			{App: "terminal", Time: 10},
+		},
+	}
+
+	if activity.RecordID, err = s.client.CreateActivity(s.ctx, activity); err != nil {
+		s.t.Fatal(err)
+	}
+
+	created, err := s.client.GetActivity(s.ctx, activity.RecordID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	apps := map[string]int64{}
+
+	for _, app := range created.Apps {
+		apps[app.App] = app.Time
+	}
+
+	expectedApps := map[string]int64{"VSCode": 35, "vscode-insiders": 20, "steam-games": 15, "terminal": 10}
+
+	if !reflect.DeepEqual(apps, expectedApps) {
+		s.t.Fatalf("Unexpected apps of activity: %v, expected: %v", apps, expectedApps)
+	}
+
+	// The longest matching pattern wins, apps without rule are neutral.
+	expected := models.CategoryTime{Productive: 35, Neutral: 30, Unproductive: 15}
+	userActivity, err := s.client.GetUsersActivity(s.ctx, userID, 0, 0)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if userActivity.Categories == nil || *userActivity.Categories != expected {
+		s.t.Fatalf("Unexpected user categories: %+v, expected: %+v", userActivity.Categories, expected)
+	}
+
+	departmentActivity, err := s.client.GetDepartmentsActivity(s.ctx, departmentID, 0, 0, true)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if departmentActivity.Categories == nil || *departmentActivity.Categories != expected {
+		s.t.Fatalf("Unexpected department categories: %+v, expected: %+v", departmentActivity.Categories, expected)
+	}
+
+	s.deleteByIds("category rules", rules, s.client.DeleteCategoryRule)
+
+	if userActivity, err = s.client.GetUsersActivity(s.ctx, userID, 0, 0); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if *userActivity.Categories != (models.CategoryTime{Neutral: 80}) {
+		s.t.Fatalf("Unexpected user categories without rules: %+v", userActivity.Categories)
+	}
+
+	s.deleteByIds("activities", []int64{activity.RecordID}, s.client.DeleteActivity)
+	s.deleteByIds("users", []int64{userID}, s.client.DeleteUser)
+	s.deleteByIds("departments", []int64{departmentID}, s.client.DeleteDepartment)
+}
+
+// checkWorkTime - checks schedules, time off and work report of user and of its department.
+func (s *smokeTest) checkWorkTime() {
+	log.Println("Checking work time reports.")
+
+	const hour = int64(time.Hour / time.Second)
+
+	departmentID, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	userID, err := s.client.CreateUser(s.ctx, &models.User{UserName: uuid.New().String(), DepartmentID: departmentID})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.GetUserSchedule(s.ctx, userID); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("User without own schedule has schedule, error: %v", err)
+	}
+
+	if _, err := s.client.SetDepartmentSchedule(s.ctx, &models.WorkSchedule{
+		DepartmentID: departmentID,
+		Monday:       -1,
+	}); err == nil {
+		s.t.Fatal("Schedule with negative work time is set")
+	}
+	// 4 hours from Monday to Friday, 3 hours east of UTC.
+	schedule := &models.WorkSchedule{
+		DepartmentID: departmentID,
+		Monday:       4 * hour,
+		Tuesday:      4 * hour,
+		Wednesday:    4 * hour,
+		Thursday:     4 * hour,
+		Friday:       4 * hour,
+		UTCOffset:    3 * hour,
+	}
+
+	if _, err := s.client.SetDepartmentSchedule(s.ctx, schedule); err != nil {
+		s.t.Fatal(err)
+	}
+
+	timeOffs := make([]int64, 0)
+
+	for _, timeOff := range []*models.TimeOff{
+		{UserID: userID, From: "2020-01-07", To: "2020-01-07", Description: "leave"},
+		{From: "2020-01-08", To: "2020-01-08", Description: "holiday"},
+	} {
+		id, err := s.client.CreateTimeOff(s.ctx, timeOff)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		timeOffs = append(timeOffs, id)
+	}
+
+	if _, err := s.client.CreateTimeOff(s.ctx, &models.TimeOff{From: "2020-01-09", To: "2020-01-08"}); err == nil {
+		s.t.Fatal("Time off ending before its start is created")
+	}
+
+	monday := time.Date(2020, 1, 6, 0, 0, 0, 0, time.FixedZone("", int(3*hour))).Unix()
+	activities := make([]int64, 0)
+
+	for _, activity := range []*models.Activity{
+		{UserID: userID, TotalTime: 5 * hour, ActiveTime: 4 * hour, Date: monday + 10*hour},
+		{UserID: userID, TotalTime: hour, ActiveTime: hour, Date: monday + 5*24*hour + 10*hour}, // Saturday
+	} {
+		id, err := s.client.CreateActivity(s.ctx, activity)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		activities = append(activities, id)
+	}
+	// Leave and holiday aren't working days, work on Monday and Saturday above schedule is overtime.
+	report, err := s.client.GetUsersWorkReport(s.ctx, userID, monday, monday+7*24*hour)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if report.From != "2020-01-06" || report.To != "2020-01-12" || len(report.Days) != 7 ||
+		report.ExpectedTime != 12*hour || report.TotalTime != 6*hour || report.ActiveTime != 5*hour ||
+		report.Overtime != 2*hour || report.OvertimeDays != 2 || report.TimeOffDays != 2 {
+		s.t.Fatalf("Unexpected user work report: %+v", report)
+	}
+
+	departmentReport, err := s.client.GetDepartmentsWorkReport(s.ctx, departmentID, monday, monday+7*24*hour)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if len(departmentReport.Users) != 1 || departmentReport.ExpectedTime != report.ExpectedTime ||
+		departmentReport.TotalTime != report.TotalTime {
+		s.t.Fatalf("Unexpected department work report: %+v", departmentReport)
+	}
+
+	if _, err := s.client.GetUsersWorkReport(s.ctx, userID, monday, monday); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusBadRequest {
+		s.t.Fatalf("Report of empty period is built, error: %v", err)
+	}
+	// Own schedule of user overrides schedule of department.
+	if _, err := s.client.SetUserSchedule(s.ctx, &models.WorkSchedule{UserID: userID, Saturday: hour}); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if report, err = s.client.GetUsersWorkReport(s.ctx, userID, monday, monday+7*24*hour); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if report.ExpectedTime != hour || report.Schedule == nil || report.Schedule.UserID != userID {
+		s.t.Fatalf("Own schedule of user isn't applied: %+v", report)
+	}
+
+	s.deleteByIds("user schedules", []int64{userID}, s.client.DeleteUserSchedule)
+	s.deleteByIds("time off", timeOffs, s.client.DeleteTimeOff)
+	s.deleteByIds("activities", activities, s.client.DeleteActivity)
+	s.deleteByIds("users", []int64{userID}, s.client.DeleteUser)
+	s.deleteByIds("departments", []int64{departmentID}, s.client.DeleteDepartment)
+}
+
+// checkRetention - checks that retention job archives old records of department with policy,
+// and that their time stays in activity reports.
+// Smoke clients run in parallel, so job could be already started by another client.
+func (s *smokeTest) checkRetention() {
+	log.Println("Checking retention policies.")
+
+	departmentID, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	userID, err := s.client.CreateUser(s.ctx, &models.User{UserName: uuid.New().String(), DepartmentID: departmentID})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	records := make([]int64, 0)
+
+	for _, date := range []int64{now - 30*24*3600, now - 8*24*3600, now} {
+		id, err := s.client.CreateActivity(s.ctx, &models.Activity{
+			UserID:     userID,
+			TotalTime:  100,
+			ActiveTime: 50,
+			Date:       date,
+			Apps:       []*models.AppTime{{App: "terminal", Time: 10}},
+		})
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		records = append(records, id)
+	}
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.CreateRetentionPolicy(s.ctx, &models.RetentionPolicy{DepartmentID: departmentID}); err == nil {
+		s.t.Fatal("Retention policy without days is created")
+	}
+
+	policyID, err := s.client.CreateRetentionPolicy(s.ctx, &models.RetentionPolicy{DepartmentID: departmentID, Days: 7})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := s.client.CreateRetentionPolicy(s.ctx, &models.RetentionPolicy{
+		DepartmentID: departmentID,
+		Days:         1,
+	}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		s.t.Fatalf("Second policy of department is created, error: %v", err)
+	}
+
+	policy, err := s.client.GetRetentionPolicy(s.ctx, policyID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if policy.DepartmentID != departmentID || policy.Days != 7 {
+		s.t.Fatalf("Unexpected retention policy: %+v", policy)
+	}
+	// Records older than 7 days are archived by the first job run started after policy was created.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		if _, err := s.client.RunJob(s.ctx, "retention"); err != nil &&
+			(!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict) {
+			s.t.Fatal(err)
+		}
+
+		_, err := s.client.GetActivity(s.ctx, records[1])
+
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			s.t.Fatalf("Old record isn't archived, error: %v", err)
+		}
+	}
+
+	if _, err := s.client.GetActivity(s.ctx, records[2]); err != nil {
+		s.t.Fatalf("Today's record is archived, error: %v", err)
+	}
+
+	userActivity, err := s.client.GetUsersActivity(s.ctx, userID, 0, 0)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if userActivity.TotalTime != 300 || userActivity.ActiveTime != 150 {
+		s.t.Fatalf("Time of archived records isn't in user activity: %+v", userActivity)
+	}
+
+	s.deleteByIds("retention policies", []int64{policyID}, s.client.DeleteRetentionPolicy)
+	s.deleteByIds("activities", records[2:], s.client.DeleteActivity)
+	s.deleteByIds("users", []int64{userID}, s.client.DeleteUser)
+	s.deleteByIds("departments", []int64{departmentID}, s.client.DeleteDepartment)
+}
+
+// checkPrivacy - checks that export archive has everything held about user, and that erasure deletes user,
+// but keeps its time in department activity.
+func (s *smokeTest) checkPrivacy() {
+	log.Println("Checking data subject export and erasure.")
+
+	departmentID, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	userID, err := s.client.CreateUser(s.ctx, &models.User{
+		UserName:     uuid.New().String(),
+		DepartmentID: departmentID,
+		Email:        "subject@example.com",
+	})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	records := make([]int64, 0)
+
+	for _, date := range []int64{1000, 2000} {
+		id, err := s.client.CreateActivity(s.ctx, &models.Activity{UserID: userID, TotalTime: 100, ActiveTime: 50, Date: date})
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		records = append(records, id)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := s.client.ExportUserData(s.ctx, userID, buf); err != nil {
+		s.t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+
+	for _, file := range archive.File {
+		reader, err := file.Open()
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		content, err := ioutil.ReadAll(reader)
+		_ = reader.Close()
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		files[file.Name] = string(content)
+	}
+
+	data := new(models.SubjectData)
+
+	if err := json.Unmarshal([]byte(files["subject.json"]), data); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if data.User == nil || data.User.Email != "subject@example.com" || len(data.Memberships) != 1 ||
+		strings.Count(files["activity.ndjson"], "\n") != len(records) {
+		s.t.Fatalf("Unexpected export archive: %+v", files)
+	}
+
+	before, err := s.client.GetDepartmentsActivity(s.ctx, departmentID, 0, 0, false)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	erased, err := s.client.EraseUser(s.ctx, userID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if erased.UserID != userID || erased.Kind != models.SubjectErasure || erased.Records != int64(len(records)) {
+		s.t.Fatalf("Unexpected erasure request: %+v", erased)
+	}
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.GetUser(s.ctx, userID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Erased user exists, error: %v", err)
+	}
+
+	if _, err := s.client.EraseUser(s.ctx, userID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Erased user is erased again, error: %v", err)
+	}
+
+	after, err := s.client.GetDepartmentsActivity(s.ctx, departmentID, 0, 0, false)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if after.TotalTime != before.TotalTime || after.ActiveTime != before.ActiveTime {
+		s.t.Fatalf("Department activity changed by erasure: %+v, expected %+v", *after, *before)
+	}
+
+	requests, err := s.client.GetSubjectRequests(s.ctx, userID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if len(requests) != 2 || requests[0].Kind != models.SubjectExport || requests[1].RequestID != erased.RequestID {
+		s.t.Fatalf("Unexpected subject requests: %+v", requests)
+	}
+	// Pseudonymous user has activity of erased user, it's the only user of department.
+	users, err := s.client.GetUsers(s.ctx)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	pseudonyms := make([]int64, 0)
+
+	for _, user := range users {
+		if user.DepartmentID == departmentID {
+			pseudonyms = append(pseudonyms, user.UserID)
+		}
+	}
+
+	if len(pseudonyms) != 1 {
+		s.t.Fatalf("Pseudonymous users of department: %v, expected one", pseudonyms)
+	}
+
+	s.deleteByIds("activities", records, s.client.DeleteActivity)
+	s.deleteByIds("users", pseudonyms, s.client.DeleteUser)
+	s.deleteByIds("departments", []int64{departmentID}, s.client.DeleteDepartment)
+}
+
+// checkJobs - checks that alerts job is registered and could be started manually.
+// Smoke clients run in parallel, so job could be already started by another client.
+func (s *smokeTest) checkJobs() {
+	log.Println("Checking background jobs.")
+
+	jobs, err := s.client.GetJobs(s.ctx)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	found := false
+
+	for _, job := range jobs {
+		found = found || job.Name == "alerts"
+	}
+
+	if !found {
+		s.t.Fatalf("Alerts job isn't registered: %+v", jobs)
+	}
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.RunJob(s.ctx, "alerts"); err != nil &&
+		(!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict) {
+		s.t.Fatal(err)
+	}
+
+	if _, err := s.client.RunJob(s.ctx, "unknown"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Unknown job is started, error: %v", err)
+	}
+
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		job, err := s.client.GetJob(s.ctx, "alerts")
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		if !job.Running && len(job.History) > 0 {
+			if job.LastRun.Trigger != models.JobTriggerManual || job.LastRun.Status != models.JobSucceeded {
+				s.t.Fatalf("Unexpected last run of alerts job: %+v", job.LastRun)
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			s.t.Fatalf("Alerts job isn't finished: %+v", job)
+		}
+	}
+}
+
+// checkDepartmentTree - checks departments hierarchy: moves, cycle prevention, tree and roll-up activity.
+func (s *smokeTest) checkDepartmentTree(ld *loadData) {
+	log.Println("Checking departments tree.")
+
+	parentID, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	childID, err := s.client.CreateDepartment(s.ctx, &models.Department{
+		DepartmentName: uuid.New().String(),
+		ParentID:       parentID,
+	})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	leaf := ld.deps[0].DepartmentID
+
+	if moved, err := s.client.MoveDepartment(s.ctx, leaf, childID); err != nil || moved.ParentID != childID {
+		s.t.Fatalf("Department isn't moved: %+v, error: %v", moved, err)
+	}
+
+	if _, err := s.client.MoveDepartment(s.ctx, parentID, leaf); err == nil {
+		s.t.Fatal("Department is moved under its descendant")
+	}
+
+	tree, err := s.client.GetDepartmentsTree(s.ctx, parentID)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if len(tree) != 3 || tree[0].DepartmentID != parentID || tree[1].DepartmentID != childID ||
+		tree[2].DepartmentID != leaf || tree[2].Depth != 2 || len(tree[0].Children) != 1 {
+		s.t.Fatalf("Unexpected departments tree: %+v", tree)
+	}
+	// Parent and child have no users, so roll-up is activity of the leaf only.
+	rollup, err := s.client.GetDepartmentsActivity(s.ctx, parentID, 0, 0, true)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	leafActivity, err := s.client.GetDepartmentsActivity(s.ctx, leaf, 0, 0, false)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.checkTime(rollup.TotalTime, leafActivity.TotalTime)
+	s.checkTime(rollup.ActiveTime, leafActivity.ActiveTime)
+	// Children of deleted department are moved to its parent.
+	s.deleteByIds("departments", []int64{parentID}, s.client.DeleteDepartment)
+
+	if child, err := s.client.GetDepartment(s.ctx, childID); err != nil || child.ParentID != 0 {
+		s.t.Fatalf("Child of deleted department isn't moved to top level: %+v, error: %v", child, err)
+	}
+
+	if _, err := s.client.MoveDepartment(s.ctx, leaf, 0); err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.deleteByIds("departments", []int64{childID}, s.client.DeleteDepartment)
+}
+
+// checkUserProfiles - checks profile validation and update, and that activity recorded before transfer
+// counts toward previous department.
+func (s *smokeTest) checkUserProfiles() {
+	log.Println("Checking user profiles and transfers.")
+
+	var apiErr *api_client.Error
+
+	from, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	existing := &models.User{UserName: uuid.New().String(), DepartmentID: from, EmployeeNumber: uuid.New().String()}
+
+	if existing.UserID, err = s.client.CreateUser(s.ctx, existing); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := s.client.CreateUser(s.ctx, &models.User{
+		UserName:       uuid.New().String(),
+		DepartmentID:   from,
+		EmployeeNumber: existing.EmployeeNumber,
+	}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		s.t.Fatalf("User with taken employee number is created, error: %v", err)
+	}
+
+	if _, err := s.client.CreateUser(s.ctx, &models.User{
+		UserName:     uuid.New().String(),
+		DepartmentID: from,
+		Status:       "retired",
+	}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("User with unknown status is created, error: %v", err)
+	}
+
+	to, err := s.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	user := &models.User{UserName: uuid.New().String(), DepartmentID: from}
+
+	if user.UserID, err = s.client.CreateUser(s.ctx, user); err != nil {
+		s.t.Fatal(err)
+	}
+
+	user.Status = models.UserOnLeave
+	user.JobTitle = "manager"
+
+	if updated, err := s.client.UpdateUser(s.ctx, user); err != nil || updated.Status != models.UserOnLeave ||
+		updated.JobTitle != "manager" || updated.DepartmentID != from {
+		s.t.Fatalf("User isn't updated: %+v, error: %v", updated, err)
+	}
+
+	user.DepartmentID = to
+
+	if _, err := s.client.UpdateUser(s.ctx, user); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("Department is changed by update, error: %v", err)
+	}
+
+	user.EmployeeNumber = existing.EmployeeNumber
+	user.DepartmentID = from
+
+	if _, err := s.client.UpdateUser(s.ctx, user); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		s.t.Fatalf("User is updated with taken employee number, error: %v", err)
+	}
+	// Record before transfer counts toward old department, record after it - toward new one.
+	const transferAt = 2000
+
+	records := make([]int64, 0, 2)
+
+	for _, activity := range []*models.Activity{
+		{UserID: user.UserID, TotalTime: 100, ActiveTime: 50, Date: transferAt - 1000},
+		{UserID: user.UserID, TotalTime: 30, ActiveTime: 10, Date: transferAt + 1000},
+	} {
+		id, err := s.client.CreateActivity(s.ctx, activity)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		records = append(records, id)
+	}
+
+	history, err := s.client.TransferUser(s.ctx, user.UserID, &models.UserTransfer{
+		DepartmentID:  to,
+		EffectiveFrom: transferAt,
+	})
+
+	if err != nil || len(history) != 2 || history[0].DepartmentID != from || history[0].ValidTo != transferAt ||
+		history[1].DepartmentID != to || history[1].ValidFrom != transferAt || history[1].ValidTo != 0 {
+		s.t.Fatalf("Unexpected membership history: %+v, error: %v", history, err)
+	}
+
+	if _, err := s.client.TransferUser(s.ctx, user.UserID, &models.UserTransfer{
+		DepartmentID:  from,
+		EffectiveFrom: transferAt - 1,
+	}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("User is transferred before start of current membership, error: %v", err)
+	}
+
+	for department, expected := range map[int64]int64{from: 100, to: 30} {
+		activity, err := s.client.GetDepartmentsActivity(s.ctx, department, 0, 0, false)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		s.checkTime(activity.TotalTime, expected)
+	}
+
+	if moved, err := s.client.GetUser(s.ctx, user.UserID); err != nil || moved.DepartmentID != to {
+		s.t.Fatalf("User isn't moved to new department: %+v, error: %v", moved, err)
+	}
+
+	s.deleteByIds("activities", records, s.client.DeleteActivity)
+	s.deleteByIds("users", []int64{user.UserID, existing.UserID}, s.client.DeleteUser)
+	s.deleteByIds("departments", []int64{from, to}, s.client.DeleteDepartment)
+}
+
+// checkAnalyst - checks that analysts get anonymized department aggregates only.
+func (s *smokeTest) checkAnalyst(ld *loadData) {
+	log.Println("Checking anonymized reports of analyst.")
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.Register(s.ctx, &models.Admin{
+		Username: uuid.New().String(),
+		Hash:     uuid.New().String(),
+		Role:     "owner",
+	}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("Admin with unknown role is registered, error: %v", err)
+	}
+
+	analyst := newSmokeTest(api_client.NewClient(baseURL, clientTLS), s.t)
+	admin := s.getTestAdmin()
+	admin.Role = models.RoleAnalyst
+	analyst.registerAndLogin(admin)
+	defer analyst.unregister()
+
+	if _, err := analyst.client.GetUsers(s.ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Users are listed by analyst, error: %v", err)
+	}
+
+	// Role is checked before user lookup, so any user ID is rejected.
+	if _, err := analyst.client.GetUsersActivity(s.ctx, 1, 0, 0); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("User activity is read by analyst, error: %v", err)
+	}
+
+	department := &models.Department{DepartmentName: uuid.New().String()}
+
+	if _, err := analyst.client.CreateDepartment(s.ctx, department); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Department is created by analyst, error: %v", err)
+	}
+
+	for _, department := range ld.deps {
+		exact, err := s.client.GetDepartmentsActivity(s.ctx, department.DepartmentID, 0, 0, false)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+
+		anonymized, err := analyst.client.GetDepartmentsActivity(s.ctx, department.DepartmentID, 0, 0, false)
+
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		// Smoke service has no noise, so only suppression changes activity.
+		if suppressed := exact.Members < anonymity.DefaultMinGroup; anonymized.Suppressed != suppressed ||
+			suppressed && (anonymized.TotalTime != 0 || anonymized.ActiveTime != 0) ||
+			!suppressed && (anonymized.TotalTime != exact.TotalTime || anonymized.ActiveTime != exact.ActiveTime) {
+			s.t.Fatalf("Unexpected activity for analyst: %+v, exact: %+v", anonymized, exact)
+		}
+	}
+
+	now := time.Now().Unix()
+	report, err := analyst.client.GetDepartmentsWorkReport(s.ctx, ld.deps[0].DepartmentID, now-7*86400, now)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if len(report.Users) != 0 {
+		s.t.Fatalf("Work reports of users are read by analyst: %+v", report.Users)
+	}
+}
+
+// checkTenants - checks that data of one tenant is invisible to admin of another tenant,
+// and that suspended tenant is locked out. Data of ld belongs to default tenant.
+func (s *smokeTest) checkTenants(ld *loadData) {
+	log.Println("Checking tenants isolation.")
+
+	var apiErr *api_client.Error
+
+	if _, err := s.client.GetTenants(s.ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Tenants are listed by admin which isn't superadmin, error: %v", err)
+	}
+
+	root := newSmokeTest(api_client.NewClient(baseURL, clientTLS), s.t)
+	// Name of superadmin is reserved, so nobody could take it by registration.
+	takeover := &models.Admin{Username: superadmin, Hash: uuid.New().String()}
+
+	if _, err := root.client.Register(s.ctx, takeover); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Admin is registered with name of superadmin, error: %v", err)
+	}
+
+	if err := root.login(&models.Admin{Username: superadmin, Hash: superadminPassword}); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if err := root.client.Unregister(s.ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Superadmin is unregistered, error: %v", err)
+	}
+
+	defer func() {
+		if err := root.client.Logout(s.ctx); err != nil {
+			s.t.Fatal(err)
+		}
+	}()
+
+	tenantID, err := root.client.CreateTenant(s.ctx, &models.Tenant{TenantName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := root.client.SuspendTenant(s.ctx, 1); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusUnprocessableEntity {
+		s.t.Fatalf("Default tenant is suspended, error: %v", err)
+	}
+
+	admin := s.getTestAdmin()
+
+	if _, err := root.client.CreateTenantAdmin(s.ctx, tenantID, admin); err != nil {
+		s.t.Fatal(err)
+	}
+
+	other := newSmokeTest(api_client.NewClient(baseURL, clientTLS), s.t)
+
+	if err := other.login(admin); err != nil {
+		s.t.Fatal(err)
+	}
+	// Objects of default tenant don't exist for other tenant.
+	department := ld.deps[0]
+	user := &models.User{UserName: uuid.New().String(), DepartmentID: department.DepartmentID}
+
+	if user.UserID, err = s.client.CreateUser(s.ctx, user); err != nil {
+		s.t.Fatal(err)
+	}
+
+	activity := &models.Activity{UserID: user.UserID, TotalTime: 10, ActiveTime: 5, Date: time.Now().Unix()}
+
+	if activity.RecordID, err = s.client.CreateActivity(s.ctx, activity); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := other.client.GetDepartment(s.ctx, department.DepartmentID); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Department of other tenant is read, error: %v", err)
+	}
+
+	if _, err := other.client.GetUser(s.ctx, user.UserID); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("User of other tenant is read, error: %v", err)
+	}
+
+	if _, err := other.client.GetActivity(s.ctx, activity.RecordID); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Activity of other tenant is read, error: %v", err)
+	}
+
+	if departments, err := other.client.GetDepartments(s.ctx); err != nil || len(departments) != 0 {
+		s.t.Fatalf("Departments of other tenant are listed: %+v, error: %v", departments, err)
+	}
+
+	if deleted, err := other.client.DeleteUser(s.ctx, user.UserID); err != nil || deleted != 0 {
+		s.t.Fatalf("User of other tenant is deleted: %d, error: %v", deleted, err)
+	}
+
+	if _, err := other.client.CreateUser(s.ctx, &models.User{
+		UserName:     uuid.New().String(),
+		DepartmentID: department.DepartmentID,
+	}); err == nil {
+		s.t.Fatal("User is created in department of other tenant")
+	}
+
+	if totals, err := other.client.GetUsersActivity(s.ctx, user.UserID, 0, 0); err == nil &&
+		(totals.TotalTime != 0 || totals.ActiveTime != 0) {
+		s.t.Fatalf("Activity time of other tenant user is read: %+v", totals)
+	}
+	// Objects of other tenant don't exist for default tenant.
+	ownID, err := other.client.CreateDepartment(s.ctx, &models.Department{DepartmentName: uuid.New().String()})
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if _, err := s.client.GetDepartment(s.ctx, ownID); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusNotFound {
+		s.t.Fatalf("Department of other tenant is read, error: %v", err)
+	}
+	// Suspended tenant can't use its token nor login again.
+	if tenant, err := root.client.SuspendTenant(s.ctx, tenantID); err != nil || !tenant.Suspended {
+		s.t.Fatalf("Tenant isn't suspended: %+v, error: %v", tenant, err)
+	}
+
+	if _, err := other.client.GetDepartments(s.ctx); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Suspended tenant is served, error: %v", err)
+	}
+
+	if err := other.login(admin); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		s.t.Fatalf("Admin of suspended tenant is logged in, error: %v", err)
+	}
+
+	if tenant, err := root.client.ResumeTenant(s.ctx, tenantID); err != nil || tenant.Suspended {
+		s.t.Fatalf("Tenant isn't resumed: %+v, error: %v", tenant, err)
+	}
+
+	other.deleteByIds("departments", []int64{ownID}, other.client.DeleteDepartment)
+	other.unregister()
+	s.deleteByIds("activities", []int64{activity.RecordID}, s.client.DeleteActivity)
+	s.deleteByIds("users", []int64{user.UserID}, s.client.DeleteUser)
+}
+
+// TestRunner - generates random data, passes it to test scenario, and executes it
+// Deletes all data afterwards
+func (s *smokeTest) TestRunner(testCase testRunner) {
+	log.Println("TEST: Starting test runner...")
+
+	admin := s.getTestAdmin()
+	s.registerAndLogin(admin)
+
+	deps := s.addDepartments()
+	users := s.addUsers(deps)
+	activities := s.addActivities(users)
+
+	ld := &loadData{
+		deps:  deps,
+		users: users,
+		act:   activities,
+	}
+
+	testCase(ld)
+
+	s.deleteObjects(ld)
+	s.unregister()
+
+	if err := s.login(admin); err == nil {
+		s.t.Fatal("User wasn't unregistered")
+	}
+}
+
+// TestTimeCalc - checks time calculations.
+// No need to return errors, could just t.Fatal(), to get full trace.
+func (s *smokeTest) TestTimeCalc(ld *loadData) {
+	log.Println("TEST: Starting time test...")
+
+	s.checkUserActivityTime(ld.act)
+	s.checkDepartActivityTime(ld.act, ld.users, ld.deps[0].DepartmentID)
+}
+
+// TestGet - tests all GET handlers.
+// No need to return errors, could just t.Fatal(), to get full trace.
+func (s *smokeTest) TestGet(ld *loadData) {
+	log.Println("TEST: Starting GET/DELETE check...")
+
+	s.checkDeparts(ld.deps)
+	s.checkUsers(ld.users)
+	s.checkActivities(ld.act)
+	s.checkExport(ld)
+	s.checkImport(ld)
+	s.checkWebhooks(ld)
+	s.checkAlertRules(ld)
+	s.checkCategories()
+	s.checkWorkTime()
+	s.checkRetention()
+	s.checkPrivacy()
+	s.checkJobs()
+	s.checkDepartmentTree(ld)
+	s.checkUserProfiles()
+	s.checkAnalyst(ld)
+	s.checkTenants(ld)
+}
+
+// RunMultiple - allows to wait for multiple routines to exit
+func (s *smokeTest) RunMultiple(wg *sync.WaitGroup, testCase testRunner) {
+	defer wg.Done()
+	s.TestRunner(testCase)
+}
+
+func RunSmokeTest(t *testing.T) {
+	// Wait group to sync diff clients.
+	var wg sync.WaitGroup
+
+	for i := 0; i < 3; i++ {
+		testName := fmt.Sprintf("Smoke_test_%d", i)
+		client := api_client.NewClient(baseURL, clientTLS)
+
+		wg.Add(1)
+
+		// Run clients
+		go t.Run(testName, func(t *testing.T) {
+			test := newSmokeTest(client, t)
+			test.RunMultiple(&wg, test.TestTimeCalc)
+		})
+	}
+	// Wait for clients.
+	wg.Wait()
+
+	// No need for DELETE test, if not all objects were deleted in prev test - this test fill fall
+	t.Run("DET/DELETE_test", func(t *testing.T) {
+		test := newSmokeTest(api_client.NewClient(baseURL, clientTLS), t)
+		test.TestRunner(test.TestGet)
+	})
+}
+
+// waitReady - waits until service readiness probe responds OK.
+func waitReady(t *testing.T) {
+	client := api_client.NewClient(baseURL, clientTLS)
+	deadline := time.Now().Add(time.Second * 10)
+
+	for time.Now().Before(deadline) {
+		if _, err := client.Ready(context.Background()); err == nil {
+			return
+		}
+
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	t.Fatal("service isn't ready after 10 seconds")
+}
+
+// newTestTLS - issues server certificate for localhost by new test CA, writes it to temp dir
+// and returns TLS config for the service. Test clients are set up to trust this CA only.
+func newTestTLS(t *testing.T) *tls_manager.Config {
+	ca, err := test_ca.NewCA()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := ca.IssueServer("localhost", "127.0.0.1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	tlsConfig := &tls_manager.Config{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+
+	if err := ioutil.WriteFile(tlsConfig.CertFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(tlsConfig.KeyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientTLS = &tls.Config{RootCAs: ca.Pool()}
+
+	return tlsConfig
+}
+
+// Base smoke test.
+func Test_AAPI(t *testing.T) {
+	// Since conn string for SQLite is it's path - generate db in temp dir, it's removed after test
+	config.ConnString = filepath.Join(t.TempDir(), uuid.New().String()+".db")
+	// Serve api over TLS with certificate issued by test CA
+	config.TLS = newTestTLS(t)
+	// Retention job writes archives to temp dir
+	config.ArchiveDir = t.TempDir()
+	// Run service for test
+	srv, err := control.NewAAService(&config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	go srv.Run()
 
+	waitReady(t)
 	RunSmokeTest(t)
 
 	srv.Stop()
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"activity_api/common/config_parser"
+	"activity_api/control"
+	"fmt"
+	"os"
+)
+
+// rollupsCommand - name of command that rebuilds daily activity rollups instead of starting service.
+const rollupsCommand = "rebuild-rollups"
+
+// runRebuildRollups - runs rollups rebuild command and returns exit code. Usage:
+// rebuild-rollups [service config flags]
+func runRebuildRollups(args []string) int {
+	config, err := config_parser.LoadConfig(args)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Load config error:", err)
+
+		return 1
+	}
+
+	rebuilt, err := control.RebuildRollups(config)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Rebuild error:", err)
+
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "Daily activity rollups rebuilt: %d\n", rebuilt)
+
+	return 0
+}